@@ -0,0 +1,167 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// flakyReport summarises the intermittent failures of one target
+type flakyReport struct {
+	Target   string
+	Runs     int // runs in the history
+	Attempts int // attempts including retries
+	Failed   int // failed attempts
+	Retried  int // runs that passed only after a retry
+	Flips    int // outcome changes between runs on unchanged sources
+}
+
+// FailureRate is the share of attempts that failed
+func (r flakyReport) FailureRate() float64 {
+	if r.Attempts == 0 {
+		return 0
+	}
+	return float64(r.Failed) / float64(r.Attempts)
+}
+
+// flakyTargets finds targets that both fail and pass without a change that
+// explains it: passing on retry, or changing outcome while their sources
+// stayed the same. Worst failure rate first.
+func flakyTargets(history runHistory) []flakyReport {
+	var reports []flakyReport
+	for _, name := range sortedKeys(history) {
+		report := flakyReport{Target: name}
+		var previous *runRecord
+		for i, run := range history[name] {
+			attempts := max(1, run.Attempts)
+			report.Runs++
+			report.Attempts += attempts
+			if run.OK {
+				report.Failed += attempts - 1
+				if attempts > 1 {
+					report.Retried++
+				}
+			} else {
+				report.Failed += attempts
+			}
+			if previous != nil && run.Inputs != "" && run.Inputs == previous.Inputs && run.OK != previous.OK {
+				report.Flips++
+			}
+			previous = &history[name][i]
+		}
+		if report.Retried > 0 || report.Flips > 0 {
+			reports = append(reports, report)
+		}
+	}
+
+	slices.SortStableFunc(reports, func(a, b flakyReport) int {
+		return cmp.Compare(b.FailureRate(), a.FailureRate())
+	})
+	return reports
+}
+
+// resourceReport is the typical resource usage of one target
+type resourceReport struct {
+	Target  string
+	Runs    int           // runs that recorded usage
+	CPU     time.Duration // median CPU time
+	PeakRSS int64         // highest peak memory
+}
+
+// heavyTargets ranks the targets by the median CPU time of their runs,
+// with the most memory one of their processes used. Runs recorded before
+// aura measured usage are left out
+func heavyTargets(history runHistory) []resourceReport {
+	var reports []resourceReport
+	for _, name := range sortedKeys(history) {
+		report := resourceReport{Target: name}
+		var cpu []time.Duration
+		for _, run := range history[name] {
+			if run.CPU == 0 && run.PeakRSS == 0 {
+				continue
+			}
+			report.Runs++
+			cpu = append(cpu, run.CPU)
+			report.PeakRSS = max(report.PeakRSS, run.PeakRSS)
+		}
+		if report.Runs == 0 {
+			continue
+		}
+		slices.Sort(cpu)
+		report.CPU = cpu[len(cpu)/2]
+		reports = append(reports, report)
+	}
+
+	slices.SortStableFunc(reports, func(a, b resourceReport) int {
+		return cmp.Or(cmp.Compare(b.CPU, a.CPU), cmp.Compare(b.PeakRSS, a.PeakRSS))
+	})
+	return reports
+}
+
+// analyzeCommand shows the available analyses
+func analyzeCommand(ctx *orpheus.Context) error {
+	fmt.Println("Build history analysis")
+	fmt.Println("Use 'aura analyze <subcommand>':")
+	fmt.Println("  flaky     - List targets that intermittently fail, with failure rates")
+	fmt.Println("  resources - List targets by the CPU time and memory their commands use")
+	return nil
+}
+
+// analyzeFlakyCommand lists flaky targets from the run history
+func analyzeFlakyCommand(ctx *orpheus.Context) error {
+	if err := cacheSetup(ctx); err != nil {
+		return err
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		return orpheus.ExecutionError("analyze", err.Error())
+	}
+	reports := flakyTargets(history)
+	if len(reports) == 0 {
+		fmt.Println("No flaky targets found in run history")
+		return nil
+	}
+
+	fmt.Printf("Flaky targets (last %d runs per target):\n", historyLimit)
+	fmt.Printf("  %-20s %5s %9s %8s  %s\n", "TARGET", "RUNS", "ATTEMPTS", "FAILURE", "EVIDENCE")
+	for _, r := range reports {
+		var evidence []string
+		if r.Retried > 0 {
+			evidence = append(evidence, fmt.Sprintf("%d passed on retry", r.Retried))
+		}
+		if r.Flips > 0 {
+			evidence = append(evidence, fmt.Sprintf("%d flips with unchanged sources", r.Flips))
+		}
+		fmt.Printf("  %-20s %5d %9d %7.0f%%  %s\n", r.Target, r.Runs, r.Attempts, 100*r.FailureRate(), strings.Join(evidence, ", "))
+	}
+	return nil
+}
+
+// analyzeResourcesCommand lists the targets by the resources they use
+func analyzeResourcesCommand(ctx *orpheus.Context) error {
+	if err := cacheSetup(ctx); err != nil {
+		return err
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		return orpheus.ExecutionError("analyze", err.Error())
+	}
+	reports := heavyTargets(history)
+	if len(reports) == 0 {
+		fmt.Println("No resource usage recorded in run history")
+		return nil
+	}
+
+	fmt.Printf("Resource usage (last %d runs per target):\n", historyLimit)
+	fmt.Printf("  %-20s %5s %12s %12s\n", "TARGET", "RUNS", "CPU", "PEAK MEMORY")
+	for _, r := range reports {
+		fmt.Printf("  %-20s %5d %12s %12s\n", r.Target, r.Runs, r.CPU.Round(time.Millisecond), formatMemory(r.PeakRSS))
+	}
+	return nil
+}
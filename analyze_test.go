@@ -0,0 +1,93 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// ===== ANALYZE.GO UNIT TESTS =====
+
+func TestFlakyTargets(t *testing.T) {
+	pass := func(attempts int, inputs string) runRecord {
+		return runRecord{OK: true, Attempts: attempts, Inputs: inputs}
+	}
+	fail := func(attempts int, inputs string) runRecord {
+		return runRecord{OK: false, Attempts: attempts, Inputs: inputs}
+	}
+
+	history := runHistory{
+		// Passes on retry now and then
+		"integration": {pass(1, ""), pass(3, ""), pass(1, ""), pass(2, "")},
+		// Fails and passes on the same sources
+		"unit": {pass(1, "a"), fail(1, "a"), pass(1, "a"), pass(1, "b")},
+		// Broken, then fixed by a source change: not flaky
+		"lint": {fail(1, "a"), fail(1, "a"), pass(1, "b")},
+		// Always passes
+		"build": {pass(0, ""), pass(1, "")},
+	}
+
+	reports := flakyTargets(history)
+	if len(reports) != 2 {
+		t.Fatalf("flakyTargets() returned %d reports, expected 2: %+v", len(reports), reports)
+	}
+
+	// integration: 3 failed of 7 attempts; unit: 1 failed of 4
+	if reports[0].Target != "integration" || reports[0].Retried != 2 || reports[0].Failed != 3 || reports[0].Attempts != 7 {
+		t.Errorf("first report = %+v", reports[0])
+	}
+	if reports[1].Target != "unit" || reports[1].Flips != 2 || reports[1].Failed != 1 {
+		t.Errorf("second report = %+v", reports[1])
+	}
+	if rate := reports[1].FailureRate(); rate != 0.25 {
+		t.Errorf("FailureRate() = %v, expected 0.25", rate)
+	}
+}
+
+func TestRunTimedRetries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	// Fails on the first attempt only
+	target := Target{Run: []string{"test -f attempted || (touch attempted && exit 1)"}, Retries: 2}
+	cfg = Config{Targets: map[string]Target{"flaky": target}}
+
+	if err := runTimed(newExpandContext("flaky", nil), &target, false, false); err != nil {
+		t.Fatalf("runTimed() should succeed on retry: %v", err)
+	}
+	history, err := loadHistory()
+	if err != nil {
+		t.Fatalf("loadHistory() error: %v", err)
+	}
+	if runs := history["flaky"]; len(runs) != 1 || !runs[0].OK || runs[0].Attempts != 2 {
+		t.Errorf("history = %+v, expected one passing run with 2 attempts", runs)
+	}
+}
+
+func TestHeavyTargets(t *testing.T) {
+	history := runHistory{
+		"lint": {
+			{OK: true, CPU: time.Second, PeakRSS: 50 << 20},
+		},
+		"build": {
+			{OK: true},
+			{OK: true, CPU: 10 * time.Second, PeakRSS: 1 << 30},
+			{OK: true, CPU: 30 * time.Second, PeakRSS: 2 << 30},
+			{OK: false, CPU: 20 * time.Second, PeakRSS: 1 << 30},
+		},
+		"docs": {{OK: true}},
+	}
+
+	reports := heavyTargets(history)
+	if len(reports) != 2 {
+		t.Fatalf("heavyTargets() = %+v, expected the 2 targets with recorded usage", reports)
+	}
+	expected := resourceReport{Target: "build", Runs: 3, CPU: 20 * time.Second, PeakRSS: 2 << 30}
+	if reports[0] != expected || reports[1].Target != "lint" {
+		t.Errorf("heavyTargets() = %+v, expected %+v first", reports, expected)
+	}
+}
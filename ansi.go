@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ansiPalette holds the 16 basic terminal colors, normal then bright, as
+// shown on a dark background
+var ansiPalette = [16]string{
+	"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+	"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#ffffff",
+}
+
+// ansiSGRRegex matches a color or style sequence and captures its
+// parameters
+var ansiSGRRegex = regexp.MustCompile(`\x1b\[([0-9;]*)m`)
+
+// ansiStyle is the color and style state a run of text is printed in
+type ansiStyle struct {
+	fg, bg                       string
+	bold, dim, italic, underline bool
+}
+
+// apply updates the style with the parameters of one SGR sequence
+func (s *ansiStyle) apply(params []int) {
+	for i := 0; i < len(params); i++ {
+		switch p := params[i]; {
+		case p == 0:
+			*s = ansiStyle{}
+		case p == 1:
+			s.bold = true
+		case p == 2:
+			s.dim = true
+		case p == 3:
+			s.italic = true
+		case p == 4:
+			s.underline = true
+		case p == 22:
+			s.bold, s.dim = false, false
+		case p == 23:
+			s.italic = false
+		case p == 24:
+			s.underline = false
+		case p >= 30 && p <= 37:
+			s.fg = ansiPalette[p-30]
+		case p >= 90 && p <= 97:
+			s.fg = ansiPalette[p-90+8]
+		case p == 39:
+			s.fg = ""
+		case p >= 40 && p <= 47:
+			s.bg = ansiPalette[p-40]
+		case p >= 100 && p <= 107:
+			s.bg = ansiPalette[p-100+8]
+		case p == 49:
+			s.bg = ""
+		case p == 38 || p == 48:
+			color, used := extendedColor(params[i+1:])
+			i += used
+			if color == "" {
+				continue
+			}
+			if p == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+}
+
+// css returns the inline style of the state, "" for the default one
+func (s ansiStyle) css() string {
+	var rules []string
+	if s.fg != "" {
+		rules = append(rules, "color:"+s.fg)
+	}
+	if s.bg != "" {
+		rules = append(rules, "background-color:"+s.bg)
+	}
+	if s.bold {
+		rules = append(rules, "font-weight:bold")
+	}
+	if s.dim {
+		rules = append(rules, "opacity:0.7")
+	}
+	if s.italic {
+		rules = append(rules, "font-style:italic")
+	}
+	if s.underline {
+		rules = append(rules, "text-decoration:underline")
+	}
+	return strings.Join(rules, ";")
+}
+
+// extendedColor reads the color of a 38/48 parameter from the ones after
+// it, 5;n for the 256-color palette or 2;r;g;b, and returns how many it used
+func extendedColor(params []int) (string, int) {
+	switch {
+	case len(params) >= 2 && params[0] == 5:
+		return color256(params[1]), 2
+	case len(params) >= 4 && params[0] == 2:
+		return fmt.Sprintf("#%02x%02x%02x", params[1]&0xff, params[2]&0xff, params[3]&0xff), 4
+	}
+	return "", len(params)
+}
+
+// color256 returns a color of the xterm 256-color palette: the basic 16,
+// a 6x6x6 cube, then 24 grays
+func color256(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return ansiPalette[n]
+	case n < 232:
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + 40*v
+		}
+		n -= 16
+		return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6))
+	}
+	gray := 8 + 10*(n-232)
+	return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+}
+
+// ansiToHTML renders sanitized command output as HTML for a <pre>: text is
+// escaped and colored runs become styled spans, so compiler diagnostics
+// keep their colors in reports
+func ansiToHTML(out string) string {
+	var b strings.Builder
+	var style ansiStyle
+	write := func(text string) {
+		if text == "" {
+			return
+		}
+		if css := style.css(); css != "" {
+			fmt.Fprintf(&b, `<span style="%s">%s</span>`, css, html.EscapeString(text))
+		} else {
+			b.WriteString(html.EscapeString(text))
+		}
+	}
+
+	last := 0
+	for _, m := range ansiSGRRegex.FindAllStringSubmatchIndex(out, -1) {
+		write(out[last:m[0]])
+		var params []int
+		for _, field := range strings.Split(out[m[2]:m[3]], ";") {
+			p, _ := strconv.Atoi(field)
+			params = append(params, p)
+		}
+		style.apply(params)
+		last = m[1]
+	}
+	write(out[last:])
+	return b.String()
+}
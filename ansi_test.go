@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+// ===== ANSI.GO UNIT TESTS =====
+
+func TestAnsiToHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		expected string
+	}{
+		{"plain", "a < b & c\n", "a &lt; b &amp; c\n"},
+		{"bold red then reset", "\x1b[1;31merror:\x1b[0m bad", `<span style="color:#cd3131;font-weight:bold">error:</span> bad`},
+		{"bright and background", "\x1b[92;41mok\x1b[m", `<span style="color:#23d18b;background-color:#cd3131">ok</span>`},
+		{"256 colors", "\x1b[38;5;208mwarn\x1b[39m.", `<span style="color:#ff8700">warn</span>.`},
+		{"true color", "\x1b[48;2;16;32;48mx", `<span style="background-color:#102030">x</span>`},
+		{"style off keeps color", "\x1b[1;4;34mA\x1b[22;24mB", `<span style="color:#2472c8;font-weight:bold;text-decoration:underline">A</span><span style="color:#2472c8">B</span>`},
+		{"truncated extended color", "\x1b[38;5mx", "x"},
+		{"escaped inside spans", "\x1b[33m<tag>\x1b[0m", `<span style="color:#e5e510">&lt;tag&gt;</span>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ansiToHTML(tt.out); got != tt.expected {
+				t.Errorf("ansiToHTML(%q) = %q, expected %q", tt.out, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestColor256(t *testing.T) {
+	tests := []struct {
+		n        int
+		expected string
+	}{
+		{1, "#cd3131"},
+		{16, "#000000"},
+		{208, "#ff8700"},
+		{231, "#ffffff"},
+		{232, "#080808"},
+		{255, "#eeeeee"},
+		{256, ""},
+	}
+
+	for _, tt := range tests {
+		if got := color256(tt.n); got != tt.expected {
+			t.Errorf("color256(%d) = %q, expected %q", tt.n, got, tt.expected)
+		}
+	}
+}
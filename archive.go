@@ -0,0 +1,205 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ReproArchive packs files into an archive that hashes identically on
+// every machine: sorted entries, one fixed timestamp, no owners
+type ReproArchive struct {
+	Output string   `yaml:"output"` // .tar, .tar.gz, .tgz or .zip
+	Files  []string `yaml:"files"`  // patterns, "**" allowed
+	Prefix string   `yaml:"prefix"` // directory prepended to every entry
+	Mtime  string   `yaml:"mtime"`  // as in output_mode; default SOURCE_DATE_EPOCH or 1980-01-01
+}
+
+// defaultArchiveTime is the earliest time a zip entry can carry
+var defaultArchiveTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// archiveFormat returns the format of an archive from its file name
+func archiveFormat(output string) (string, bool) {
+	lower := strings.ToLower(output)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz", true
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar", true
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip", true
+	}
+	return "", false
+}
+
+// validateArchives checks every repro_archive has an output of a known
+// format and files to pack
+func validateArchives() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		archive := cfg.Targets[name].ReproArchive
+		if archive == nil {
+			continue
+		}
+		if _, ok := archiveFormat(archive.Output); !ok {
+			return fmt.Errorf("repro_archive of target '%s' needs an output ending in .tar, .tar.gz, .tgz or .zip", name)
+		}
+		if len(archive.Files) == 0 {
+			return fmt.Errorf("repro_archive of target '%s' has no files", name)
+		}
+		if archive.Mtime != "" && archive.Mtime != "SOURCE_DATE_EPOCH" {
+			if _, err := parseMtime(archive.Mtime); err != nil {
+				return fmt.Errorf("repro_archive of target '%s': %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// archiveTime is the timestamp every entry gets
+func archiveTime(mtime string) (time.Time, error) {
+	if mtime == "" {
+		if buildEnv.Getenv("SOURCE_DATE_EPOCH") == "" {
+			return defaultArchiveTime, nil
+		}
+		mtime = "SOURCE_DATE_EPOCH"
+	}
+	t, err := parseMtime(mtime)
+	return t.UTC(), err
+}
+
+// archiveEntry is a file to pack under its name inside the archive
+type archiveEntry struct {
+	path string
+	name string
+	mode int64
+}
+
+// createArchive writes a reproducible archive of the matched files; the
+// patterns and output are expanded by ectx. Entries are sorted by name and
+// only the execute bit of the original mode is kept.
+func createArchive(ectx *expandContext, archive *ReproArchive) (int, error) {
+	output := ectx.Expand(archive.Output)
+	format, ok := archiveFormat(output)
+	if !ok {
+		return 0, fmt.Errorf("unsupported archive format '%s'", output)
+	}
+	mtime, err := archiveTime(archive.Mtime)
+	if err != nil {
+		return 0, err
+	}
+
+	var patterns []string
+	for _, pattern := range archive.Files {
+		patterns = append(patterns, ectx.Expand(pattern))
+	}
+	outputSlash := filepath.ToSlash(filepath.Clean(output))
+	var entries []archiveEntry
+	for _, file := range expandPatterns(patterns, ectx.target) {
+		if file == outputSlash {
+			continue
+		}
+		info, err := os.Stat(filepath.FromSlash(file))
+		if err != nil {
+			return 0, err
+		}
+		mode := int64(0o644)
+		if info.Mode()&0o100 != 0 {
+			mode = 0o755
+		}
+		name := path.Join(ectx.Expand(archive.Prefix), strings.TrimPrefix(path.Clean(file), "./"))
+		entries = append(entries, archiveEntry{path: file, name: name, mode: mode})
+	}
+	if len(entries) == 0 {
+		return 0, fmt.Errorf("no files match %s", strings.Join(patterns, ", "))
+	}
+
+	if dir := filepath.Dir(output); dir != "." {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return 0, err
+		}
+	}
+	// Write next to the output and rename, so a failed build leaves no
+	// half-written archive behind
+	tmp, err := os.CreateTemp(filepath.Dir(output), ".aura-archive-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	switch format {
+	case "zip":
+		err = writeZip(tmp, entries, mtime)
+	case "tar.gz":
+		gz := gzip.NewWriter(tmp)
+		if err = writeTar(gz, entries, mtime); err == nil {
+			err = gz.Close()
+		}
+	default:
+		err = writeTar(tmp, entries, mtime)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return 0, err
+	}
+	return len(entries), os.Rename(tmp.Name(), output)
+}
+
+// writeTar writes entries as a tar stream with root ownership
+func writeTar(w io.Writer, entries []archiveEntry, mtime time.Time) error {
+	tw := tar.NewWriter(w)
+	for _, entry := range entries {
+		// #nosec G304 - Files come from the target's own patterns
+		data, err := os.ReadFile(filepath.FromSlash(entry.path))
+		if err != nil {
+			return err
+		}
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     entry.name,
+			Mode:     entry.mode,
+			Size:     int64(len(data)),
+			ModTime:  mtime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// writeZip writes entries as a deflated zip
+func writeZip(w io.Writer, entries []archiveEntry, mtime time.Time) error {
+	zw := zip.NewWriter(w)
+	for _, entry := range entries {
+		// #nosec G304 - Files come from the target's own patterns
+		data, err := os.ReadFile(filepath.FromSlash(entry.path))
+		if err != nil {
+			return err
+		}
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: mtime}
+		header.SetMode(os.FileMode(entry.mode))
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// ===== ARCHIVE.GO UNIT TESTS =====
+
+func TestArchiveFormat(t *testing.T) {
+	tests := map[string]string{
+		"app.tar":      "tar",
+		"app.tar.gz":   "tar.gz",
+		"APP.TGZ":      "tar.gz",
+		"out/app.zip":  "zip",
+		"app.tar.zst":  "",
+		"app-1.0.0.gz": "",
+	}
+	for output, expected := range tests {
+		if got, _ := archiveFormat(output); got != expected {
+			t.Errorf("archiveFormat(%q) = %q, expected %q", output, got, expected)
+		}
+	}
+}
+
+func TestValidateArchives(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		archive *ReproArchive
+		wantErr bool
+	}{
+		{"valid", &ReproArchive{Output: "app.tgz", Files: []string{"dist/**"}}, false},
+		{"unknown format", &ReproArchive{Output: "app.rar", Files: []string{"dist/**"}}, true},
+		{"no files", &ReproArchive{Output: "app.zip"}, true},
+		{"bad mtime", &ReproArchive{Output: "app.zip", Files: []string{"x"}, Mtime: "soon"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: map[string]Target{"package": {ReproArchive: tt.archive}}}
+			if err := validateArchives(); (err != nil) != tt.wantErr {
+				t.Errorf("validateArchives() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// archiveFixture creates files to pack in a fresh working directory
+func archiveFixture(t *testing.T, mode os.FileMode, mtime time.Time) {
+	t.Helper()
+	files := map[string]string{"dist/b.txt": "b", "dist/a/x.txt": "x", "dist/run.sh": "#!/bin/sh\n"}
+	for name, content := range files {
+		path := filepath.FromSlash(name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		fileMode := mode
+		if name == "dist/run.sh" {
+			fileMode = 0o700
+		}
+		if err := os.WriteFile(path, []byte(content), fileMode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, fileMode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCreateArchiveReproducible(t *testing.T) {
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	buildEnv.Snapshot(0)
+
+	for _, output := range []string{"app.tar", "app.tar.gz", "app.zip"} {
+		t.Run(output, func(t *testing.T) {
+			archive := &ReproArchive{Output: "out/" + output, Files: []string{"dist/**"}, Prefix: "app"}
+			var builds [][]byte
+			for i, mode := range []os.FileMode{0o600, 0o664} {
+				if err := os.Chdir(t.TempDir()); err != nil {
+					t.Fatal(err)
+				}
+				archiveFixture(t, mode, time.Now().Add(time.Duration(i)*time.Hour))
+				count, err := createArchive(newExpandContext("package", nil), archive)
+				if err != nil || count != 3 {
+					t.Fatalf("createArchive() = %d, %v", count, err)
+				}
+				data, err := os.ReadFile(filepath.Join("out", output))
+				if err != nil {
+					t.Fatal(err)
+				}
+				builds = append(builds, data)
+			}
+			if !bytes.Equal(builds[0], builds[1]) {
+				t.Error("archives of the same content differ")
+			}
+		})
+	}
+}
+
+func TestCreateArchiveEntries(t *testing.T) {
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "946684800")
+	buildEnv.Snapshot(0)
+	archiveFixture(t, 0o600, time.Now())
+
+	if _, err := createArchive(newExpandContext("package", nil), &ReproArchive{Output: "app.tgz", Files: []string{"dist/**"}}); err != nil {
+		t.Fatalf("createArchive() error: %v", err)
+	}
+	f, err := os.Open("app.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+		if header.Uid != 0 || header.Gid != 0 || header.Uname != "" || header.Gname != "" {
+			t.Errorf("%s has an owner: %d/%d %s/%s", header.Name, header.Uid, header.Gid, header.Uname, header.Gname)
+		}
+		if !header.ModTime.Equal(time.Unix(946684800, 0)) {
+			t.Errorf("%s mtime = %v", header.Name, header.ModTime)
+		}
+		expected := int64(0o644)
+		if header.Name == "dist/run.sh" && runtime.GOOS != "windows" {
+			expected = 0o755
+		}
+		if header.Mode != expected {
+			t.Errorf("%s mode = %o, expected %o", header.Name, header.Mode, expected)
+		}
+	}
+	if !slices.Equal(names, []string{"dist/a/x.txt", "dist/b.txt", "dist/run.sh"}) {
+		t.Errorf("entries = %q", names)
+	}
+}
+
+func TestCreateArchiveZipTime(t *testing.T) {
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	buildEnv.Snapshot(0)
+	archiveFixture(t, 0o600, time.Now())
+
+	if _, err := createArchive(newExpandContext("package", nil), &ReproArchive{Output: "app.zip", Files: []string{"dist/*.txt"}}); err != nil {
+		t.Fatalf("createArchive() error: %v", err)
+	}
+	zr, err := zip.OpenReader("app.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = zr.Close() }()
+	if len(zr.File) != 1 || zr.File[0].Name != "dist/b.txt" || !zr.File[0].Modified.Equal(defaultArchiveTime) {
+		t.Errorf("zip entries = %+v", zr.File)
+	}
+
+	if _, err := createArchive(newExpandContext("package", nil), &ReproArchive{Output: "none.zip", Files: []string{"missing/*"}}); err == nil {
+		t.Error("createArchive() should fail when no files match")
+	}
+}
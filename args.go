@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// passthroughArgs are the command line arguments after `--`, available to
+// commands as $ARGS / $CLI_ARGS, and one by one as $CLI_ARGS_1 ... with
+// $CLI_ARGS_COUNT
+var passthroughArgs []string
+
+// argsRefRegex matches a reference to any of the passthrough variables
+var argsRefRegex = regexp.MustCompile(`\$\{?(ARGS|CLI_ARGS(_\w+)?)\b`)
+
+// varAssignRegex matches a NAME=value command line argument
+var varAssignRegex = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.]*)=(.*)$`)
+
+// parseVarOverrides reads Make-style NAME=value arguments, as in `aura
+// build -t compile CC=clang`, into variable overrides; other arguments are
+// an error, targets going in -t
+func parseVarOverrides(args []string) (map[string]string, error) {
+	overrides := map[string]string{}
+	for _, arg := range args {
+		m := varAssignRegex.FindStringSubmatch(arg)
+		if m == nil {
+			return nil, fmt.Errorf("unexpected argument '%s': pass targets with -t and variables as NAME=value", arg)
+		}
+		overrides[m[1]] = m[2]
+	}
+	return overrides, nil
+}
+
+// splitRepeatedFlag takes the --name VALUE flags out of the command line
+// arguments, wherever they are, so the flag repeats and a value may hold
+// commas. --var and --arg are read this way
+func splitRepeatedFlag(args []string, name string) ([]string, []string, error) {
+	var rest, values []string
+	for i := 0; i < len(args); i++ {
+		if value, ok := strings.CutPrefix(args[i], "--"+name+"="); ok {
+			values = append(values, value)
+			continue
+		}
+		if args[i] != "--"+name {
+			rest = append(rest, args[i])
+			continue
+		}
+		if i+1 == len(args) {
+			return nil, nil, fmt.Errorf("--%s needs a NAME=VALUE argument", name)
+		}
+		i++
+		values = append(values, args[i])
+	}
+	return rest, values, nil
+}
+
+// splitBoolFlag takes a --name switch out of the command line arguments,
+// wherever it is, and reports whether it was set. --no-color is read this
+// way, as errors are printed after the command has returned
+func splitBoolFlag(args []string, name string) ([]string, bool) {
+	var rest []string
+	set := false
+	for _, arg := range args {
+		if arg == "--"+name {
+			set = true
+			continue
+		}
+		if value, ok := strings.CutPrefix(arg, "--"+name+"="); ok {
+			set, _ = strconv.ParseBool(value)
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, set
+}
+
+// splitPassthrough splits command line arguments at the first `--`
+func splitPassthrough(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:]
+		}
+	}
+	return args, nil
+}
+
+// quoteArgs joins args into a string the command shell splits back into
+// the same arguments
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteArg(arg, runtime.GOOS == "windows")
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quoteArg quotes one argument for cmd.exe (windows) or a POSIX shell
+func quoteArg(arg string, windows bool) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\$`&|;<>()*?[]{}!#~%^") {
+		return arg
+	}
+	if windows {
+		return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// cliArgVar resolves $CLI_ARGS_<n>, the n-th argument after `--` (from 1),
+// quoted; missing arguments are empty so optional ones need no guard
+func cliArgVar(name string) (string, bool) {
+	index, ok := strings.CutPrefix(name, "CLI_ARGS_")
+	if !ok {
+		return "", false
+	}
+	n, err := strconv.Atoi(index)
+	if err != nil || n < 1 {
+		return "", false
+	}
+	if n > len(passthroughArgs) {
+		return "", true
+	}
+	return quoteArg(passthroughArgs[n-1], runtime.GOOS == "windows"), true
+}
+
+// applyPassthroughArgs appends $ARGS to the last command of each requested
+// target that does not place the arguments itself, so `aura build -t test -- -run
+// TestFoo` narrows a test run without editing the config
+func applyPassthroughArgs(names []string) {
+	if len(passthroughArgs) == 0 {
+		return
+	}
+
+	for _, name := range names {
+		target, ok := cfg.Targets[name]
+		if !ok || slices.ContainsFunc(target.Run, argsRefRegex.MatchString) {
+			continue
+		}
+		if len(target.Run) == 0 {
+			logWarn("target '%s' has no commands to pass arguments to", name)
+			continue
+		}
+
+		run := append([]string{}, target.Run...)
+		run[len(run)-1] += " $ARGS"
+		target.Run = run
+		cfg.Targets[name] = target
+	}
+}
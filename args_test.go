@@ -0,0 +1,211 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// ===== ARGS.GO UNIT TESTS =====
+
+func TestSplitPassthrough(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expected    []string
+		passthrough []string
+	}{
+		{"no separator", []string{"build", "-t", "test"}, []string{"build", "-t", "test"}, nil},
+		{"separator", []string{"build", "-t", "test", "--", "-run", "TestFoo"}, []string{"build", "-t", "test"}, []string{"-run", "TestFoo"}},
+		{"only first separator", []string{"-t", "x", "--", "a", "--", "b"}, []string{"-t", "x"}, []string{"a", "--", "b"}},
+		{"trailing separator", []string{"-t", "x", "--"}, []string{"-t", "x"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, passthrough := splitPassthrough(tt.args)
+			if !slices.Equal(args, tt.expected) || !slices.Equal(passthrough, tt.passthrough) {
+				t.Errorf("splitPassthrough(%q) = %q, %q, expected %q, %q", tt.args, args, passthrough, tt.expected, tt.passthrough)
+			}
+		})
+	}
+}
+
+func TestSplitRepeatedFlag(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		rest    []string
+		assigns []string
+		wantErr bool
+	}{
+		{"none", []string{"build", "-t", "test"}, []string{"build", "-t", "test"}, nil, false},
+		{"separate value", []string{"--var", "CC=clang", "build"}, []string{"build"}, []string{"CC=clang"}, false},
+		{"repeated anywhere", []string{"build", "--var=CC=clang", "-t", "test", "--var", "OUT=app"}, []string{"build", "-t", "test"}, []string{"CC=clang", "OUT=app"}, false},
+		{"missing value", []string{"build", "--var"}, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rest, assigns, err := splitRepeatedFlag(tt.args, "var")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitRepeatedFlag(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if !slices.Equal(rest, tt.rest) || !slices.Equal(assigns, tt.assigns) {
+				t.Errorf("splitRepeatedFlag(%q) = %q, %q, expected %q, %q", tt.args, rest, assigns, tt.rest, tt.assigns)
+			}
+		})
+	}
+}
+
+func TestParseVarOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{"none", nil, map[string]string{}, false},
+		{"make style", []string{"CC=clang", "OUTPUT=app2"}, map[string]string{"CC": "clang", "OUTPUT": "app2"}, false},
+		{"empty and with equals", []string{"CFLAGS=", "DEFS=-DX=1"}, map[string]string{"CFLAGS": "", "DEFS": "-DX=1"}, false},
+		{"last wins", []string{"CC=gcc", "CC=clang"}, map[string]string{"CC": "clang"}, false},
+		{"not an assignment", []string{"compile"}, nil, true},
+		{"bad name", []string{"1CC=clang"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVarOverrides(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVarOverrides(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if !tt.wantErr && !maps.Equal(got, tt.expected) {
+				t.Errorf("parseVarOverrides(%q) = %v, expected %v", tt.args, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	tests := []struct {
+		arg     string
+		windows bool
+		want    string
+	}{
+		{"-run", false, "-run"},
+		{"TestFoo", false, "TestFoo"},
+		{"Test Foo", false, "'Test Foo'"},
+		{"it's", false, `'it'\''s'`},
+		{"$HOME", false, "'$HOME'"},
+		{"", false, "''"},
+		{"Test Foo", true, `"Test Foo"`},
+		{`say "hi"`, true, `"say \"hi\""`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteArg(tt.arg, tt.windows); got != tt.want {
+			t.Errorf("quoteArg(%q, %t) = %q, expected %q", tt.arg, tt.windows, got, tt.want)
+		}
+	}
+}
+
+func TestApplyPassthroughArgs(t *testing.T) {
+	original, originalArgs := cfg, passthroughArgs
+	defer func() { cfg, passthroughArgs = original, originalArgs }()
+
+	cfg = Config{Targets: map[string]Target{
+		"test":   {Run: []string{"go vet ./...", "go test ./..."}},
+		"placed": {Run: []string{"go test ${ARGS} ./..."}},
+		"all":    {Deps: []string{"test"}},
+		"dep":    {Run: []string{"go generate"}},
+	}}
+	passthroughArgs = []string{"-run", "TestFoo"}
+
+	applyPassthroughArgs([]string{"test", "placed", "all"})
+
+	tests := []struct {
+		target   string
+		expected []string
+	}{
+		{"test", []string{"go vet ./...", "go test ./... $ARGS"}},
+		{"placed", []string{"go test ${ARGS} ./..."}},
+		{"all", nil},
+		{"dep", []string{"go generate"}},
+	}
+	for _, tt := range tests {
+		if got := cfg.Targets[tt.target].Run; !slices.Equal(got, tt.expected) {
+			t.Errorf("%s run = %q, expected %q", tt.target, got, tt.expected)
+		}
+	}
+
+	if got, _ := builtinVar("ARGS", "test"); got != "-run TestFoo" {
+		t.Errorf("$ARGS = %q, expected %q", got, "-run TestFoo")
+	}
+}
+
+func TestCliArgVars(t *testing.T) {
+	originalArgs := passthroughArgs
+	defer func() { passthroughArgs = originalArgs }()
+	passthroughArgs = []string{"deploy", "eu west"}
+
+	tests := []struct {
+		name    string
+		want    string
+		defined bool
+	}{
+		{"CLI_ARGS", quoteArgs(passthroughArgs), true},
+		{"CLI_ARGS_COUNT", "2", true},
+		{"CLI_ARGS_1", "deploy", true},
+		{"CLI_ARGS_2", quoteArgs([]string{"eu west"}), true},
+		{"CLI_ARGS_3", "", true},
+		{"CLI_ARGS_0", "", false},
+		{"CLI_ARGS_X", "", false},
+	}
+
+	for _, tt := range tests {
+		got, defined := builtinVar(tt.name, "test")
+		if got != tt.want || defined != tt.defined {
+			t.Errorf("builtinVar(%s) = %q, %t, expected %q, %t", tt.name, got, defined, tt.want, tt.defined)
+		}
+	}
+}
+
+func TestArgsRefRegex(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"go test $ARGS", true},
+		{"go test ${ARGS} ./...", true},
+		{"task $CLI_ARGS", true},
+		{"deploy ${CLI_ARGS_1}", true},
+		{"echo $ARGSX", false},
+		{"go test ./...", false},
+	}
+
+	for _, tt := range tests {
+		if got := argsRefRegex.MatchString(tt.command); got != tt.want {
+			t.Errorf("argsRefRegex.MatchString(%q) = %t, expected %t", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestSplitBoolFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		rest     []string
+		expected bool
+	}{
+		{[]string{"build", "-t", "test"}, []string{"build", "-t", "test"}, false},
+		{[]string{"--no-color", "build"}, []string{"build"}, true},
+		{[]string{"list", "--no-color"}, []string{"list"}, true},
+		{[]string{"--no-color=false", "build"}, []string{"build"}, false},
+	}
+
+	for _, tt := range tests {
+		rest, set := splitBoolFlag(tt.args, "no-color")
+		if !slices.Equal(rest, tt.rest) || set != tt.expected {
+			t.Errorf("splitBoolFlag(%q) = %q, %v; expected %q, %v", tt.args, rest, set, tt.rest, tt.expected)
+		}
+	}
+}
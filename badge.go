@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// buildStatus is the outcome of the last build, written by --status-file
+// for dashboards and README badges
+type buildStatus struct {
+	Result     string        `json:"result"` // passing or failing
+	Duration   time.Duration `json:"-"`
+	DurationMS int64         `json:"duration_ms"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Targets    []string      `json:"targets"`
+	Failed     []string      `json:"failed,omitempty"`
+
+	results []targetResult // for the HTML report
+}
+
+// newBuildStatus summarises a build from its target results and the error
+// it ended with, if any
+func newBuildStatus(list []targetResult, buildErr error, took time.Duration) buildStatus {
+	status := buildStatus{Result: "passing", Duration: took, DurationMS: took.Milliseconds(), FinishedAt: time.Now().UTC(), Targets: []string{}, results: list}
+	for _, r := range list {
+		status.Targets = append(status.Targets, r.Name)
+		if r.Err != nil {
+			status.Failed = append(status.Failed, r.Name)
+		}
+	}
+	if buildErr != nil || len(status.Failed) > 0 {
+		status.Result = "failing"
+	}
+	return status
+}
+
+// badgeSVG renders the status as a flat "build | passing 12s" badge
+func badgeSVG(status buildStatus) string {
+	color := "#4c1"
+	if status.Result != "passing" {
+		color = "#e05d44"
+	}
+	label := "build"
+	took := status.Duration.Round(time.Second)
+	if status.Duration < time.Second {
+		took = status.Duration.Round(time.Millisecond)
+	}
+	message := fmt.Sprintf("%s %s", status.Result, took)
+	// Roughly 7px per character of 11px Verdana plus padding
+	labelWidth := 7*len(label) + 10
+	messageWidth := 7*len(message) + 10
+	width := labelWidth + messageWidth
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`+"\n", width, label, html.EscapeString(message))
+	fmt.Fprintf(&b, `  <title>%s: %s (%s)</title>`+"\n", label, html.EscapeString(message), status.FinishedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, `  <rect width="%d" height="20" rx="3" fill="#555"/>`+"\n", width)
+	fmt.Fprintf(&b, `  <rect x="%d" width="%d" height="20" rx="3" fill="%s"/>`+"\n", labelWidth, messageWidth, color)
+	fmt.Fprintf(&b, `  <rect x="%d" width="4" height="20" fill="%s"/>`+"\n", labelWidth, color)
+	b.WriteString(`  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` + "\n")
+	fmt.Fprintf(&b, `    <text x="%d" y="14">%s</text>`+"\n", labelWidth/2, label)
+	fmt.Fprintf(&b, `    <text x="%d" y="14">%s</text>`+"\n", labelWidth+messageWidth/2, html.EscapeString(message))
+	b.WriteString("  </g>\n</svg>\n")
+	return b.String()
+}
+
+// writeStatusFile writes the status as an SVG badge when path ends in
+// .svg, as an HTML build report when it ends in .html and as JSON otherwise
+func writeStatusFile(path string, status buildStatus) error {
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg":
+		data = []byte(badgeSVG(status))
+	case ".html", ".htm":
+		if data, err = buildReportHTML(status); err != nil {
+			return err
+		}
+	default:
+		if data, err = json.MarshalIndent(status, "", "  "); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===== BADGE.GO UNIT TESTS =====
+
+func TestNewBuildStatus(t *testing.T) {
+	list := []targetResult{{Name: "lint"}, {Name: "test", Err: errors.New("exit status 1")}}
+
+	tests := []struct {
+		name     string
+		list     []targetResult
+		buildErr error
+		result   string
+		failed   []string
+	}{
+		{"passing", list[:1], nil, "passing", nil},
+		{"failed target", list, errors.New("exit status 1"), "failing", []string{"test"}},
+		{"failed hook", list[:1], errors.New("post_build hook failed"), "failing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := newBuildStatus(tt.list, tt.buildErr, 1500*time.Millisecond)
+			if status.Result != tt.result || !slices.Equal(status.Failed, tt.failed) {
+				t.Errorf("newBuildStatus() = %+v, expected result %s and failed %v", status, tt.result, tt.failed)
+			}
+			if status.DurationMS != 1500 || len(status.Targets) != len(tt.list) {
+				t.Errorf("newBuildStatus() = %+v", status)
+			}
+		})
+	}
+}
+
+func TestWriteStatusFile(t *testing.T) {
+	dir := t.TempDir()
+	status := buildStatus{Result: "failing", Duration: 42 * time.Second, DurationMS: 42000, Targets: []string{"build"}, Failed: []string{"build"}}
+
+	jsonPath := filepath.Join(dir, "out", "status.json")
+	if err := writeStatusFile(jsonPath, status); err != nil {
+		t.Fatalf("writeStatusFile() error: %v", err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("status file is not JSON: %v", err)
+	}
+	if decoded["result"] != "failing" || decoded["duration_ms"] != float64(42000) {
+		t.Errorf("status JSON = %s", data)
+	}
+
+	svgPath := filepath.Join(dir, "badge.SVG")
+	if err := writeStatusFile(svgPath, status); err != nil {
+		t.Fatalf("writeStatusFile() error: %v", err)
+	}
+	data, err = os.ReadFile(svgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := string(data)
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, ">failing 42s</text>") || !strings.Contains(svg, "#e05d44") {
+		t.Errorf("badge = %s", svg)
+	}
+}
+
+func TestBadgeSVGShortBuild(t *testing.T) {
+	svg := badgeSVG(buildStatus{Result: "passing", Duration: 340 * time.Millisecond})
+	if !strings.Contains(svg, ">passing 340ms</text>") || !strings.Contains(svg, "#4c1") {
+		t.Errorf("badge = %s", svg)
+	}
+}
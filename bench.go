@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// benchResult compares two runs of a target in a row. aura has no
+// artifact cache, so the target runs both times; what the second run
+// tells is whether aura considers the target up-to-date after it
+type benchResult struct {
+	Target  string
+	First   time.Duration
+	Second  time.Duration
+	Status  string   // targetStatus after the second run, against the first
+	Reasons []string // why the target is not up-to-date
+}
+
+// forgetBuilds drops the recorded builds of targets, as if they never ran
+func forgetBuilds(names []string) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	state, err := loadBuildState()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		delete(state, name)
+	}
+	return state.save()
+}
+
+// timedBuild runs the scheduled targets once each, in order, and returns
+// how long each took
+func timedBuild(order []string, verbose bool) (map[string]time.Duration, error) {
+	takeResults()
+	for _, name := range order {
+		if err := runTargetOnly(name, nil, verbose, false); err != nil {
+			takeResults()
+			return nil, err
+		}
+	}
+	durations := map[string]time.Duration{}
+	for _, result := range takeResults() {
+		durations[result.Name] += result.Duration
+	}
+	return durations, nil
+}
+
+// benchCache runs the targets and their deps with their build state
+// cleared, then again, and reports per target both times and why aura
+// does not consider it up-to-date after the second run
+func benchCache(names []string, verbose bool) ([]benchResult, error) {
+	requested, err := buildSchedule(names)
+	if err != nil {
+		return nil, err
+	}
+	var order []string
+	for _, node := range scheduleOrder(requested) {
+		order = append(order, node.name)
+	}
+	if err := forgetBuilds(order); err != nil {
+		return nil, err
+	}
+
+	first, err := timedBuild(order, verbose)
+	if err != nil {
+		return nil, err
+	}
+	// Nothing is edited between the runs, so sources that differ from
+	// the first build afterwards were changed by the build itself
+	state, err := loadBuildState()
+	if err != nil {
+		return nil, err
+	}
+	second, err := timedBuild(order, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []benchResult
+	for _, name := range order {
+		target := GetTarget(name)
+		if len(target.Run) == 0 && target.ReproArchive == nil {
+			continue
+		}
+		status, changes, err := targetStatus(name, &target, state)
+		if err != nil {
+			return nil, err
+		}
+		result := benchResult{Target: name, First: first[name], Second: second[name], Status: status}
+		switch status {
+		case statusUntracked:
+			result.Reasons = []string{"no sources declared, aura cannot tell when it is up-to-date"}
+		case statusNeverBuilt:
+			result.Reasons = []string{"no build recorded"}
+		case statusStale:
+			for _, change := range changes {
+				if strings.HasPrefix(change, "! ") {
+					result.Reasons = append(result.Reasons, "declared output never produced: "+change[2:])
+				} else {
+					result.Reasons = append(result.Reasons, "source changed by the build itself: "+change[2:])
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// benchCommand shows the available benchmarks
+func benchCommand(ctx *orpheus.Context) error {
+	fmt.Println("Benchmark builds")
+	fmt.Println("Use 'aura bench <subcommand>':")
+	fmt.Println("  cache  - Run targets twice and explain why aura does not see them as up-to-date")
+	return nil
+}
+
+// benchCacheCommand runs targets twice and reports which aura would see
+// as up-to-date
+func benchCacheCommand(ctx *orpheus.Context) error {
+	workDir := ctx.GetGlobalFlagString("directory")
+	configFile := ctx.GetGlobalFlagString("config")
+	verbose := ctx.GetGlobalFlagBool("verbose")
+	names := splitList(ctx.GetFlagString("targets"))
+	if len(names) == 0 {
+		return orpheus.ValidationError("targets", "usage: aura bench cache -t <targets>")
+	}
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	if err := loadConfigProfile(configFile, ctx.GetGlobalFlagString("profile")); err != nil {
+		return err
+	}
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+
+	// Everything runs twice, dangerous targets included
+	if !ctx.GetFlagBool("yes-i-mean-it") {
+		if err := confirmDangerous(names, os.Stdin, stdinIsTerminal()); err != nil {
+			return err
+		}
+	}
+
+	results, err := benchCache(names, verbose)
+	if err != nil {
+		return err
+	}
+
+	// Every target ran both times: the times differ by noise only
+	fmt.Println()
+	fmt.Println("aura has no artifact cache: targets run on every build, so both runs executed everything.")
+	fmt.Println("UP-TO-DATE tells whether aura sees a target as unchanged after a rebuild with no edits;")
+	fmt.Println("when it does not, its sources or outputs need tuning.")
+	fmt.Println()
+
+	fmt.Printf("  %-20s %10s %10s  %s\n", "TARGET", "FIRST", "SECOND", "UP-TO-DATE")
+	for _, r := range results {
+		upToDate := "yes"
+		if r.Status != statusUpToDate {
+			upToDate = "no"
+		}
+		fmt.Printf("  %-20s %10s %10s  %s\n", r.Target, r.First.Round(time.Millisecond), r.Second.Round(time.Millisecond), upToDate)
+		for _, reason := range r.Reasons {
+			fmt.Printf("  %-20s %s\n", "", "- "+reason)
+		}
+	}
+	return nil
+}
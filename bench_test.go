@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ===== BENCH.GO UNIT TESTS =====
+
+func TestBenchCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	if err := os.MkdirAll("src", 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("src/main.c", []byte("int main;"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg = Config{Targets: map[string]Target{
+		"gen":   {Sources: []string{"src/*"}, Run: []string{"echo x >> src/gen.c"}},
+		"build": {Deps: []string{"gen"}, Sources: []string{"src/main.c"}, Outputs: []string{"app"}, Run: []string{"cp src/main.c app"}},
+		"docs":  {Deps: []string{"gen"}, Sources: []string{"src/main.c"}, Outputs: []string{"docs/index.html"}, Run: []string{"true"}},
+		"lint":  {Run: []string{"true"}},
+	}}
+
+	// A stale record from an earlier build must not count
+	if err := (buildState{"build": {Files: map[string]string{"src/main.c": "old"}}}).save(); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := benchCache([]string{"build", "docs", "lint"}, false)
+	if err != nil {
+		t.Fatalf("benchCache() error: %v", err)
+	}
+
+	byName := map[string]benchResult{}
+	var order []string
+	for _, r := range results {
+		byName[r.Target] = r
+		order = append(order, r.Target)
+	}
+	if !slices.Equal(order, []string{"gen", "build", "docs", "lint"}) {
+		t.Errorf("results order = %q", order)
+	}
+
+	tests := []struct {
+		target string
+		status string
+		miss   string
+	}{
+		{"gen", statusStale, "source changed by the build itself: src/gen.c"},
+		{"build", statusUpToDate, ""},
+		{"docs", statusStale, "declared output never produced: docs/index.html"},
+		{"lint", statusUntracked, "no sources declared"},
+	}
+	for _, tt := range tests {
+		r := byName[tt.target]
+		if r.Status != tt.status {
+			t.Errorf("%s status = %s, expected %s", tt.target, r.Status, tt.status)
+		}
+		if tt.miss == "" && len(r.Reasons) > 0 {
+			t.Errorf("%s should be up-to-date, reasons = %q", tt.target, r.Reasons)
+		}
+		if tt.miss != "" && (len(r.Reasons) == 0 || !strings.HasPrefix(r.Reasons[0], tt.miss)) {
+			t.Errorf("%s reasons = %q, expected %q", tt.target, r.Reasons, tt.miss)
+		}
+		if r.First <= 0 || r.Second <= 0 {
+			t.Errorf("%s timings = %v / %v", tt.target, r.First, r.Second)
+		}
+	}
+
+	// gen, a dep of both build and docs, runs once per run
+	if data, err := os.ReadFile("src/gen.c"); err != nil || strings.Count(string(data), "x") != 2 {
+		t.Errorf("src/gen.c = %q, %v; expected gen to run once per run", data, err)
+	}
+}
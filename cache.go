@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+	"gopkg.in/yaml.v3"
+)
+
+// cacheDirFlag holds the value of the global --cache-dir flag
+var cacheDirFlag string
+
+// buildCacheDir returns the directory used for the build cache:
+// --cache-dir > AURA_CACHE_DIR > cache.path > per-project dir under the user cache dir
+func buildCacheDir() string {
+	if cacheDirFlag != "" {
+		return cacheDirFlag
+	}
+	if dir := os.Getenv("AURA_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	if cfg.Cache.Path != "" {
+		return cfg.Cache.Path
+	}
+
+	base, ok := userCacheBase()
+	if !ok {
+		return ".aura_cache"
+	}
+	return filepath.Join(base, projectCacheKey())
+}
+
+// userCacheBase returns the per-user directory shared by all projects:
+// the cache_dir user default, else aura's directory in the user cache dir
+func userCacheBase() (string, bool) {
+	if userDefaults.CacheDir != "" {
+		return userDefaults.CacheDir, true
+	}
+	userCache, err := os.UserCacheDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(userCache, "aura"), true
+}
+
+// projectCacheKey names the cache directory of the project in the working
+// directory, so projects sharing a base cache dir never collide
+func projectCacheKey() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		wd = "."
+	}
+	if abs, err := filepath.Abs(wd); err == nil {
+		wd = abs
+	}
+	sum := sha256.Sum256([]byte(wd))
+	return fmt.Sprintf("%s-%s", filepath.Base(wd), hex.EncodeToString(sum[:])[:12])
+}
+
+// cacheSetup prepares cache commands, which run without loading the full
+// configuration: it applies -D and --cache-dir and picks up cache.path
+func cacheSetup(ctx *orpheus.Context) error {
+	workDir := ctx.GetGlobalFlagString("directory")
+	configFile := ctx.GetGlobalFlagString("config")
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+
+	// A missing or broken config simply means no cache.path
+	var peek struct {
+		Cache CacheConfig `yaml:"cache"`
+	}
+	// #nosec G304 - User-specified config file, same as loadConfig
+	if data, err := os.ReadFile(configFile); err == nil && yaml.Unmarshal(data, &peek) == nil {
+		cfg.Cache = peek.Cache
+	}
+	return nil
+}
+
+// cacheStorage sets up the storage backend in the build cache directory,
+// which is only known once cacheSetup has applied the flags and the config,
+// and returns it; nil when it cannot be set up
+func cacheStorage(ctx *orpheus.Context) orpheus.Storage {
+	if ctx.App == nil {
+		return nil
+	}
+	if ctx.App.Storage() == nil {
+		ctx.App.ConfigureStorage(&orpheus.StorageConfig{
+			Provider: "file",
+			Config: map[string]interface{}{
+				"path": buildCacheDir(),
+			},
+			EnableMetrics: true,
+		})
+	}
+	return ctx.App.Storage()
+}
+
+// cacheRecords are the files aura keeps in a project's cache directory,
+// with a decoder for each; all of them are rebuilt when missing
+var cacheRecords = map[string]func([]byte) error{
+	"state.json":    func(data []byte) error { return json.Unmarshal(data, &buildState{}) },
+	"history.json":  func(data []byte) error { return json.Unmarshal(data, &runHistory{}) },
+	"manifest.json": func(data []byte) error { return json.Unmarshal(data, &buildManifest{}) },
+}
+
+// cacheProblem is a cache record that cannot be read back, or an artifact
+// whose recorded digest no longer matches; Evict is the record to remove
+type cacheProblem struct {
+	Path   string
+	Reason string
+	Evict  string
+}
+
+// verifyCache decodes every record in dir and re-hashes the artifacts of
+// the last manifest, returning how many records and artifacts it checked
+// and what is wrong with them
+func verifyCache(dir string) (int, int, []cacheProblem) {
+	records, artifacts := 0, 0
+	var problems []cacheProblem
+	for _, name := range sortedKeys(cacheRecords) {
+		path := filepath.Join(dir, name)
+		// #nosec G304 - Records live in aura's own cache directory
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		records++
+		if err == nil {
+			err = cacheRecords[name](data)
+		}
+		if err != nil {
+			problems = append(problems, cacheProblem{Path: path, Reason: err.Error(), Evict: path})
+			continue
+		}
+		if name == "manifest.json" {
+			checked, mismatches := verifyArtifacts(path, data)
+			artifacts += checked
+			problems = append(problems, mismatches...)
+		}
+	}
+	return records, artifacts, problems
+}
+
+// verifyArtifacts re-hashes the outputs listed in a manifest against their
+// recorded size and digest; a mismatch evicts the manifest, never the file
+func verifyArtifacts(manifestPath string, data []byte) (int, []cacheProblem) {
+	var manifest buildManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return 0, nil
+	}
+	checked := 0
+	var problems []cacheProblem
+	for _, target := range manifest.Targets {
+		for _, output := range target.Outputs {
+			checked++
+			path := output.Path
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(manifest.Project, path)
+			}
+			reason := ""
+			if info, err := os.Stat(path); err != nil {
+				reason = err.Error()
+			} else if info.Size() != output.Size {
+				reason = fmt.Sprintf("size %d, recorded %d", info.Size(), output.Size)
+			} else if sum, err := hashFile(path); err != nil {
+				reason = err.Error()
+			} else if sum != output.SHA256 {
+				reason = fmt.Sprintf("sha256 %s, recorded %s", shortHash(sum), shortHash(output.SHA256))
+			}
+			if reason != "" {
+				problems = append(problems, cacheProblem{Path: path, Reason: reason, Evict: manifestPath})
+			}
+		}
+	}
+	return checked, problems
+}
+
+// cacheVerifyCommand checks the cache records and the artifacts they list,
+// and with --repair evicts the records that are corrupt or out of date
+func cacheVerifyCommand(ctx *orpheus.Context) error {
+	if err := cacheSetup(ctx); err != nil {
+		return err
+	}
+	repair := ctx.GetFlagBool("repair")
+
+	dir := buildCacheDir()
+	records, artifacts, problems := verifyCache(dir)
+	fmt.Printf("Verified %d cache records and %d artifacts in %s\n", records, artifacts, dir)
+	evicted := map[string]bool{}
+	for _, problem := range problems {
+		fmt.Printf("%s %s: %s\n", crossMark(), problem.Path, problem.Reason)
+		if !repair || evicted[problem.Evict] {
+			continue
+		}
+		if err := os.Remove(problem.Evict); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return orpheus.ExecutionError("cache", fmt.Sprintf("cannot evict %s: %v", problem.Evict, err))
+		}
+		evicted[problem.Evict] = true
+		fmt.Printf("%s Evicted %s\n", checkMark(), problem.Evict)
+	}
+
+	switch {
+	case len(problems) == 0:
+		fmt.Println(checkMark(), "No corruption found")
+	case !repair:
+		return orpheus.ExecutionError("cache", fmt.Sprintf("%d cache problems found (use --repair to evict the records)", len(problems)))
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ===== CACHE.GO UNIT TESTS =====
+
+func TestBuildCacheDirPrecedence(t *testing.T) {
+	originalCfg, originalDefaults, originalFlag := cfg, userDefaults, cacheDirFlag
+	defer func() { cfg, userDefaults, cacheDirFlag = originalCfg, originalDefaults, originalFlag }()
+
+	tests := []struct {
+		name     string
+		flag     string
+		env      string
+		path     string
+		userBase string
+		expected string
+	}{
+		{"flag wins", "/flag", "/env", "/config", "/user", "/flag"},
+		{"env beats config", "", "/env", "/config", "/user", "/env"},
+		{"config beats user defaults", "", "", "/config", "/user", "/config"},
+		{"user base is per project", "", "", "", "/user", filepath.Join("/user", projectCacheKey())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDirFlag = tt.flag
+			t.Setenv("AURA_CACHE_DIR", tt.env)
+			cfg = Config{Cache: CacheConfig{Path: tt.path}}
+			userDefaults = UserDefaults{CacheDir: tt.userBase}
+
+			if got := buildCacheDir(); got != tt.expected {
+				t.Errorf("buildCacheDir() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildCacheDirDefault(t *testing.T) {
+	originalCfg, originalDefaults, originalFlag := cfg, userDefaults, cacheDirFlag
+	defer func() { cfg, userDefaults, cacheDirFlag = originalCfg, originalDefaults, originalFlag }()
+
+	cfg, userDefaults, cacheDirFlag = Config{}, UserDefaults{}, ""
+	t.Setenv("AURA_CACHE_DIR", "")
+
+	userCache, err := os.UserCacheDir()
+	if err != nil {
+		t.Skipf("no user cache dir: %v", err)
+	}
+	expected := filepath.Join(userCache, "aura", projectCacheKey())
+	if got := buildCacheDir(); got != expected {
+		t.Errorf("buildCacheDir() = %q, want %q", got, expected)
+	}
+}
+
+func TestProjectCacheKey(t *testing.T) {
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer func() { _ = os.Chdir(originalWd) }()
+
+	first, second := filepath.Join(t.TempDir(), "app"), filepath.Join(t.TempDir(), "app")
+	for _, dir := range []string{first, second} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	_ = os.Chdir(first)
+	firstKey := projectCacheKey()
+	if projectCacheKey() != firstKey {
+		t.Errorf("projectCacheKey() is not stable")
+	}
+	_ = os.Chdir(second)
+	secondKey := projectCacheKey()
+
+	if !strings.HasPrefix(firstKey, "app-") {
+		t.Errorf("projectCacheKey() = %q, want app- prefix", firstKey)
+	}
+	if firstKey == secondKey {
+		t.Errorf("projectCacheKey() collides for different paths: %q", firstKey)
+	}
+}
+
+func TestVerifyCache(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "state.json"):    `{"build": {"built_at": "2024-01-02T03:04:05Z", "files": {}}}`,
+		filepath.Join(dir, "history.json"):  `{"build": [{"ok": tru`,
+		filepath.Join(dir, "manifest.json"): `{"targets": "none"}`,
+	})
+
+	checked, _, problems := verifyCache(dir)
+	if checked != 3 {
+		t.Errorf("verifyCache() checked %d records, expected 3", checked)
+	}
+	var corrupt []string
+	for _, problem := range problems {
+		corrupt = append(corrupt, filepath.Base(problem.Path))
+	}
+	if expected := []string{"history.json", "manifest.json"}; !reflect.DeepEqual(corrupt, expected) {
+		t.Errorf("verifyCache() corrupt = %v, expected %v", corrupt, expected)
+	}
+
+	if checked, _, problems := verifyCache(t.TempDir()); checked != 0 || problems != nil {
+		t.Errorf("verifyCache() on an empty cache = %d, %v", checked, problems)
+	}
+}
+
+func TestVerifyCacheArtifacts(t *testing.T) {
+	project := t.TempDir()
+	dir := t.TempDir()
+	writeFiles(t, map[string]string{
+		filepath.Join(project, "good.txt"):    "good",
+		filepath.Join(project, "changed.txt"): "tampered",
+	})
+	good, _ := hashFile(filepath.Join(project, "good.txt"))
+	manifest := buildManifest{Project: project, Targets: []manifestTarget{{
+		Name: "build",
+		Outputs: []artifact{
+			{Path: "good.txt", Size: 4, SHA256: good},
+			{Path: "changed.txt", Size: 8, SHA256: good},
+			{Path: "missing.txt", Size: 4, SHA256: good},
+		},
+	}}}
+	data, _ := json.Marshal(manifest)
+	writeFiles(t, map[string]string{filepath.Join(dir, "manifest.json"): string(data)})
+
+	records, artifacts, problems := verifyCache(dir)
+	if records != 1 || artifacts != 3 {
+		t.Errorf("verifyCache() checked %d records and %d artifacts, expected 1 and 3", records, artifacts)
+	}
+	var mismatched []string
+	for _, problem := range problems {
+		mismatched = append(mismatched, filepath.Base(problem.Path))
+		if problem.Evict != filepath.Join(dir, "manifest.json") {
+			t.Errorf("verifyCache() evicts %s for %s, expected the manifest", problem.Evict, problem.Path)
+		}
+	}
+	if expected := []string{"changed.txt", "missing.txt"}; !reflect.DeepEqual(mismatched, expected) {
+		t.Errorf("verifyCache() mismatched = %v, expected %v", mismatched, expected)
+	}
+}
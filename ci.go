@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CI services aura adapts its output to
+const (
+	ciGitHub  = "github"
+	ciGitLab  = "gitlab"
+	ciGeneric = "ci"
+)
+
+// ciFold wraps each target's output in a collapsible section of the CI
+// log. Only sequential builds fold: interleaved sections would not nest.
+var ciFold bool
+
+// detectCI returns the CI service aura runs on, or "" outside CI
+func detectCI() string {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return ciGitHub
+	case os.Getenv("GITLAB_CI") == "true":
+		return ciGitLab
+	}
+	switch strings.ToLower(os.Getenv("CI")) {
+	case "", "0", "false", "no":
+		return ""
+	}
+	return ciGeneric
+}
+
+// gitlabSectionRegex matches the characters GitLab rejects in section names
+var gitlabSectionRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// ciGroup starts a collapsible log section for a target on the given CI
+// service and returns the function that ends it
+func ciGroup(w io.Writer, provider, name string) func() {
+	switch provider {
+	case ciGitHub:
+		fmt.Fprintf(w, "::group::%s\n", name)
+		return func() { fmt.Fprintln(w, "::endgroup::") }
+	case ciGitLab:
+		section := "aura_" + gitlabSectionRegex.ReplaceAllString(name, "_")
+		fmt.Fprintf(w, "\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s\n", time.Now().Unix(), section, name)
+		return func() { fmt.Fprintf(w, "\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", time.Now().Unix(), section) }
+	}
+	return func() {}
+}
+
+// targetResult is the outcome of one target in the current build
+type targetResult struct {
+	Name     string
+	Duration time.Duration
+	Usage    resourceUsage
+	Attempts int
+	Err      error
+	Skipped  string // why the target did not run: its platforms: or a commit marker
+}
+
+// results collects the targets that ran during the current build
+var results struct {
+	sync.Mutex
+	list []targetResult
+}
+
+func recordResult(result targetResult) {
+	results.Lock()
+	defer results.Unlock()
+	results.list = append(results.list, result)
+}
+
+// takeResults returns the results so far and starts a new build
+func takeResults() []targetResult {
+	results.Lock()
+	defer results.Unlock()
+	list := results.list
+	results.list = nil
+	return list
+}
+
+// writeSummary prints one key=value line per target, easy to grep and
+// parse from CI logs
+func writeSummary(w io.Writer, list []targetResult) {
+	if len(list) == 0 {
+		return
+	}
+	var failed int
+	var total time.Duration
+	for _, r := range list {
+		total += r.Duration
+		if r.Err != nil {
+			failed++
+		}
+	}
+	fmt.Fprintf(w, "aura summary: targets=%d failed=%d duration=%s\n", len(list), failed, total.Round(time.Millisecond))
+	for _, r := range list {
+		status := "ok"
+		if r.Err != nil {
+			status = "failed"
+		} else if r.Skipped != "" {
+			status = "skipped"
+		}
+		fmt.Fprintf(w, "aura result: target=%s status=%s duration=%s attempts=%d\n", r.Name, status, r.Duration.Round(time.Millisecond), max(1, r.Attempts))
+	}
+}
+
+// annotate adds an annotation (error, warning or notice) about a target to
+// the GitHub Actions job, on a single line
+func annotate(w io.Writer, level, name, message string) {
+	fmt.Fprintf(w, "::%s title=%s::%s\n", level, githubEscape("aura "+name, true), githubEscape(strings.Join(strings.Fields(message), " "), false))
+}
+
+// writeStepSummary appends a markdown table of the target results to the
+// GitHub Actions job summary named by $GITHUB_STEP_SUMMARY
+func writeStepSummary(list []targetResult) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" || len(list) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("### aura build\n\n| Target | Result | Duration | Attempts |\n| --- | --- | ---: | ---: |\n")
+	for _, r := range list {
+		status := "✅ ok"
+		if r.Err != nil {
+			status = "❌ failed"
+		} else if r.Skipped != "" {
+			status = "⏭️ skipped"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %d |\n", r.Name, status, r.Duration.Round(time.Millisecond), max(1, r.Attempts))
+	}
+	b.WriteString("\n")
+
+	// #nosec G304 - Path is provided by the GitHub Actions runner
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
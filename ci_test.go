@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// ===== CI.GO UNIT TESTS =====
+
+func TestDetectCI(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{"not in CI", nil, ""},
+		{"github", map[string]string{"GITHUB_ACTIONS": "true", "CI": "true"}, ciGitHub},
+		{"gitlab", map[string]string{"GITLAB_CI": "true", "CI": "true"}, ciGitLab},
+		{"generic", map[string]string{"CI": "1"}, ciGeneric},
+		{"CI disabled", map[string]string{"CI": "false"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI"} {
+				t.Setenv(key, tt.env[key])
+			}
+			if got := detectCI(); got != tt.expected {
+				t.Errorf("detectCI() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCIDisablesPromptsAndColor(t *testing.T) {
+	t.Setenv("CI", "true")
+	if stdinIsTerminal() {
+		t.Error("stdinIsTerminal() should be false in CI")
+	}
+	if colorEnabled() {
+		t.Error("colorEnabled() should be false in CI")
+	}
+}
+
+func TestCIGroup(t *testing.T) {
+	var buf bytes.Buffer
+	end := ciGroup(&buf, ciGitHub, "build")
+	buf.WriteString("output\n")
+	end()
+	if buf.String() != "::group::build\noutput\n::endgroup::\n" {
+		t.Errorf("github group = %q", buf.String())
+	}
+
+	buf.Reset()
+	ciGroup(&buf, ciGitLab, "test:unit")()
+	if !regexp.MustCompile(`^\x1b\[0Ksection_start:\d+:aura_test_unit\[collapsed=true\]\r\x1b\[0Ktest:unit\n\x1b\[0Ksection_end:\d+:aura_test_unit\r\x1b\[0K\n$`).MatchString(buf.String()) {
+		t.Errorf("gitlab section = %q", buf.String())
+	}
+
+	buf.Reset()
+	ciGroup(&buf, ciGeneric, "build")()
+	if buf.Len() != 0 {
+		t.Errorf("generic CI should not fold, got %q", buf.String())
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	takeResults()
+	recordResult(targetResult{Name: "lint", Duration: 1500 * time.Millisecond})
+	recordResult(targetResult{Name: "test", Duration: 2 * time.Second, Attempts: 3, Err: errors.New("exit status 1")})
+
+	var buf bytes.Buffer
+	writeSummary(&buf, takeResults())
+	expected := "aura summary: targets=2 failed=1 duration=3.5s\n" +
+		"aura result: target=lint status=ok duration=1.5s attempts=1\n" +
+		"aura result: target=test status=failed duration=2s attempts=3\n"
+	if buf.String() != expected {
+		t.Errorf("writeSummary() = %q, expected %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	writeSummary(&buf, takeResults())
+	if buf.Len() != 0 {
+		t.Errorf("empty build should print no summary, got %q", buf.String())
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	var buf bytes.Buffer
+	annotate(&buf, "error", "test:unit", "in test:unit -> \nexit status 1")
+	expected := "::error title=aura test%3Aunit::in test:unit -> exit status 1\n"
+	if buf.String() != expected {
+		t.Errorf("annotate() = %q, expected %q", buf.String(), expected)
+	}
+}
+
+func TestWriteStepSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	if err := os.WriteFile(path, []byte("earlier step\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+	list := []targetResult{
+		{Name: "lint", Duration: 1500 * time.Millisecond, Attempts: 1},
+		{Name: "test", Duration: 2 * time.Second, Attempts: 2, Err: errors.New("exit status 1")},
+	}
+	if err := writeStepSummary(list); err != nil {
+		t.Fatalf("writeStepSummary() error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "earlier step\n### aura build\n\n| Target | Result | Duration | Attempts |\n| --- | --- | ---: | ---: |\n" +
+		"| `lint` | ✅ ok | 1.5s | 1 |\n| `test` | ❌ failed | 2s | 2 |\n\n"
+	if string(data) != expected {
+		t.Errorf("summary file = %q, expected %q", data, expected)
+	}
+
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	if err := writeStepSummary(list); err != nil {
+		t.Errorf("writeStepSummary() without a summary file should do nothing, got %v", err)
+	}
+}
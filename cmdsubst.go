@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// commandOutputs caches the output of the $(command)s of vars by command
+// and shell, so a command used by several vars or targets runs once per
+// load
+var commandOutputs struct {
+	sync.Mutex
+	byCommand map[string]string
+}
+
+// resetCommandOutputs forgets the outputs of an earlier load
+func resetCommandOutputs() {
+	commandOutputs.Lock()
+	defer commandOutputs.Unlock()
+	commandOutputs.byCommand = map[string]string{}
+}
+
+// substituteValue replaces each $(command) of a value with what run returns
+// for it, matching parentheses so commands may contain their own
+func substituteValue(value string, run func(command string) (string, error)) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "$(")
+		if start < 0 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+		depth, end := 0, -1
+		for i := start + 1; i < len(value) && end < 0; i++ {
+			switch value[i] {
+			case '(':
+				depth++
+			case ')':
+				if depth--; depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			return "", fmt.Errorf("unterminated %s", value[start:])
+		}
+
+		command := strings.TrimSpace(value[start+2 : end])
+		if command == "" {
+			return "", fmt.Errorf("empty $()")
+		}
+		out, err := run(command)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value[:start])
+		b.WriteString(out)
+		value = value[end+1:]
+	}
+}
+
+// commandOutput runs the command of a $(...) through shell once and returns
+// what it printed, trailing newlines removed as the shell does
+func commandOutput(command string, shell Var) (string, error) {
+	commandOutputs.Lock()
+	defer commandOutputs.Unlock()
+	key := string(shell) + "\x00" + command
+	if out, ok := commandOutputs.byCommand[key]; ok {
+		return out, nil
+	}
+
+	out, err := shellCommand(shell, command).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			err = fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("$(%s) failed: %v", command, err)
+	}
+	output := strings.TrimRight(string(out), "\r\n")
+	if commandOutputs.byCommand == nil {
+		commandOutputs.byCommand = map[string]string{}
+	}
+	commandOutputs.byCommand[key] = output
+	return output, nil
+}
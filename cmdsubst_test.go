@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== CMDSUBST.GO UNIT TESTS =====
+
+func TestSubstituteValue(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	resetCommandOutputs()
+
+	tests := []struct {
+		value    string
+		expected string
+		wantErr  string
+	}{
+		{"plain $VAR", "plain $VAR", ""},
+		{"$(echo abc)", "abc", ""},
+		{"v$(printf '1\\n\\n').$(echo 2)", "v1.2", ""},
+		{"$(echo $((1 + 2)))", "3", ""},
+		{"$(echo a", "", "unterminated $(echo a"},
+		{"$( )", "", "empty $()"},
+		{"$(echo oops >&2; exit 3)", "", "failed: exit status 3: oops"},
+	}
+
+	for _, tt := range tests {
+		got, err := substituteValue(tt.value, func(command string) (string, error) {
+			return commandOutput(command, "sh")
+		})
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("substituteValue(%q) error = %v, expected %q", tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.expected {
+			t.Errorf("substituteValue(%q) = %q, %v; expected %q", tt.value, got, err, tt.expected)
+		}
+	}
+}
+
+func TestCommandVars(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original, originalProfile := cfg, activeProfile
+	defer func() { cfg, activeProfile = original, originalProfile }()
+	chdirTemp(t)
+	resetCommandOutputs()
+
+	// Each command runs once however many vars and targets use it, and
+	// only once a target needs its value
+	run := "$(echo x >> runs; wc -l < runs)"
+	cfg = Config{
+		Vars:     map[string]Var{"A": Var(run), "B": Var("b-" + run), "N": "$(echo 3)", "JOBS": "${N * 2}", "UNUSED": "$(echo x >> unused)"},
+		Profiles: map[string]Profile{"release": {Vars: map[string]Var{"KEY": "$(cat /nonexistent/key)"}}},
+		Targets:  map[string]Target{"lib": {Vars: map[string]Var{"C": Var(run)}}},
+	}
+	if err := checkVars(); err != nil {
+		t.Fatalf("checkVars() error = %v, expected the commands left to the targets", err)
+	}
+	if _, err := os.Stat("runs"); err == nil {
+		t.Errorf("checkVars() ran the commands of vars")
+	}
+
+	ectx := newExpandContext("lib", nil)
+	a, b, c := ectx.Expand("$A"), ectx.Expand("$B"), ectx.Expand("$C")
+	if strings.TrimSpace(a) != "1" || b != "b-"+a || c != a || ectx.Err() != nil {
+		t.Errorf("vars = %q, %q, %q, %v; expected the output of one run", a, b, c, ectx.Err())
+	}
+	if jobs := ectx.Expand("$JOBS"); jobs != "6" {
+		t.Errorf("Expand($JOBS) = %q, expected an expression over a command", jobs)
+	}
+	if _, err := os.Stat("unused"); err == nil {
+		t.Errorf("a var no command used ran its command")
+	}
+
+	// Plans show the commands instead of running them
+	if got := planContext("lib", cfg.Targets["lib"]).Expand("$B"); got != "b-"+run {
+		t.Errorf("planContext().Expand($B) = %q, expected the command", got)
+	}
+
+	activeProfile = "release"
+	ectx = newExpandContext("lib", nil)
+	ectx.Expand("key=$KEY")
+	if err := ectx.Err(); err == nil || !strings.HasPrefix(err.Error(), "profiles.release.vars.KEY: $(cat /nonexistent/key) failed") {
+		t.Errorf("Err() = %v, expected the failing var", err)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// codePages maps the bytes 0x80-0xFF of the single-byte code pages aura
+// transcodes command output from to their characters; the lower half is
+// ASCII in all of them. Bytes a code page leaves undefined are U+FFFD
+var codePages = map[int]string{
+	// US
+	437: "ÇüéâäàåçêëèïîìÄÅ" +
+		"ÉæÆôöòûùÿÖÜ¢£¥₧ƒ" +
+		"áíóúñÑªº¿⌐¬½¼¡«»" +
+		"░▒▓│┤╡╢╖╕╣║╗╝╜╛┐" +
+		"└┴┬├─┼╞╟╚╔╩╦╠═╬╧" +
+		"╨╤╥╙╘╒╓╫╪┘┌█▄▌▐▀" +
+		"αßΓπΣσµτΦΘΩδ∞φε∩" +
+		"≡±≥≤⌠⌡÷≈°∙·√ⁿ²■\u00A0",
+	// Western Europe
+	850: "ÇüéâäàåçêëèïîìÄÅ" +
+		"ÉæÆôöòûùÿÖÜø£Ø×ƒ" +
+		"áíóúñÑªº¿®¬½¼¡«»" +
+		"░▒▓│┤ÁÂÀ©╣║╗╝¢¥┐" +
+		"└┴┬├─┼ãÃ╚╔╩╦╠═╬¤" +
+		"ðÐÊËÈıÍÎÏ┘┌█▄¦Ì▀" +
+		"ÓßÔÒõÕµþÞÚÛÙýÝ¯´" +
+		"\u00AD±‗¾¶§÷¸°¨·¹³²■\u00A0",
+	// Central Europe
+	852: "ÇüéâäůćçłëŐőîŹÄĆ" +
+		"ÉĹĺôöĽľŚśÖÜŤťŁ×č" +
+		"áíóúĄąŽžĘę¬źČş«»" +
+		"░▒▓│┤ÁÂĚŞ╣║╗╝Żż┐" +
+		"└┴┬├─┼Ăă╚╔╩╦╠═╬¤" +
+		"đĐĎËďŇÍÎě┘┌█▄ŢŮ▀" +
+		"ÓßÔŃńňŠšŔÚŕŰýÝţ´" +
+		"\u00AD˝˛ˇ˘§÷¸°¨˙űŘř■\u00A0",
+	// Cyrillic
+	866: "АБВГДЕЖЗИЙКЛМНОП" +
+		"РСТУФХЦЧШЩЪЫЬЭЮЯ" +
+		"абвгдежзийклмноп" +
+		"░▒▓│┤╡╢╖╕╣║╗╝╜╛┐" +
+		"└┴┬├─┼╞╟╚╔╩╦╠═╬╧" +
+		"╨╤╥╙╘╒╓╫╪┘┌█▄▌▐▀" +
+		"рстуфхцчшщъыьэюя" +
+		"ЁёЄєЇїЎў°∙·√№¤■\u00A0",
+	// Central Europe (ANSI)
+	1250: "€\uFFFD‚\uFFFD„…†‡\uFFFD‰Š‹ŚŤŽŹ" +
+		"\uFFFD‘’“”•–—\uFFFD™š›śťžź" +
+		"\u00A0ˇ˘Ł¤Ą¦§¨©Ş«¬\u00AD®Ż" +
+		"°±˛ł´µ¶·¸ąş»Ľ˝ľż" +
+		"ŔÁÂĂÄĹĆÇČÉĘËĚÍÎĎ" +
+		"ĐŃŇÓÔŐÖ×ŘŮÚŰÜÝŢß" +
+		"ŕáâăäĺćçčéęëěíîď" +
+		"đńňóôőö÷řůúűüýţ˙",
+	// Cyrillic (ANSI)
+	1251: "ЂЃ‚ѓ„…†‡€‰Љ‹ЊЌЋЏ" +
+		"ђ‘’“”•–—\uFFFD™љ›њќћџ" +
+		"\u00A0ЎўЈ¤Ґ¦§Ё©Є«¬\u00AD®Ї" +
+		"°±Ііґµ¶·ё№є»јЅѕї" +
+		"АБВГДЕЖЗИЙКЛМНОП" +
+		"РСТУФХЦЧШЩЪЫЬЭЮЯ" +
+		"абвгдежзийклмноп" +
+		"рстуфхцчшщъыьэюя",
+	// Western Europe (ANSI)
+	1252: "€\uFFFD‚ƒ„…†‡ˆ‰Š‹Œ\uFFFDŽ\uFFFD" +
+		"\uFFFD‘’“”•–—˜™š›œ\uFFFDžŸ" +
+		"\u00A0¡¢£¤¥¦§¨©ª«¬\u00AD®¯" +
+		"°±²³´µ¶·¸¹º»¼½¾¿" +
+		"ÀÁÂÃÄÅÆÇÈÉÊËÌÍÎÏ" +
+		"ÐÑÒÓÔÕÖ×ØÙÚÛÜÝÞß" +
+		"àáâãäåæçèéêëìíîï" +
+		"ðñòóôõö÷øùúûüýþÿ",
+}
+
+// outputCodePage resolves an output_encoding setting to the code page
+// command output is transcoded from, 0 for none. auto (the default) is
+// the console code page on Windows and none elsewhere
+func outputCodePage(setting string) (int, error) {
+	switch strings.ToLower(setting) {
+	case "", "auto":
+		return systemCodePage(), nil
+	case "utf-8", "utf8", "cp65001":
+		return 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(setting), "cp"))
+	if _, known := codePages[n]; err != nil || !known {
+		names := []string{}
+		for _, cp := range slices.Sorted(maps.Keys(codePages)) {
+			names = append(names, fmt.Sprintf("cp%d", cp))
+		}
+		return 0, fmt.Errorf("unknown output_encoding '%s' (use auto, utf-8, %s)", setting, strings.Join(names, ", "))
+	}
+	return n, nil
+}
+
+// targetCodePage returns the code page of a target's output: its own
+// output_encoding, else the config's. Settings are checked at load
+func targetCodePage(target *Target) int {
+	setting := target.OutputEncoding
+	if setting == "" {
+		setting = cfg.OutputEncoding
+	}
+	codePage, _ := outputCodePage(setting)
+	return codePage
+}
+
+// transcodeOutput converts output in codePage to UTF-8. Output that is
+// valid UTF-8 already, as from tools that ignore the console code page,
+// is left alone
+func transcodeOutput(out string, codePage int) string {
+	table, ok := codePages[codePage]
+	if !ok || utf8.ValidString(out) {
+		return out
+	}
+	upper := []rune(table)
+	var b strings.Builder
+	b.Grow(len(out) * 2)
+	for i := 0; i < len(out); i++ {
+		if c := out[i]; c < utf8.RuneSelf {
+			b.WriteByte(c)
+		} else {
+			b.WriteRune(upper[c-utf8.RuneSelf])
+		}
+	}
+	return b.String()
+}
+
+// validateOutputEncodings checks the output_encoding of the config and its
+// targets
+func validateOutputEncodings() error {
+	if _, err := outputCodePage(cfg.OutputEncoding); err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(cfg.Targets) {
+		if _, err := outputCodePage(cfg.Targets[name].OutputEncoding); err != nil {
+			return fmt.Errorf("target '%s': %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// systemCodePage is the code page of the console; outside Windows output
+// is taken for UTF-8
+func systemCodePage() int {
+	return 0
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// ===== CODEPAGE.GO UNIT TESTS =====
+
+func TestCodePageTables(t *testing.T) {
+	for cp, table := range codePages {
+		if n := utf8.RuneCountInString(table); n != 128 {
+			t.Errorf("code page %d maps %d bytes, expected 128", cp, n)
+		}
+	}
+}
+
+func TestTranscodeOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		codePage int
+		expected string
+	}{
+		{"oem western", "Gr\x94\xe1e\r\n", 850, "Größe\r\n"},
+		{"oem cyrillic", "\x8f\xe0\xa8\xa2\xa5\xe2", 866, "Привет"},
+		{"ansi western", "caf\xe9", 1252, "café"},
+		{"ansi central", "\xbf\xf3\xb3\xe6", 1250, "żółć"},
+		{"box drawing", "\xc9\xcd\xbb", 437, "╔═╗"},
+		{"already utf-8", "Größe", 850, "Größe"},
+		{"ascii", "ok\n", 850, "ok\n"},
+		{"no code page", "caf\xe9", 0, "caf\xe9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transcodeOutput(tt.out, tt.codePage); got != tt.expected {
+				t.Errorf("transcodeOutput(%q, %d) = %q, expected %q", tt.out, tt.codePage, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOutputCodePage(t *testing.T) {
+	tests := []struct {
+		setting  string
+		expected int
+		wantErr  bool
+	}{
+		{"utf-8", 0, false},
+		{"UTF8", 0, false},
+		{"cp850", 850, false},
+		{"CP1252", 1252, false},
+		{"866", 866, false},
+		{"cp936", 0, true},
+		{"latin1", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.setting, func(t *testing.T) {
+			got, err := outputCodePage(tt.setting)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("outputCodePage(%q) error = %v, wantErr %v", tt.setting, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("outputCodePage(%q) = %d, expected %d", tt.setting, got, tt.expected)
+			}
+		})
+	}
+
+	if runtime.GOOS != "windows" {
+		if got, _ := outputCodePage("auto"); got != 0 {
+			t.Errorf("outputCodePage(auto) = %d, expected no transcoding outside Windows", got)
+		}
+	}
+}
+
+func TestValidateOutputEncodings(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{OutputEncoding: "cp850", Targets: map[string]Target{"build": {OutputEncoding: "utf-8"}}}
+	if err := validateOutputEncodings(); err != nil {
+		t.Errorf("validateOutputEncodings() error = %v", err)
+	}
+	cfg.Targets["legacy"] = Target{OutputEncoding: "ebcdic"}
+	if err := validateOutputEncodings(); err == nil || !strings.Contains(err.Error(), "target 'legacy'") {
+		t.Errorf("validateOutputEncodings() error = %v, expected the target's unknown encoding", err)
+	}
+}
+
+func TestTargetOutputEncoding(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{OutputEncoding: "cp1252"}
+
+	// The target's setting wins over the config's
+	target := Target{OutputEncoding: "cp850", Run: []string{`printf 'Gr\224\341e'`}}
+	stdout := captureStdout(t)
+	if err := ExecuteAllWithContext("legacy", &target, false, false); err != nil {
+		t.Fatalf("ExecuteAllWithContext() error = %v", err)
+	}
+	if output := stdout(); !strings.Contains(output, "Größe") {
+		t.Errorf("stdout = %q, expected the output transcoded from cp850", output)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package main
+
+import "syscall"
+
+var (
+	getConsoleOutputCP = syscall.NewLazyDLL("kernel32.dll").NewProc("GetConsoleOutputCP")
+	getOEMCP           = syscall.NewLazyDLL("kernel32.dll").NewProc("GetOEMCP")
+)
+
+// systemCodePage is the code page console programs write in: the console's
+// output code page, or the OEM one when aura has no console (a service, a
+// CI agent). A code page aura has no table for, UTF-8 included, is none
+func systemCodePage() int {
+	cp, _, _ := getConsoleOutputCP.Call()
+	if cp == 0 {
+		cp, _, _ = getOEMCP.Call()
+	}
+	if _, ok := codePages[int(cp)]; !ok {
+		return 0
+	}
+	return int(cp)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+)
+
+// noColor is --no-color: plain output even on a terminal
+var noColor bool
+
+// Terminal colors (SGR parameters) of aura's own output
+const (
+	colorRed    = "31"
+	colorGreen  = "32"
+	colorYellow = "33"
+	colorCyan   = "36"
+	colorBold   = "1"
+)
+
+// colorEnabled reports whether stdout takes colors
+func colorEnabled() bool {
+	return colorEnabledFor(os.Stdout)
+}
+
+// colorEnabledFor reports whether f is a terminal and colors are not
+// turned off: --no-color, NO_COLOR set or aura running in CI
+func colorEnabledFor(f *os.File) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || detectCI() != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint colors text for f, leaving it plain when f takes no colors
+func paint(f *os.File, color, text string) string {
+	if text == "" || !colorEnabledFor(f) {
+		return text
+	}
+	return "\033[" + color + "m" + text + "\033[0m"
+}
+
+// checkMark is the ✓ of a success line on stdout
+func checkMark() string {
+	return paint(os.Stdout, colorGreen, "✓")
+}
+
+// crossMark is the ✗ of a failure line on stdout
+func crossMark() string {
+	return paint(os.Stdout, colorRed, "✗")
+}
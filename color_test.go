@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// ===== COLOR.GO UNIT TESTS =====
+
+func TestColorEnabledFor(t *testing.T) {
+	original := noColor
+	defer func() { noColor = original }()
+
+	// Pipes and files never get colors
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close(); _ = w.Close() }()
+	if colorEnabledFor(w) {
+		t.Error("colorEnabledFor(pipe) = true, expected no colors")
+	}
+	if got := paint(w, colorRed, "failed"); got != "failed" {
+		t.Errorf("paint(pipe) = %q, expected plain text", got)
+	}
+
+	// A terminal does not either once colors are turned off
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip("no terminal")
+	}
+	defer func() { _ = tty.Close() }()
+	noColor = true
+	if colorEnabledFor(tty) {
+		t.Error("colorEnabledFor(tty) = true with --no-color")
+	}
+	noColor = false
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabledFor(tty) {
+		t.Error("colorEnabledFor(tty) = true with NO_COLOR")
+	}
+}
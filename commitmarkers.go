@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// commitMarkerRegex matches the build markers of a commit message: [skip aura]
+// (also [aura skip] and [skip build]) skips the build, [only: a, b] builds
+// only the targets named and [skip: a, b] leaves them out
+var commitMarkerRegex = regexp.MustCompile(`(?i)\[\s*(skip aura|aura skip|skip build|only|skip)\s*(?::([^\]]*))?\]`)
+
+// markerSkips holds the targets commit markers leave out of the current
+// build, with the marker that did it
+var markerSkips map[string]string
+
+// commitMarkers is what a commit message asks of the build
+type commitMarkers struct {
+	skipAll string   // the marker skipping the whole build
+	only    []string // [only: ...] targets
+	skip    []string // [skip: ...] targets
+	onlyTag string   // the [only: ...] marker as written
+	skipTag string   // the [skip: ...] marker as written
+}
+
+// parseCommitMarkers finds the build markers of a commit message. Markers
+// without targets ([only], [skip: ]) are ignored
+func parseCommitMarkers(message string) commitMarkers {
+	var m commitMarkers
+	for _, match := range commitMarkerRegex.FindAllStringSubmatch(message, -1) {
+		var names []string
+		for _, name := range strings.Split(match[2], ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		switch kind := strings.ToLower(match[1]); {
+		case kind == "only" && len(names) > 0:
+			m.only, m.onlyTag = append(m.only, names...), match[0]
+		case kind == "skip" && len(names) > 0:
+			m.skip, m.skipTag = append(m.skip, names...), match[0]
+		case kind != "only" && kind != "skip" && m.skipAll == "":
+			m.skipAll = match[0]
+		}
+	}
+	return m
+}
+
+// commitMessage returns the message of the commit being built: GitLab's
+// $CI_COMMIT_MESSAGE, else the message of HEAD
+func commitMessage() (string, error) {
+	if message := os.Getenv("CI_COMMIT_MESSAGE"); message != "" {
+		return message, nil
+	}
+	out, err := exec.Command("git", "log", "-1", "--format=%B").Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// applyCommitMarkers narrows a CI build by the markers of the commit
+// message and returns the targets left to run, none when the build is
+// skipped. The targets left out are recorded in markerSkips for the summary
+// and count as done for the targets after them
+func applyCommitMarkers(targetList []string) []string {
+	message, err := commitMessage()
+	if err != nil {
+		logWarn("cannot read the commit message for markers: %v", err)
+		return targetList
+	}
+	m := parseCommitMarkers(message)
+	markerSkips = map[string]string{}
+
+	requested := querySet{}
+	for _, name := range targetList {
+		requested[name] = true
+	}
+	all := reachable(requested, -1, targetEdges)
+	if m.skipAll != "" {
+		for name := range all {
+			markerSkips[name] = "commit message " + m.skipAll
+		}
+		logInfo("commit_markers", "", "Skipping the build: the commit message has %s", m.skipAll)
+		return nil
+	}
+
+	runList := targetList
+	if len(m.only) > 0 {
+		runList = nil
+		for _, name := range m.only {
+			if all[name] {
+				runList = append(runList, name)
+			} else {
+				logWarn("commit message %s names '%s', which is not part of this build", m.onlyTag, name)
+			}
+		}
+		kept := querySet{}
+		for _, name := range runList {
+			kept[name] = true
+		}
+		kept = reachable(kept, -1, targetEdges)
+		for name := range all {
+			if !kept[name] {
+				markerSkips[name] = "commit message " + m.onlyTag
+			}
+		}
+		if len(runList) == 0 {
+			logInfo("commit_markers", "", "Skipping the build: none of its targets are in %s", m.onlyTag)
+			return nil
+		}
+		logInfo("commit_markers", "", "Building only %s: the commit message has %s", strings.Join(runList, ", "), m.onlyTag)
+	}
+	for _, name := range m.skip {
+		if !all[name] {
+			logWarn("commit message %s names '%s', which is not part of this build", m.skipTag, name)
+			continue
+		}
+		markerSkips[name] = "commit message " + m.skipTag
+		logInfo("commit_markers", "", "Skipping %s: the commit message has %s", name, m.skipTag)
+	}
+	return runList
+}
+
+// skipReason says why a target does not run in this build, "" when it
+// does: its platforms: leave it out here or a commit marker does
+func skipReason(name string, target *Target) string {
+	if skippedOnPlatform(target) {
+		return platformSkipReason(target)
+	}
+	return markerSkips[name]
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ===== COMMITMARKERS.GO UNIT TESTS =====
+
+func TestParseCommitMarkers(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected commitMarkers
+	}{
+		{"Fix typo", commitMarkers{}},
+		{"Fix typo [skip aura]", commitMarkers{skipAll: "[skip aura]"}},
+		{"Fix typo\n\n[Skip Build]", commitMarkers{skipAll: "[Skip Build]"}},
+		{"Docs [only: docs, site]", commitMarkers{only: []string{"docs", "site"}, onlyTag: "[only: docs, site]"}},
+		{"Quick [skip: lint]", commitMarkers{skip: []string{"lint"}, skipTag: "[skip: lint]"}},
+		{"[skip ci] is for the CI service", commitMarkers{}},
+		{"Empty [only: ] [skip]", commitMarkers{}},
+	}
+
+	for _, tt := range tests {
+		if got := parseCommitMarkers(tt.message); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseCommitMarkers(%q) = %+v, expected %+v", tt.message, got, tt.expected)
+		}
+	}
+}
+
+func TestApplyCommitMarkers(t *testing.T) {
+	original := cfg
+	defer func() { cfg, markerSkips = original, nil }()
+	cfg = Config{Targets: map[string]Target{
+		"docs": {Run: []string{"echo docs"}},
+		"lint": {Run: []string{"echo lint"}},
+		"test": {Deps: []string{"lint"}, Run: []string{"echo test"}},
+		"all":  {Deps: []string{"docs", "test"}},
+	}}
+
+	tests := []struct {
+		name     string
+		message  string
+		expected []string
+		skipped  map[string]string
+	}{
+		{"no markers", "Fix typo", []string{"all"}, map[string]string{}},
+		{"skip the build", "WIP [skip aura]", nil, map[string]string{
+			"all": "commit message [skip aura]", "docs": "commit message [skip aura]",
+			"lint": "commit message [skip aura]", "test": "commit message [skip aura]",
+		}},
+		{"only", "Docs [only: docs]", []string{"docs"}, map[string]string{
+			"all": "commit message [only: docs]", "lint": "commit message [only: docs]", "test": "commit message [only: docs]",
+		}},
+		{"only keeps dependencies", "Tests [only: test]", []string{"test"}, map[string]string{
+			"all": "commit message [only: test]", "docs": "commit message [only: test]",
+		}},
+		{"only outside the build", "[only: deploy]", nil, map[string]string{
+			"all": "commit message [only: deploy]", "docs": "commit message [only: deploy]",
+			"lint": "commit message [only: deploy]", "test": "commit message [only: deploy]",
+		}},
+		{"skip targets", "Quick [skip: lint, deploy]", []string{"all"}, map[string]string{"lint": "commit message [skip: lint, deploy]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CI_COMMIT_MESSAGE", tt.message)
+			stdout := captureStdout(t)
+			got := applyCommitMarkers([]string{"all"})
+			stdout()
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("applyCommitMarkers() = %v, expected %v", got, tt.expected)
+			}
+			if !reflect.DeepEqual(markerSkips, tt.skipped) {
+				t.Errorf("markerSkips = %v, expected %v", markerSkips, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+	defer func() { markerSkips = nil }()
+	markerSkips = map[string]string{"lint": "commit message [skip: lint]"}
+
+	if got := skipReason("lint", &Target{}); got != "commit message [skip: lint]" {
+		t.Errorf("skipReason(lint) = %q, expected the marker", got)
+	}
+	if got := skipReason("test", &Target{}); got != "" {
+		t.Errorf("skipReason(test) = %q, expected the target to run", got)
+	}
+	if got := skipReason("test", &Target{Platforms: stringList{"plan9"}}); got != "runs on plan9" {
+		t.Errorf("skipReason() = %q, expected the platforms", got)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+	"gopkg.in/yaml.v3"
+)
+
+// peekTargets reads the targets of a config file (and its includes) without
+// touching the global configuration. Errors are ignored: this only feeds
+// help text and shell completion.
+func peekTargets(configPath string) map[string]Target {
+	var peek Config
+
+	// #nosec G304 - Read-only, used for help and completion output
+	data, err := os.ReadFile(configPath)
+	if err != nil || yaml.Unmarshal(data, &peek) != nil {
+		return nil
+	}
+
+	for _, inc := range peek.Includes {
+		if load, err := inc.When.matches(); err != nil || !load {
+			continue
+		}
+		incPath := inc.Path
+		if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(filepath.Dir(configPath), inc.Path)
+		}
+		incPath = filepath.Clean(incPath)
+		if strings.Contains(incPath, "..") {
+			continue
+		}
+		// #nosec G304 - We validate the path above
+		if incData, err := os.ReadFile(incPath); err == nil {
+			_, _ = mergeInclude(&peek, inc, incData)
+		}
+	}
+	_, _ = loadMembers(&peek, filepath.Dir(configPath), os.ReadFile)
+
+	return peek.Targets
+}
+
+// targetHelp renders the target list appended to `aura build --help`
+func targetHelp(targets map[string]Target) string {
+	if len(targets) == 0 {
+		return ""
+	}
+
+	names := sortedKeys(targets)
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Available targets:\n")
+	for _, name := range names {
+		sb.WriteString("  " + name)
+		if desc := targets[name].Desc; desc != "" {
+			sb.WriteString(strings.Repeat(" ", width-len(name)+2) + desc)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// wantsHelp reports whether the command line asks for help, so the config
+// is only peeked at when the output will actually be shown
+func wantsHelp(args []string) bool {
+	return slices.Contains(args, "help") || slices.Contains(args, "-h") || slices.Contains(args, "--help")
+}
+
+// argsConfig returns the config file a command line names with
+// -c/--config, inside its -D/--directory if any, before the flags are
+// parsed: for completion and for the targets listed by build --help
+func argsConfig(args []string) string {
+	configFile, workDir := "aura.yaml", "."
+	for i, arg := range args {
+		next := ""
+		if i+1 < len(args) {
+			next = args[i+1]
+		}
+		switch {
+		case arg == "-c" || arg == "--config":
+			configFile = next
+		case strings.HasPrefix(arg, "--config="):
+			configFile = strings.TrimPrefix(arg, "--config=")
+		case arg == "-D" || arg == "--directory":
+			workDir = next
+		case strings.HasPrefix(arg, "--directory="):
+			workDir = strings.TrimPrefix(arg, "--directory=")
+		}
+	}
+	if filepath.IsAbs(configFile) {
+		return configFile
+	}
+	return filepath.Join(workDir, configFile)
+}
+
+// completeTargets is the orpheus completion handler for target arguments
+func completeTargets(req *orpheus.CompletionRequest) *orpheus.CompletionResult {
+	var suggestions []string
+	for _, name := range sortedKeys(peekTargets(argsConfig(req.Args))) {
+		if strings.HasPrefix(name, req.CurrentWord) {
+			suggestions = append(suggestions, name)
+		}
+	}
+	return &orpheus.CompletionResult{
+		Suggestions: suggestions,
+		Directive:   orpheus.CompletionNoFiles,
+	}
+}
+
+// completionCommand prints a shell completion script. Unlike the static
+// scripts orpheus generates, these ask `aura list --format names` for the
+// targets of the config the command line names, aura.yaml by default.
+func completionCommand(ctx *orpheus.Context) error {
+	shell := "bash"
+	if ctx.ArgCount() > 0 {
+		shell = ctx.GetArg(0)
+	}
+
+	commands := ctx.App.GetCommands()
+	names := sortedKeys(commands)
+
+	switch shell {
+	case "bash":
+		fmt.Printf(bashCompletion, strings.Join(names, " "))
+	case "zsh":
+		var described []string
+		for _, name := range names {
+			described = append(described, fmt.Sprintf("'%s:%s'", name, completionDesc(commands[name].Description())))
+		}
+		fmt.Printf(zshCompletion, strings.Join(described, " "))
+	case "fish":
+		fmt.Println("complete -c aura -f")
+		for _, name := range names {
+			fmt.Printf("complete -c aura -n '__fish_use_subcommand' -a %s -d '%s'\n", name, completionDesc(commands[name].Description()))
+		}
+		fmt.Println(fishTargetCompletion)
+	default:
+		return orpheus.ValidationError("completion", fmt.Sprintf("unsupported shell: %s (supported: bash, zsh, fish)", shell))
+	}
+	return nil
+}
+
+// completionDesc makes a description safe inside single-quoted shell words
+func completionDesc(desc string) string {
+	return strings.NewReplacer("'", "", ":", " -").Replace(desc)
+}
+
+const bashCompletion = `# Bash completion for aura
+_aura_completion() {
+    local cur prev
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    if [ "$COMP_CWORD" -eq 1 ]; then
+        COMPREPLY=($(compgen -W "%s help" -- "$cur"))
+        return 0
+    fi
+
+    case "$prev" in
+        -t|--targets)
+            local targets prefix="" i opts=()
+            for ((i = 1; i < COMP_CWORD; i++)); do
+                case "${COMP_WORDS[i]}" in
+                    -c|--config|-D|--directory) opts+=("${COMP_WORDS[i]}" "${COMP_WORDS[i+1]}") ;;
+                    --config=*|--directory=*) opts+=("${COMP_WORDS[i]}") ;;
+                esac
+            done
+            targets=$(aura "${opts[@]}" list --format names 2>/dev/null | cut -f1)
+            if [[ "$cur" == *,* ]]; then
+                prefix="${cur%%,*},"
+                cur="${cur##*,}"
+            fi
+            COMPREPLY=($(compgen -P "$prefix" -W "$targets" -- "$cur"))
+            return 0
+            ;;
+    esac
+}
+
+complete -F _aura_completion aura
+`
+
+const zshCompletion = `#compdef aura
+
+_aura() {
+    local -a commands targets
+    commands=(%s)
+
+    if (( CURRENT == 2 )); then
+        _describe 'command' commands
+        return
+    fi
+
+    case $words[CURRENT-1] in
+        -t|--targets)
+            local -a opts
+            local i
+            for (( i = 2; i < CURRENT; i++ )); do
+                case $words[i] in
+                    -c|--config|-D|--directory) opts+=($words[i] $words[i+1]) ;;
+                    --config=*|--directory=*) opts+=($words[i]) ;;
+                esac
+            done
+            targets=(${(f)"$(aura $opts list --format names 2>/dev/null | sed 's/	/:/')"})
+            _describe 'target' targets
+            return
+            ;;
+    esac
+    _files
+}
+
+compdef _aura aura
+`
+
+const fishTargetCompletion = `function __aura_targets
+    set -l opts
+    set -l tokens (commandline -opc)
+    for i in (seq 2 (count $tokens))
+        switch $tokens[$i]
+            case -c --config -D --directory
+                set -a opts $tokens[$i] $tokens[(math $i + 1)]
+            case '--config=*' '--directory=*'
+                set -a opts $tokens[$i]
+        end
+    end
+    aura $opts list --format names 2>/dev/null
+end
+complete -c aura -n '__fish_seen_subcommand_from build watch clean' -s t -l targets -x -a '(__aura_targets)'`
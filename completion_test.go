@@ -0,0 +1,163 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// ===== COMPLETION.GO UNIT TESTS =====
+
+func writeCompletionConfig(t *testing.T) string {
+	t.Helper()
+	tempDir := t.TempDir()
+
+	main := `include:
+  - "extra.yaml"
+targets:
+  build:
+    desc: "Compile the application"
+    run:
+      - "echo build"
+  lint:
+    run:
+      - "echo lint"
+`
+	extra := `targets:
+  deploy:
+    desc: "Ship it"
+    run:
+      - "echo deploy"
+`
+	if err := os.WriteFile(filepath.Join(tempDir, "aura.yaml"), []byte(main), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "extra.yaml"), []byte(extra), 0600); err != nil {
+		t.Fatalf("Failed to write include: %v", err)
+	}
+	return tempDir
+}
+
+func TestPeekTargets(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{"untouched": {}}}
+
+	dir := writeCompletionConfig(t)
+	targets := peekTargets(filepath.Join(dir, "aura.yaml"))
+
+	if len(targets) != 3 {
+		t.Fatalf("peekTargets() returned %d targets, want 3", len(targets))
+	}
+	if targets["build"].Desc != "Compile the application" {
+		t.Errorf("build desc = %q", targets["build"].Desc)
+	}
+	if targets["deploy"].Desc != "Ship it" {
+		t.Errorf("included deploy desc = %q", targets["deploy"].Desc)
+	}
+	if _, ok := cfg.Targets["untouched"]; !ok || len(cfg.Targets) != 1 {
+		t.Errorf("peekTargets() modified the global config")
+	}
+
+	if got := peekTargets(filepath.Join(dir, "missing.yaml")); got != nil {
+		t.Errorf("peekTargets(missing) = %v, want nil", got)
+	}
+}
+
+func TestTargetHelp(t *testing.T) {
+	help := targetHelp(map[string]Target{
+		"test":  {Desc: "Run tests"},
+		"build": {Desc: "Compile"},
+		"lint":  {},
+	})
+
+	expected := "Available targets:\n  build  Compile\n  lint\n  test   Run tests"
+	if help != expected {
+		t.Errorf("targetHelp() = %q, want %q", help, expected)
+	}
+	if targetHelp(nil) != "" {
+		t.Errorf("targetHelp(nil) should be empty")
+	}
+}
+
+func TestWantsHelp(t *testing.T) {
+	if !wantsHelp([]string{"build", "--help"}) || !wantsHelp([]string{"help", "build"}) {
+		t.Errorf("wantsHelp() missed a help request")
+	}
+	if wantsHelp([]string{"build", "-t", "help-docs"}) {
+		t.Errorf("wantsHelp() matched a target name")
+	}
+}
+
+func TestArgsConfig(t *testing.T) {
+	abs := filepath.Join(t.TempDir(), "ci.yaml")
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"build", "--help"}, "aura.yaml"},
+		{[]string{"-c", "ci.yaml", "build", "--help"}, "ci.yaml"},
+		{[]string{"build", "--config=ci.yaml", "-h"}, "ci.yaml"},
+		{[]string{"-D", "app", "build", "--help"}, filepath.Join("app", "aura.yaml")},
+		{[]string{"--directory=app", "-c", "ci.yaml", "help", "build"}, filepath.Join("app", "ci.yaml")},
+		{[]string{"-D", "app", "-c", abs, "build", "--help"}, abs},
+	}
+	for _, tt := range tests {
+		if got := argsConfig(tt.args); got != tt.expected {
+			t.Errorf("argsConfig(%q) = %q, want %q", tt.args, got, tt.expected)
+		}
+	}
+}
+
+func TestCompleteTargets(t *testing.T) {
+	dir := writeCompletionConfig(t)
+
+	originalDir, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalDir) }()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	result := completeTargets(&orpheus.CompletionRequest{CurrentWord: "b"})
+	if len(result.Suggestions) != 1 || result.Suggestions[0] != "build" {
+		t.Errorf("completeTargets(b) = %v, want [build]", result.Suggestions)
+	}
+
+	result = completeTargets(&orpheus.CompletionRequest{})
+	if strings.Join(result.Suggestions, ",") != "build,deploy,lint" {
+		t.Errorf("completeTargets() = %v, want all targets sorted", result.Suggestions)
+	}
+}
+
+func TestCompleteTargetsConfigFlag(t *testing.T) {
+	dir := writeCompletionConfig(t)
+	if err := os.Rename(filepath.Join(dir, "aura.yaml"), filepath.Join(dir, "ci.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	chdirTemp(t)
+
+	tests := [][]string{
+		{"-t", "-c", filepath.Join(dir, "ci.yaml")},
+		{"--config=" + filepath.Join(dir, "ci.yaml"), "-t"},
+		{"-D", dir, "--config", "ci.yaml", "-t"},
+		{"--directory=" + dir, "-c", "ci.yaml", "-t"},
+	}
+	for _, args := range tests {
+		result := completeTargets(&orpheus.CompletionRequest{CurrentWord: "d", Args: args})
+		if strings.Join(result.Suggestions, ",") != "deploy" {
+			t.Errorf("completeTargets(%q) = %v, want the targets of ci.yaml", args, result.Suggestions)
+		}
+	}
+	if result := completeTargets(&orpheus.CompletionRequest{Args: []string{"-t"}}); len(result.Suggestions) != 0 {
+		t.Errorf("completeTargets() without a config = %v, want none", result.Suggestions)
+	}
+}
+
+func TestCompletionDesc(t *testing.T) {
+	if got := completionDesc("it's a: test"); got != "its a - test" {
+		t.Errorf("completionDesc() = %q", got)
+	}
+}
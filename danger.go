@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// dangerousTargets returns the targets marked dangerous among names and
+// everything they depend on, sorted
+func dangerousTargets(names []string) []string {
+	seen := map[string]bool{}
+	var found []string
+	var visit func(name string)
+
+	visit = func(name string) {
+		if seen[name] || strings.Contains(name, ".") {
+			return
+		}
+		seen[name] = true
+
+		target := GetTarget(name)
+		if target.Dangerous {
+			found = append(found, name)
+		}
+		for _, dep := range target.Deps {
+			visit(dep)
+		}
+	}
+
+	for _, name := range names {
+		visit(strings.TrimSpace(name))
+	}
+	slices.Sort(found)
+	return found
+}
+
+// stdinIsTerminal reports whether a user can answer a prompt; in CI
+// nobody can, whatever stdin is
+func stdinIsTerminal() bool {
+	if detectCI() != "" {
+		return false
+	}
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// confirmDangerous asks before running dangerous targets. Without a
+// terminal to ask on, only --yes-i-mean-it lets them run.
+func confirmDangerous(names []string, in io.Reader, interactive bool) error {
+	// The prologue and epilogue dependencies run with every build
+	for _, stage := range slices.Concat(cfg.activePrologues(), cfg.activeEpilogues()) {
+		names = slices.Concat(names, stage.target.Deps)
+	}
+	dangerous := dangerousTargets(names)
+	if len(dangerous) == 0 {
+		return nil
+	}
+
+	list := strings.Join(dangerous, ", ")
+	if !interactive {
+		return orpheus.ValidationError("dangerous", fmt.Sprintf("target(s) %s are marked dangerous; re-run with --yes-i-mean-it to confirm", list))
+	}
+
+	fmt.Printf("⚠ Target(s) marked dangerous: %s\n", list)
+	fmt.Print("Type 'yes' to continue: ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	if strings.TrimSpace(answer) != "yes" {
+		return orpheus.ValidationError("dangerous", "aborted: dangerous target(s) not confirmed")
+	}
+	return nil
+}
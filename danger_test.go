@@ -0,0 +1,85 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ===== DANGER.GO UNIT TESTS =====
+
+func TestDangerousTargets(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{Targets: map[string]Target{
+		"build":       {Run: []string{"go build"}},
+		"deploy-prod": {Run: []string{"deploy"}, Dangerous: true},
+		"release":     {Deps: []string{"build", "deploy-prod", "notes.txt"}},
+		"wipe":        {Run: []string{"rm -rf data"}, Dangerous: true, Deps: []string{"wipe"}},
+	}}
+
+	tests := []struct {
+		name     string
+		targets  []string
+		expected []string
+	}{
+		{"safe target", []string{"build"}, nil},
+		{"dangerous target", []string{" deploy-prod"}, []string{"deploy-prod"}},
+		{"dangerous dependency", []string{"release"}, []string{"deploy-prod"}},
+		{"sorted and deduplicated", []string{"wipe", "release", "deploy-prod"}, []string{"deploy-prod", "wipe"}},
+		{"unknown target", []string{"missing"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dangerousTargets(tt.targets); !slices.Equal(got, tt.expected) {
+				t.Errorf("dangerousTargets(%v) = %v, expected %v", tt.targets, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfirmDangerous(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{
+		Targets: map[string]Target{
+			"build":  {Run: []string{"go build"}},
+			"deploy": {Run: []string{"deploy"}, Dangerous: true},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		targets     []string
+		input       string
+		interactive bool
+		expectError bool
+	}{
+		{"safe target never asks", []string{"build"}, "", false, false},
+		{"no terminal", []string{"deploy"}, "yes\n", false, true},
+		{"confirmed", []string{"deploy"}, "yes\n", true, false},
+		{"declined", []string{"deploy"}, "y\n", true, true},
+		{"no answer", []string{"deploy"}, "", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := confirmDangerous(tt.targets, strings.NewReader(tt.input), tt.interactive)
+			if tt.expectError && err == nil {
+				t.Error("confirmDangerous() expected error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("confirmDangerous() unexpected error: %v", err)
+			}
+		})
+	}
+
+	// Prologue dependencies run with every build
+	cfg.Prologue = Target{Deps: []string{"deploy"}}
+	if err := confirmDangerous([]string{"build"}, strings.NewReader(""), false); err == nil {
+		t.Error("confirmDangerous() should check prologue dependencies")
+	}
+}
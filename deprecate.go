@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+	"gopkg.in/yaml.v3"
+)
+
+// deprecation is a construct of the config that still works but is on its
+// way out: where it is, what replaces it and, when the change keeps what
+// the build does, fix, the rewrite `aura migrate` applies
+type deprecation struct {
+	line    int
+	message string
+	hint    string
+	fix     func()
+}
+
+// deprecationChecks find deprecated constructs in the node tree of the
+// config file; a syntax change adds its check here
+var deprecationChecks = []func(e *configEditor) []deprecation{
+	fileDepDeprecations,
+}
+
+// reportDeprecations turns the warnings of loadConfig off for commands
+// that report deprecations themselves
+var reportDeprecations = true
+
+// deprecationsReported lists the config files already warned about, so a
+// config loaded twice warns once
+var deprecationsReported = map[string]bool{}
+
+// findDeprecations runs every check on the config file being edited
+func findDeprecations(e *configEditor) []deprecation {
+	var found []deprecation
+	for _, check := range deprecationChecks {
+		found = append(found, check(e)...)
+	}
+	slices.SortStableFunc(found, func(a, b deprecation) int { return a.line - b.line })
+	return found
+}
+
+// warnDeprecations warns about the deprecated constructs of the loaded
+// config file. Partial loads skip it: deps may name targets left undecoded
+func warnDeprecations(configPath string) {
+	key, _ := filepath.Abs(configPath)
+	if !reportDeprecations || len(partialTargets) > 0 || deprecationsReported[key] {
+		return
+	}
+	deprecationsReported[key] = true
+	e, err := openConfigEditor(configPath)
+	if err != nil {
+		return
+	}
+
+	found := findDeprecations(e)
+	fixable := 0
+	for _, d := range found {
+		logWarn("%s:%d: %s; %s", filepath.Base(configPath), d.line, d.message, d.hint)
+		if d.fix != nil {
+			fixable++
+		}
+	}
+	if fixable > 0 {
+		logWarn("run 'aura migrate' to update %d of them automatically", fixable)
+	}
+}
+
+// fileDepDeprecations flags files listed in deps. A dep is taken for a
+// file when it has a dot and no target has its name, so a typo in a target
+// name silently becomes a file; sources says what is meant
+func fileDepDeprecations(e *configEditor) []deprecation {
+	var found []deprecation
+	owners := e.depOwners()
+	for _, owner := range sortedKeys(owners) {
+		node := owners[owner]
+		if node.Kind != yaml.MappingNode {
+			continue
+		}
+		deps := mappingValue(node, "deps")
+		if deps == nil || deps.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, dep := range deps.Content {
+			if dep.Kind != yaml.ScalarNode || !isFileDep(dep.Value) {
+				continue
+			}
+			found = append(found, deprecation{
+				line:    dep.Line,
+				message: fmt.Sprintf("'%s' lists the file '%s' in deps", owner, dep.Value),
+				hint:    "list files in sources instead",
+				fix:     func() { moveToSources(node, dep) },
+			})
+		}
+	}
+	return found
+}
+
+// moveToSources moves a deps entry of a target node to its sources, next
+// to deps, dropping deps once empty
+func moveToSources(target, dep *yaml.Node) {
+	deps := mappingValue(target, "deps")
+	sources := mappingValue(target, "sources")
+	if sources == nil || sources.Kind != yaml.SequenceNode {
+		sources = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: deps.Style}
+		if i := mappingIndex(target, "sources"); i >= 0 {
+			target.Content[i+1] = sources
+		} else {
+			i := mappingIndex(target, "deps") + 2
+			target.Content = slices.Insert(target.Content, i, scalarNode("sources", 0), sources)
+		}
+	}
+	if !slices.ContainsFunc(sources.Content, func(n *yaml.Node) bool { return n.Value == dep.Value }) {
+		sources.Content = append(sources.Content, dep)
+	}
+
+	deps.Content = slices.DeleteFunc(deps.Content, func(n *yaml.Node) bool { return n == dep })
+	if len(deps.Content) == 0 {
+		i := mappingIndex(target, "deps")
+		target.Content = slices.Delete(target.Content, i, i+2)
+	}
+}
+
+// migrateCommand rewrites the deprecated constructs of the config that can
+// be updated safely and lists the others; --dry-run only lists them
+func migrateCommand(ctx *orpheus.Context) error {
+	reportDeprecations = false
+	e, err := editSetup(ctx)
+	if err != nil {
+		return err
+	}
+	configFile := ctx.GetGlobalFlagString("config")
+	if err := loadConfigProfile(configFile, ""); err != nil {
+		return err
+	}
+	dryRun := ctx.GetGlobalFlagBool("dry-run")
+
+	found := findDeprecations(e)
+	if len(found) == 0 {
+		fmt.Printf("%s %s uses no deprecated syntax\n", checkMark(), configFile)
+		return nil
+	}
+
+	fixed, manual := 0, 0
+	for _, d := range found {
+		status := "fixed"
+		switch {
+		case d.fix == nil:
+			manual++
+			status = "needs a manual change"
+		case dryRun:
+			fixed++
+			status = "would be fixed"
+		default:
+			fixed++
+			d.fix()
+		}
+		fmt.Printf("  %s:%d: %s; %s (%s)\n", configFile, d.line, d.message, d.hint, status)
+	}
+
+	if fixed > 0 && !dryRun {
+		if err := e.save(); err != nil {
+			return orpheus.ExecutionError("migrate", fmt.Sprintf("cannot write %s: %v", configFile, err))
+		}
+		fmt.Printf("%s Updated %d deprecated entries in %s\n", checkMark(), fixed, configFile)
+	}
+	if manual > 0 {
+		return orpheus.ValidationError("migrate", fmt.Sprintf("%d deprecated entries need a manual change", manual))
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// ===== DEPRECATE.GO UNIT TESTS =====
+
+func TestFileDepMigration(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	tests := []struct {
+		name     string
+		config   string
+		found    int
+		expected string
+	}{
+		{
+			"flow style",
+			"targets:\n  gen:\n    run: [\"go generate\"]\n  build:\n    deps: [gen, go.mod]\n    run: [\"go build\"]\n",
+			1,
+			"targets:\n  gen:\n    run: [\"go generate\"]\n  build:\n    deps: [gen]\n    sources: [go.mod]\n    run: [\"go build\"]\n",
+		},
+		{
+			"only files",
+			"targets:\n  build:\n    deps:\n      - go.mod\n      - go.sum\n    sources:\n      - go.mod\n    run: [\"go build\"]\n",
+			2,
+			"targets:\n  build:\n    sources:\n      - go.mod\n      - go.sum\n    run: [\"go build\"]\n",
+		},
+		{
+			"dotted target names are targets",
+			"targets:\n  gen.proto:\n    run: [\"protoc\"]\n  build:\n    deps: [gen.proto]\n    run: [\"go build\"]\n",
+			0,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFiles(t, map[string]string{"aura.yaml": tt.config})
+			cfg = Config{}
+			if err := loadConfigFrom("aura.yaml", os.ReadFile); err != nil {
+				t.Fatalf("loadConfigFrom() error = %v", err)
+			}
+			e, err := openConfigEditor("aura.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := findDeprecations(e)
+			if len(found) != tt.found {
+				t.Fatalf("findDeprecations() found %d, expected %d", len(found), tt.found)
+			}
+			if tt.found == 0 {
+				return
+			}
+			for _, d := range found {
+				d.fix()
+			}
+			if err := e.save(); err != nil {
+				t.Fatal(err)
+			}
+			if data, _ := os.ReadFile("aura.yaml"); string(data) != tt.expected {
+				t.Errorf("migrated config =\n%s\nexpected\n%s", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWarnDeprecations(t *testing.T) {
+	original, originalReported := cfg, deprecationsReported
+	defer func() { cfg, deprecationsReported = original, originalReported }()
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"aura.yaml": "targets:\n  build:\n    deps: [go.mod]\n    run: [\"go build\"]\n"})
+	deprecationsReported = map[string]bool{}
+
+	stderr := captureStderr(t)
+	cfg = Config{}
+	if err := loadConfig("aura.yaml"); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	cfg = Config{}
+	if err := loadConfig("aura.yaml"); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	output := stderr()
+
+	if strings.Count(output, "aura.yaml:3: 'build' lists the file 'go.mod' in deps") != 1 {
+		t.Errorf("stderr = %q, expected the deprecation once", output)
+	}
+	if !strings.Contains(output, "run 'aura migrate' to update 1 of them") {
+		t.Errorf("stderr = %q, expected the migrate hint", output)
+	}
+}
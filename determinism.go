@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// outputDiff is a declared output that came out differently from two runs
+// of a target; a hash is empty when the run did not produce the file
+type outputDiff struct {
+	Path   string
+	First  string
+	Second string
+}
+
+// determinismReport is what verifying a target found
+type determinismReport struct {
+	Target  string
+	Outputs int // files compared
+	Diffs   []outputDiff
+}
+
+// verifyDeterminism builds the target's deps, then runs the target itself
+// twice, each time in a sandbox of its own, and compares the hashes of the
+// outputs. The project is left as it was: nothing is promoted from the
+// sandboxes and no build is recorded
+func verifyDeterminism(name string, verbose bool) (determinismReport, error) {
+	target := GetTarget(name)
+	report := determinismReport{Target: name}
+	if target.Run == nil && target.Deps == nil {
+		return report, orpheus.NotFoundError(name, fmt.Sprintf("target '%s' not found", name))
+	}
+	if len(target.Outputs) == 0 {
+		return report, orpheus.ValidationError(name, fmt.Sprintf("target '%s' declares no outputs to compare", name))
+	}
+	if err := target.RunDepsWithContext(verbose, false); err != nil {
+		return report, err
+	}
+
+	var runs [2]map[string]string
+	for i := range runs {
+		hashes, err := sandboxedRun(name, &target)
+		if err != nil {
+			return report, orpheus.ExecutionError(name, fmt.Sprintf("in %s -> \nrun %d in a sandbox, which holds only the declared sources: %v", name, i+1, err))
+		}
+		runs[i] = hashes
+	}
+
+	paths := map[string]bool{}
+	for _, hashes := range runs {
+		for path := range hashes {
+			paths[path] = true
+		}
+	}
+	report.Outputs = len(paths)
+	for _, path := range sortedKeys(paths) {
+		if first, second := runs[0][path], runs[1][path]; first != second {
+			report.Diffs = append(report.Diffs, outputDiff{Path: path, First: first, Second: second})
+		}
+	}
+	return report, nil
+}
+
+// sandboxedRun runs the target's commands in a fresh sandbox and returns
+// the hashes of its outputs by project-relative path
+func sandboxedRun(name string, target *Target) (map[string]string, error) {
+	sandbox, err := stageSandbox(name, target)
+	if err != nil {
+		return nil, err
+	}
+	defer removeSandbox(sandbox, name)
+
+	ectx := newExpandContext(name, nil)
+	limits := targetLimits(target)
+	wrapper := ectx.Expand(string(commandWrapper(target)))
+	dir := filepath.Join(sandbox, target.dir)
+	for _, cmd := range target.Run {
+		cmd = wrapCommand(wrapper, ectx.Expand(cmd))
+		if err := ectx.Err(); err != nil {
+			return nil, fmt.Errorf("invalid variable: %v", err)
+		}
+		stream := newOutputStream(name, cmd, target)
+		out, err := executeCommandIn(name, target.Shell, &dir, cmd, nil, stream, limits)
+		if err != nil {
+			return nil, err
+		}
+		if out = filterOutput(out, target.OutputFilter); strings.TrimSpace(out) != "" && stream == nil {
+			logEvent{Level: levelInfo, Event: "output", Target: name, Command: cmd, Output: out}.emit(out)
+		}
+	}
+
+	files, err := stagedOutputs(sandbox, name, target)
+	if err != nil {
+		return nil, err
+	}
+	hashes := map[string]string{}
+	for _, file := range files {
+		rel, err := filepath.Rel(sandbox, file)
+		if err != nil {
+			return nil, err
+		}
+		if hashes[filepath.ToSlash(rel)], err = hashFile(file); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// shortHash abbreviates a hash for display, - for a file that is missing
+func shortHash(hash string) string {
+	if hash == "" {
+		return "-"
+	}
+	return hash[:min(len(hash), 12)]
+}
+
+// verifyDeterminismCommand checks that targets produce the same outputs
+// every time they run, which sharing outputs between machines relies on
+func verifyDeterminismCommand(ctx *orpheus.Context) error {
+	workDir := ctx.GetGlobalFlagString("directory")
+	configFile := ctx.GetGlobalFlagString("config")
+	verbose := ctx.GetGlobalFlagBool("verbose")
+	names := splitList(ctx.GetFlagString("targets"))
+	if len(names) == 0 {
+		return orpheus.ValidationError("targets", "usage: aura verify-determinism -t <targets>")
+	}
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	if err := loadConfigProfile(configFile, ctx.GetGlobalFlagString("profile")); err != nil {
+		return err
+	}
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+
+	// Every target runs twice, dangerous ones included
+	if !ctx.GetFlagBool("yes-i-mean-it") {
+		if err := confirmDangerous(names, os.Stdin, stdinIsTerminal()); err != nil {
+			return err
+		}
+	}
+
+	nondeterministic := 0
+	for _, name := range names {
+		report, err := verifyDeterminism(name, verbose)
+		if err != nil {
+			return err
+		}
+		fmt.Println()
+		if len(report.Diffs) == 0 {
+			fmt.Printf("%s %s is deterministic: %d outputs identical in both runs\n", checkMark(), name, report.Outputs)
+			continue
+		}
+		nondeterministic += len(report.Diffs)
+		fmt.Printf("%s %s is not deterministic: %d of %d outputs differ between runs\n", crossMark(), name, len(report.Diffs), report.Outputs)
+		for _, diff := range report.Diffs {
+			fmt.Printf("  %-40s %s → %s\n", diff.Path, shortHash(diff.First), shortHash(diff.Second))
+		}
+	}
+	if nondeterministic > 0 {
+		return orpheus.ExecutionError("verify-determinism", fmt.Sprintf("%d outputs differ between runs", nondeterministic))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== DETERMINISM.GO UNIT TESTS =====
+
+func TestVerifyDeterminism(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"in.txt": "source"})
+
+	tests := []struct {
+		name      string
+		target    Target
+		wantDiffs []string
+		wantErr   string
+	}{
+		{"deterministic", Target{Sources: []string{"in.txt"}, Outputs: []string{"out/copy.txt"}, Run: []string{"cp in.txt out/copy.txt"}}, nil, ""},
+		{"sandbox path in output", Target{Outputs: []string{"out/"}, Run: []string{"pwd > out/dir.txt", "echo same > out/same.txt"}}, []string{"out/dir.txt"}, ""},
+		{"no outputs", Target{Run: []string{"true"}}, nil, "declares no outputs"},
+		{"undeclared source", Target{Outputs: []string{"out/copy.txt"}, Run: []string{"cp in.txt out/copy.txt"}}, nil, "run 1 in a sandbox"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: map[string]Target{"gen": tt.target}}
+			report, err := verifyDeterminism("gen", false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("verifyDeterminism() error = %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var diffs []string
+			for _, diff := range report.Diffs {
+				diffs = append(diffs, diff.Path)
+				if diff.First == "" || diff.Second == "" {
+					t.Errorf("%s: both runs produced it, hashes = %q, %q", diff.Path, diff.First, diff.Second)
+				}
+			}
+			if strings.Join(diffs, ",") != strings.Join(tt.wantDiffs, ",") {
+				t.Errorf("differing outputs = %v, expected %v", diffs, tt.wantDiffs)
+			}
+		})
+	}
+	if _, err := os.Stat("out"); !os.IsNotExist(err) {
+		t.Errorf("verifying should leave the project untouched, stat error = %v", err)
+	}
+}
+
+func TestShortHash(t *testing.T) {
+	if got := shortHash(""); got != "-" {
+		t.Errorf("shortHash(\"\") = %q, expected -", got)
+	}
+	if got := shortHash("0123456789abcdef"); got != "0123456789ab" {
+		t.Errorf("shortHash() = %q, expected 12 characters", got)
+	}
+}
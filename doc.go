@@ -9,16 +9,40 @@ advanced CLI operations.
 
 Variable Substitution:
 Aura supports variable substitution in commands using $VAR or ${VAR} syntax.
-Built-in variables include $cwd (current directory), $@ (target name), and
-$TIMESTAMP (current time).
+Built-in variables include $cwd (current directory), $@ (target name),
+$TIMESTAMP (current time), $NPROC (CPU count), $OS and $ARCH, $HOME,
+$GIT_SHA and $GIT_BRANCH, $CONFIG_DIR, $BUILD_ID (a UUID per run) and
+$ARGS or $CLI_ARGS (arguments after --, shell quoted; $CLI_ARGS_1... and
+$CLI_ARGS_COUNT give them one by one), and $FREE_PORT / $FREE_PORT_<n>
+(unused TCP ports, the same for all commands of a target) and $TMPDIR_TARGET
+(a temporary directory removed after the target unless build --keep-temp).
+Variable definitions may use ${...} expressions such as ${NPROC - 1} or
+${REGISTRY}/${NAME}. They are evaluated when a target first uses the
+variable, against that target's profile, parameters and target vars, so a
+variable no target uses is never evaluated.
 
 Target Dependencies:
 Build targets can declare dependencies on other targets or files, ensuring
 proper build order and incremental builds.
 
+Parallel Execution:
+With -p N independent targets run concurrently, each once and only after its
+dependencies succeeded. On Unix aura joins a GNU make jobserver it inherits
+through MAKEFLAGS, and hosts one for make processes it starts, so mixed
+make/aura builds share a single job budget.
+
+Sandboxed Targets:
+A target with sandbox: true runs in a staging directory holding only its
+declared sources; its declared outputs are moved back afterwards, so reading
+an undeclared input fails instead of silently working. On Linux, build
+--trace-deps runs commands under strace and reports the project files a
+target read without declaring them.
+
 Template System:
-Initialize new projects with built-in templates for Go, Rust, Node.js, and
-basic C/C++ projects using the init command.
+Initialize new projects with built-in templates for Go, Rust, Node.js,
+Python (venv, pytest, ruff), Java (Gradle wrapper), basic C/C++ and CMake
+(presets, compile_commands.json) projects, or a workspace with example
+members, using the init command.
 
 File Watching:
 Continuously monitor files for changes and automatically rebuild targets
@@ -32,18 +56,42 @@ and configurable storage providers.
 
 Build Operations:
   - build: Execute build targets with parallel jobs and force rebuild options
+  - run: Run one target, passing the arguments after -- to it
   - list: Display available targets in table, JSON, or YAML format
   - clean: Remove build artifacts and cache files
   - validate: Validate configuration file syntax and structure
+  - test-config: Check assertions about the config (commands, deps, vars per profile)
+  - migrate: Rewrite deprecated config syntax where it is safe
+  - status: Report targets as up-to-date, stale or never built without running them
+  - plan: Print the expanded build plan; plan diff compares it with a git revision,
+    plan --lanes N simulates a parallel build from past durations, plan --snapshot
+    file [--check] writes the plan to a file or fails when it no longer matches
+  - analyze flaky: List intermittently failing targets from the run history
+  - analyze resources: List targets by the CPU time and memory their commands use
+  - bench cache: Run targets twice and explain why they are not up-to-date
+  - suggest-deps: Propose deps, sources and outputs from the files a target's commands name
+  - explain: Show what building a target runs, in order, with commands, directories and status
+  - verify-determinism: Run targets twice in separate sandboxes and report outputs that differ
+  - query: Select targets of the dependency graph with deps(), rdeps() and allpaths()
+  - graph: Draw the target graph with last build times, as DOT, Mermaid, a tree or an
+    interactive HTML page
 
 Project Management:
   - init: Initialize new project with language-specific templates
   - watch: Monitor files and rebuild on changes
+  - watch install-service / uninstall-service: Run watch as a background service
+  - export vscode-tasks: Write a VS Code task per target to .vscode/tasks.json
+  - env snapshot / env diff: Record and compare build environments
+
+Tool Management:
+  - tools sync: Install the tools declared under tools: into the toolchain cache
+  - tools list: Show declared tools and whether they are installed
 
 Cache Management:
   - cache clear: Clear build cache
   - cache info: Show cache information and statistics
   - cache list: List cached items
+  - cache verify: Check cache records and re-hash manifest artifacts (--repair evicts bad records)
 
 # Configuration
 
@@ -68,6 +116,11 @@ Aura uses YAML configuration files (default: aura.yaml) with the following struc
 	  run:
 	    - "echo Build completed"
 
+A configuration file ending in .go is a Go program, run with go run, that
+prints the configuration; the github.com/agilira/aura/pkg/dsl package
+builds it from Go types.
+One ending in .cue is CUE, evaluated with cue export.
+
 # Security
 
 The tool implements comprehensive security measures:
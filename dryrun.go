@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// identifierRegex matches variable names, as opposed to ${...} expressions
+var identifierRegex = regexp.MustCompile(`^\w+(\.\w+)?$`)
+
+// dryRunStep is one target of a dry run, as it would run
+type dryRunStep struct {
+	Target    string            `json:"target"`
+	Deps      []string          `json:"deps"`
+	Dir       string            `json:"dir"`
+	Vars      map[string]string `json:"vars,omitempty"` // the variables its commands use, with their values
+	Commands  []string          `json:"commands"`
+	Dangerous bool              `json:"dangerous,omitempty"`
+	Skipped   string            `json:"skipped,omitempty"` // why it would not run
+}
+
+// dryRunPlan is what `aura --dry-run build` prints: the requested targets
+// and every step in the order the build runs them, the prologue first and
+// the epilogue last
+type dryRunPlan struct {
+	Targets []string     `json:"targets"`
+	Steps   []dryRunStep `json:"steps"`
+}
+
+// planBuild lays out the build of the requested targets without running it
+func planBuild(names []string) (dryRunPlan, error) {
+	requested, err := buildSchedule(names)
+	if err != nil {
+		return dryRunPlan{}, err
+	}
+	plan := dryRunPlan{Targets: names, Steps: []dryRunStep{}}
+	for _, stage := range cfg.activePrologues() {
+		if len(stage.target.Run) > 0 {
+			plan.Steps = append(plan.Steps, planStep(stage.name, stage.target, nil))
+		}
+	}
+	for _, node := range scheduleOrder(requested) {
+		var deps []string
+		for _, dep := range node.deps {
+			deps = append(deps, dep.name)
+		}
+		plan.Steps = append(plan.Steps, planStep(node.name, node.target, deps))
+	}
+	for _, stage := range cfg.activeEpilogues() {
+		if len(stage.target.Run) > 0 {
+			plan.Steps = append(plan.Steps, planStep(stage.name, stage.target, nil))
+		}
+	}
+	return plan, nil
+}
+
+// planStep expands a target's commands as planContext does and collects
+// the variables they reference
+func planStep(name string, target Target, deps []string) dryRunStep {
+	wd, _ := os.Getwd()
+	step := dryRunStep{Target: name, Deps: deps, Dir: filepath.Join(wd, target.dir), Commands: []string{}, Dangerous: target.Dangerous}
+	if step.Deps == nil {
+		step.Deps = []string{}
+	}
+	if reason := skipReason(name, &target); reason != "" {
+		step.Skipped = reason
+		return step
+	}
+
+	ectx := planContext(name, target)
+	wrapper := string(commandWrapper(&target))
+	for _, cmd := range append([]string{wrapper}, target.Run...) {
+		for _, ref := range varRefRegex.FindAllString(cmd, -1) {
+			ref = strings.Trim(strings.TrimPrefix(ref, "$"), "{}")
+			if !identifierRegex.MatchString(ref) {
+				continue
+			}
+			value, ok := ectx.Lookup(ref)
+			if head, _, dotted := strings.Cut(ref, "."); !ok && dotted {
+				// $NAME.txt is $NAME followed by .txt, as Expand reads it
+				ref = head
+				value, ok = ectx.Lookup(ref)
+			}
+			if ok {
+				if step.Vars == nil {
+					step.Vars = map[string]string{}
+				}
+				step.Vars[ref] = value
+			}
+		}
+	}
+	wrapper = ectx.Expand(wrapper)
+	for _, cmd := range target.Run {
+		step.Commands = append(step.Commands, wrapCommand(wrapper, ectx.Expand(cmd)))
+	}
+	return step
+}
+
+// writeDryRunText prints the plan step by step
+func writeDryRunText(w io.Writer, plan dryRunPlan) {
+	fmt.Fprintf(w, "DRY RUN - building %s runs %d steps in this order, nothing is executed\n", strings.Join(plan.Targets, ", "), len(plan.Steps))
+	for i, step := range plan.Steps {
+		heading := fmt.Sprintf("\n[%d/%d] %s", i+1, len(plan.Steps), step.Target)
+		if len(step.Deps) > 0 {
+			heading += " (after " + strings.Join(step.Deps, ", ") + ")"
+		}
+		if step.Dangerous {
+			heading += " [dangerous]"
+		}
+		fmt.Fprintln(w, heading)
+		if step.Skipped != "" {
+			fmt.Fprintf(w, "  skipped: %s\n", step.Skipped)
+			continue
+		}
+		fmt.Fprintf(w, "  dir: %s\n", step.Dir)
+		for _, name := range sortedKeys(step.Vars) {
+			fmt.Fprintf(w, "  var %s = %s\n", name, step.Vars[name])
+		}
+		for _, cmd := range step.Commands {
+			fmt.Fprintf(w, "  $ %s\n", cmd)
+		}
+	}
+}
+
+// writeDryRun prints the plan in the --format of the build
+func writeDryRun(w io.Writer, format string, plan dryRunPlan) error {
+	if format == "json" {
+		return json.NewEncoder(w).Encode(plan)
+	}
+	writeDryRunText(w, plan)
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ===== DRYRUN.GO UNIT TESTS =====
+
+func TestPlanBuild(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{
+		CommandWrapper: "nice",
+		Vars:           map[string]Var{"OUT": "dist"},
+		Prologue:       Target{Run: []string{"mkdir -p $OUT"}},
+		Targets: map[string]Target{
+			"gen":  {Run: []string{"gen > $OUT/gen.go at $TIMESTAMP"}},
+			"lib":  {Deps: []string{"gen", "go.mod"}, Vars: map[string]Var{"LEVEL": "2"}, Run: []string{"make -O$LEVEL $OUT.lib"}},
+			"app":  {Deps: []string{"lib", "gen"}, Dangerous: true, CommandWrapper: "none", Run: []string{"make app"}},
+			"port": {Deps: []string{"app"}, Platforms: stringList{"plan9"}, Run: []string{"mk"}},
+		},
+	}
+	wd, _ := os.Getwd()
+
+	plan, err := planBuild([]string{"port"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := dryRunPlan{Targets: []string{"port"}, Steps: []dryRunStep{
+		{Target: "prologue", Deps: []string{}, Dir: wd, Vars: map[string]string{"OUT": "dist"}, Commands: []string{"nice mkdir -p dist"}},
+		{Target: "gen", Deps: []string{}, Dir: wd, Vars: map[string]string{"OUT": "dist", "TIMESTAMP": "$TIMESTAMP"}, Commands: []string{"nice gen > dist/gen.go at $TIMESTAMP"}},
+		{Target: "lib", Deps: []string{"gen"}, Dir: wd, Vars: map[string]string{"LEVEL": "2", "OUT": "dist"}, Commands: []string{"nice make -O2 dist.lib"}},
+		{Target: "app", Deps: []string{"lib", "gen"}, Dir: wd, Commands: []string{"make app"}, Dangerous: true},
+		{Target: "port", Deps: []string{"app"}, Dir: wd, Commands: []string{}, Skipped: "runs on plan9"},
+	}}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Errorf("planBuild() = %+v\nexpected %+v", plan, expected)
+	}
+
+	if _, err := planBuild([]string{"missing"}); err == nil {
+		t.Error("planBuild() should fail for an unknown target")
+	}
+}
+
+func TestWriteDryRun(t *testing.T) {
+	dir := filepath.Join("src", "app")
+	plan := dryRunPlan{Targets: []string{"app"}, Steps: []dryRunStep{
+		{Target: "lib", Deps: []string{}, Dir: dir, Vars: map[string]string{"OUT": "dist"}, Commands: []string{"make dist"}},
+		{Target: "app", Deps: []string{"lib"}, Dir: dir, Commands: []string{"cd cmd", "go build"}, Dangerous: true},
+		{Target: "msi", Deps: []string{"app"}, Dir: dir, Commands: []string{}, Skipped: "runs on windows"},
+	}}
+
+	var text bytes.Buffer
+	if err := writeDryRun(&text, "text", plan); err != nil {
+		t.Fatal(err)
+	}
+	expected := `DRY RUN - building app runs 3 steps in this order, nothing is executed
+
+[1/3] lib
+  dir: ` + dir + `
+  var OUT = dist
+  $ make dist
+
+[2/3] app (after lib) [dangerous]
+  dir: ` + dir + `
+  $ cd cmd
+  $ go build
+
+[3/3] msi (after app)
+  skipped: runs on windows
+`
+	if text.String() != expected {
+		t.Errorf("text plan =\n%s\nexpected\n%s", text.String(), expected)
+	}
+
+	var out bytes.Buffer
+	if err := writeDryRun(&out, "json", plan); err != nil {
+		t.Fatal(err)
+	}
+	var decoded dryRunPlan
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || !reflect.DeepEqual(decoded, plan) {
+		t.Errorf("json plan = %s (%v), expected the plan", out.String(), err)
+	}
+	if strings.Count(out.String(), "\n") != 1 {
+		t.Errorf("json plan = %q, expected one line", out.String())
+	}
+}
@@ -0,0 +1,706 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+	"gopkg.in/yaml.v3"
+)
+
+// configEditor edits a config file through its yaml.Node tree so comments
+// and unrelated entries survive the round trip
+type configEditor struct {
+	path string
+	data []byte // the file as read, which save keeps where nothing changed
+	doc  yaml.Node
+	root *yaml.Node
+}
+
+// openConfigEditor parses the config file for editing
+func openConfigEditor(path string) (*configEditor, error) {
+	// #nosec G304 - User-specified config file, same as loadConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, orpheus.NotFoundError("config", fmt.Sprintf("cannot read '%s': %v", path, err))
+	}
+
+	e := &configEditor{path: path, data: data}
+	if err := yaml.Unmarshal(data, &e.doc); err != nil {
+		return nil, orpheus.ValidationError("config", fmt.Sprintf("failed to parse '%s': %v", path, err))
+	}
+	if e.doc.Kind == 0 {
+		e.doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	e.root = documentRoot(&e.doc)
+	if e.root == nil || e.root.Kind != yaml.MappingNode {
+		return nil, orpheus.ValidationError("config", fmt.Sprintf("'%s' is not a YAML mapping", path))
+	}
+	return e, nil
+}
+
+// section returns the mapping stored under key, creating it when missing
+func (e *configEditor) section(key string) *yaml.Node {
+	if node := mappingValue(e.root, key); node != nil {
+		if node.Kind != yaml.MappingNode {
+			// `targets:` with no entries decodes as a null scalar
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		return node
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	e.root.Content = append(e.root.Content, scalarNode(key, 0), node)
+	return node
+}
+
+// save writes the document back to disk. The entries the edits left alone
+// keep their text as read, blank lines included; those that changed are
+// encoded again. A file laid out in a way this cannot follow is encoded
+// whole, as aura fmt does
+func (e *configEditor) save() error {
+	out, err := e.splice()
+	if err != nil {
+		if out, err = encodeConfigNode(&e.doc); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(e.path, out, 0600)
+}
+
+// splice renders the edited document over the file as read: entries that
+// did not change are copied line for line, changed ones are encoded again
+// in their place and new ones are added where the edits put them
+func (e *configEditor) splice() ([]byte, error) {
+	var original yaml.Node
+	if err := yaml.Unmarshal(e.data, &original); err != nil {
+		return nil, err
+	}
+	root := documentRoot(&original)
+	if root == nil || !isBlockMapping(root, true) || !isBlockMapping(e.root, false) {
+		return nil, fmt.Errorf("not a block mapping")
+	}
+
+	text := string(e.data)
+	s := &splicer{newline: "\n"}
+	if strings.Contains(text, "\r\n") {
+		s.newline = "\r\n"
+	}
+	s.lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	first := commentStart(s.lines, root.Content[0].Line-1, "")
+	s.copy(0, first)
+	gap, err := s.mapping(e.root, root, len(s.lines), 0)
+	if err != nil {
+		return nil, err
+	}
+	s.out.WriteString(gap)
+	out := []byte(s.out.String())
+
+	// What was written must read back as the edited document
+	var want, got any
+	wantText, err := encodeConfigNode(&e.doc)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(wantText, &want); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(out, &got); err != nil || !reflect.DeepEqual(got, want) {
+		return nil, fmt.Errorf("spliced document differs")
+	}
+	return out, nil
+}
+
+// isBlockMapping reports whether node is a mapping in block style, which
+// splice can follow when, as read, it has one entry per line
+func isBlockMapping(node *yaml.Node, read bool) bool {
+	if node.Kind != yaml.MappingNode || node.Style&yaml.FlowStyle != 0 || len(node.Content) == 0 {
+		return false
+	}
+	for i := 2; read && i < len(node.Content); i += 2 {
+		if node.Content[i].Line <= node.Content[i-2].Line {
+			return false
+		}
+	}
+	return true
+}
+
+// splicer builds the output of configEditor.splice
+type splicer struct {
+	lines   []string
+	newline string
+	out     strings.Builder
+}
+
+// copy writes lines [from, to) of the file as read
+func (s *splicer) copy(from, to int) {
+	for _, line := range s.lines[from:to] {
+		s.out.WriteString(line + "\n")
+	}
+}
+
+// entrySpan is the lines of a mapping entry in the file as read: its head
+// comment, the entry and the blank lines after it, from gap on
+type entrySpan struct {
+	start, gap, end int
+	key, value      *yaml.Node
+}
+
+// spans finds the lines of the entries of a mapping of the file as read,
+// the last one ending at end
+func (s *splicer) spans(mapping *yaml.Node, end int) []entrySpan {
+	spans := make([]entrySpan, len(mapping.Content)/2)
+	for i := range spans {
+		key := mapping.Content[2*i]
+		spans[i] = entrySpan{start: commentStart(s.lines, key.Line-1, strings.Repeat(" ", key.Column-1)), key: key, value: mapping.Content[2*i+1]}
+		if i > 0 {
+			spans[i].start = max(spans[i].start, spans[i-1].key.Line)
+		}
+	}
+	for i := range spans {
+		spans[i].end = end
+		if i+1 < len(spans) {
+			spans[i].end = spans[i+1].start
+		}
+		spans[i].gap = spans[i].end
+		for spans[i].gap > spans[i].key.Line && strings.TrimSpace(s.lines[spans[i].gap-1]) == "" {
+			spans[i].gap--
+		}
+	}
+	return spans
+}
+
+// mapping writes the edited form of a mapping of the file as read, whose
+// last entry ends at end, and returns the blank lines that close it, left
+// to the caller. Entries keep their text unless they changed; a changed
+// mapping is spliced entry by entry in turn
+func (s *splicer) mapping(edited, original *yaml.Node, end, indent int) (string, error) {
+	spans := s.spans(original, end)
+	byLine := map[int]int{}
+	for i, span := range spans {
+		byLine[span.key.Line] = i
+	}
+	blanks := func(from, to int) string {
+		return strings.Repeat(s.newline, to-from)
+	}
+	// New entries are set apart like the first two entries are
+	separator := ""
+	if len(spans) > 1 {
+		separator = blanks(spans[0].gap, spans[0].end)
+	}
+
+	// New and changed entries are indented like the file's nested blocks
+	step := 2
+	for _, span := range spans {
+		content := span.value.Content
+		if span.value.Style&yaml.FlowStyle != 0 || len(content) == 0 || content[0].Line == span.key.Line {
+			continue
+		}
+		// An item of a sequence sits past its "- "
+		column := content[0].Column
+		if span.value.Kind == yaml.SequenceNode {
+			column -= 2
+		}
+		if column > span.key.Column {
+			step = column - span.key.Column
+			break
+		}
+	}
+
+	next, pending, written := 0, "", false
+	for i := 0; i+1 < len(edited.Content); i += 2 {
+		key, value := edited.Content[i], edited.Content[i+1]
+		at, ok := byLine[key.Line]
+		if key.Line == 0 || !ok {
+			if written {
+				s.out.WriteString(separator)
+			}
+			text, err := encodeEntry(key, value, indent, step, s.newline)
+			if err != nil {
+				return "", err
+			}
+			s.out.WriteString(text)
+			written = true
+			continue
+		}
+		if at < next {
+			return "", fmt.Errorf("entries were reordered")
+		}
+		// Entries skipped over were removed, with their blank lines
+		for ; next < at; next++ {
+			pending = blanks(spans[next].gap, spans[next].end)
+		}
+		next = at + 1
+
+		span := spans[at]
+		s.out.WriteString(pending)
+		pending = blanks(span.gap, span.end)
+		written = true
+
+		// An entry whose scalars alone changed, such as a renamed dep, keeps
+		// its text with those scalars replaced
+		keyEdits, keyOK := s.scalarEdits(span.key, key, nil)
+		if edits, ok := s.scalarEdits(span.value, value, keyEdits); keyOK && ok {
+			s.copyPatched(span.start, span.gap, edits)
+			continue
+		}
+		if keyOK && isBlockMapping(value, false) && isBlockMapping(span.value, true) && span.value.Content[0].Line > span.key.Line {
+			first := span.value.Content[0]
+			s.copyPatched(span.start, commentStart(s.lines, first.Line-1, strings.Repeat(" ", first.Column-1)), keyEdits)
+			gap, err := s.mapping(value, span.value, span.gap, first.Column-1)
+			if err != nil {
+				return "", err
+			}
+			s.out.WriteString(gap)
+			continue
+		}
+
+		// The head comment is kept as written
+		s.copy(span.start, key.Line-1)
+		head := key.HeadComment
+		key.HeadComment = ""
+		text, err := encodeEntry(key, value, indent, step, s.newline)
+		key.HeadComment = head
+		if err != nil {
+			return "", err
+		}
+		s.out.WriteString(text)
+	}
+	for ; next < len(spans); next++ {
+		pending = blanks(spans[next].gap, spans[next].end)
+	}
+	return pending, nil
+}
+
+// tokenEdit replaces bytes [from, to) of a line of the file as read
+type tokenEdit struct {
+	line, from, to int
+	text           string
+}
+
+// scalarEdits compares a node of the file as read with its edited form and
+// adds to edits the replacement of each scalar whose value changed. It
+// reports false when anything else changed, or when a scalar cannot be
+// replaced where it is written
+func (s *splicer) scalarEdits(original, edited *yaml.Node, edits []tokenEdit) ([]tokenEdit, bool) {
+	if original.Kind != edited.Kind || len(original.Content) != len(edited.Content) || original.Anchor != edited.Anchor ||
+		original.HeadComment != edited.HeadComment || original.LineComment != edited.LineComment || original.FootComment != edited.FootComment {
+		return edits, false
+	}
+	if original.Kind != yaml.ScalarNode {
+		if original.Style != edited.Style || original.Value != edited.Value {
+			return edits, false
+		}
+		for i := range original.Content {
+			var ok bool
+			if edits, ok = s.scalarEdits(original.Content[i], edited.Content[i], edits); !ok {
+				return edits, false
+			}
+		}
+		return edits, true
+	}
+	if original.Value == edited.Value && original.Style == edited.Style && original.Tag == edited.Tag {
+		return edits, true
+	}
+
+	// Only a scalar on one line, after plain ASCII, is replaced
+	if original.Line < 1 || original.Line > len(s.lines) {
+		return edits, false
+	}
+	line := s.lines[original.Line-1]
+	from := original.Column - 1
+	if from < 0 || from >= len(line) || strings.IndexFunc(line[:from], func(r rune) bool { return r >= utf8.RuneSelf }) >= 0 {
+		return edits, false
+	}
+	to := -1
+	switch original.Style {
+	case 0:
+		if strings.HasPrefix(line[from:], original.Value) {
+			to = from + len(original.Value)
+		}
+	case yaml.DoubleQuotedStyle:
+		for i := from + 1; i < len(line) && to < 0; i++ {
+			switch line[i] {
+			case '\\':
+				i++
+			case '"':
+				to = i + 1
+			}
+		}
+	case yaml.SingleQuotedStyle:
+		for i := from + 1; i < len(line) && to < 0; i++ {
+			if line[i] != '\'' {
+				continue
+			}
+			if i+1 < len(line) && line[i+1] == '\'' {
+				i++
+			} else {
+				to = i + 1
+			}
+		}
+	}
+	if to < 0 {
+		return edits, false
+	}
+
+	text, err := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Tag: edited.Tag, Value: edited.Value, Style: edited.Style})
+	if err != nil || strings.Count(string(text), "\n") != 1 {
+		return edits, false
+	}
+	return append(edits, tokenEdit{line: original.Line - 1, from: from, to: to, text: strings.TrimSuffix(string(text), "\n")}), true
+}
+
+// copyPatched writes lines [from, to) of the file as read with edits made
+func (s *splicer) copyPatched(from, to int, edits []tokenEdit) {
+	// The later edits on a line go first, so the offsets of the others hold
+	slices.SortFunc(edits, func(a, b tokenEdit) int {
+		if a.line != b.line {
+			return a.line - b.line
+		}
+		return b.from - a.from
+	})
+	for i := from; i < to; i++ {
+		line := s.lines[i]
+		for _, edit := range edits {
+			if edit.line == i {
+				line = line[:edit.from] + edit.text + line[edit.to:]
+			}
+		}
+		s.out.WriteString(line + "\n")
+	}
+}
+
+// encodeEntry encodes one mapping entry indented by indent spaces, its
+// nested blocks by step more each
+func encodeEntry(key, value *yaml.Node, indent, step int, newline string) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(step)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{key, value}}); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if line != "" {
+			b.WriteString(strings.Repeat(" ", indent))
+		}
+		b.WriteString(line + newline)
+	}
+	return b.String(), nil
+}
+
+// mappingIndex returns the index of key in mapping.Content, or -1
+func mappingIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func scalarNode(value string, style yaml.Style) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: style}
+}
+
+func sequenceNode(values []string) *yaml.Node {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, v := range values {
+		seq.Content = append(seq.Content, scalarNode(v, yaml.DoubleQuotedStyle))
+	}
+	return seq
+}
+
+// addTarget appends a new target; it fails if the name is taken
+func (e *configEditor) addTarget(name, desc string, deps, run []string) error {
+	targets := e.section("targets")
+	if mappingIndex(targets, name) >= 0 {
+		return orpheus.ValidationError("target", fmt.Sprintf("target '%s' already exists", name))
+	}
+
+	target := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if desc != "" {
+		target.Content = append(target.Content, scalarNode("desc", 0), scalarNode(desc, yaml.DoubleQuotedStyle))
+	}
+	if len(deps) > 0 {
+		target.Content = append(target.Content, scalarNode("deps", 0), sequenceNode(deps))
+	}
+	target.Content = append(target.Content, scalarNode("run", 0), sequenceNode(run))
+
+	targets.Content = append(targets.Content, scalarNode(name, 0), target)
+	return nil
+}
+
+// removeTarget deletes a target and returns the entries that still name it
+func (e *configEditor) removeTarget(name string) ([]string, error) {
+	targets := e.section("targets")
+	i := mappingIndex(targets, name)
+	if i < 0 {
+		return nil, orpheus.NotFoundError("target", fmt.Sprintf("target '%s' not found", name))
+	}
+	targets.Content = slices.Delete(targets.Content, i, i+2)
+
+	var dependents []string
+	for _, ref := range e.targetReferences(name) {
+		dependents = append(dependents, ref.owner)
+	}
+	return dependents, nil
+}
+
+// renameTarget renames a target in place and rewrites the entries that
+// name it
+func (e *configEditor) renameTarget(oldName, newName string) (int, error) {
+	targets := e.section("targets")
+	i := mappingIndex(targets, oldName)
+	if i < 0 {
+		return 0, orpheus.NotFoundError("target", fmt.Sprintf("target '%s' not found", oldName))
+	}
+	if mappingIndex(targets, newName) >= 0 {
+		return 0, orpheus.ValidationError("target", fmt.Sprintf("target '%s' already exists", newName))
+	}
+	targets.Content[i].Value = newName
+
+	refs := e.targetReferences(oldName)
+	for _, ref := range refs {
+		ref.node.Value = newName
+	}
+	return len(refs), nil
+}
+
+type targetReference struct {
+	owner string
+	node  *yaml.Node
+}
+
+// depOwners returns the nodes of the targets, prologue and epilogue and of
+// the prologue and epilogue of profiles, the mappings that can list deps
+func (e *configEditor) depOwners() map[string]*yaml.Node {
+	owners := map[string]*yaml.Node{}
+	if targets := mappingValue(e.root, "targets"); targets != nil && targets.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(targets.Content); i += 2 {
+			owners[targets.Content[i].Value] = targets.Content[i+1]
+		}
+	}
+	for _, hook := range []string{"prologue", "epilogue"} {
+		if node := mappingValue(e.root, hook); node != nil {
+			owners[hook] = node
+		}
+	}
+	if profiles := mappingValue(e.root, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			if profiles.Content[i+1].Kind != yaml.MappingNode {
+				continue
+			}
+			for _, hook := range []string{"prologue", "epilogue"} {
+				if node := mappingValue(profiles.Content[i+1], hook); node != nil {
+					owners["profiles."+profiles.Content[i].Value+"."+hook] = node
+				}
+			}
+		}
+	}
+	return owners
+}
+
+// targetReferences finds the entries naming target: deps in targets, the
+// prologue, the epilogue and those of profiles, and the targets of watch
+// rules
+func (e *configEditor) targetReferences(target string) []targetReference {
+	var refs []targetReference
+	add := func(owner string, list *yaml.Node) {
+		if list == nil || list.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, item := range list.Content {
+			if item.Kind == yaml.ScalarNode && item.Value == target {
+				refs = append(refs, targetReference{owner: owner, node: item})
+			}
+		}
+	}
+
+	owners := e.depOwners()
+	for _, owner := range sortedKeys(owners) {
+		if node := owners[owner]; node.Kind == yaml.MappingNode {
+			add(owner, mappingValue(node, "deps"))
+		}
+	}
+	if watch := mappingValue(e.root, "watch"); watch != nil && watch.Kind == yaml.SequenceNode {
+		for i, rule := range watch.Content {
+			if rule.Kind != yaml.MappingNode {
+				continue
+			}
+			owner := fmt.Sprintf("watch[%d]", i)
+			if name := mappingValue(rule, "name"); name != nil && name.Value != "" {
+				owner = "watch " + name.Value
+			}
+			add(owner, mappingValue(rule, "targets"))
+		}
+	}
+	return refs
+}
+
+// setVar creates or updates an entry under vars
+func (e *configEditor) setVar(name, value string) bool {
+	vars := e.section("vars")
+	if i := mappingIndex(vars, name); i >= 0 {
+		// Update in place so comments attached to the value survive
+		node := vars.Content[i+1]
+		node.Kind, node.Tag, node.Value, node.Style, node.Content = yaml.ScalarNode, "!!str", value, yaml.DoubleQuotedStyle, nil
+		return false
+	}
+	vars.Content = append(vars.Content, scalarNode(name, 0), scalarNode(value, yaml.DoubleQuotedStyle))
+	return true
+}
+
+// editSetup changes to the working directory and opens the config editor
+func editSetup(ctx *orpheus.Context) (*configEditor, error) {
+	workDir := ctx.GetGlobalFlagString("directory")
+	configFile := ctx.GetGlobalFlagString("config")
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return nil, orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	return openConfigEditor(configFile)
+}
+
+// positionalArgs returns the non-flag arguments of a command
+func positionalArgs(ctx *orpheus.Context) []string {
+	if ctx.Flags != nil {
+		return ctx.Flags.Args()
+	}
+	return ctx.Args
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// targetCommand shows usage for the target subcommands
+func targetCommand(ctx *orpheus.Context) error {
+	fmt.Println("Edit targets in the configuration file")
+	fmt.Println("Use 'aura target <subcommand>':")
+	fmt.Println("  add <name> <command>...  - Add a target")
+	fmt.Println("  remove <name>            - Remove a target")
+	fmt.Println("  rename <old> <new>       - Rename a target and its references")
+	return nil
+}
+
+// targetAddCommand adds a target: aura target add <name> <command>...
+func targetAddCommand(ctx *orpheus.Context) error {
+	args := positionalArgs(ctx)
+	if len(args) < 2 {
+		return orpheus.ValidationError("target add", "usage: aura target add <name> <command>... [--deps a,b] [--desc text]")
+	}
+
+	editor, err := editSetup(ctx)
+	if err != nil {
+		return err
+	}
+	name := args[0]
+	if err := editor.addTarget(name, ctx.GetFlagString("desc"), splitList(ctx.GetFlagString("deps")), args[1:]); err != nil {
+		return err
+	}
+	if err := editor.save(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", editor.path, err)
+	}
+
+	fmt.Printf("%s Added target: %s\n", checkMark(), name)
+	return nil
+}
+
+// targetRemoveCommand removes a target: aura target remove <name>
+func targetRemoveCommand(ctx *orpheus.Context) error {
+	args := positionalArgs(ctx)
+	if len(args) != 1 {
+		return orpheus.ValidationError("target remove", "usage: aura target remove <name>")
+	}
+
+	editor, err := editSetup(ctx)
+	if err != nil {
+		return err
+	}
+	dependents, err := editor.removeTarget(args[0])
+	if err != nil {
+		return err
+	}
+	if err := editor.save(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", editor.path, err)
+	}
+
+	fmt.Printf("%s Removed target: %s\n", checkMark(), args[0])
+	if len(dependents) > 0 {
+		fmt.Printf("Warning: still referenced by: %s\n", strings.Join(dependents, ", "))
+	}
+	return nil
+}
+
+// targetRenameCommand renames a target: aura target rename <old> <new>
+func targetRenameCommand(ctx *orpheus.Context) error {
+	args := positionalArgs(ctx)
+	if len(args) != 2 {
+		return orpheus.ValidationError("target rename", "usage: aura target rename <old> <new>")
+	}
+
+	editor, err := editSetup(ctx)
+	if err != nil {
+		return err
+	}
+	updated, err := editor.renameTarget(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if err := editor.save(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", editor.path, err)
+	}
+
+	fmt.Printf("%s Renamed target: %s -> %s (%d references updated)\n", checkMark(), args[0], args[1], updated)
+	return nil
+}
+
+// varCommand shows usage for the var subcommands
+func varCommand(ctx *orpheus.Context) error {
+	fmt.Println("Edit variables in the configuration file")
+	fmt.Println("Use 'aura var <subcommand>':")
+	fmt.Println("  set <NAME> <value>  - Create or update a variable")
+	return nil
+}
+
+// varSetCommand sets a variable: aura var set <NAME> <value>
+func varSetCommand(ctx *orpheus.Context) error {
+	args := positionalArgs(ctx)
+	if len(args) != 2 {
+		return orpheus.ValidationError("var set", "usage: aura var set <NAME> <value>")
+	}
+
+	editor, err := editSetup(ctx)
+	if err != nil {
+		return err
+	}
+	created := editor.setVar(args[0], args[1])
+	if err := editor.save(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", editor.path, err)
+	}
+
+	if created {
+		fmt.Printf("%s Added variable: %s\n", checkMark(), args[0])
+	} else {
+		fmt.Printf("%s Updated variable: %s\n", checkMark(), args[0])
+	}
+	return nil
+}
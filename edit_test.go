@@ -0,0 +1,286 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ===== EDIT.GO UNIT TESTS =====
+
+const editTestConfig = `# project config
+vars:
+  GO: "go" # toolchain
+
+targets:
+  # compile everything
+  build:
+    run:
+      - "$GO build"
+
+  test:
+    deps:
+      - build
+    run:
+      - "$GO test ./..."
+`
+
+func newTestEditor(t *testing.T, content string) *configEditor {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "aura.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	editor, err := openConfigEditor(path)
+	if err != nil {
+		t.Fatalf("openConfigEditor() unexpected error: %v", err)
+	}
+	return editor
+}
+
+func savedConfig(t *testing.T, editor *configEditor) string {
+	t.Helper()
+	if err := editor.save(); err != nil {
+		t.Fatalf("save() unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(editor.path)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	return string(data)
+}
+
+func TestConfigEditorAddTarget(t *testing.T) {
+	editor := newTestEditor(t, editTestConfig)
+
+	if err := editor.addTarget("lint", "Lint sources", []string{"build"}, []string{"go vet ./..."}); err != nil {
+		t.Fatalf("addTarget() unexpected error: %v", err)
+	}
+	if err := editor.addTarget("build", "", nil, []string{"echo"}); err == nil {
+		t.Errorf("addTarget() expected error for duplicate target")
+	}
+
+	out := savedConfig(t, editor)
+	for _, want := range []string{
+		"# project config",
+		"# compile everything",
+		"GO: \"go\" # toolchain",
+		"  lint:\n    desc: \"Lint sources\"\n    deps:\n      - \"build\"\n    run:\n      - \"go vet ./...\"",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("saved config missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestConfigEditorRemoveTarget(t *testing.T) {
+	editor := newTestEditor(t, editTestConfig)
+
+	dependents, err := editor.removeTarget("build")
+	if err != nil {
+		t.Fatalf("removeTarget() unexpected error: %v", err)
+	}
+	if strings.Join(dependents, ",") != "test" {
+		t.Errorf("removeTarget() dependents = %v, want [test]", dependents)
+	}
+	if _, err := editor.removeTarget("missing"); err == nil {
+		t.Errorf("removeTarget() expected error for missing target")
+	}
+
+	out := savedConfig(t, editor)
+	if strings.Contains(out, "build:") || strings.Contains(out, "compile everything") {
+		t.Errorf("removed target still present:\n%s", out)
+	}
+}
+
+func TestConfigEditorRenameTarget(t *testing.T) {
+	editor := newTestEditor(t, editTestConfig)
+
+	updated, err := editor.renameTarget("build", "compile")
+	if err != nil {
+		t.Fatalf("renameTarget() unexpected error: %v", err)
+	}
+	if updated != 1 {
+		t.Errorf("renameTarget() updated %d references, want 1", updated)
+	}
+	if _, err := editor.renameTarget("test", "compile"); err == nil {
+		t.Errorf("renameTarget() expected error when new name exists")
+	}
+
+	out := savedConfig(t, editor)
+	if !strings.Contains(out, "# compile everything\n  compile:") {
+		t.Errorf("renamed target lost its comment:\n%s", out)
+	}
+	if !strings.Contains(out, "deps:\n      - compile") {
+		t.Errorf("deps reference not renamed:\n%s", out)
+	}
+}
+
+func TestConfigEditorRenameTargetEverywhere(t *testing.T) {
+	editor := newTestEditor(t, editTestConfig+`
+prologue:
+  deps: [build]
+profiles:
+  ci:
+    epilogue:
+      deps: [build, test]
+watch:
+  - name: go
+    patterns: ["**/*.go"]
+    targets: [build, test]
+  - patterns: ["*.yaml"]
+    targets: [build]
+`)
+
+	updated, err := editor.renameTarget("build", "compile")
+	if err != nil {
+		t.Fatalf("renameTarget() unexpected error: %v", err)
+	}
+	if updated != 5 {
+		t.Errorf("renameTarget() updated %d references, want 5", updated)
+	}
+
+	out := savedConfig(t, editor)
+	for _, want := range []string{
+		"prologue:\n  deps: [compile]",
+		"epilogue:\n      deps: [compile, test]",
+		"targets: [compile, test]",
+		"targets: [compile]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("saved config missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "[build") || strings.Contains(out, "build]") || strings.Contains(out, "- build") {
+		t.Errorf("saved config still names build:\n%s", out)
+	}
+
+	// Removing a target lists what still names it
+	dependents, err := editor.removeTarget("test")
+	if err != nil {
+		t.Fatalf("removeTarget() unexpected error: %v", err)
+	}
+	if got := strings.Join(dependents, ", "); got != "profiles.ci.epilogue, watch go" {
+		t.Errorf("removeTarget() dependents = %s", got)
+	}
+}
+
+func TestConfigEditorSetVar(t *testing.T) {
+	editor := newTestEditor(t, editTestConfig)
+
+	if created := editor.setVar("GO", "go1.22"); created {
+		t.Errorf("setVar() reported existing variable as created")
+	}
+	if created := editor.setVar("OUT", "bin/app"); !created {
+		t.Errorf("setVar() reported new variable as updated")
+	}
+
+	out := savedConfig(t, editor)
+	if !strings.Contains(out, "GO: \"go1.22\" # toolchain") || !strings.Contains(out, "OUT: \"bin/app\"") {
+		t.Errorf("variables not written as expected:\n%s", out)
+	}
+}
+
+func TestConfigEditorKeepsLayout(t *testing.T) {
+	const config = `# project config
+
+vars:
+    GO:   go     # toolchain
+    FLAGS: '-v'
+
+
+targets:
+    # compile everything
+    build:
+        run:   ["$GO build $FLAGS"]
+
+
+    # run the tests
+    test:
+        deps: [build]    # first
+        run:
+          - $GO test ./...
+
+    docs:
+        run: [godoc]
+
+watch:
+  - patterns: ["*.go"]
+    targets: [build]
+`
+	const expected = `# project config
+
+vars:
+    GO:   "go1.25"     # toolchain
+    FLAGS: '-v'
+    NEW: "x"
+
+
+targets:
+    # compile everything
+    compile:
+        run:   ["$GO build $FLAGS"]
+
+
+    # run the tests
+    test:
+        deps: [compile]    # first
+        run:
+          - $GO test ./...
+
+
+    lint:
+        deps:
+            - "compile"
+        run:
+            - "go vet ./..."
+
+watch:
+  - patterns: ["*.go"]
+    targets: [compile]
+`
+
+	for _, newline := range []string{"\n", "\r\n"} {
+		editor := newTestEditor(t, strings.ReplaceAll(config, "\n", newline))
+		if _, err := editor.renameTarget("build", "compile"); err != nil {
+			t.Fatalf("renameTarget() unexpected error: %v", err)
+		}
+		if _, err := editor.removeTarget("docs"); err != nil {
+			t.Fatalf("removeTarget() unexpected error: %v", err)
+		}
+		if err := editor.addTarget("lint", "", []string{"compile"}, []string{"go vet ./..."}); err != nil {
+			t.Fatalf("addTarget() unexpected error: %v", err)
+		}
+		editor.setVar("GO", "go1.25")
+		editor.setVar("NEW", "x")
+
+		if out, want := savedConfig(t, editor), strings.ReplaceAll(expected, "\n", newline); out != want {
+			t.Errorf("saved config = %q, want %q", out, want)
+		}
+	}
+}
+
+func TestConfigEditorEmptyFile(t *testing.T) {
+	editor := newTestEditor(t, "")
+
+	editor.setVar("A", "1")
+	if err := editor.addTarget("build", "", nil, []string{"make"}); err != nil {
+		t.Fatalf("addTarget() unexpected error: %v", err)
+	}
+
+	out := savedConfig(t, editor)
+	expected := "vars:\n  A: \"1\"\n\ntargets:\n  build:\n    run:\n      - \"make\"\n"
+	if out != expected {
+		t.Errorf("saved config = %q, want %q", out, expected)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	if got := splitList(" a, b,,c "); strings.Join(got, "|") != "a|b|c" {
+		t.Errorf("splitList() = %v", got)
+	}
+	if got := splitList(""); got != nil {
+		t.Errorf("splitList(\"\") = %v, want nil", got)
+	}
+}
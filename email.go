@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Default templates of mailed events; notifiers set their own per event by
+// listing it in events: with a subject: and body: of their own
+const (
+	defaultEmailSubject = `[aura] {{.Type}}{{if .Target}}: {{.Target}}{{end}}`
+	defaultEmailBody    = `{{.Message}}
+{{if .Summary}}
+{{.Summary}}{{end}}`
+)
+
+// summaryAttachment is the file name the summary of a failed build is
+// attached under
+const summaryAttachment = "aura-summary.txt"
+
+// smtpTimeout bounds how long a mail server may hold up the build
+var smtpTimeout = 10 * time.Second
+
+// sendEmail renders an event with the notifier's templates and mails it
+func sendEmail(e *EmailNotifier, event buildEvent) error {
+	ectx := newExpandContext(event.Target, nil)
+	subject, err := renderEmailTemplate(e.Subject, defaultEmailSubject, event)
+	if err != nil {
+		return fmt.Errorf("subject: %v", err)
+	}
+	body, err := renderEmailTemplate(e.Body, defaultEmailBody, event)
+	if err != nil {
+		return fmt.Errorf("body: %v", err)
+	}
+
+	from := ectx.Expand(e.From)
+	to := make([]string, len(e.To))
+	for i, addr := range e.To {
+		to[i] = ectx.Expand(addr)
+	}
+	msg, err := buildEmail(from, to, strings.TrimSpace(subject), body, event.Summary)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	host, _, _ := net.SplitHostPort(ectx.Expand(e.SMTP))
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", ectx.Expand(e.Username), ectx.Expand(e.Password), host)
+	}
+	return deliverEmail(ectx.Expand(e.SMTP), host, auth, from, to, msg)
+}
+
+// renderEmailTemplate executes a subject or body template over an event,
+// the default one when the notifier sets none
+func renderEmailTemplate(text, fallback string, event buildEvent) (string, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := template.New("email").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// buildEmail composes the message: a plain text body, with the build
+// summary attached when there is one
+func buildEmail(from string, to []string, subject, body, summary string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if summary == "" {
+		buf.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\n")
+		buf.WriteString(crlf(body))
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", parts.Boundary())
+	part, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write([]byte(crlf(body))); err != nil {
+		return nil, err
+	}
+	part, err = parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": summaryAttachment})},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write([]byte(crlf(summary))); err != nil {
+		return nil, err
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// crlf gives text the line endings mail requires
+func crlf(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n")
+}
+
+// deliverEmail sends a message through an SMTP server, upgrading to TLS
+// when the server offers STARTTLS. Unlike smtp.SendMail every step is
+// bounded by smtpTimeout
+func deliverEmail(addr, host string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	conn, err := net.DialTimeout("tcp", addr, smtpTimeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(addressOf(from)); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(addressOf(rcpt)); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// addressOf returns the bare address of "Name <addr>", for the envelope
+func addressOf(addr string) string {
+	if parsed, err := mail.ParseAddress(addr); err == nil {
+		return parsed.Address
+	}
+	return addr
+}
+
+// validateEmail checks an email notifier's server, addresses and templates
+func validateEmail(e *EmailNotifier) error {
+	switch {
+	case e.SMTP == "":
+		return fmt.Errorf("smtp is required (host:port)")
+	case e.From == "":
+		return fmt.Errorf("from is required")
+	case len(e.To) == 0:
+		return fmt.Errorf("to needs at least one address")
+	}
+	if !strings.Contains(e.SMTP, "$") {
+		if _, _, err := net.SplitHostPort(e.SMTP); err != nil {
+			return fmt.Errorf("smtp '%s' is not host:port", e.SMTP)
+		}
+	}
+	for _, addr := range append([]string{e.From}, e.To...) {
+		if strings.Contains(addr, "$") {
+			continue
+		}
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("invalid address '%s': %v", addr, err)
+		}
+	}
+	if _, err := template.New("subject").Parse(e.Subject); err != nil {
+		return fmt.Errorf("subject template: %v", err)
+	}
+	if _, err := template.New("body").Parse(e.Body); err != nil {
+		return fmt.Errorf("body template: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// ===== EMAIL.GO UNIT TESTS =====
+
+// fakeSMTP accepts one message on a local port and returns its address
+// and a channel with the envelope recipients and data received
+func fakeSMTP(t *testing.T) (string, chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+		var rcpts []string
+		var data strings.Builder
+		reply("220 localhost fake")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				rcpts = append(rcpts, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+				reply("250 ok")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				reply("250 queued")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				received <- append(rcpts, data.String())
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestSendEmail(t *testing.T) {
+	addr, received := fakeSMTP(t)
+	email := &EmailNotifier{
+		SMTP:    addr,
+		From:    "Aura <aura@example.com>",
+		To:      []string{"team@example.com", "oncall@example.com"},
+		Subject: "{{.Type}} in {{.Target}}",
+	}
+	event := buildEvent{Type: eventFailed, Target: "test", Message: "exit status 1", Summary: "aura summary: targets=1 failed=1\n"}
+	if err := sendEmail(email, event); err != nil {
+		t.Fatalf("sendEmail() error = %v", err)
+	}
+
+	got := <-received
+	if rcpts := got[:len(got)-1]; len(rcpts) != 2 || rcpts[0] != "team@example.com" {
+		t.Errorf("recipients = %q, expected both addresses", rcpts)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(got[len(got)-1]))
+	if err != nil {
+		t.Fatalf("message does not parse: %v", err)
+	}
+	if subject := msg.Header.Get("Subject"); subject != "failed in test" {
+		t.Errorf("Subject = %q, expected the rendered template", subject)
+	}
+}
+
+func TestBuildEmailAttachment(t *testing.T) {
+	data, err := buildEmail("aura@example.com", []string{"team@example.com"}, "aura: failed ✗", "exit status 1\n", "aura summary: targets=2 failed=1\n")
+	if err != nil {
+		t.Fatalf("buildEmail() error = %v", err)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("message does not parse: %v", err)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subject != "aura: failed ✗" {
+		t.Errorf("Subject = %q", subject)
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	var bodies, files []string
+	for {
+		part, err := parts.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(part)
+		bodies = append(bodies, string(content))
+		files = append(files, part.FileName())
+	}
+	if len(bodies) != 2 || bodies[0] != "exit status 1\r\n" || files[1] != summaryAttachment || !strings.Contains(bodies[1], "failed=1") {
+		t.Errorf("parts = %q (files %q), expected the body then the summary attached", bodies, files)
+	}
+
+	// Without a summary the message is a single text part
+	data, _ = buildEmail("aura@example.com", []string{"team@example.com"}, "aura: slow", "too slow\n", "")
+	if !strings.Contains(string(data), "Content-Type: text/plain") || strings.Contains(string(data), "multipart") {
+		t.Errorf("message = %q, expected plain text", data)
+	}
+}
+
+func TestValidateEmail(t *testing.T) {
+	valid := EmailNotifier{SMTP: "smtp.example.com:587", From: "aura@example.com", To: []string{"team@example.com"}}
+	tests := []struct {
+		name    string
+		modify  func(e *EmailNotifier)
+		wantErr string
+	}{
+		{"valid", func(e *EmailNotifier) {}, ""},
+		{"variables", func(e *EmailNotifier) { e.SMTP, e.To = "$SMTP_HOST", []string{"${TEAM}"} }, ""},
+		{"no server", func(e *EmailNotifier) { e.SMTP = "" }, "smtp is required"},
+		{"no port", func(e *EmailNotifier) { e.SMTP = "smtp.example.com" }, "not host:port"},
+		{"no recipients", func(e *EmailNotifier) { e.To = nil }, "at least one address"},
+		{"bad address", func(e *EmailNotifier) { e.To = []string{"team"} }, "invalid address 'team'"},
+		{"bad template", func(e *EmailNotifier) { e.Subject = "{{.Type" }, "subject template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := valid
+			tt.modify(&email)
+			err := validateEmail(&email)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("validateEmail() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validateEmail() error = %v, expected %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// envCache holds a snapshot of the process environment so that every
+// expansion during a build sees the same values. A zero ttl keeps the
+// snapshot for the lifetime of the build.
+type envCache struct {
+	mu     sync.RWMutex
+	values map[string]string
+	taken  time.Time
+	ttl    time.Duration
+}
+
+var buildEnv = &envCache{}
+
+// Snapshot captures the current environment, replacing any previous one
+func (e *envCache) Snapshot(ttl time.Duration) {
+	values := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			values[k] = v
+		}
+	}
+
+	e.mu.Lock()
+	e.values = values
+	e.taken = time.Now()
+	e.ttl = ttl
+	e.mu.Unlock()
+}
+
+// refresh takes a new snapshot when none exists yet or the current one is
+// older than its ttl
+func (e *envCache) refresh() {
+	e.mu.RLock()
+	stale := e.values == nil || (e.ttl > 0 && time.Since(e.taken) > e.ttl)
+	ttl := e.ttl
+	e.mu.RUnlock()
+
+	if stale {
+		e.Snapshot(ttl)
+	}
+}
+
+// Lookup returns a variable from the snapshot
+func (e *envCache) Lookup(name string) (string, bool) {
+	e.refresh()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	val, ok := e.values[name]
+	return val, ok
+}
+
+// Getenv is the snapshot-backed equivalent of os.Getenv
+func (e *envCache) Getenv(name string) string {
+	val, _ := e.Lookup(name)
+	return val
+}
+
+// Values returns a copy of the current snapshot
+func (e *envCache) Values() map[string]string {
+	e.refresh()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return maps.Clone(e.values)
+}
+
+// toolPathEnv returns the environment for commands with cfg.ToolPaths and
+// the directories of declared tools prepended to PATH, or nil to inherit
+// aura's own environment
+func toolPathEnv() []string {
+	dirs := append(slices.Clone(cfg.ToolPaths), toolDirs()...)
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	env := os.Environ()
+	prefix := strings.Join(dirs, string(os.PathListSeparator))
+	for i, kv := range env {
+		// Windows spells it "Path" and treats names case-insensitively
+		k, v, _ := strings.Cut(kv, "=")
+		if k == "PATH" || (runtime.GOOS == "windows" && strings.EqualFold(k, "PATH")) {
+			env[i] = k + "=" + prefix + string(os.PathListSeparator) + v
+			return env
+		}
+	}
+	return append(env, "PATH="+prefix)
+}
+
+// commandEnv returns the environment for executed commands, or nil to
+// inherit aura's own
+func commandEnv() []string {
+	env := toolPathEnv()
+	if jobserver == nil {
+		return env
+	}
+	if env == nil {
+		env = os.Environ()
+	}
+	return jobserver.childEnv(env)
+}
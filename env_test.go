@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===== ENV.GO UNIT TESTS =====
+
+func TestEnvCacheSnapshot(t *testing.T) {
+	t.Setenv("AURA_TEST_SNAPSHOT", "before")
+
+	cache := &envCache{}
+	cache.Snapshot(0)
+
+	if err := os.Setenv("AURA_TEST_SNAPSHOT", "after"); err != nil {
+		t.Fatalf("Failed to set env: %v", err)
+	}
+
+	if got := cache.Getenv("AURA_TEST_SNAPSHOT"); got != "before" {
+		t.Errorf("Getenv() = %q, want snapshot value %q", got, "before")
+	}
+
+	if _, ok := cache.Lookup("AURA_TEST_NEVER_SET_12345"); ok {
+		t.Errorf("Lookup() of unset variable reported it as defined")
+	}
+}
+
+func TestEnvCacheTTLExpiry(t *testing.T) {
+	t.Setenv("AURA_TEST_TTL", "before")
+
+	cache := &envCache{}
+	cache.Snapshot(time.Millisecond)
+
+	if err := os.Setenv("AURA_TEST_TTL", "after"); err != nil {
+		t.Fatalf("Failed to set env: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if got := cache.Getenv("AURA_TEST_TTL"); got != "after" {
+		t.Errorf("Getenv() after ttl = %q, want refreshed value %q", got, "after")
+	}
+}
+
+func TestEnvCacheLazySnapshot(t *testing.T) {
+	t.Setenv("AURA_TEST_LAZY", "value")
+
+	cache := &envCache{}
+	if got := cache.Getenv("AURA_TEST_LAZY"); got != "value" {
+		t.Errorf("Getenv() without snapshot = %q, want %q", got, "value")
+	}
+}
+
+func TestGetVarFreshEnvRead(t *testing.T) {
+	original := cfg.Vars
+	defer func() { cfg.Vars = original }()
+	cfg.Vars = map[string]Var{}
+
+	t.Setenv("AURA_TEST_FRESH", "old")
+	buildEnv.Snapshot(0)
+	defer buildEnv.Snapshot(0)
+
+	if err := os.Setenv("AURA_TEST_FRESH", "new"); err != nil {
+		t.Fatalf("Failed to set env: %v", err)
+	}
+
+	if got := GetVar("AURA_TEST_FRESH", "test"); got != "old" {
+		t.Errorf("GetVar() = %q, want snapshot value %q", got, "old")
+	}
+	if got := GetVar("env:AURA_TEST_FRESH", "test"); got != "new" {
+		t.Errorf("GetVar(env:) = %q, want live value %q", got, "new")
+	}
+	if got := ParseVars("echo ${env:AURA_TEST_FRESH}", "test"); got != "echo new" {
+		t.Errorf("ParseVars() = %q, want %q", got, "echo new")
+	}
+}
+
+func TestLoadConfigEnvCacheTTL(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "aura.yaml")
+
+	if err := os.WriteFile(configPath, []byte("env_cache_ttl: \"soon\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg = Config{}
+	err := loadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "env_cache_ttl") {
+		t.Errorf("loadConfig() error = %v, want invalid env_cache_ttl", err)
+	}
+}
+
+func TestToolPathEnv(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{}
+	if env := toolPathEnv(); env != nil {
+		t.Errorf("toolPathEnv() without toolpaths = %d entries, want nil", len(env))
+	}
+
+	t.Setenv("PATH", "/usr/bin")
+	cfg.ToolPaths = []string{"/project/bin", "/project/node_modules/.bin"}
+
+	sep := string(os.PathListSeparator)
+	expected := "/project/bin" + sep + "/project/node_modules/.bin" + sep + "/usr/bin"
+	found := false
+	for _, kv := range toolPathEnv() {
+		if k, v, _ := strings.Cut(kv, "="); strings.EqualFold(k, "PATH") {
+			found = true
+			if v != expected {
+				t.Errorf("PATH = %q, want %q", v, expected)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("toolPathEnv() has no PATH entry")
+	}
+}
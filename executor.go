@@ -5,17 +5,26 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"runtime"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/agilira/orpheus/pkg/orpheus"
 	"gopkg.in/yaml.v3"
 )
 
 func ExecuteCommand(command string) (string, error) {
-	var cmd *exec.Cmd
-	var shell string
+	dir := ""
+	return executeCommandIn("", "", &dir, command, nil, nil, commandLimits{grace: defaultKillGrace})
+}
 
+// executeCommandIn runs a command of target through its shell in *dir, or
+// the current directory when empty. A non-nil trace records the files it
+// uses and a non-nil stream shows its output live; limits stops it on
+// timeout or interrupt.
+// A cd command changes *dir, never aura's own directory, so targets running
+// in parallel each keep theirs
+func executeCommandIn(target string, shell Var, dir *string, command string, trace *depTrace, stream *outputStream, limits commandLimits) (string, error) {
 	// Check for empty command
 	if strings.TrimSpace(command) == "" {
 		return "", fmt.Errorf("empty command")
@@ -26,46 +35,83 @@ func ExecuteCommand(command string) (string, error) {
 		// Allow common patterns but be aware this is a build tool that needs command chaining
 	}
 
-	fmt.Println(command)
+	logEvent{Level: levelInfo, Event: "command", Target: target, Command: command}.emit(command + "\n")
 
 	if strings.HasPrefix(command, "cd ") {
-		dir := strings.TrimSpace(strings.TrimPrefix(command, "cd "))
-		if dir == "" {
+		to := strings.TrimSpace(strings.TrimPrefix(command, "cd "))
+		if to == "" {
 			return "", fmt.Errorf("no directory specified for cd")
 		}
-		if err := os.Chdir(dir); err != nil {
+		changed, err := changeDir(*dir, to)
+		if err != nil {
 			return "", err
 		}
+		*dir = changed
 		return "", nil
 	}
 
-	// Windows
-	if runtime.GOOS == "windows" {
-		shell = "cmd"
-		// #nosec G204 - This is a build tool that executes user-defined commands by design
-		cmd = exec.Command(shell, "/C", command)
-	} else {
-		// Linux && MacOsX
-		shell = "/bin/bash"
-		// #nosec G204 - This is a build tool that executes user-defined commands by design
-		cmd = exec.Command(shell, "-c", command)
+	cmd := shellCommand(shell, command)
+	cmd.Dir = *dir
+	if limits.offline {
+		cutNetwork(cmd)
 	}
-
-	out, err := cmd.CombinedOutput()
+	if stream != nil {
+		cmd.Stdout = stream
+	}
+	trace.wrap(cmd)
+	out, usage, err := runMeasured(runCtx, cmd, limits)
+	recordUsage(target, usage)
+	trace.collect()
+	stream.flush()
 	return string(out), err
 }
 
+// shellCommand prepares command to run through shell, resolved as
+// resolveShell does, with the command environment applied
+func shellCommand(shell Var, command string) *exec.Cmd {
+	args := shellArgs(resolveShell(shell))
+	// #nosec G204 - This is a build tool that executes user-defined commands by design
+	cmd := exec.Command(args[0], append(args[1:], command)...)
+
+	cmd.Env = commandEnv()
+	if jobserver != nil {
+		cmd.ExtraFiles = jobserver.extra
+	}
+	return cmd
+}
+
+// changeDir resolves a cd to the directory to relative to dir, the
+// current directory when empty, checking it exists
+func changeDir(dir, to string) (string, error) {
+	if !filepath.IsAbs(to) {
+		to = filepath.Join(dir, to)
+	}
+	info, err := os.Stat(to)
+	if err != nil {
+		return "", fmt.Errorf("cd %s: %w", to, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("cd %s: not a directory", to)
+	}
+	return filepath.Clean(to), nil
+}
+
 func ExecuteCommandWithContext(command string, verbose, dryRun bool) (string, error) {
+	dir := ""
+	return executeCommandInWithContext("", "", &dir, command, nil, nil, commandLimits{grace: defaultKillGrace}, verbose, dryRun)
+}
+
+func executeCommandInWithContext(target string, shell Var, dir *string, command string, trace *depTrace, stream *outputStream, limits commandLimits, verbose, dryRun bool) (string, error) {
 	if verbose {
-		fmt.Printf("→ %s\n", command)
+		logEvent{Level: levelDebug, Event: "command_start", Target: target, Command: command}.emit(fmt.Sprintf("→ %s\n", command))
 	}
 
 	if dryRun {
-		fmt.Printf("  [DRY RUN] Would execute: %s\n", command)
+		logEvent{Level: levelInfo, Event: "dry_run", Target: target, Command: command}.emit(fmt.Sprintf("  [DRY RUN] Would execute: %s\n", command))
 		return "", nil
 	}
 
-	return ExecuteCommand(command)
+	return executeCommandIn(target, shell, dir, command, trace, stream, limits)
 }
 
 func ExecuteAll(name string, target *Target) {
@@ -73,10 +119,91 @@ func ExecuteAll(name string, target *Target) {
 }
 
 func ExecuteAllWithContext(name string, target *Target, verbose, dryRun bool) error {
+	return executeTarget(newExpandContext(name, nil), target, verbose, dryRun)
+}
+
+// executeTarget runs a target's commands, expanding them against the
+// context captured when the target was scheduled
+func executeTarget(ectx *expandContext, target *Target, verbose, dryRun bool) error {
+	name := ectx.target
+
+	// Sandboxed commands only see the declared sources
+	dir, sandbox := target.dir, ""
+	if target.Sandbox && dryRun {
+		logInfo("dry_run", name, "  [DRY RUN] Would run in a sandbox with %d staged sources", len(expandSources(target.Sources, name)))
+	} else if target.Sandbox {
+		var err error
+		if sandbox, err = stageSandbox(name, target); err != nil {
+			return orpheus.ExecutionError(name, fmt.Sprintf("in %s -> \ncannot stage sandbox: %v", name, err))
+		}
+		defer removeSandbox(sandbox, name)
+		dir = filepath.Join(sandbox, target.dir)
+	}
+	var trace *depTrace
+	if !dryRun {
+		if trace = newDepTrace(sandbox, target.dir); trace != nil {
+			defer func() {
+				reportUndeclared(os.Stderr, name, trace.undeclaredInputs(name, target))
+				trace.close()
+			}()
+		}
+	}
+
+	// Raw output is kept in the target's log, what is shown is sanitized
+	var rawLog *targetLog
+	if !dryRun {
+		rawLog = openTargetLog(name)
+		defer rawLog.close()
+	}
+	warnedBinary := false
+	limits := targetLimits(target)
+	codePage := targetCodePage(target)
+	wrapper := ectx.Expand(string(commandWrapper(target)))
+
 	cmds := target.Run
-	for _, cmd := range cmds {
-		cmd = ParseVars(cmd, name)
-		out, err := ExecuteCommandWithContext(cmd, verbose, dryRun)
+	for i, cmd := range cmds {
+		if runCtx.Err() != nil {
+			return orpheus.ExecutionError(name, fmt.Sprintf("in %s -> \n%v", name, errInterrupted))
+		}
+		cmd = wrapCommand(wrapper, ectx.Expand(cmd))
+		if err := ectx.Err(); err != nil {
+			return orpheus.ExecutionError(name, fmt.Sprintf("in %s -> \ninvalid variable: %v", name, err))
+		}
+		var out string
+		var err error
+		// Output shows as it is printed unless a filter needs all of it
+		stream := newOutputStream(name, cmd, target)
+		if i == 0 && simulatedFailures[name] && !dryRun {
+			err = simulateFailure(name, cmd)
+		} else {
+			started := time.Now()
+			out, err = executeCommandInWithContext(name, target.Shell, &dir, cmd, trace, stream, limits, verbose, dryRun)
+			profileRecord(name, cmd, started, err)
+		}
+		rawLog.record(cmd, out)
+		out = transcodeOutput(out, codePage)
+		if !target.RawOutput {
+			var changed bool
+			if out, changed = sanitizeOutput(out); changed && !warnedBinary {
+				where := ""
+				if rawLog != nil {
+					where = "; the raw output is in " + rawLog.path
+				}
+				logWarn("Target '%s' printed invalid UTF-8 or terminal control characters, which were removed%s (raw_output: true shows it as is)", name, where)
+				warnedBinary = true
+			}
+		}
+		out = filterOutput(out, target.OutputFilter)
+
+		// Diagnostics are reported before a failure stops the target
+		if len(target.ProblemMatchers) > 0 && !dryRun {
+			problems := matchProblems(out, target.ProblemMatchers)
+			if detectCI() == ciGitHub {
+				reportProblems(os.Stdout, name, problems, true)
+			} else {
+				reportProblems(os.Stderr, name, problems, false)
+			}
+		}
 
 		// If error then (get target on_error || cmd stderr)
 		if err != nil && !dryRun {
@@ -89,16 +216,40 @@ func ExecuteAllWithContext(name string, target *Target, verbose, dryRun bool) er
 
 			if target.ContinueOnError || cfg.ContinueOnError {
 				// Log error but continue
-				fmt.Fprintf(os.Stderr, "Warning: %s\n", outerr)
+				logEvent{Level: levelWarn, Event: "command_failed", Target: name, Command: cmd, Error: err.Error()}.emit(fmt.Sprintf("Warning: %s\n", outerr))
 			} else {
 				// Return Orpheus error and stop
 				return orpheus.ExecutionError(name, outerr)
 			}
 		}
 
-		if strings.TrimSpace(out) != "" && !dryRun {
-			fmt.Print(out)
+		if strings.TrimSpace(out) != "" && !dryRun && stream == nil {
+			logEvent{Level: levelInfo, Event: "output", Target: name, Command: cmd, Output: out}.emit(out)
+		}
+	}
+
+	if sandbox != "" {
+		count, err := promoteOutputs(sandbox, name, target)
+		if err != nil {
+			return orpheus.ExecutionError(name, fmt.Sprintf("in %s -> \n%v", name, err))
+		}
+		if verbose {
+			logDebug("sandbox", name, "Promoted %d output files from the sandbox", count)
+		}
+	}
+
+	// The archive packs what the commands produced
+	if target.ReproArchive != nil {
+		output := ectx.Expand(target.ReproArchive.Output)
+		if dryRun {
+			logInfo("dry_run", name, "  [DRY RUN] Would create archive: %s", output)
+			return nil
+		}
+		count, err := createArchive(ectx, target.ReproArchive)
+		if err != nil {
+			return orpheus.ExecutionError(name, fmt.Sprintf("in %s -> \ncannot create archive %s: %v", name, output, err))
 		}
+		logInfo("archive", name, "%s Archived %d files to %s", checkMark(), count, output)
 	}
 	return nil
 }
@@ -111,10 +262,10 @@ func (t *Target) RunDepsWithContext(verbose, dryRun bool) error {
 	deps := t.Deps
 	for _, dep := range deps {
 		// if dep is file
-		if strings.Contains(dep, ".") {
+		if isFileDep(dep) {
 			// TODO: Handle file dependencies
 			if verbose {
-				fmt.Printf("Checking file dependency: %s\n", dep)
+				logDebug("file_dep", "", "Checking file dependency: %s", dep)
 			}
 		} else {
 			if err := runTargetWithContext(dep, verbose, dryRun); err != nil {
@@ -129,22 +280,33 @@ func (c *Config) RunPrologue() {
 	_ = c.RunPrologueWithContext(false, false)
 }
 
+// RunPrologueWithContext runs the prologue, then those of the workspace
+// members
 func (c *Config) RunPrologueWithContext(verbose, dryRun bool) error {
-	if err := c.Prologue.RunDepsWithContext(verbose, dryRun); err != nil {
-		return err
-	}
-	return ExecuteAllWithContext("prologue", &c.Prologue, verbose, dryRun)
+	return runStages(c.activePrologues(), verbose, dryRun)
 }
 
 func (c *Config) RunEpilogue() {
 	_ = c.RunEpilogueWithContext(false, false)
 }
 
+// RunEpilogueWithContext runs the epilogues of the workspace members, then
+// the epilogue
 func (c *Config) RunEpilogueWithContext(verbose, dryRun bool) error {
-	if err := c.Epilogue.RunDepsWithContext(verbose, dryRun); err != nil {
-		return err
+	return runStages(c.activeEpilogues(), verbose, dryRun)
+}
+
+// runStages runs prologues or epilogues in order, each after its deps
+func runStages(stages []stage, verbose, dryRun bool) error {
+	for _, stage := range stages {
+		if err := stage.target.RunDepsWithContext(verbose, dryRun); err != nil {
+			return err
+		}
+		if err := ExecuteAllWithContext(stage.name, &stage.target, verbose, dryRun); err != nil {
+			return err
+		}
 	}
-	return ExecuteAllWithContext("epilogue", &c.Epilogue, verbose, dryRun)
+	return nil
 }
 
 func RunTarget(name string) {
@@ -152,17 +314,99 @@ func RunTarget(name string) {
 }
 
 func runTargetWithContext(name string, verbose, dryRun bool) error {
+	return runTargetWithParams(name, nil, verbose, dryRun)
+}
+
+// runTargetWithParams runs a target with extra variables, such as
+// $CHANGED_FILE in watch --single-file, visible to its own commands
+func runTargetWithParams(name string, params map[string]string, verbose, dryRun bool) error {
 	target := GetTarget(name)
 
 	if err := target.RunDepsWithContext(verbose, dryRun); err != nil {
 		return err
 	}
+	return runTargetOnly(name, params, verbose, dryRun)
+}
 
-	if target.Run == nil && target.Deps == nil {
+// runTargetOnly runs a target without its deps, which already ran
+func runTargetOnly(name string, params map[string]string, verbose, dryRun bool) error {
+	target := GetTarget(name)
+	if target.Run == nil && target.Deps == nil && target.ReproArchive == nil {
 		return orpheus.NotFoundError(name, fmt.Sprintf("target '%s' not found", name))
 	}
 
-	return ExecuteAllWithContext(name, &target, verbose, dryRun)
+	unlock := lockTargetMutex(&target)
+	err := runTimed(newExpandContext(name, params), &target, verbose, dryRun)
+	unlock()
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		targetSucceeded(name, &target)
+	}
+	return nil
+}
+
+// runTimed executes a target, retrying it up to its retries setting, and
+// records the run in the history
+func runTimed(ectx *expandContext, target *Target, verbose, dryRun bool) error {
+	if ciFold && !dryRun {
+		defer ciGroup(os.Stdout, detectCI(), ectx.target)()
+	}
+	defer groupOutput(ectx.target)()
+	defer ectx.removeTempDir()
+
+	if reason := skipReason(ectx.target, target); reason != "" {
+		logInfo("target_skipped", ectx.target, "Skipping %s: %s", ectx.target, reason)
+		if !dryRun {
+			recordResult(targetResult{Name: ectx.target, Skipped: reason})
+		}
+		return nil
+	}
+
+	logEvent{Level: levelInfo, Event: "target_start", Target: ectx.target}.emit("")
+	takeUsage(ectx.target) // usage of earlier untimed runs is not this run's
+	start := time.Now()
+	attempts := 0
+	var err error
+	for {
+		attempts++
+		err = executeTarget(ectx, target, verbose, dryRun)
+		if err == nil || dryRun || attempts > target.Retries || runCtx.Err() != nil {
+			break
+		}
+		logWarn("target '%s' failed (attempt %d of %d), retrying: %v", ectx.target, attempts, target.Retries+1, err)
+	}
+	finished := logEvent{Level: levelInfo, Event: "target_finish", Target: ectx.target, DurationMS: time.Since(start).Milliseconds(), Attempts: attempts}
+	if err != nil {
+		finished.Level, finished.Error = levelError, err.Error()
+	}
+	finished.emit("")
+	if !dryRun {
+		took, usage := time.Since(start), takeUsage(ectx.target)
+		profileRecord(ectx.target, "", start, err)
+		targetFinished(ectx.target, target, took, usage, attempts, err)
+		recordResult(targetResult{Name: ectx.target, Duration: took, Usage: usage, Attempts: attempts, Err: err})
+		if err != nil && detectCI() == ciGitHub {
+			annotate(os.Stdout, "error", ectx.target, err.Error())
+		}
+	}
+	return err
+}
+
+// targetSucceeded applies output_mode to a finished target and records it
+// for `aura status` and the post-build manifest
+func targetSucceeded(name string, target *Target) {
+	if skipReason(name, target) != "" {
+		return
+	}
+	if err := normalizeOutputs(name, target); err != nil {
+		logWarn("cannot apply output_mode for %s: %v", name, err)
+	}
+	if err := recordBuild(name, target); err != nil {
+		logWarn("cannot record build state for %s: %v", name, err)
+	}
+	markCompleted(name)
 }
 
 // Context-aware wrapper functions
@@ -180,6 +424,8 @@ func listTargets(format string) error {
 		return listTargetsJSON()
 	case "yaml":
 		return listTargetsYAML()
+	case "names":
+		return listTargetNames()
 	default: // table
 		return listTargetsTable()
 	}
@@ -203,13 +449,20 @@ func listTargetsTable() error {
 	}
 
 	// Print targets
-	for name, target := range cfg.Targets {
+	for _, name := range sortedKeys(cfg.Targets) {
+		target := cfg.Targets[name]
 		padding := strings.Repeat(" ", maxNameLen-len(name)+2)
 		deps := ""
 		if len(target.Deps) > 0 {
 			deps = fmt.Sprintf(" (depends: %s)", strings.Join(target.Deps, ", "))
 		}
-		fmt.Printf("  %s%s%d commands%s\n", name, padding, len(target.Run), deps)
+		if target.Dangerous {
+			deps += " " + paint(os.Stdout, colorRed, "[dangerous]")
+		}
+		fmt.Printf("  %s%s%d commands%s\n", paint(os.Stdout, colorCyan, name), padding, len(target.Run), deps)
+		if target.Desc != "" {
+			fmt.Printf("  %s  %s\n", strings.Repeat(" ", maxNameLen), target.Desc)
+		}
 	}
 
 	fmt.Printf("\nTotal: %d targets\n", len(cfg.Targets))
@@ -218,17 +471,22 @@ func listTargetsTable() error {
 
 func listTargetsJSON() error {
 	type TargetInfo struct {
-		Name     string   `json:"name"`
-		Commands int      `json:"commands"`
-		Deps     []string `json:"dependencies,omitempty"`
+		Name        string   `json:"name"`
+		Description string   `json:"description,omitempty"`
+		Commands    int      `json:"commands"`
+		Deps        []string `json:"dependencies,omitempty"`
+		Dangerous   bool     `json:"dangerous,omitempty"`
 	}
 
 	var targets []TargetInfo
-	for name, target := range cfg.Targets {
+	for _, name := range sortedKeys(cfg.Targets) {
+		target := cfg.Targets[name]
 		targets = append(targets, TargetInfo{
-			Name:     name,
-			Commands: len(target.Run),
-			Deps:     target.Deps,
+			Name:        name,
+			Description: target.Desc,
+			Commands:    len(target.Run),
+			Deps:        target.Deps,
+			Dangerous:   target.Dangerous,
 		})
 	}
 
@@ -242,17 +500,22 @@ func listTargetsJSON() error {
 
 func listTargetsYAML() error {
 	type TargetInfo struct {
-		Name     string   `yaml:"name"`
-		Commands int      `yaml:"commands"`
-		Deps     []string `yaml:"dependencies,omitempty"`
+		Name        string   `yaml:"name"`
+		Description string   `yaml:"description,omitempty"`
+		Commands    int      `yaml:"commands"`
+		Deps        []string `yaml:"dependencies,omitempty"`
+		Dangerous   bool     `yaml:"dangerous,omitempty"`
 	}
 
 	var targets []TargetInfo
-	for name, target := range cfg.Targets {
+	for _, name := range sortedKeys(cfg.Targets) {
+		target := cfg.Targets[name]
 		targets = append(targets, TargetInfo{
-			Name:     name,
-			Commands: len(target.Run),
-			Deps:     target.Deps,
+			Name:        name,
+			Description: target.Desc,
+			Commands:    len(target.Run),
+			Deps:        target.Deps,
+			Dangerous:   target.Dangerous,
 		})
 	}
 
@@ -263,3 +526,11 @@ func listTargetsYAML() error {
 		"total":   len(targets),
 	})
 }
+
+// listTargetNames prints "name<TAB>description" lines for shell completion
+func listTargetNames() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		fmt.Printf("%s\t%s\n", name, cfg.Targets[name].Desc)
+	}
+	return nil
+}
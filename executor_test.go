@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -214,6 +216,43 @@ func TestExecuteAllWithContextCancellation(t *testing.T) {
 	}
 }
 
+func TestCdStaysInTarget(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"sub/deeper/keep": ""})
+	wd, _ := os.Getwd()
+
+	cfg = Config{Targets: map[string]Target{
+		"moves": {Run: []string{"cd sub", "cd deeper", "touch moved"}},
+		"stays": {Run: []string{"touch stayed"}},
+	}}
+	if err := runTargetsParallel([]string{"moves", "stays"}, 2, false, false); err != nil {
+		t.Fatalf("runTargetsParallel() error = %v", err)
+	}
+	if now, _ := os.Getwd(); now != wd {
+		t.Errorf("cd changed aura's directory to %s", now)
+	}
+	for _, file := range []string{filepath.Join("sub", "deeper", "moved"), "stayed"} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("expected %s: %v", file, err)
+		}
+	}
+
+	dir := ""
+	for _, bad := range []string{"cd missing", "cd sub/deeper/keep"} {
+		if _, err := executeCommandIn("", "", &dir, bad, nil, nil, commandLimits{}); err == nil {
+			t.Errorf("%q should fail", bad)
+		}
+	}
+	if dir != "" {
+		t.Errorf("a failed cd changed the directory to %q", dir)
+	}
+}
+
 func TestShellCommandGeneration(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// $var, $prefix.var (a var of a prefixed include) or ${var} or $@
+var varRefRegex = regexp.MustCompile(`\$\w+(?:\.\w+)?|\$\{[^}]+\}|\$@`)
+
+// expandContext holds everything a target's commands can expand: variables,
+// environment, working directory and parameters. The layers are captured
+// when the target is scheduled, so targets running concurrently never
+// observe each other's overrides or a changed cwd; the values of variables
+// are resolved against them on first use and kept for the context's life.
+type expandContext struct {
+	target  string
+	params  map[string]string
+	layers  []varLayer // lowest precedence first
+	env     map[string]string
+	cwd     string
+	ports   map[string]string // $FREE_PORT values handed out so far
+	tempDir string            // $TMPDIR_TARGET, once created
+
+	resolved  map[varKey]string // variables whose values were expanded
+	resolving []varKey          // variables being expanded, innermost last
+	checking  bool              // checkVars: no warnings, ports or temp dirs
+	symbolic  bool              // $(command)s stay as written, not run
+	deferred  bool              // a $(command) was left unrun in the value
+	err       error             // the first variable that could not be resolved
+}
+
+// varLayer is one level of variables, such as the config's vars or the
+// command line overrides, the section errors name it by and the shell its
+// $(command)s run through. The values of a verbatim layer are used as they
+// are
+type varLayer struct {
+	section  string
+	vars     map[string]string
+	shell    Var
+	verbatim bool
+}
+
+// varKey is the definition of a variable in one layer
+type varKey struct {
+	name  string
+	layer int
+}
+
+// newExpandContext captures the expansion state for a target. params are
+// values aura hands the commands, such as $AURA_MANIFEST, and rank just
+// below command line overrides; they are used as they are.
+func newExpandContext(targetName string, params map[string]string) *expandContext {
+	layer := func(section string, vars map[string]Var) varLayer {
+		values := make(map[string]string, len(vars))
+		for name, val := range vars {
+			values[name] = string(val)
+		}
+		return varLayer{section: section, vars: values}
+	}
+
+	// The config layers, lowest precedence first
+	layers := []varLayer{layer("vars", cfg.Vars)}
+	if profile, ok := cfg.Profiles[activeProfile]; ok {
+		layers = append(layers, layer("profiles."+activeProfile+".vars", profile.Vars))
+	}
+	target, section := cfg.Targets[targetName], "targets."+targetName
+	if stage, ok := cfg.stageNamed(targetName); ok {
+		target, section = stage, targetName
+	}
+	if namespace := target.namespace; namespace != "" {
+		flat := map[string]string{}
+		for _, l := range layers {
+			maps.Copy(flat, l.vars)
+		}
+		layers = append(layers, varLayer{section: "vars." + namespace, vars: namespaceVars(flat, namespace)})
+	}
+	own := layer(section+".vars", target.Vars)
+	own.shell = target.Shell
+	layers = append(layers,
+		own,
+		varLayer{section: section + ".params", vars: maps.Clone(targetArgs[targetName])},
+		varLayer{section: "params", vars: maps.Clone(params), verbatim: true},
+		varLayer{section: "overrides", vars: maps.Clone(overrideVars)},
+	)
+
+	// Targets of workspace members run in the member's directory
+	cwd, _ := os.Getwd()
+	cwd = filepath.Join(cwd, target.dir)
+	return &expandContext{
+		target: targetName,
+		params: maps.Clone(params),
+		layers: layers,
+		env:    buildEnv.Values(),
+		cwd:    cwd,
+
+		resolved: map[varKey]string{},
+	}
+}
+
+// Lookup resolves a variable with the same precedence as LookupVar, but
+// against the captured layers
+func (c *expandContext) Lookup(name string) (string, bool) {
+	val, ok, err := c.lookupBelow(name, len(c.layers))
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+	return val, ok
+}
+
+// Err returns the first variable of the context that could not be
+// resolved, such as one whose $(command) failed; its value was used as it
+// is written
+func (c *expandContext) Err() error {
+	return c.err
+}
+
+// lookupBelow resolves a variable from the layers under top, then from the
+// platform, the environment and the builtins
+func (c *expandContext) lookupBelow(name string, top int) (string, bool, error) {
+	// ${env:NAME} bypasses the build snapshot and reads the live value
+	if envName, fresh := strings.CutPrefix(name, "env:"); fresh {
+		val, ok := os.LookupEnv(envName)
+		return val, ok, nil
+	}
+
+	for layer := top - 1; layer >= 0; layer-- {
+		if _, ok := c.layers[layer].vars[name]; ok {
+			val, err := c.resolveVar(varKey{name, layer})
+			return val, true, err
+		}
+	}
+	if val, ok := platformVar(name); ok {
+		return val, true, nil
+	}
+	if val, ok := c.env[name]; ok {
+		return val, true, nil
+	}
+	if name == "cwd" {
+		return c.cwd, true, nil
+	}
+	if c.checking && (isFreePortVar(name) || name == tmpdirVar) {
+		return "", false, nil
+	}
+	if port, ok := c.lookupFreePort(name); ok {
+		return port, true, nil
+	}
+	if dir, ok := c.lookupTempDir(name); ok {
+		return dir, true, nil
+	}
+	val, ok := builtinVar(name, c.target)
+	return val, ok, nil
+}
+
+// resolveVar expands the references and evaluates the expressions in the
+// value of a variable, such as $BASE_FLAGS in `CFLAGS: "$BASE_FLAGS -O2"`
+// or `JOBS: "${NPROC - 1}"`, against the same context, so they see the
+// same layers as the commands do. Its $(command)s run first, when the value
+// is first needed, so only the vars a target uses run theirs. A variable
+// referring to itself gets its value from the layer below, down to the
+// environment, as in `PATH: "$PATH:/opt/bin"`; only a chain of variables
+// leading back to one being expanded is a cycle, whose value is then used
+// as it is
+func (c *expandContext) resolveVar(key varKey) (string, error) {
+	layer := c.layers[key.layer]
+	val := layer.vars[key.name]
+	if layer.verbatim || !strings.Contains(val, "$") {
+		return val, nil
+	}
+	if resolved, ok := c.resolved[key]; ok {
+		return resolved, nil
+	}
+	if i := slices.Index(c.resolving, key); i >= 0 {
+		var chain []string
+		for _, k := range c.resolving[i:] {
+			chain = append(chain, k.name)
+		}
+		return val, fmt.Errorf("variable %s refers to itself: %s -> %s", key.name, strings.Join(chain, " -> "), key.name)
+	}
+
+	c.resolving = append(c.resolving, key)
+	defer func() { c.resolving = c.resolving[:len(c.resolving)-1] }()
+
+	// Expressions over the output of commands left unrun cannot be checked
+	outer := c.deferred
+	c.deferred = false
+	defer func() { c.deferred = c.deferred || outer }()
+
+	// Commands left unrun are held out of the expansion, which would
+	// otherwise read their shell variables as aura's
+	var unrun []string
+	value, err := substituteValue(val, func(command string) (string, error) {
+		if !c.symbolic {
+			return commandOutput(command, layer.shell)
+		}
+		c.deferred = true
+		unrun = append(unrun, "$("+command+")")
+		return fmt.Sprintf("\x00%d\x00", len(unrun)-1), nil
+	})
+	if err != nil {
+		return val, fmt.Errorf("%s.%s: %v", layer.section, key.name, err)
+	}
+
+	var lookupErr error
+	lookup := func(ref string) (string, bool) {
+		// Vars of a prefixed include refer to their siblings first
+		if i := strings.LastIndex(key.name, "."); i > 0 && ref != key.name && c.defines(key.name[:i]+"."+ref) {
+			ref = key.name[:i] + "." + ref
+		}
+		top := len(c.layers)
+		if ref == key.name {
+			top = key.layer
+		}
+		val, ok, err := c.lookupBelow(ref, top)
+		if err != nil && lookupErr == nil {
+			lookupErr = err
+		}
+		return val, ok
+	}
+
+	computed, err := evalComputed(value, lookup)
+	if err != nil && !c.deferred {
+		return val, fmt.Errorf("%s.%s: %v", layer.section, key.name, err)
+	} else if err != nil {
+		computed = value
+	}
+	resolved := c.expandWith(computed, lookup)
+	if lookupErr != nil {
+		return val, lookupErr
+	}
+	for i, command := range unrun {
+		resolved = strings.Replace(resolved, fmt.Sprintf("\x00%d\x00", i), command, 1)
+	}
+	c.resolved[key] = resolved
+	return resolved, nil
+}
+
+// defines reports whether a layer of the context defines a variable
+func (c *expandContext) defines(name string) bool {
+	return slices.ContainsFunc(c.layers, func(l varLayer) bool {
+		_, ok := l.vars[name]
+		return ok
+	})
+}
+
+// checkVars resolves every variable a target can see, reporting cycles and
+// expressions that cannot be evaluated at load rather than when a command
+// expands them. Targets see their own vars, args and the vars of their
+// include on top of the config's and the profile's, so those with any are
+// checked on their own
+func checkVars() error {
+	scopes := []string{""}
+	for _, stage := range slices.Concat(cfg.activePrologues(), cfg.activeEpilogues()) {
+		if len(stage.target.Vars) > 0 || stage.target.namespace != "" {
+			scopes = append(scopes, stage.name)
+		}
+	}
+	for _, name := range sortedKeys(cfg.Targets) {
+		target := cfg.Targets[name]
+		if len(target.Vars) > 0 || target.namespace != "" || len(targetArgs[name]) > 0 {
+			scopes = append(scopes, name)
+		}
+	}
+
+	for _, scope := range scopes {
+		ectx := newExpandContext(scope, nil)
+		ectx.checking, ectx.symbolic = true, true
+		for layer, l := range ectx.layers {
+			for _, name := range sortedKeys(l.vars) {
+				if _, err := ectx.resolveVar(varKey{name, layer}); err != nil {
+					if scope != "" {
+						return fmt.Errorf("%v (in %s)", err, scope)
+					}
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Expand substitutes every variable reference in text, warning about
+// undefined ones and leaving them in place
+func (c *expandContext) Expand(text string) string {
+	return c.expandWith(text, c.Lookup)
+}
+
+// expandWith substitutes the references in text through lookup
+func (c *expandContext) expandWith(text string, lookup func(string) (string, bool)) string {
+	return varRefRegex.ReplaceAllStringFunc(text, func(m string) string {
+		varname := strings.Trim(strings.TrimPrefix(m, "$"), "{}")
+
+		// $NAME.txt is $NAME followed by ".txt" unless NAME.txt is a var
+		suffix := ""
+		if head, rest, dotted := strings.Cut(varname, "."); dotted && !strings.HasPrefix(m, "${") {
+			if _, defined := lookup(varname); !defined {
+				varname, suffix, m = head, "."+rest, "$"+head
+			}
+		}
+
+		// Defined-but-empty variables substitute silently
+		val, defined := lookup(varname)
+		if !defined {
+			if !c.checking {
+				fmt.Fprintf(os.Stderr, "[warn] undefined variable %s in target %s\n", m, c.target)
+			}
+			return m + suffix
+		}
+		return val + suffix
+	})
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// ===== EXPAND.GO UNIT TESTS =====
+
+func TestExpandContextPrecedence(t *testing.T) {
+	original, originalOverrides, originalProfile := cfg, overrideVars, activeProfile
+	defer func() { cfg, overrideVars, activeProfile = original, originalOverrides, originalProfile }()
+
+	cfg = Config{
+		Vars:     map[string]Var{"A": "config", "B": "config", "C": "config", "D": "config", "E": "config"},
+		Profiles: map[string]Profile{"ci": {Vars: map[string]Var{"B": "profile", "C": "profile", "D": "profile", "E": "profile"}}},
+		Targets:  map[string]Target{"build": {Vars: map[string]Var{"C": "target", "D": "target", "E": "target"}}},
+	}
+	activeProfile = "ci"
+	overrideVars = map[string]string{"E": "override"}
+
+	ectx := newExpandContext("build", map[string]string{"D": "param", "E": "param"})
+	expected := map[string]string{"A": "config", "B": "profile", "C": "target", "D": "param", "E": "override", "@": "build"}
+	for name, want := range expected {
+		if got, ok := ectx.Lookup(name); !ok || got != want {
+			t.Errorf("Lookup(%q) = %q, %t; want %q", name, got, ok, want)
+		}
+	}
+}
+
+func TestExpandContextIsSnapshot(t *testing.T) {
+	original, originalOverrides := cfg, overrideVars
+	defer func() { cfg, overrideVars = original, originalOverrides }()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	defer func() { _ = os.Chdir(originalWd) }()
+
+	cfg = Config{Vars: map[string]Var{"OUT": "bin/app"}}
+	overrideVars = map[string]string{}
+	params := map[string]string{"VERSION": "1.0"}
+
+	ectx := newExpandContext("build", params)
+
+	// Mutations after capture must not leak into the context
+	cfg.Vars["OUT"] = "changed"
+	overrideVars["OUT"] = "override"
+	params["VERSION"] = "2.0"
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	got := ectx.Expand("$OUT $VERSION ${cwd}")
+	expected := "bin/app 1.0 " + originalWd
+	if got != expected {
+		t.Errorf("Expand() = %q, want %q", got, expected)
+	}
+}
+
+func TestExpandContextExpand(t *testing.T) {
+	original, originalOverrides := cfg, overrideVars
+	defer func() { cfg, overrideVars = original, originalOverrides }()
+
+	cfg = Config{Vars: map[string]Var{"A": "x", "AB": "y", "DOLLAR": "$A", "EMPTY": ""}}
+	overrideVars = map[string]string{}
+	ectx := newExpandContext("", nil)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"$AB $A", "y x"},
+		{"${A}B", "xB"},
+		{"[$EMPTY]", "[]"},
+		{"$DOLLAR", "x"}, // values are expanded in turn
+		{"$AURA_TEST_UNDEFINED_VAR", "$AURA_TEST_UNDEFINED_VAR"},
+	}
+	for _, tt := range tests {
+		if got := ectx.Expand(tt.input); got != tt.expected {
+			t.Errorf("Expand(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestExpandNestedVars(t *testing.T) {
+	original, originalOverrides, originalProfile := cfg, overrideVars, activeProfile
+	defer func() { cfg, overrideVars, activeProfile = original, originalOverrides, originalProfile }()
+
+	cfg = Config{
+		Vars: map[string]Var{
+			"BASE_FLAGS": "-Wall",
+			"CFLAGS":     "$BASE_FLAGS -O2",
+			"CC_CMD":     "cc ${CFLAGS} -o $OUT/$@",
+			"OUT":        "dist",
+			"SHELL_VAR":  "$AURA_TEST_UNDEFINED_VAR",
+		},
+		Profiles: map[string]Profile{"strict": {Vars: map[string]Var{"BASE_FLAGS": "-Wall -Werror"}}},
+		Targets:  map[string]Target{"app": {Vars: map[string]Var{"OUT": "build/$MODE"}}},
+	}
+	activeProfile = "strict"
+	overrideVars = map[string]string{"MODE": "release"}
+
+	// References resolve against the target's layers, as commands do
+	ectx := newExpandContext("app", nil)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"$CFLAGS", "-Wall -Werror -O2"},
+		{"$CC_CMD", "cc -Wall -Werror -O2 -o build/release/app"},
+		{"$SHELL_VAR", "$AURA_TEST_UNDEFINED_VAR"},
+	}
+	for _, tt := range tests {
+		if got := ectx.Expand(tt.input); got != tt.expected {
+			t.Errorf("Expand(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestExpandSelfReference(t *testing.T) {
+	original, originalOverrides, originalProfile := cfg, overrideVars, activeProfile
+	defer func() { cfg, overrideVars, activeProfile = original, originalOverrides, originalProfile }()
+	t.Setenv("AURA_TEST_PATH", "/usr/bin")
+	buildEnv.Snapshot(0)
+	defer buildEnv.Snapshot(0)
+
+	cfg = Config{
+		Vars:     map[string]Var{"AURA_TEST_PATH": "$AURA_TEST_PATH:/opt/extra", "FLAGS": "-O2", "OUT": "$OUT.txt"},
+		Profiles: map[string]Profile{"debug": {Vars: map[string]Var{"FLAGS": "$FLAGS -g"}}},
+		Targets: map[string]Target{"app": {Vars: map[string]Var{
+			"AURA_TEST_PATH": "${AURA_TEST_PATH}:/opt/app",
+			"FLAGS":          "$FLAGS -Wall",
+		}}},
+	}
+	activeProfile = "debug"
+	overrideVars = map[string]string{}
+
+	// Each layer extends the one below it, the config's the environment
+	ectx := newExpandContext("app", nil)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"$AURA_TEST_PATH", "/usr/bin:/opt/extra:/opt/app"},
+		{"$FLAGS", "-O2 -g -Wall"},
+		{"$OUT", "$OUT.txt"},
+	}
+	for _, tt := range tests {
+		if got := ectx.Expand(tt.input); got != tt.expected {
+			t.Errorf("Expand(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+	if err := checkVars(); err != nil {
+		t.Errorf("checkVars() error = %v", err)
+	}
+}
+
+func TestCheckVars(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"chain", Config{Vars: map[string]Var{"A": "$B", "B": "${C}.txt", "C": "c"}}, ""},
+		{"self reference", Config{Vars: map[string]Var{"PATH": "$PATH:/opt/extra", "A": "$A.txt"}}, ""},
+		{"cycle", Config{Vars: map[string]Var{"A": "$B", "B": "x $C", "C": "$A"}}, "variable A refers to itself: A -> B -> C -> A"},
+		{"cycle through itself", Config{Vars: map[string]Var{"A": "$B $A", "B": "$A"}}, "variable A refers to itself: A -> B -> A"},
+		{"through a target", Config{
+			Vars:    map[string]Var{"A": "$B"},
+			Targets: map[string]Target{"lib": {Vars: map[string]Var{"B": "$A"}}},
+		}, "variable A refers to itself: A -> B -> A (in lib)"},
+		{"expression", Config{Vars: map[string]Var{"JOBS": "${NPROC -}"}}, "vars.JOBS: ${NPROC -}: "},
+	}
+
+	for _, tt := range tests {
+		cfg = tt.config
+		err := checkVars()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: checkVars() error = %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: checkVars() error = %v, expected %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// explainTarget prints what building a target runs: its deps and itself in
+// build order, each with its cache status, directory, shell and commands
+// expanded as they would run, then the environment the commands get
+func explainTarget(w io.Writer, name string, state buildState) error {
+	order := queryOrder(reachable(querySet{name: true}, -1, targetEdges))
+	wd, _ := os.Getwd()
+
+	if len(order) == 1 {
+		fmt.Fprintf(w, "Building %s runs 1 target\n", name)
+	} else {
+		fmt.Fprintf(w, "Building %s runs %d targets in this order: %s\n", name, len(order), strings.Join(order, " → "))
+	}
+	for i, step := range order {
+		target := cfg.Targets[step]
+		status, changes, err := targetStatus(step, &target, state)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			status = fmt.Sprintf("%s, %d changed", status, len(changes))
+		}
+		fmt.Fprintf(w, "\n%d. %s (%s)\n", i+1, step, status)
+		for _, change := range changes {
+			fmt.Fprintf(w, "     %s\n", change)
+		}
+		if reason := skipReason(step, &target); reason != "" {
+			fmt.Fprintf(w, "   skipped here: %s\n", reason)
+			continue
+		}
+
+		dir := filepath.Join(wd, target.dir)
+		if target.Sandbox {
+			dir = "a sandbox holding its sources, " + filepath.ToSlash(target.dir) + " inside it"
+		}
+		fmt.Fprintf(w, "   directory: %s\n", dir)
+		fmt.Fprintf(w, "   shell:     %s\n", strings.Join(shellArgs(resolveShell(target.Shell)), " "))
+		if target.Timeout != "" {
+			fmt.Fprintf(w, "   timeout:   %s\n", target.Timeout)
+		}
+		if target.Network == networkNone {
+			fmt.Fprintln(w, "   network:   none")
+		}
+		if target.Retries > 0 {
+			fmt.Fprintf(w, "   retries:   %d\n", target.Retries)
+		}
+		if target.Dangerous {
+			fmt.Fprintln(w, "   dangerous: asks for confirmation first")
+		}
+		ectx := planContext(step, target)
+		wrapper := ectx.Expand(string(commandWrapper(&target)))
+		for _, cmd := range target.Run {
+			fmt.Fprintf(w, "   $ %s\n", wrapCommand(wrapper, ectx.Expand(cmd)))
+		}
+		if len(target.Run) == 0 {
+			fmt.Fprintln(w, "   (no commands)")
+		}
+	}
+
+	fmt.Fprintln(w)
+	dirs := append(slices.Clone(cfg.ToolPaths), toolDirs()...)
+	if len(dirs) == 0 {
+		fmt.Fprintln(w, "Environment: aura's own")
+		return nil
+	}
+	fmt.Fprintln(w, "Environment: aura's own, with PATH starting with:")
+	for _, dir := range dirs {
+		fmt.Fprintf(w, "  %s\n", dir)
+	}
+	return nil
+}
+
+// explainCommand shows what `aura build -t <target>` would run
+func explainCommand(ctx *orpheus.Context) error {
+	args := positionalArgs(ctx)
+	if len(args) != 1 {
+		return orpheus.ValidationError("explain", "usage: aura explain <target>")
+	}
+	if err := planSetup(ctx); err != nil {
+		return err
+	}
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+	name := args[0]
+	if _, ok := cfg.Targets[name]; !ok {
+		return orpheus.NotFoundError(name, fmt.Sprintf("target '%s' not found", name))
+	}
+
+	state, err := loadBuildState()
+	if err != nil {
+		return orpheus.ExecutionError("explain", err.Error())
+	}
+	if err := explainTarget(os.Stdout, name, state); err != nil {
+		return orpheus.ExecutionError(name, err.Error())
+	}
+	return nil
+}
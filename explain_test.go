@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== EXPLAIN.GO UNIT TESTS =====
+
+func TestExplainTarget(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("expects POSIX shells")
+	}
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"gen.yaml": "v: 1"})
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{
+		CommandWrapper: "nice",
+		Vars:           map[string]Var{"OUT": "dist"},
+		Targets: map[string]Target{
+			"gen":  {Sources: []string{"gen.yaml"}, Run: []string{"gen > $OUT/gen.go"}},
+			"lib":  {Deps: []string{"gen"}, Shell: "sh", Timeout: "5m", Run: []string{"cd lib", "make"}},
+			"app":  {Deps: []string{"lib"}, Dangerous: true, CommandWrapper: "none", Run: []string{"make app"}},
+			"port": {Deps: []string{"app"}, Platforms: stringList{"plan9"}, Run: []string{"mk"}},
+			"docs": {Run: []string{"mkdocs build"}},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := explainTarget(&out, "port", buildState{}); err != nil {
+		t.Fatal(err)
+	}
+	explained := out.String()
+	for _, want := range []string{
+		"Building port runs 4 targets in this order: gen → lib → app → port\n",
+		"1. gen (never built)\n",
+		"   $ nice gen > dist/gen.go\n",
+		"2. lib (no sources)\n",
+		"   shell:     sh -c\n   timeout:   5m\n   $ cd lib\n   $ nice make\n",
+		"   dangerous: asks for confirmation first\n   $ make app\n",
+		"4. port (no sources)\n   skipped here: runs on plan9\n",
+		"Environment: aura's own\n",
+	} {
+		if !strings.Contains(explained, want) {
+			t.Errorf("explanation lacks %q:\n%s", want, explained)
+		}
+	}
+	if strings.Contains(explained, "docs") {
+		t.Errorf("explanation lists a target the build does not run:\n%s", explained)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// vscodeTaskPrefix marks the tasks aura owns in tasks.json; others are kept
+const vscodeTaskPrefix = "aura: "
+
+// vscodeProblemMatcher turns "file:line:col: message" output, the format of
+// most compilers and linters, into clickable problems
+var vscodeProblemMatcher = map[string]any{
+	"owner":        "aura",
+	"fileLocation": []string{"autoDetect", "${workspaceFolder}"},
+	"pattern": map[string]any{
+		"regexp":   `^(.+?):(\d+):(\d+):\s+(?:(error|warning|note):\s+)?(.*)$`,
+		"file":     1,
+		"line":     2,
+		"column":   3,
+		"severity": 4,
+		"message":  5,
+	},
+}
+
+// vscodeTasks builds a task per target that runs it through aura, with
+// extra global arguments such as -c or -P
+func vscodeTasks(globalArgs []string) []map[string]any {
+	var tasks []map[string]any
+	for _, name := range sortedKeys(cfg.Targets) {
+		target := cfg.Targets[name]
+		task := map[string]any{
+			"label":          vscodeTaskPrefix + name,
+			"type":           "shell",
+			"command":        "aura",
+			"args":           append(append([]string{}, globalArgs...), "build", "-t", name),
+			"problemMatcher": vscodeProblemMatcher,
+		}
+		if target.Desc != "" {
+			task["detail"] = target.Desc
+		}
+		switch {
+		case name == "build":
+			task["group"] = map[string]any{"kind": "build", "isDefault": true}
+		case name == "test":
+			task["group"] = map[string]any{"kind": "test", "isDefault": true}
+		case strings.Contains(name, "test"):
+			task["group"] = "test"
+		case strings.Contains(name, "build"):
+			task["group"] = "build"
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks
+}
+
+// mergeVSCodeTasks replaces the aura tasks in an existing tasks.json and
+// keeps everything else
+func mergeVSCodeTasks(existing []byte, tasks []map[string]any) ([]byte, error) {
+	doc := map[string]any{"version": "2.0.0"}
+	if len(existing) > 0 {
+		if err := json.Unmarshal(existing, &doc); err != nil {
+			return nil, fmt.Errorf("cannot parse existing tasks file (comments are not supported): %v", err)
+		}
+	}
+
+	var merged []any
+	if current, ok := doc["tasks"].([]any); ok {
+		for _, task := range current {
+			if t, ok := task.(map[string]any); ok {
+				if label, _ := t["label"].(string); strings.HasPrefix(label, vscodeTaskPrefix) {
+					continue
+				}
+			}
+			merged = append(merged, task)
+		}
+	}
+	for _, task := range tasks {
+		merged = append(merged, task)
+	}
+	doc["tasks"] = merged
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// exportCommand shows the available export formats
+func exportCommand(ctx *orpheus.Context) error {
+	fmt.Println("Export targets for other tools")
+	fmt.Println("Use 'aura export <subcommand>':")
+	fmt.Println("  vscode-tasks  - Write .vscode/tasks.json with a task per target")
+	return nil
+}
+
+// exportVSCodeTasksCommand writes a VS Code task for every target
+func exportVSCodeTasksCommand(ctx *orpheus.Context) error {
+	workDir := ctx.GetGlobalFlagString("directory")
+	configFile := ctx.GetGlobalFlagString("config")
+	output := ctx.GetFlagString("output")
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	if err := loadConfig(configFile); err != nil {
+		return err
+	}
+
+	// Tasks run from the workspace folder, so only non-default flags matter
+	var globalArgs []string
+	if configFile != "aura.yaml" {
+		globalArgs = append(globalArgs, "-c", configFile)
+	}
+	if profile := ctx.GetGlobalFlagString("profile"); profile != "" {
+		globalArgs = append(globalArgs, "-P", profile)
+	}
+
+	// #nosec G304 - Output path is chosen by the user
+	existing, err := os.ReadFile(output)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return orpheus.ExecutionError("export", err.Error())
+	}
+	data, err := mergeVSCodeTasks(existing, vscodeTasks(globalArgs))
+	if err != nil {
+		return orpheus.ValidationError("output", err.Error())
+	}
+
+	if err := os.MkdirAll(filepath.Dir(output), 0750); err != nil {
+		return orpheus.ExecutionError("export", err.Error())
+	}
+	if err := os.WriteFile(output, data, 0600); err != nil {
+		return orpheus.ExecutionError("export", err.Error())
+	}
+	fmt.Printf("%s Wrote %d tasks to %s\n", checkMark(), len(cfg.Targets), output)
+	return nil
+}
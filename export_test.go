@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// ===== EXPORT.GO UNIT TESTS =====
+
+func TestVSCodeTasks(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{Targets: map[string]Target{
+		"build":     {Desc: "Build the app", Run: []string{"go build"}},
+		"test-unit": {Run: []string{"go test"}},
+		"lint":      {Run: []string{"golangci-lint run"}},
+	}}
+
+	tasks := vscodeTasks([]string{"-P", "ci"})
+	if len(tasks) != 3 {
+		t.Fatalf("vscodeTasks() returned %d tasks, expected 3", len(tasks))
+	}
+
+	build := tasks[0]
+	if build["label"] != "aura: build" || build["detail"] != "Build the app" {
+		t.Errorf("build task = %+v", build)
+	}
+	if args := build["args"].([]string); !slices.Equal(args, []string{"-P", "ci", "build", "-t", "build"}) {
+		t.Errorf("build task args = %q", args)
+	}
+	if group, ok := build["group"].(map[string]any); !ok || group["isDefault"] != true {
+		t.Errorf("build should be the default build task, group = %v", build["group"])
+	}
+	if _, grouped := tasks[1]["group"]; grouped {
+		t.Errorf("lint task should not be grouped, got %v", tasks[1]["group"])
+	}
+	if tasks[2]["group"] != "test" {
+		t.Errorf("test-unit task group = %v, expected test", tasks[2]["group"])
+	}
+}
+
+func TestVSCodeProblemMatcher(t *testing.T) {
+	pattern := vscodeProblemMatcher["pattern"].(map[string]any)
+	re := regexp.MustCompile(pattern["regexp"].(string))
+
+	m := re.FindStringSubmatch("src/main.c:12:5: warning: unused variable 'x'")
+	if m == nil || m[1] != "src/main.c" || m[2] != "12" || m[3] != "5" || m[4] != "warning" || m[5] != "unused variable 'x'" {
+		t.Errorf("gcc line matched as %q", m)
+	}
+	m = re.FindStringSubmatch("./main.go:3:2: undefined: foo")
+	if m == nil || m[1] != "./main.go" || m[5] != "undefined: foo" {
+		t.Errorf("go line matched as %q", m)
+	}
+}
+
+func TestMergeVSCodeTasks(t *testing.T) {
+	existing := []byte(`{
+  "version": "2.0.0",
+  "tasks": [
+    {"label": "docker up", "type": "shell", "command": "docker compose up"},
+    {"label": "aura: removed-target", "type": "shell", "command": "aura"}
+  ],
+  "inputs": []
+}`)
+	tasks := []map[string]any{{"label": "aura: build"}}
+
+	data, err := mergeVSCodeTasks(existing, tasks)
+	if err != nil {
+		t.Fatalf("mergeVSCodeTasks() error: %v", err)
+	}
+
+	var doc struct {
+		Version string           `json:"version"`
+		Tasks   []map[string]any `json:"tasks"`
+		Inputs  []any            `json:"inputs"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("merged file is not JSON: %v", err)
+	}
+	var labels []string
+	for _, task := range doc.Tasks {
+		labels = append(labels, task["label"].(string))
+	}
+	if !slices.Equal(labels, []string{"docker up", "aura: build"}) {
+		t.Errorf("merged labels = %q", labels)
+	}
+	if doc.Version != "2.0.0" || doc.Inputs == nil {
+		t.Errorf("other fields should be kept: %s", data)
+	}
+
+	if _, err := mergeVSCodeTasks([]byte("// comment\n{}"), tasks); err == nil {
+		t.Error("mergeVSCodeTasks() should refuse files it cannot parse")
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ${...} occurrences inside variable definitions
+var exprRegex = regexp.MustCompile(`\$\{[^}]+\}`)
+
+// plain ${NAME} or ${env:NAME} reference, as opposed to an expression
+var identRegex = regexp.MustCompile(`^(env:)?\w+(\.\w+)?$`)
+
+// evalComputed evaluates the ${...} expressions in the value of a
+// variable, so `JOBS: "${NPROC - 1}"` or `TAG: "${"v" + MAJOR}"` end up
+// as plain values. Plain ${NAME} references are left for Expand
+func evalComputed(value string, lookup func(string) (string, bool)) (string, error) {
+	var evalErr error
+	value = exprRegex.ReplaceAllStringFunc(value, func(m string) string {
+		body := strings.TrimSpace(m[2 : len(m)-1])
+		if evalErr != nil || identRegex.MatchString(body) {
+			return m
+		}
+		out, err := evalExpr(body, lookup)
+		if err != nil {
+			evalErr = err
+		}
+		return out
+	})
+	return value, evalErr
+}
+
+// evalExpr evaluates an arithmetic/string expression such as `NPROC - 1`
+// or `"v" + MAJOR + "." + (MINOR + 1)`. Identifiers are resolved through
+// lookup; values that parse as integers take part in arithmetic, anything
+// else is a string and only supports concatenation with +.
+func evalExpr(expr string, lookup func(string) (string, bool)) (string, error) {
+	p := &exprParser{src: expr, lookup: lookup}
+	p.next()
+	v, err := p.parseSum()
+	if err == nil {
+		err = p.err
+	}
+	if err != nil {
+		return "", fmt.Errorf("${%s}: %v", expr, err)
+	}
+	if p.tok.kind != tokEOF {
+		return "", fmt.Errorf("${%s}: unexpected %q at position %d", expr, p.tok.text, p.tok.pos+1)
+	}
+	return v.String(), nil
+}
+
+type exprValue struct {
+	isInt  bool
+	i      int64
+	s      string
+	quoted bool // string literals never take part in arithmetic
+}
+
+func (v exprValue) String() string {
+	if v.isInt {
+		return strconv.FormatInt(v.i, 10)
+	}
+	return v.s
+}
+
+func (v exprValue) asInt() (int64, bool) {
+	if v.isInt {
+		return v.i, true
+	}
+	if v.quoted {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(v.s), 10, 64)
+	return n, err == nil
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNum
+	tokStr
+	tokIdent
+	tokOp
+)
+
+type exprToken struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+type exprParser struct {
+	src    string
+	pos    int
+	tok    exprToken
+	lookup func(string) (string, bool)
+	err    error
+}
+
+func (p *exprParser) next() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = exprToken{kind: tokEOF, pos: start}
+		return
+	}
+
+	c := rune(p.src[p.pos])
+	switch {
+	case unicode.IsDigit(c):
+		for p.pos < len(p.src) && unicode.IsDigit(rune(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = exprToken{kind: tokNum, text: p.src[start:p.pos], pos: start}
+	case c == '_' || unicode.IsLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		p.tok = exprToken{kind: tokIdent, text: p.src[start:p.pos], pos: start}
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(p.src[p.pos+1:], byte(c))
+		if end < 0 {
+			p.err = fmt.Errorf("unterminated string at position %d", start+1)
+			p.tok = exprToken{kind: tokEOF, pos: start}
+			return
+		}
+		p.pos += end + 2
+		p.tok = exprToken{kind: tokStr, text: p.src[start+1 : p.pos-1], pos: start}
+	case strings.ContainsRune("+-*/%()", c):
+		p.pos++
+		p.tok = exprToken{kind: tokOp, text: string(c), pos: start}
+	default:
+		p.err = fmt.Errorf("unexpected character %q at position %d", c, start+1)
+		p.tok = exprToken{kind: tokEOF, pos: start}
+	}
+}
+
+// sum := product (('+' | '-') product)*
+func (p *exprParser) parseSum() (exprValue, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return left, err
+	}
+	for p.tok.kind == tokOp && (p.tok.text == "+" || p.tok.text == "-") {
+		op := p.tok.text
+		p.next()
+		right, err := p.parseProduct()
+		if err != nil {
+			return right, err
+		}
+		if left, err = applyOp(op, left, right); err != nil {
+			return left, err
+		}
+	}
+	return left, nil
+}
+
+// product := unary (('*' | '/' | '%') unary)*
+func (p *exprParser) parseProduct() (exprValue, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return left, err
+	}
+	for p.tok.kind == tokOp && strings.Contains("*/%", p.tok.text) {
+		op := p.tok.text
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return right, err
+		}
+		if left, err = applyOp(op, left, right); err != nil {
+			return left, err
+		}
+	}
+	return left, nil
+}
+
+// unary := '-' unary | primary
+func (p *exprParser) parseUnary() (exprValue, error) {
+	if p.tok.kind == tokOp && p.tok.text == "-" {
+		p.next()
+		v, err := p.parseUnary()
+		if err != nil {
+			return v, err
+		}
+		n, ok := v.asInt()
+		if !ok {
+			return v, fmt.Errorf("cannot negate non-numeric value %q", v.s)
+		}
+		return exprValue{isInt: true, i: -n}, nil
+	}
+	return p.parsePrimary()
+}
+
+// primary := number | string | identifier | '(' sum ')'
+func (p *exprParser) parsePrimary() (exprValue, error) {
+	if p.err != nil {
+		return exprValue{}, p.err
+	}
+
+	tok := p.tok
+	switch tok.kind {
+	case tokNum:
+		p.next()
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return exprValue{}, fmt.Errorf("invalid number %q", tok.text)
+		}
+		return exprValue{isInt: true, i: n}, nil
+	case tokStr:
+		p.next()
+		return exprValue{s: tok.text, quoted: true}, nil
+	case tokIdent:
+		p.next()
+		val, ok := p.lookup(tok.text)
+		if !ok {
+			return exprValue{}, fmt.Errorf("undefined variable %s", tok.text)
+		}
+		return exprValue{s: val}, nil
+	case tokOp:
+		if tok.text == "(" {
+			p.next()
+			v, err := p.parseSum()
+			if err != nil {
+				return v, err
+			}
+			if p.tok.kind != tokOp || p.tok.text != ")" {
+				return v, fmt.Errorf("missing ')' at position %d", p.tok.pos+1)
+			}
+			p.next()
+			return v, nil
+		}
+		return exprValue{}, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos+1)
+	default:
+		return exprValue{}, fmt.Errorf("unexpected end of expression")
+	}
+}
+
+func applyOp(op string, left, right exprValue) (exprValue, error) {
+	l, lok := left.asInt()
+	r, rok := right.asInt()
+
+	if !lok || !rok {
+		if op == "+" {
+			return exprValue{s: left.String() + right.String()}, nil
+		}
+		bad := left
+		if lok {
+			bad = right
+		}
+		return exprValue{}, fmt.Errorf("operator %s needs numbers, got %q", op, bad.String())
+	}
+
+	switch op {
+	case "+":
+		return exprValue{isInt: true, i: l + r}, nil
+	case "-":
+		return exprValue{isInt: true, i: l - r}, nil
+	case "*":
+		return exprValue{isInt: true, i: l * r}, nil
+	case "/", "%":
+		if r == 0 {
+			return exprValue{}, fmt.Errorf("division by zero")
+		}
+		if op == "/" {
+			return exprValue{isInt: true, i: l / r}, nil
+		}
+		return exprValue{isInt: true, i: l % r}, nil
+	}
+	return exprValue{}, fmt.Errorf("unknown operator %s", op)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ===== EXPR.GO UNIT TESTS =====
+
+func TestEvalExpr(t *testing.T) {
+	vars := map[string]string{
+		"NPROC":   "8",
+		"MAJOR":   "1",
+		"MINOR":   "4",
+		"NAME":    "app",
+		"PADDED":  " 3 ",
+		"VERSION": "1.4.0",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected string
+	}{
+		{name: "Subtraction", expr: "NPROC - 1", expected: "7"},
+		{name: "Precedence", expr: "2 + 3 * 4", expected: "14"},
+		{name: "Parentheses", expr: "(2 + 3) * 4", expected: "20"},
+		{name: "Division and modulo", expr: "NPROC / 3 + NPROC % 3", expected: "4"},
+		{name: "Unary minus", expr: "-MAJOR + 5", expected: "4"},
+		{name: "Numeric string operand", expr: "PADDED * 2", expected: "6"},
+		{name: "String concatenation", expr: "NAME + \"-\" + VERSION", expected: "app-1.4.0"},
+		{name: "Mixed concatenation", expr: "'v' + MAJOR + '.' + (MINOR + 1)", expected: "v1.5"},
+		{name: "Quoted digits stay strings", expr: "'1' + 2", expected: "12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := evalExpr(tt.expr, lookup)
+			if err != nil {
+				t.Fatalf("evalExpr(%q) unexpected error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("evalExpr(%q) = %q, want %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvalExprErrors(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "NAME" {
+			return "app", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		errPart string
+	}{
+		{name: "Undefined variable", expr: "MISSING - 1", errPart: "undefined variable MISSING"},
+		{name: "Division by zero", expr: "4 / 0", errPart: "division by zero"},
+		{name: "Arithmetic on string", expr: "NAME * 2", errPart: "needs numbers"},
+		{name: "Missing paren", expr: "(1 + 2", errPart: "missing ')'"},
+		{name: "Trailing token", expr: "1 2", errPart: "unexpected"},
+		{name: "Bad character", expr: "1 # 2", errPart: "unexpected character"},
+		{name: "Unterminated string", expr: "'abc", errPart: "unterminated string"},
+		{name: "Dangling operator", expr: "1 +", errPart: "unexpected end"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := evalExpr(tt.expr, lookup)
+			if err == nil {
+				t.Fatalf("evalExpr(%q) expected error", tt.expr)
+			}
+			if !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("evalExpr(%q) error = %v, want it to contain %q", tt.expr, err, tt.errPart)
+			}
+		})
+	}
+}
+
+func TestComputedVars(t *testing.T) {
+	original, originalOverrides := cfg, overrideVars
+	defer func() { cfg, overrideVars = original, originalOverrides }()
+
+	cfg = Config{Vars: map[string]Var{
+		"REGISTRY": "ghcr.io/acme",
+		"NAME":     "api",
+		"VERSION":  "${MAJOR}.${MINOR}",
+		"MAJOR":    "2",
+		"MINOR":    "${MAJOR * 5}",
+		"IMAGE":    "${REGISTRY}/${NAME}:${VERSION}",
+		"JOBS":     "${NPROC - 1}",
+		"UNKNOWN":  "${NOT_DEFINED_ANYWHERE_12345}",
+	}}
+	overrideVars = map[string]string{}
+
+	if err := checkVars(); err != nil {
+		t.Fatalf("checkVars() unexpected error: %v", err)
+	}
+
+	ectx := newExpandContext("", nil)
+	expected := map[string]string{
+		"VERSION": "2.10",
+		"IMAGE":   "ghcr.io/acme/api:2.10",
+		"JOBS":    strconv.Itoa(runtime.NumCPU() - 1),
+		"UNKNOWN": "${NOT_DEFINED_ANYWHERE_12345}",
+	}
+	for name, want := range expected {
+		if got, _ := ectx.Lookup(name); got != want {
+			t.Errorf("Lookup(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestComputedVarsOverrides(t *testing.T) {
+	original, originalOverrides := cfg, overrideVars
+	defer func() { cfg, overrideVars = original, originalOverrides }()
+
+	// A command line override reaches the vars computed from it
+	cfg = Config{Vars: map[string]Var{"OUTPUT": "app", "BIN": "bin/${OUTPUT}", "SLOTS": "${PARALLEL * 2}"}}
+	overrideVars = map[string]string{"OUTPUT": "app2", "PARALLEL": "3"}
+	ectx := newExpandContext("", nil)
+	if got, _ := ectx.Lookup("BIN"); got != "bin/app2" {
+		t.Errorf("Lookup(BIN) = %q, want %q", got, "bin/app2")
+	}
+	if got, _ := ectx.Lookup("SLOTS"); got != "6" {
+		t.Errorf("Lookup(SLOTS) = %q, want %q", got, "6")
+	}
+}
+
+func TestComputedVarsErrors(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		vars    map[string]Var
+		errPart string
+	}{
+		{
+			name:    "Self reference",
+			vars:    map[string]Var{"A": "${B}", "B": "${A + 1}"},
+			errPart: "refers to itself",
+		},
+		{
+			name:    "Bad expression names variable",
+			vars:    map[string]Var{"JOBS": "${NPROC -}"},
+			errPart: "vars.JOBS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Vars: tt.vars}
+			err := checkVars()
+			if err == nil {
+				t.Fatalf("checkVars() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("checkVars() error = %v, want it to contain %q", err, tt.errPart)
+			}
+		})
+	}
+}
+
+func TestLoadConfigComputedVars(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "aura.yaml")
+
+	content := `vars:
+  BASE: "10"
+  HALF: "${BASE / 2}"
+targets:
+  build:
+    run:
+      - "echo $HALF"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg = Config{}
+	if err := loadConfig(configPath); err != nil {
+		t.Fatalf("loadConfig() unexpected error: %v", err)
+	}
+	if got, _ := LookupVar("HALF", ""); got != "5" {
+		t.Errorf("HALF = %q, want %q", got, "5")
+	}
+
+	bad := strings.Replace(content, "${BASE / 2}", "${BASE / 0}", 1)
+	if err := os.WriteFile(configPath, []byte(bad), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg = Config{}
+	err := loadConfig(configPath)
+	if err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Errorf("loadConfig() error = %v, want division by zero", err)
+	}
+}
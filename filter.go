@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OutputFilter is one step of a target's output_filter, applied to command
+// output before it is shown. Exactly one kind is set per step.
+type OutputFilter struct {
+	Replace    string `yaml:"replace"`     // regex replaced by With
+	With       string `yaml:"with"`        // replacement, may use $1
+	StripANSI  bool   `yaml:"strip_ansi"`  // drop color and cursor escapes
+	PrettyJSON bool   `yaml:"pretty_json"` // indent JSON output or JSON lines
+	Grep       string `yaml:"grep"`        // keep only matching lines
+	Exclude    string `yaml:"exclude"`     // drop matching lines
+}
+
+// ansiRegex matches CSI and OSC terminal escape sequences
+var ansiRegex = regexp.MustCompile(`\x1b(?:\[[0-9;?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\))`)
+
+// pattern returns the regex a step uses, if any
+func (f OutputFilter) pattern() string {
+	switch {
+	case f.Replace != "":
+		return f.Replace
+	case f.Grep != "":
+		return f.Grep
+	}
+	return f.Exclude
+}
+
+// validateOutputFilters checks every output_filter step has one kind and
+// a valid regex
+func validateOutputFilters() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		for i, f := range cfg.Targets[name].OutputFilter {
+			kinds := 0
+			for _, set := range []bool{f.Replace != "", f.StripANSI, f.PrettyJSON, f.Grep != "", f.Exclude != ""} {
+				if set {
+					kinds++
+				}
+			}
+			if kinds != 1 {
+				return fmt.Errorf("output_filter %d of target '%s' must set exactly one of replace, strip_ansi, pretty_json, grep or exclude", i+1, name)
+			}
+			if f.With != "" && f.Replace == "" {
+				return fmt.Errorf("output_filter %d of target '%s' has 'with' but no 'replace'", i+1, name)
+			}
+			if pattern := f.pattern(); pattern != "" {
+				if _, err := regexp.Compile(pattern); err != nil {
+					return fmt.Errorf("invalid output_filter regex '%s' for target '%s': %v", pattern, name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// filterOutput runs output through the filter steps in order
+func filterOutput(out string, filters []OutputFilter) string {
+	for _, f := range filters {
+		switch {
+		case f.StripANSI:
+			out = ansiRegex.ReplaceAllString(out, "")
+		case f.PrettyJSON:
+			out = prettyJSON(out)
+		case f.Replace != "":
+			out = regexp.MustCompile(f.Replace).ReplaceAllString(out, f.With)
+		case f.Grep != "":
+			out = filterLines(out, regexp.MustCompile(f.Grep), true)
+		case f.Exclude != "":
+			out = filterLines(out, regexp.MustCompile(f.Exclude), false)
+		}
+	}
+	return out
+}
+
+// filterLines keeps the lines that match re, or with keep false the ones
+// that do not
+func filterLines(out string, re *regexp.Regexp, keep bool) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(out, "\n") {
+		if line != "" && re.MatchString(strings.TrimRight(line, "\r\n")) == keep {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// prettyJSON indents output that is a JSON document, or failing that each
+// line that is one, leaving everything else untouched
+func prettyJSON(out string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(strings.TrimSpace(out)), "", "  "); err == nil {
+		return buf.String() + "\n"
+	}
+
+	lines := strings.SplitAfter(out, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+			continue
+		}
+		buf.Reset()
+		if json.Indent(&buf, []byte(trimmed), "", "  ") == nil {
+			lines[i] = buf.String() + "\n"
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// ===== FILTER.GO UNIT TESTS =====
+
+func TestFilterOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		filters  []OutputFilter
+		expected string
+	}{
+		{"no filters", "a\nb\n", nil, "a\nb\n"},
+		{"strip ansi", "\x1b[1;31merror\x1b[0m: bad\n\x1b]0;title\x07done\n", []OutputFilter{{StripANSI: true}}, "error: bad\ndone\n"},
+		{"replace with group", "token=abc123\n", []OutputFilter{{Replace: `token=(\w{3})\w*`, With: "token=$1..."}}, "token=abc...\n"},
+		{"grep", "ok 1\nFAIL 2\nok 3\n", []OutputFilter{{Grep: "^ok"}}, "ok 1\nok 3\n"},
+		{"exclude", "ok 1\nDEBUG x\nok 3", []OutputFilter{{Exclude: "DEBUG"}}, "ok 1\nok 3"},
+		{"pretty document", `{"a":[1,2]}` + "\n", []OutputFilter{{PrettyJSON: true}}, "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n"},
+		{"pretty json lines", "start\n{\"level\":\"info\"}\nend\n", []OutputFilter{{PrettyJSON: true}}, "start\n{\n  \"level\": \"info\"\n}\nend\n"},
+		{"steps in order", "\x1b[32mPASS\x1b[0m a\nFAIL b\n", []OutputFilter{{StripANSI: true}, {Grep: "^PASS"}}, "PASS a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filterOutput(tt.out, tt.filters); got != tt.expected {
+				t.Errorf("filterOutput() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateOutputFilters(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		filters []OutputFilter
+		errMsg  string
+	}{
+		{"valid", []OutputFilter{{StripANSI: true}, {Replace: "a+", With: "a"}, {Grep: "x"}}, ""},
+		{"empty step", []OutputFilter{{}}, "exactly one"},
+		{"two kinds", []OutputFilter{{Grep: "x", Exclude: "y"}}, "exactly one"},
+		{"with without replace", []OutputFilter{{Grep: "x", With: "y"}}, "no 'replace'"},
+		{"bad regex", []OutputFilter{{Exclude: "("}}, "invalid output_filter regex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: map[string]Target{"build": {OutputFilter: tt.filters}}}
+			err := validateOutputFilters()
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("validateOutputFilters() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("validateOutputFilters() error = %v, expected to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// targetRecord is what a successful build remembers about a target's sources
+type targetRecord struct {
+	BuiltAt time.Time         `json:"built_at"`
+	Files   map[string]string `json:"files"`
+	Tools   map[string]string `json:"tools,omitempty"`
+}
+
+// buildState maps target names to their last successful build
+type buildState map[string]targetRecord
+
+// stateFile is where build state lives inside the cache directory
+func stateFile() string {
+	return filepath.Join(buildCacheDir(), "state.json")
+}
+
+// loadBuildState reads the build state; a missing file means nothing was built yet
+func loadBuildState() (buildState, error) {
+	state := buildState{}
+	// #nosec G304 - State file lives in aura's own cache directory
+	data, err := os.ReadFile(stateFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return buildState{}, fmt.Errorf("corrupt build state %s: %v", stateFile(), err)
+	}
+	return state, nil
+}
+
+// save writes the build state back to the cache directory
+func (s buildState) save() error {
+	if err := os.MkdirAll(buildCacheDir(), 0750); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(stateFile(), data, 0600)
+}
+
+var stateMu sync.Mutex
+
+// recordBuild stores the current source fingerprint of a target after it ran
+func recordBuild(name string, target *Target) error {
+	if len(target.Sources) == 0 {
+		return nil
+	}
+	files, err := fingerprintFiles(expandSources(target.Sources, name))
+	if err != nil {
+		return err
+	}
+
+	// Targets finishing in parallel must not overwrite each other's records
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	state, err := loadBuildState()
+	if err != nil {
+		return err
+	}
+	state[name] = targetRecord{BuiltAt: time.Now(), Files: files, Tools: probeVersions(targetProbes(target))}
+	return state.save()
+}
+
+// Target staleness states reported by `aura status`
+const (
+	statusUpToDate   = "up-to-date"
+	statusStale      = "stale"
+	statusNeverBuilt = "never built"
+	statusUntracked  = "no sources"
+)
+
+// targetStatus compares a target's sources and outputs against its last build.
+// For stale targets it returns the reasons as "M file", "+ file", "- file",
+// "~ probe: old -> new" or "! output" lines.
+func targetStatus(name string, target *Target, state buildState) (string, []string, error) {
+	if len(target.Sources) == 0 {
+		return statusUntracked, nil, nil
+	}
+	record, built := state[name]
+	if !built {
+		return statusNeverBuilt, nil, nil
+	}
+
+	current, err := fingerprintFiles(expandSources(target.Sources, name))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var changes []string
+	for _, file := range sortedKeys(current) {
+		previous, existed := record.Files[file]
+		switch {
+		case !existed:
+			changes = append(changes, "+ "+file)
+		case previous != current[file]:
+			changes = append(changes, "M "+file)
+		}
+	}
+	for _, file := range sortedKeys(record.Files) {
+		if _, exists := current[file]; !exists {
+			changes = append(changes, "- "+file)
+		}
+	}
+	current = probeVersions(targetProbes(target))
+	for _, probe := range sortedKeys(current) {
+		if previous, ok := record.Tools[probe]; !ok {
+			changes = append(changes, fmt.Sprintf("~ %s: (not recorded) -> %s", probe, current[probe]))
+		} else if previous != current[probe] {
+			changes = append(changes, fmt.Sprintf("~ %s: %s -> %s", probe, previous, current[probe]))
+		}
+	}
+	for _, output := range target.Outputs {
+		output = ParseVars(output, name)
+		if len(globFiles(output)) == 0 {
+			changes = append(changes, "! "+output)
+		}
+	}
+
+	if len(changes) > 0 {
+		return statusStale, changes, nil
+	}
+	return statusUpToDate, nil, nil
+}
+
+// expandPatterns substitutes variables in each pattern and returns the sorted,
+// de-duplicated list of matching files
+func expandPatterns(patterns []string, targetName string) []string {
+	return expandPatternsIgnoring(patterns, targetName, nil)
+}
+
+// expandSources is expandPatterns for sources: files matched by
+// .auraignore are left out
+func expandSources(patterns []string, targetName string) []string {
+	return expandPatternsIgnoring(patterns, targetName, loadIgnore())
+}
+
+func expandPatternsIgnoring(patterns []string, targetName string, ignore ignoreRules) []string {
+	var files []string
+	for _, pattern := range patterns {
+		files = append(files, globFilesIgnoring(ParseVars(pattern, targetName), ignore)...)
+	}
+	slices.Sort(files)
+	return slices.Compact(files)
+}
+
+// globFiles matches a slash-separated pattern against regular files; unlike
+// filepath.Glob it understands "**" as any number of directories
+func globFiles(pattern string) []string {
+	return globFilesIgnoring(pattern, nil)
+}
+
+// globFilesIgnoring is globFiles leaving out ignored files; walks do not
+// descend into ignored directories
+func globFilesIgnoring(pattern string, ignore ignoreRules) []string {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if !strings.Contains(pattern, "**") {
+		matches, _ := filepath.Glob(filepath.FromSlash(pattern))
+		var files []string
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() && !ignore.Ignored(match, false) {
+				files = append(files, filepath.ToSlash(match))
+			}
+		}
+		return files
+	}
+
+	// Walk from the longest prefix without wildcards
+	segments := strings.Split(pattern, "/")
+	root := "."
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			if i > 0 {
+				root = strings.Join(segments[:i], "/")
+			}
+			break
+		}
+	}
+
+	var files []string
+	_ = filepath.WalkDir(filepath.FromSlash(root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != filepath.FromSlash(root) && ignore.Ignored(p, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignore.Ignored(p, false) {
+			return nil
+		}
+		p = filepath.ToSlash(p)
+		if matchSegments(segments, strings.Split(p, "/")) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files
+}
+
+// matchSegments matches path segments against pattern segments, where a "**"
+// segment matches zero or more path segments
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// hashAlgorithms are the fingerprint hashes cache.hash can select. xxhash
+// is a fast non-cryptographic hash for large trees where sha256 dominates
+// status checks; sha512 is the faster cryptographic one on 64-bit CPUs
+// without SHA extensions. BLAKE3 has no implementation in the standard
+// library and is not offered
+var hashAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"xxhash": func() hash.Hash { return newXXHash64() },
+}
+
+// parallelHashMin is the number of files from which hashing is spread
+// over all CPUs
+const parallelHashMin = 16
+
+// validateCacheHash checks cache.hash names a known algorithm
+func validateCacheHash() error {
+	if name := cfg.Cache.Hash; name != "" && hashAlgorithms[name] == nil {
+		return fmt.Errorf("unknown cache.hash '%s' (use %s)", name, strings.Join(sortedKeys(hashAlgorithms), ", "))
+	}
+	return nil
+}
+
+// probeTimeout bounds each cache.probes command
+const probeTimeout = 10 * time.Second
+
+// probeResults memoizes cache.probes output, so each probe runs once a run
+var (
+	probeMu      sync.Mutex
+	probeResults = map[string]string{}
+)
+
+// validateCacheProbes checks targets only select probes cache.probes defines
+func validateCacheProbes() error {
+	for _, name := range sortedKeys(cfg.Cache.Probes) {
+		if strings.TrimSpace(cfg.Cache.Probes[name]) == "" {
+			return fmt.Errorf("cache probe '%s' has no command", name)
+		}
+	}
+	for _, name := range sortedKeys(cfg.Targets) {
+		for _, probe := range cfg.Targets[name].Probes {
+			if _, ok := cfg.Cache.Probes[probe]; !ok {
+				return fmt.Errorf("target '%s' uses unknown cache probe '%s'", name, probe)
+			}
+		}
+	}
+	return nil
+}
+
+// targetProbes returns the probes whose output is part of a target's
+// record: the ones listed in its probes, else every cache probe
+func targetProbes(target *Target) []string {
+	if target.Probes != nil {
+		return target.Probes
+	}
+	return sortedKeys(cfg.Cache.Probes)
+}
+
+// probeVersions runs the named cache probes and returns the first line
+// each printed; a probe that fails is recorded as such, with a warning
+func probeVersions(names []string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	probeMu.Lock()
+	defer probeMu.Unlock()
+	versions := make(map[string]string, len(names))
+	for _, name := range names {
+		version, done := probeResults[name]
+		if !done {
+			version = runProbe(name, cfg.Cache.Probes[name])
+			probeResults[name] = version
+		}
+		versions[name] = version
+	}
+	return versions
+}
+
+// runProbe runs a probe command through the configured shell
+func runProbe(name, command string) string {
+	out, err := runCommand(context.Background(), shellCommand("", command), commandLimits{timeout: probeTimeout, grace: time.Second})
+	if err != nil {
+		logWarn("cache probe '%s' failed: %v", name, err)
+		return "(failed)"
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line)
+}
+
+// fingerprintFiles hashes the content of each file with the cache.hash
+// algorithm, concurrently for large file sets. Sums other than sha256 are
+// prefixed with the algorithm so a switch shows up as changed files.
+func fingerprintFiles(files []string) (map[string]string, error) {
+	algorithm := cmp.Or(cfg.Cache.Hash, "sha256")
+	newHash := hashAlgorithms[algorithm]
+	if newHash == nil {
+		return nil, fmt.Errorf("unknown hash algorithm '%s'", algorithm)
+	}
+	prefix := ""
+	if algorithm != "sha256" {
+		prefix = algorithm + ":"
+	}
+
+	sums := make([]string, len(files))
+	errs := make([]error, len(files))
+	hashAt := func(i int) {
+		sum, err := hashFileWith(files[i], newHash())
+		sums[i], errs[i] = prefix+sum, err
+	}
+
+	if workers := min(runtime.NumCPU(), len(files)); len(files) < parallelHashMin || workers < 2 {
+		for i := range files {
+			hashAt(i)
+		}
+	} else {
+		next := make(chan int)
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					hashAt(i)
+				}
+			}()
+		}
+		for i := range files {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+	}
+
+	result := make(map[string]string, len(files))
+	for i, file := range files {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		result[file] = sums[i]
+	}
+	return result, nil
+}
+
+// hashFile returns the sha256 of a file, as recorded in build manifests
+func hashFile(name string) (string, error) {
+	return hashFileWith(name, sha256.New())
+}
+
+// hashFileWith returns the hex digest of a file under h
+func hashFileWith(name string, h hash.Hash) (string, error) {
+	// #nosec G304 - Files come from the target's declared sources
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// statusCommand reports which targets are up-to-date without running anything
+func statusCommand(ctx *orpheus.Context) error {
+	workDir := ctx.GetGlobalFlagString("directory")
+	configFile := ctx.GetGlobalFlagString("config")
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+
+	// Load configuration
+	if err := loadConfigProfile(configFile, ctx.GetGlobalFlagString("profile")); err != nil {
+		return err
+	}
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+
+	state, err := loadBuildState()
+	if err != nil {
+		return orpheus.ExecutionError("status", err.Error())
+	}
+
+	fmt.Println("Target status:")
+	fmt.Println("--------------")
+
+	names := sortedKeys(cfg.Targets)
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	for _, name := range names {
+		target := cfg.Targets[name]
+		status, changes, err := targetStatus(name, &target, state)
+		if err != nil {
+			return orpheus.ExecutionError(name, err.Error())
+		}
+		padding := strings.Repeat(" ", width-len(name)+2)
+		if status == statusStale {
+			fmt.Printf("  %s%s%s (%d changed)\n", name, padding, status, len(changes))
+			for _, change := range changes {
+				fmt.Printf("  %s  %s\n", strings.Repeat(" ", width), change)
+			}
+			continue
+		}
+		fmt.Printf("  %s%s%s\n", name, padding, status)
+	}
+	return nil
+}
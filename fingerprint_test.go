@@ -0,0 +1,320 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ===== FINGERPRINT.GO UNIT TESTS =====
+
+// chdirTemp switches to a fresh project directory with its own cache
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	originalFlag := cacheDirFlag
+	t.Cleanup(func() {
+		_ = os.Chdir(originalWd)
+		cacheDirFlag = originalFlag
+	})
+
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	cacheDirFlag = filepath.Join(dir, ".cache")
+}
+
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0750); err != nil {
+			t.Fatalf("Failed to create dir for %s: %v", name, err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"src/**/*.go", "src/main.go", true},
+		{"src/**/*.go", "src/a/b/c.go", true},
+		{"src/**/*.go", "src/a/b/c.txt", false},
+		{"src/**/*.go", "other/main.go", false},
+		{"**", "any/depth/file", true},
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/main.go", false},
+		{"**/test_*.py", "test_a.py", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			got := matchSegments(strings.Split(tt.pattern, "/"), strings.Split(tt.name, "/"))
+			if got != tt.expected {
+				t.Errorf("matchSegments(%q, %q) = %t, want %t", tt.pattern, tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExpandPatterns(t *testing.T) {
+	chdirTemp(t)
+	writeFiles(t, map[string]string{
+		"main.go":        "package main",
+		"src/a.go":       "package src",
+		"src/deep/b.go":  "package deep",
+		"src/deep/c.txt": "text",
+	})
+
+	got := expandPatterns([]string{"src/**/*.go", "*.go", "./src/a.go"}, "")
+	expected := "main.go,src/a.go,src/deep/b.go"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("expandPatterns() = %v, want %s", got, expected)
+	}
+}
+
+func TestTargetStatus(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{}
+
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"src/a.go": "v1", "src/b.go": "v1"})
+
+	target := Target{Sources: []string{"src/*.go"}, Outputs: []string{"bin/app"}}
+	status := func() (string, []string) {
+		t.Helper()
+		state, err := loadBuildState()
+		if err != nil {
+			t.Fatalf("loadBuildState() unexpected error: %v", err)
+		}
+		got, changes, err := targetStatus("build", &target, state)
+		if err != nil {
+			t.Fatalf("targetStatus() unexpected error: %v", err)
+		}
+		return got, changes
+	}
+
+	if got, _ := status(); got != statusNeverBuilt {
+		t.Errorf("status before build = %q, want %q", got, statusNeverBuilt)
+	}
+
+	writeFiles(t, map[string]string{"bin/app": "binary"})
+	if err := recordBuild("build", &target); err != nil {
+		t.Fatalf("recordBuild() unexpected error: %v", err)
+	}
+	if got, changes := status(); got != statusUpToDate {
+		t.Errorf("status after build = %q (%v), want %q", got, changes, statusUpToDate)
+	}
+
+	writeFiles(t, map[string]string{"src/a.go": "v2", "src/c.go": "new"})
+	_ = os.Remove("src/b.go")
+	_ = os.Remove("bin/app")
+	got, changes := status()
+	if got != statusStale {
+		t.Fatalf("status after edits = %q, want %q", got, statusStale)
+	}
+	expected := "M src/a.go|+ src/c.go|- src/b.go|! bin/app"
+	if strings.Join(changes, "|") != expected {
+		t.Errorf("changes = %v, want %s", changes, expected)
+	}
+}
+
+func TestTargetStatusProbes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original, results := cfg, probeResults
+	defer func() { cfg, probeResults = original, results }()
+	cfg = Config{Cache: CacheConfig{Probes: map[string]string{"cc": "cat cc-version", "go": "echo go1.25"}}}
+
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"main.c": "int main;", "cc-version": "cc 13.2\nCopyright"})
+	target := Target{Sources: []string{"main.c"}}
+	status := func() (string, []string) {
+		t.Helper()
+		probeResults = map[string]string{}
+		state, err := loadBuildState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, changes, err := targetStatus("app", &target, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got, changes
+	}
+
+	probeResults = map[string]string{}
+	if err := recordBuild("app", &target); err != nil {
+		t.Fatal(err)
+	}
+	if got, changes := status(); got != statusUpToDate {
+		t.Errorf("status after build = %q (%v), want %q", got, changes, statusUpToDate)
+	}
+
+	// Upgrading the compiler makes the target stale
+	writeFiles(t, map[string]string{"cc-version": "cc 14.1\nCopyright"})
+	got, changes := status()
+	if expected := []string{"~ cc: cc 13.2 -> cc 14.1"}; got != statusStale || !reflect.DeepEqual(changes, expected) {
+		t.Errorf("status after upgrade = %q %v, want %q %v", got, changes, statusStale, expected)
+	}
+
+	// A target can depend on some of the probes only
+	target.Probes = []string{"go"}
+	if got, changes := status(); got != statusUpToDate {
+		t.Errorf("status with probes [go] = %q (%v), want %q", got, changes, statusUpToDate)
+	}
+}
+
+func TestValidateCacheProbes(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"none", Config{}, false},
+		{"known", Config{Cache: CacheConfig{Probes: map[string]string{"go": "go version"}}, Targets: map[string]Target{"a": {Probes: []string{"go"}}}}, false},
+		{"empty command", Config{Cache: CacheConfig{Probes: map[string]string{"go": " "}}}, true},
+		{"unknown", Config{Targets: map[string]Target{"a": {Probes: []string{"cc"}}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = tt.config
+			if err := validateCacheProbes(); (err != nil) != tt.wantErr {
+				t.Errorf("validateCacheProbes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTargetStatusWithoutSources(t *testing.T) {
+	got, _, err := targetStatus("lint", &Target{Run: []string{"echo"}}, buildState{})
+	if err != nil || got != statusUntracked {
+		t.Errorf("targetStatus() = %q, %v; want %q", got, err, statusUntracked)
+	}
+}
+
+func TestLoadBuildStateCorrupt(t *testing.T) {
+	chdirTemp(t)
+	writeFiles(t, map[string]string{filepath.Join(cacheDirFlag, "state.json"): "{not json"})
+
+	if _, err := loadBuildState(); err == nil {
+		t.Errorf("loadBuildState() expected error for corrupt state file")
+	}
+}
+
+func TestFingerprintFilesAlgorithms(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	files := map[string]string{}
+	var names []string
+	for i := range parallelHashMin * 2 {
+		name := filepath.ToSlash(filepath.Join("src", "f"+strconv.Itoa(i)+".txt"))
+		files[name] = strings.Repeat("x", i)
+		names = append(names, name)
+	}
+	writeFiles(t, files)
+
+	tests := []struct {
+		algorithm string
+		prefix    string
+		hexLen    int
+	}{
+		{"", "", 64},
+		{"sha256", "", 64},
+		{"sha512", "sha512:", 128},
+		{"xxhash", "xxhash:", 16},
+	}
+	for _, tt := range tests {
+		t.Run("algorithm "+tt.algorithm, func(t *testing.T) {
+			cfg.Cache.Hash = tt.algorithm
+			// Above parallelHashMin the work is spread over workers; the
+			// result must match hashing one file at a time
+			all, err := fingerprintFiles(names)
+			if err != nil {
+				t.Fatalf("fingerprintFiles() error: %v", err)
+			}
+			for _, name := range names {
+				single, err := fingerprintFiles([]string{name})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if all[name] != single[name] {
+					t.Errorf("%s: parallel sum %s, sequential %s", name, all[name], single[name])
+				}
+				sum, ok := strings.CutPrefix(all[name], tt.prefix)
+				if !ok || len(sum) != tt.hexLen {
+					t.Errorf("%s sum = %q, expected prefix %q and %d hex digits", name, all[name], tt.prefix, tt.hexLen)
+				}
+			}
+		})
+	}
+
+	if _, err := fingerprintFiles([]string{"src/missing.txt"}); err == nil {
+		t.Error("fingerprintFiles() should fail for a missing file")
+	}
+}
+
+func TestValidateCacheHash(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	for _, algorithm := range []string{"", "sha256", "sha512", "xxhash"} {
+		cfg.Cache.Hash = algorithm
+		if err := validateCacheHash(); err != nil {
+			t.Errorf("validateCacheHash(%q) unexpected error: %v", algorithm, err)
+		}
+	}
+	cfg.Cache.Hash = "md5"
+	if err := validateCacheHash(); err == nil || !strings.Contains(err.Error(), "sha256, sha512, xxhash") {
+		t.Errorf("validateCacheHash(md5) error = %v", err)
+	}
+}
+
+func BenchmarkFingerprintFiles(b *testing.B) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	dir := b.TempDir()
+	var names []string
+	data := []byte(strings.Repeat("0123456789abcdef", 4096))
+	for i := range 200 {
+		name := filepath.Join(dir, "f"+strconv.Itoa(i))
+		if err := os.WriteFile(name, data, 0600); err != nil {
+			b.Fatal(err)
+		}
+		names = append(names, name)
+	}
+
+	for _, algorithm := range sortedKeys(hashAlgorithms) {
+		b.Run(algorithm, func(b *testing.B) {
+			cfg.Cache.Hash = algorithm
+			b.SetBytes(int64(len(data) * len(names)))
+			for b.Loop() {
+				if _, err := fingerprintFiles(names); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+	"gopkg.in/yaml.v3"
+)
+
+// Canonical order of top-level keys and target keys; unknown keys keep
+// their relative order after the known ones
+var (
+	configKeyOrder = []string{"include", "members", "continue_on_error", "env_cache_ttl", "parallel", "kill_grace", "shell", "command_wrapper", "output_encoding", "cache", "toolpaths", "tools", "vars", "profiles", "prologue", "targets", "epilogue", "hooks", "notify", "watch"}
+	targetKeyOrder = []string{"desc", "platforms", "deps", "sources", "outputs", "watch", "probes", "params", "vars", "shell", "command_wrapper", "run", "onerror", "continue_on_error", "dangerous", "min_interval", "warn_after", "retries", "timeout", "kill_grace", "output_filter", "output_encoding", "raw_output", "problem_matchers", "output_mode", "repro_archive", "mutex", "sandbox", "network"}
+)
+
+// fmtCommand rewrites the configuration file in canonical form
+func fmtCommand(ctx *orpheus.Context) error {
+	workDir := ctx.GetGlobalFlagString("directory")
+	configFile := ctx.GetGlobalFlagString("config")
+	check := ctx.GetFlagBool("check")
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+
+	// #nosec G304 - User-specified config file, same as loadConfig
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return orpheus.NotFoundError("fmt", fmt.Sprintf("cannot read '%s': %v", configFile, err))
+	}
+
+	formatted, err := formatConfig(data)
+	if err != nil {
+		return orpheus.ValidationError("fmt", fmt.Sprintf("failed to parse '%s': %v", configFile, err))
+	}
+
+	if bytes.Equal(data, formatted) {
+		fmt.Printf("%s %s is already formatted\n", checkMark(), configFile)
+		return nil
+	}
+
+	if check {
+		return orpheus.ValidationError("fmt", fmt.Sprintf("%s is not formatted (run 'aura fmt')", configFile))
+	}
+
+	if err := os.WriteFile(configFile, formatted, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", configFile, err)
+	}
+	fmt.Printf("%s Formatted %s\n", checkMark(), configFile)
+	return nil
+}
+
+// formatConfig returns the canonical form of a config document: two-space
+// indentation, known keys in a fixed order, vars/targets/profiles sorted
+// alphabetically, string values double quoted and comments preserved.
+func formatConfig(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		return data, nil
+	}
+
+	if root := documentRoot(&doc); root != nil && root.Kind == yaml.MappingNode {
+		orderMapping(root, configKeyOrder)
+		for _, section := range []string{"vars", "profiles", "tools", "targets"} {
+			if node := mappingValue(root, section); node != nil && node.Kind == yaml.MappingNode {
+				sortMapping(node)
+			}
+		}
+		for _, section := range []string{"prologue", "epilogue"} {
+			if node := mappingValue(root, section); node != nil && node.Kind == yaml.MappingNode {
+				orderMapping(node, targetKeyOrder)
+			}
+		}
+		if targets := mappingValue(root, "targets"); targets != nil && targets.Kind == yaml.MappingNode {
+			for i := 1; i < len(targets.Content); i += 2 {
+				if targets.Content[i].Kind == yaml.MappingNode {
+					orderMapping(targets.Content[i], targetKeyOrder)
+				}
+			}
+		}
+	}
+	normalizeQuoting(&doc, false)
+
+	return encodeConfigNode(&doc)
+}
+
+// encodeConfigNode writes a config document with two-space indentation and
+// blank lines between sections
+func encodeConfigNode(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return separateSections(buf.Bytes()), nil
+}
+
+// documentRoot returns the top-level node of a parsed document
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+	return nil
+}
+
+// mappingValue returns the value node stored under key, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// orderMapping reorders key/value pairs following order; keys not listed
+// keep their relative order at the end
+func orderMapping(mapping *yaml.Node, order []string) {
+	rank := func(key string) int {
+		if i := slices.Index(order, key); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+	reorderPairs(mapping, func(a, b string) bool { return rank(a) < rank(b) })
+}
+
+// sortMapping orders key/value pairs alphabetically
+func sortMapping(mapping *yaml.Node) {
+	reorderPairs(mapping, func(a, b string) bool { return a < b })
+}
+
+func reorderPairs(mapping *yaml.Node, less func(a, b string) bool) {
+	type pair struct{ key, value *yaml.Node }
+	pairs := make([]pair, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		pairs = append(pairs, pair{mapping.Content[i], mapping.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return less(pairs[i].key.Value, pairs[j].key.Value) })
+
+	mapping.Content = mapping.Content[:0]
+	for _, p := range pairs {
+		mapping.Content = append(mapping.Content, p.key, p.value)
+	}
+}
+
+// normalizeQuoting leaves keys plain and double quotes single-line string
+// values; booleans, numbers and block scalars keep their style
+func normalizeQuoting(node *yaml.Node, isKey bool) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			normalizeQuoting(child, false)
+		}
+	case yaml.MappingNode:
+		for i, child := range node.Content {
+			normalizeQuoting(child, i%2 == 0)
+		}
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" || node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			return
+		}
+		if isKey {
+			node.Style = 0
+			return
+		}
+		if !strings.Contains(node.Value, "\n") {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	}
+}
+
+// separateSections puts a blank line before every top-level key and every
+// target, keeping head comments attached to the entry that follows them
+func separateSections(out []byte) []byte {
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	blankBefore := make(map[int]bool)
+
+	inTargets := false
+	seenTopLevel, seenTarget := false, false
+	for i, line := range lines {
+		isComment := strings.HasPrefix(strings.TrimSpace(line), "#")
+		switch {
+		case line == "" || isComment:
+			continue
+		case !strings.HasPrefix(line, " "):
+			if seenTopLevel {
+				blankBefore[commentStart(lines, i, "")] = true
+			}
+			seenTopLevel = true
+			inTargets = strings.HasPrefix(line, "targets:")
+			seenTarget = false
+		case inTargets && strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "   "):
+			if seenTarget {
+				blankBefore[commentStart(lines, i, "  ")] = true
+			}
+			seenTarget = true
+		}
+	}
+
+	var sb strings.Builder
+	for i, line := range lines {
+		if blankBefore[i] && i > 0 && lines[i-1] != "" {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return []byte(sb.String())
+}
+
+// commentStart walks back over comment lines at the given indentation that
+// belong to the entry on line i
+func commentStart(lines []string, i int, indent string) int {
+	for i > 0 && strings.HasPrefix(lines[i-1], indent+"#") {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// ===== FMT.GO UNIT TESTS =====
+
+func TestFormatConfig(t *testing.T) {
+	input := `targets:
+  # run the tests
+  test:
+    run: ['go test ./...']
+    desc: Run tests
+  build:
+    run:
+      - go build   # compile
+    deps:
+      - gen
+vars:
+  Z: 'z'
+  A: a
+  N: 3
+continue_on_error: true
+`
+	expected := `continue_on_error: true
+
+vars:
+  A: "a"
+  N: 3
+  Z: "z"
+
+targets:
+  build:
+    deps:
+      - "gen"
+    run:
+      - "go build" # compile
+
+  # run the tests
+  test:
+    desc: "Run tests"
+    run: ["go test ./..."]
+`
+
+	out, err := formatConfig([]byte(input))
+	if err != nil {
+		t.Fatalf("formatConfig() unexpected error: %v", err)
+	}
+	if string(out) != expected {
+		t.Errorf("formatConfig() =\n%s\nwant\n%s", out, expected)
+	}
+
+	again, err := formatConfig(out)
+	if err != nil {
+		t.Fatalf("formatConfig() second pass unexpected error: %v", err)
+	}
+	if string(again) != string(out) {
+		t.Errorf("formatConfig() is not idempotent:\n%s", again)
+	}
+}
+
+func TestFormatConfigKeyOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "shell",
+			input: `targets:
+  build:
+    run: ["echo hi"]
+    shell: bash
+    desc: Build
+shell: sh
+parallel: 2
+`,
+			expected: `parallel: 2
+
+shell: "sh"
+
+targets:
+  build:
+    desc: "Build"
+    shell: "bash"
+    run: ["echo hi"]
+`,
+		},
+		{
+			name: "output_encoding",
+			input: `targets:
+  build:
+    output_encoding: cp1252
+    raw_output: true
+    output_filter: "^warning"
+    run: ["echo hi"]
+output_encoding: cp850
+shell: cmd
+`,
+			expected: `shell: "cmd"
+
+output_encoding: "cp850"
+
+targets:
+  build:
+    run: ["echo hi"]
+    output_filter: "^warning"
+    output_encoding: "cp1252"
+    raw_output: true
+`,
+		},
+		{
+			name: "params",
+			input: `targets:
+  deploy:
+    run: ["deploy $ENV"]
+    vars:
+      ENV: staging
+    params: [ENV]
+`,
+			expected: `targets:
+  deploy:
+    params: ["ENV"]
+    vars:
+      ENV: "staging"
+    run: ["deploy $ENV"]
+`,
+		},
+		{
+			name: "platforms",
+			input: `targets:
+  installer:
+    deps: [build]
+    platforms: [windows/amd64, darwin]
+    desc: Package the installer
+`,
+			expected: `targets:
+  installer:
+    desc: "Package the installer"
+    platforms: ["windows/amd64", "darwin"]
+    deps: ["build"]
+`,
+		},
+		{
+			name: "command_wrapper",
+			input: `targets:
+  build:
+    run: ["cc -c main.c"]
+    command_wrapper: ccache
+    shell: sh
+command_wrapper: nice -n 10
+shell: bash
+`,
+			expected: `shell: "bash"
+
+command_wrapper: "nice -n 10"
+
+targets:
+  build:
+    shell: "sh"
+    command_wrapper: "ccache"
+    run: ["cc -c main.c"]
+`,
+		},
+		{
+			name: "network",
+			input: `targets:
+  test:
+    network: none
+    sandbox: true
+    run: ["go test ./..."]
+`,
+			expected: `targets:
+  test:
+    run: ["go test ./..."]
+    sandbox: true
+    network: "none"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := formatConfig([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("formatConfig() unexpected error: %v", err)
+			}
+			if string(out) != tt.expected {
+				t.Errorf("formatConfig() =\n%s\nwant\n%s", out, tt.expected)
+			}
+			if again, _ := formatConfig(out); string(again) != string(out) {
+				t.Errorf("formatConfig() is not idempotent:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestFormatConfigKeepsBlockScalars(t *testing.T) {
+	input := "prologue:\n  run:\n    - |\n      echo one\n      echo two\n"
+
+	out, err := formatConfig([]byte(input))
+	if err != nil {
+		t.Fatalf("formatConfig() unexpected error: %v", err)
+	}
+	if !strings.Contains(string(out), "- |\n      echo one\n      echo two") {
+		t.Errorf("formatConfig() changed block scalar:\n%s", out)
+	}
+}
+
+func TestFormatConfigErrors(t *testing.T) {
+	if _, err := formatConfig([]byte("targets: [[[")); err == nil {
+		t.Errorf("formatConfig() expected error for invalid YAML")
+	}
+
+	out, err := formatConfig([]byte(""))
+	if err != nil || len(out) != 0 {
+		t.Errorf("formatConfig(empty) = %q, %v", out, err)
+	}
+}
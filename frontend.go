@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// configFrontend returns how a configuration written in another language
+// than YAML evaluates to the YAML document aura loads, nil for YAML. A
+// .go file is a program built with aura/pkg/dsl, a .cue file is CUE
+func configFrontend(path string) func(string) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		return readGoConfig
+	case ".cue":
+		return readCueConfig
+	}
+	return nil
+}
+
+// readGoConfig runs a Go configuration program from its directory and
+// returns the configuration it prints, a YAML document like any other.
+// The program is compiled by go run, so the Go build cache keeps repeated
+// loads fast
+func readGoConfig(path string) ([]byte, error) {
+	return evaluateConfig(path, "go", "the Go toolchain", "run", filepath.Base(path))
+}
+
+// readCueConfig evaluates a CUE configuration with the cue command. CUE
+// checks it against the definitions it declares (a #Target schema, say)
+// and unrolls its comprehensions before aura sees it
+func readCueConfig(path string) ([]byte, error) {
+	return evaluateConfig(path, "cue", "the cue command (cuelang.org)", "export", "--out", "yaml", filepath.Base(path))
+}
+
+// evaluateConfig runs tool with args from the directory of the
+// configuration and returns what it prints
+func evaluateConfig(path, tool, needs string, args ...string) ([]byte, error) {
+	program, err := exec.LookPath(tool)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s needs %s: %v", filepath.Base(path), needs, err)
+	}
+	// #nosec G204 - the configuration is the user's own
+	cmd := exec.Command(program, args...)
+	cmd.Dir = filepath.Dir(path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v: %s", filepath.Base(path), err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
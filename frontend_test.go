@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== FRONTEND.GO UNIT TESTS =====
+
+func TestConfigFrontend(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"aura.go", true},
+		{"build/Aura.GO", true},
+		{"aura.cue", true},
+		{"aura.yaml", false},
+		{"go", false},
+	}
+
+	for _, tt := range tests {
+		if got := configFrontend(tt.path) != nil; got != tt.expected {
+			t.Errorf("configFrontend(%q) != nil is %v, expected %v", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestLoadGoConfig(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("needs the Go toolchain")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+
+	// A program without imports needs no module
+	chdirTemp(t)
+	writeFiles(t, map[string]string{
+		"aura.go": `//go:build ignore
+
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Print("targets:\n  hello:\n    run: [\"echo hi\"]\n")
+}
+`,
+		"broken.go": "package main\n",
+	})
+	if err := loadConfig("aura.go"); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if target, ok := cfg.Targets["hello"]; !ok || len(target.Run) != 1 {
+		t.Errorf("cfg.Targets = %v, expected the printed target", cfg.Targets)
+	}
+
+	err := loadConfig("broken.go")
+	if err == nil || !strings.Contains(err.Error(), "broken.go") {
+		t.Errorf("loadConfig() error = %v, expected the failing program", err)
+	}
+}
+
+func TestLoadGoConfigImportsDSL(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("needs the Go toolchain")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	repo, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A project of its own imports the DSL by the module path; the
+	// dependencies come from the module cache, without the network
+	chdirTemp(t)
+	t.Setenv("GOFLAGS", "-mod=mod")
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOSUMDB", "off")
+	writeFiles(t, map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.25\n\nrequire github.com/agilira/aura v0.0.0\n\nreplace github.com/agilira/aura => " + filepath.ToSlash(repo) + "\n",
+		"aura.go": `//go:build ignore
+
+package main
+
+import "github.com/agilira/aura/pkg/dsl"
+
+func main() {
+	dsl.Main(dsl.Config{Targets: map[string]dsl.Target{"hello": {Run: []string{"echo hi"}}}})
+}
+`,
+	})
+	if err := loadConfig("aura.go"); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if _, ok := cfg.Targets["hello"]; !ok {
+		t.Errorf("cfg.Targets = %v, expected the target built with the DSL", cfg.Targets)
+	}
+}
+
+func TestLoadCueConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell script as cue")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+
+	// A stand-in cue prints the evaluated YAML, or fails like cue vet
+	chdirTemp(t)
+	writeFiles(t, map[string]string{
+		"bin/cue": `#!/bin/sh
+[ "$1 $2 $3" = "export --out yaml" ] || exit 2
+if [ "$4" = "bad.cue" ]; then echo "targets.build.run: conflicting values" >&2; exit 1; fi
+printf 'targets:\n  build-api:\n    run: ["go build ./api"]\n'
+`,
+		"aura.cue": "targets: {}\n",
+		"bad.cue":  "targets: {}\n",
+	})
+	if err := os.Chmod("bin/cue", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	t.Setenv("PATH", filepath.Join(wd, "bin")+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if err := loadConfig("aura.cue"); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if _, ok := cfg.Targets["build-api"]; !ok {
+		t.Errorf("cfg.Targets = %v, expected the exported target", cfg.Targets)
+	}
+	if err := loadConfig("bad.cue"); err == nil || !strings.Contains(err.Error(), "conflicting values") {
+		t.Errorf("loadConfig() error = %v, expected cue's message", err)
+	}
+}
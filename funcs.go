@@ -1,31 +1,130 @@
 package main
 
 import (
+	"crypto/rand"
+	"fmt"
 	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-// Get a variable else -> environment variable -> ""
+// Variables given on the command line, highest precedence
+var overrideVars = map[string]string{}
+
+// Profile selected with --profile, "" for none
+var activeProfile string
+
+// Directory of the loaded configuration file, $CONFIG_DIR
+var configDir string
+
+// buildID identifies this invocation of aura, $BUILD_ID
+var buildID = sync.OnceValue(newBuildID)
+
+// gitHead is the commit and branch of the project's checkout, read once
+var gitHead = sync.OnceValues(readGitHead)
+
+// Get a variable by name, "" when undefined. See LookupVar for precedence.
 func GetVar(name string, target_name string) string {
+	val, _ := LookupVar(name, target_name)
+	return val
+}
 
-	name = strings.Trim(name, "$")
+// LookupVar resolves a variable name (without the leading "$") through the
+// precedence chain, highest first:
+//
+//	CLI overrides > target params > target vars > profile vars > config vars > $OS/$ARCH > environment > builtins
+//
+// The boolean reports whether any layer defines the variable. It looks the
+// name up as the target's commands would, through a fresh expandContext.
+func LookupVar(name string, targetName string) (string, bool) {
+	return newExpandContext(targetName, nil).Lookup(name)
+}
+
+// builtinVar resolves the variables aura provides itself
+func builtinVar(name string, targetName string) (string, bool) {
 	switch name {
 	case "TIMESTAMP":
-		return time.Now().Format("2006-01-02 15:04:05")
+		return time.Now().Format("2006-01-02 15:04:05"), true
 	case "@":
-		return target_name
+		return targetName, true
 	case "cwd":
 		path, _ := os.Getwd()
-		return path
-	default:
-		ret, exists := cfg.Vars[name]
-		if exists {
-			return string(ret)
+		return path, true
+	case "NPROC":
+		return strconv.Itoa(runtime.NumCPU()), true
+	case "ARGS", "CLI_ARGS":
+		return quoteArgs(passthroughArgs), true
+	case "CLI_ARGS_COUNT":
+		return strconv.Itoa(len(passthroughArgs)), true
+	case "HOME":
+		home, err := os.UserHomeDir()
+		return home, err == nil
+	case "CONFIG_DIR":
+		return configDir, configDir != ""
+	case "BUILD_ID":
+		return buildID(), true
+	case "GIT_SHA", "GIT_BRANCH":
+		head, err := gitHead()
+		if err != nil {
+			return "", false
+		}
+		if name == "GIT_SHA" {
+			return head.sha, true
 		}
-		return os.Getenv(name)
+		return head.branch, true
+	}
+	return cliArgVar(name)
+}
+
+// platformVar resolves $OS and $ARCH, the platform aura runs on as
+// platforms: names it. They rank above the environment, where Windows
+// sets OS to Windows_NT
+func platformVar(name string) (string, bool) {
+	switch name {
+	case "OS":
+		return runtime.GOOS, true
+	case "ARCH":
+		return runtime.GOARCH, true
+	}
+	return "", false
+}
+
+// gitCheckout is the commit checked out and its branch, empty when
+// detached
+type gitCheckout struct {
+	sha    string
+	branch string
+}
+
+// readGitHead asks git for the checkout of the current directory
+func readGitHead() (gitCheckout, error) {
+	out, err := exec.Command("git", "rev-parse", "HEAD", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return gitCheckout{}, err
 	}
+	lines := strings.Fields(string(out))
+	if len(lines) != 2 {
+		return gitCheckout{}, fmt.Errorf("unexpected git rev-parse output %q", out)
+	}
+	head := gitCheckout{sha: lines[0], branch: lines[1]}
+	if head.branch == "HEAD" {
+		head.branch = ""
+	}
+	return head, nil
+}
 
+// newBuildID returns a random UUID (version 4)
+func newBuildID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
 }
 
 // Get target by name
@@ -35,3 +134,13 @@ func GetTarget(name string) Target {
 	return target
 
 }
+
+// sortedKeys returns the keys of a map in alphabetical order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
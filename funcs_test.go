@@ -1,7 +1,11 @@
 package main
 
 import (
+	"io"
 	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -171,6 +175,184 @@ func TestGetVarEnvironmentFallback(t *testing.T) {
 	}
 }
 
+func TestLookupVarPrecedence(t *testing.T) {
+	original := cfg
+	originalOverrides := overrideVars
+	originalProfile := activeProfile
+	defer func() {
+		cfg = original
+		overrideVars = originalOverrides
+		activeProfile = originalProfile
+	}()
+
+	t.Setenv("AURA_PREC", "env")
+	t.Setenv("NPROC", "env-nproc")
+	buildEnv.Snapshot(0)
+	defer buildEnv.Snapshot(0)
+
+	layers := []string{"cli", "target", "profile", "config"}
+	setup := func(upTo int) {
+		overrideVars = map[string]string{}
+		cfg = Config{
+			Vars:     map[string]Var{},
+			Profiles: map[string]Profile{"ci": {Vars: map[string]Var{}}},
+			Targets:  map[string]Target{"build": {Vars: map[string]Var{}}},
+		}
+		activeProfile = "ci"
+		for _, layer := range layers[upTo:] {
+			switch layer {
+			case "cli":
+				overrideVars["AURA_PREC"] = "cli"
+			case "target":
+				cfg.Targets["build"].Vars["AURA_PREC"] = "target"
+			case "profile":
+				cfg.Profiles["ci"].Vars["AURA_PREC"] = "profile"
+			case "config":
+				cfg.Vars["AURA_PREC"] = "config"
+			}
+		}
+	}
+
+	expected := []string{"cli", "target", "profile", "config", "env"}
+	for i, want := range expected {
+		t.Run("highest layer "+want, func(t *testing.T) {
+			setup(i)
+			got, ok := LookupVar("AURA_PREC", "build")
+			if !ok || got != want {
+				t.Errorf("LookupVar() = %q, %v, want %q", got, ok, want)
+			}
+		})
+	}
+
+	t.Run("target vars only apply to their target", func(t *testing.T) {
+		setup(1)
+		if got := GetVar("AURA_PREC", "other"); got != "profile" {
+			t.Errorf("GetVar() = %q, want %q", got, "profile")
+		}
+	})
+
+	t.Run("inactive profile is ignored", func(t *testing.T) {
+		setup(2)
+		activeProfile = ""
+		if got := GetVar("AURA_PREC", "build"); got != "config" {
+			t.Errorf("GetVar() = %q, want %q", got, "config")
+		}
+	})
+
+	t.Run("environment beats builtins", func(t *testing.T) {
+		setup(0)
+		if got := GetVar("NPROC", "build"); got != "env-nproc" {
+			t.Errorf("GetVar(NPROC) = %q, want %q", got, "env-nproc")
+		}
+	})
+
+	t.Run("platform beats environment", func(t *testing.T) {
+		setup(0)
+		t.Setenv("OS", "Windows_NT")
+		buildEnv.Snapshot(0)
+		if got := GetVar("OS", "build"); got != runtime.GOOS {
+			t.Errorf("GetVar(OS) = %q, want %q", got, runtime.GOOS)
+		}
+		cfg.Vars["OS"] = "config"
+		if got := GetVar("OS", "build"); got != "config" {
+			t.Errorf("GetVar(OS) = %q, want the config's", got)
+		}
+	})
+
+	t.Run("undefined variable", func(t *testing.T) {
+		setup(0)
+		if _, ok := LookupVar("AURA_UNDEFINED_12345", "build"); ok {
+			t.Errorf("LookupVar() reported undefined variable as defined")
+		}
+	})
+
+	t.Run("dollar prefix is not stripped", func(t *testing.T) {
+		setup(3)
+		if _, ok := LookupVar("$AURA_PREC", "build"); ok {
+			t.Errorf("LookupVar() should not accept a leading $")
+		}
+	})
+}
+
+func TestLookupVarMatchesCommands(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	// Vars of a prefixed include resolve as the target's commands see them
+	cfg = Config{
+		Vars:    map[string]Var{"OUT": "dist", "lib.OUT": "lib/dist"},
+		Targets: map[string]Target{"lib.build": {namespace: "lib"}},
+	}
+	ectx := newExpandContext("lib.build", nil)
+	for _, name := range []string{"OUT", "lib.OUT", "@"} {
+		want, _ := ectx.Lookup(name)
+		if got, ok := LookupVar(name, "lib.build"); !ok || got != want {
+			t.Errorf("LookupVar(%s) = %q, %t; want %q as the commands see it", name, got, ok, want)
+		}
+	}
+	if got := GetVar("OUT", "lib.build"); got != "lib/dist" {
+		t.Errorf("GetVar(OUT) = %q, want the include's lib/dist", got)
+	}
+}
+
+func TestBuiltinVars(t *testing.T) {
+	originalDir := configDir
+	defer func() { configDir = originalDir }()
+	configDir = "/src/app"
+	home, _ := os.UserHomeDir()
+
+	expected := map[string]string{
+		"HOME":       home,
+		"CONFIG_DIR": "/src/app",
+		"BUILD_ID":   buildID(),
+	}
+	for name, want := range expected {
+		if got, ok := builtinVar(name, "build"); !ok || got != want {
+			t.Errorf("builtinVar(%s) = %q, %t; want %q", name, got, ok, want)
+		}
+	}
+	if got, ok := platformVar("ARCH"); !ok || got != runtime.GOARCH {
+		t.Errorf("platformVar(ARCH) = %q, %t; want %q", got, ok, runtime.GOARCH)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(buildID()) {
+		t.Errorf("BUILD_ID = %q, expected a version 4 UUID", buildID())
+	}
+	if newBuildID() == buildID() {
+		t.Error("newBuildID() should differ between builds")
+	}
+}
+
+func TestReadGitHead(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("needs git")
+	}
+	chdirTemp(t)
+	if _, err := readGitHead(); err == nil {
+		t.Error("readGitHead() outside a checkout should fail")
+	}
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=aura", "-c", "user.email=aura@example.com"}, args...)...)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", "-b", "main")
+	git("commit", "-q", "--allow-empty", "-m", "first")
+	sha := git("rev-parse", "HEAD")
+
+	head, err := readGitHead()
+	if err != nil || head != (gitCheckout{sha: sha, branch: "main"}) {
+		t.Errorf("readGitHead() = %+v, %v; expected %s on main", head, err, sha)
+	}
+	git("checkout", "-q", "--detach")
+	if head, err := readGitHead(); err != nil || head.branch != "" {
+		t.Errorf("readGitHead() = %+v, %v; expected no branch when detached", head, err)
+	}
+}
+
 // ===== PARSE.GO UNIT TESTS =====
 
 func TestParseVarsSimple(t *testing.T) {
@@ -344,6 +526,34 @@ func TestParseVarsUndefinedVariables(t *testing.T) {
 	}
 }
 
+func TestParseVarsWarnsOnlyForUndefined(t *testing.T) {
+	original := cfg.Vars
+	defer func() { cfg.Vars = original }()
+	cfg.Vars = map[string]Var{"EMPTY": ""}
+
+	capture := func(input string) string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("Failed to create pipe: %v", err)
+		}
+		stderr := os.Stderr
+		os.Stderr = w
+		ParseVars(input, "test")
+		os.Stderr = stderr
+		_ = w.Close()
+
+		out, _ := io.ReadAll(r)
+		return string(out)
+	}
+
+	if warn := capture("echo $EMPTY"); warn != "" {
+		t.Errorf("ParseVars() warned for defined empty variable: %q", warn)
+	}
+	if warn := capture("echo $AURA_UNDEFINED_12345"); !strings.Contains(warn, "undefined variable $AURA_UNDEFINED_12345") {
+		t.Errorf("ParseVars() warning = %q, want undefined variable warning", warn)
+	}
+}
+
 func TestParseVarsEdgeCases(t *testing.T) {
 	// Setup test variables
 	original := cfg.Vars
@@ -403,6 +613,12 @@ func TestParseVarsEdgeCases(t *testing.T) {
 			target:   "test",
 			expected: "$NORMAL123", // Should not substitute (different var name)
 		},
+		{
+			name:     "Defined empty variable is substituted",
+			input:    "before$EMPTY after${EMPTY}",
+			target:   "test",
+			expected: "before after",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1,4 +1,4 @@
-module aura
+module github.com/agilira/aura
 
 go 1.25.1
 
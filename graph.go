@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// findCycle returns a dependency cycle among the configured targets, as
+// the path from a target back to itself, or nil when the graph is a DAG
+func findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			i := slices.Index(path, name)
+			return append(slices.Clone(path[i:]), name)
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range targetEdges(name) {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, name := range sortedKeys(cfg.Targets) {
+		if cycle := visit(name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// validateDeps rejects dependency cycles, which would otherwise make
+// running a target recurse forever
+func validateDeps() error {
+	if cycle := findCycle(); cycle != nil {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// scheduleOrder lists the nodes of a schedule in topological order, deps
+// before the targets needing them, in request order otherwise
+func scheduleOrder(requested []*schedNode) []*schedNode {
+	var order []*schedNode
+	visited := map[*schedNode]bool{}
+	var visit func(node *schedNode)
+	visit = func(node *schedNode) {
+		if visited[node] {
+			return
+		}
+		visited[node] = true
+		for _, dep := range node.deps {
+			visit(dep)
+		}
+		order = append(order, node)
+	}
+	for _, node := range requested {
+		visit(node)
+	}
+	return order
+}
+
+// runTargetsSequential runs the requested targets and their deps one at a
+// time in topological order, each once
+func runTargetsSequential(names []string, verbose, dryRun bool) error {
+	requested, err := buildSchedule(names)
+	if err != nil {
+		return err
+	}
+	for _, node := range scheduleOrder(requested) {
+		if verbose {
+			for _, dep := range node.target.Deps {
+				if isFileDep(dep) {
+					logDebug("file_dep", node.name, "Checking file dependency: %s", dep)
+				}
+			}
+		}
+		if err := runTargetOnly(node.name, nil, verbose, dryRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// graphNode is a target as drawn by `aura graph`. Its layer is the length
+// of its longest dependency chain, so deps are always left of dependents
+type graphNode struct {
+	Name     string   `json:"name"`
+	Desc     string   `json:"desc,omitempty"`
+	Deps     []string `json:"deps"`
+	Layer    int      `json:"layer"`
+	Row      int      `json:"row"`
+	Ran      bool     `json:"ran"`
+	Duration float64  `json:"duration"` // seconds taken by the last run
+	Failed   bool     `json:"failed,omitempty"`
+}
+
+// graphNodes lays out the targets of set with the outcome of their last
+// run in history; only edges within the set are kept
+func graphNodes(set querySet, history runHistory) []graphNode {
+	layers := map[string]int{}
+	var layer func(name string) int
+	layer = func(name string) int {
+		if l, ok := layers[name]; ok {
+			return l
+		}
+		l := 0
+		for _, dep := range targetEdges(name) {
+			if set[dep] {
+				l = max(l, layer(dep)+1)
+			}
+		}
+		layers[name] = l
+		return l
+	}
+
+	rows := map[int]int{}
+	nodes := []graphNode{}
+	for _, name := range sortedKeys(set) {
+		node := graphNode{Name: name, Desc: cfg.Targets[name].Desc, Deps: []string{}, Layer: layer(name)}
+		for _, dep := range targetEdges(name) {
+			if set[dep] {
+				node.Deps = append(node.Deps, dep)
+			}
+		}
+		node.Row = rows[node.Layer]
+		rows[node.Layer]++
+		if runs := history[name]; len(runs) > 0 {
+			last := runs[len(runs)-1]
+			node.Ran, node.Duration, node.Failed = true, last.Duration.Seconds(), !last.OK
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// lastTime is how long a node's last run took, "" when it never ran
+func (n graphNode) lastTime() string {
+	if !n.Ran {
+		return ""
+	}
+	return time.Duration(n.Duration * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+// writeGraphDot writes nodes as a Graphviz digraph, edges pointing from a
+// target to its deps
+func writeGraphDot(w io.Writer, nodes []graphNode) {
+	fmt.Fprintln(w, "digraph aura {")
+	fmt.Fprintln(w, "  rankdir=RL;")
+	fmt.Fprintln(w, "  node [shape=box];")
+	for _, node := range nodes {
+		label := node.Name
+		if node.Ran {
+			label += "\n" + node.lastTime()
+		}
+		fmt.Fprintf(w, "  %q [label=%q];\n", node.Name, label)
+		for _, dep := range node.Deps {
+			fmt.Fprintf(w, "  %q -> %q;\n", node.Name, dep)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// writeGraphMermaid writes nodes as a Mermaid flowchart for Markdown
+// documentation, edges pointing from a target to its deps like the DOT
+// graph. Nodes get generated ids since target names may hold characters
+// Mermaid reserves; failed ones are outlined in red
+func writeGraphMermaid(w io.Writer, nodes []graphNode) {
+	ids := map[string]string{}
+	for i, node := range nodes {
+		ids[node.Name] = fmt.Sprintf("t%d", i)
+	}
+	label := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+
+	fmt.Fprintln(w, "flowchart RL")
+	var failed []string
+	for _, node := range nodes {
+		text := label.Replace(node.Name)
+		if node.Ran {
+			text += "<br/>" + node.lastTime()
+		}
+		fmt.Fprintf(w, "  %s[\"%s\"]\n", ids[node.Name], text)
+		if node.Failed {
+			failed = append(failed, ids[node.Name])
+		}
+	}
+	for _, node := range nodes {
+		for _, dep := range node.Deps {
+			fmt.Fprintf(w, "  %s --> %s\n", ids[node.Name], ids[dep])
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintln(w, "  classDef failed stroke:#d33,stroke-width:2px")
+		fmt.Fprintf(w, "  class %s failed\n", strings.Join(failed, ","))
+	}
+}
+
+// writeGraphTree prints nodes as an indented tree, each target above its
+// deps, starting from the targets nothing in the graph depends on. A target
+// reached again is marked (*) instead of repeating its deps
+func writeGraphTree(w io.Writer, nodes []graphNode) {
+	byName := map[string]graphNode{}
+	needed := map[string]bool{}
+	for _, node := range nodes {
+		byName[node.Name] = node
+		for _, dep := range node.Deps {
+			needed[dep] = true
+		}
+	}
+
+	shown := map[string]bool{}
+	var visit func(name, indent, branch, next string)
+	visit = func(name, indent, branch, next string) {
+		node := byName[name]
+		line := indent + branch + name
+		switch {
+		case shown[name] && len(node.Deps) > 0:
+			fmt.Fprintln(w, line+" (*)")
+			return
+		case node.Failed:
+			line += " (" + node.lastTime() + ", failed)"
+		case node.Ran:
+			line += " (" + node.lastTime() + ")"
+		}
+		fmt.Fprintln(w, line)
+		shown[name] = true
+		for i, dep := range node.Deps {
+			if i == len(node.Deps)-1 {
+				visit(dep, indent+next, "└── ", "    ")
+			} else {
+				visit(dep, indent+next, "├── ", "│   ")
+			}
+		}
+	}
+	for _, node := range nodes {
+		if !needed[node.Name] {
+			visit(node.Name, "", "", "")
+		}
+	}
+}
+
+// graphCommand draws the target graph, or the part of it a query selects
+func graphCommand(ctx *orpheus.Context) error {
+	format := ctx.GetFlagString("format")
+	if !slices.Contains([]string{"dot", "mermaid", "tree", "html"}, format) {
+		return orpheus.ValidationError("format", fmt.Sprintf("unknown format '%s': use dot, mermaid, tree or html", format))
+	}
+	workDir := ctx.GetGlobalFlagString("directory")
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	if err := loadConfigProfile(ctx.GetGlobalFlagString("config"), ctx.GetGlobalFlagString("profile")); err != nil {
+		return err
+	}
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+
+	set := querySet{}
+	for name := range cfg.Targets {
+		set[name] = true
+	}
+	if args := positionalArgs(ctx); len(args) > 0 {
+		var err error
+		if set, err = evalQuery(strings.Join(args, " ")); err != nil {
+			return orpheus.ValidationError("query", err.Error())
+		}
+	}
+	history, err := loadHistory()
+	if err != nil {
+		logWarn("build timings not shown: %v", err)
+	}
+	nodes := graphNodes(set, history)
+
+	var buf bytes.Buffer
+	switch format {
+	case "dot":
+		writeGraphDot(&buf, nodes)
+	case "mermaid":
+		writeGraphMermaid(&buf, nodes)
+	case "tree":
+		writeGraphTree(&buf, nodes)
+	default:
+		wd, _ := os.Getwd()
+		if err := graphPage.Execute(&buf, graphPageData{Project: filepath.Base(wd), Nodes: nodes}); err != nil {
+			return orpheus.ExecutionError("graph", err.Error())
+		}
+	}
+
+	output := ctx.GetFlagString("output")
+	if output == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0600); err != nil {
+		return orpheus.ExecutionError("graph", fmt.Sprintf("cannot write %s: %v", output, err))
+	}
+	fmt.Printf("%s Wrote the graph of %d targets to %s\n", checkMark(), len(nodes), output)
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===== GRAPH.GO UNIT TESTS =====
+
+func TestFindCycle(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name     string
+		targets  map[string]Target
+		expected []string
+	}{
+		{"dag", map[string]Target{
+			"app": {Deps: []string{"lib", "assets"}},
+			"lib": {Deps: []string{"gen", "lib.h"}},
+			"gen": {}, "assets": {Deps: []string{"gen"}},
+		}, nil},
+		{"two targets", map[string]Target{
+			"circular1": {Deps: []string{"circular2"}},
+			"circular2": {Deps: []string{"circular1"}},
+		}, []string{"circular1", "circular2", "circular1"}},
+		{"self", map[string]Target{"loop": {Deps: []string{"loop"}}}, []string{"loop", "loop"}},
+		{"below an entry", map[string]Target{
+			"a": {Deps: []string{"b"}},
+			"b": {Deps: []string{"c"}},
+			"c": {Deps: []string{"d"}},
+			"d": {Deps: []string{"b"}},
+		}, []string{"b", "c", "d", "b"}},
+		{"unknown deps ignored", map[string]Target{"a": {Deps: []string{"missing"}}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: tt.targets}
+			if got := findCycle(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("findCycle() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	cfg = Config{Targets: tests[1].targets}
+	if err := validateDeps(); err == nil || err.Error() != "dependency cycle: circular1 -> circular2 -> circular1" {
+		t.Errorf("validateDeps() = %v", err)
+	}
+}
+
+func TestLoadConfigRejectsCycle(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{}
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"aura.yaml": `
+targets:
+  a:
+    deps: ["b"]
+    run: ["echo a"]
+  b:
+    deps: ["a"]
+    run: ["echo b"]
+`})
+	err := loadConfig("aura.yaml")
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle: a -> b -> a") {
+		t.Errorf("loadConfig() error = %v, expected the cycle", err)
+	}
+}
+
+func TestRunTargetsSequential(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	// gen is shared by lib and assets but runs once, before both
+	cfg = Config{Targets: map[string]Target{
+		"app":    {Deps: []string{"lib", "assets"}, Run: []string{"echo app >> order.txt"}},
+		"lib":    {Deps: []string{"gen"}, Run: []string{"echo lib >> order.txt"}},
+		"assets": {Deps: []string{"gen"}, Run: []string{"echo assets >> order.txt"}},
+		"gen":    {Run: []string{"echo gen >> order.txt"}},
+	}}
+	if err := runTargetsSequential([]string{"app", "gen"}, false, false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile("order.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(data)); !reflect.DeepEqual(got, []string{"gen", "lib", "assets", "app"}) {
+		t.Errorf("order = %v", got)
+	}
+
+	if err := runTargetsSequential([]string{"missing"}, false, false); err == nil {
+		t.Error("runTargetsSequential() should fail for an unknown target")
+	}
+}
+
+func TestGraphNodes(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{
+		"app":  {Deps: []string{"lib", "gen", "main.c"}},
+		"lib":  {Desc: "core library", Deps: []string{"gen"}},
+		"gen":  {},
+		"docs": {},
+	}}
+	history := runHistory{"lib": {
+		{Duration: time.Minute, OK: true},
+		{Duration: 1500 * time.Millisecond, OK: false},
+	}}
+
+	nodes := graphNodes(querySet{"app": true, "lib": true, "gen": true, "docs": true}, history)
+	expected := []graphNode{
+		{Name: "app", Deps: []string{"lib", "gen"}, Layer: 2},
+		{Name: "docs", Deps: []string{}, Layer: 0},
+		{Name: "gen", Deps: []string{}, Layer: 0, Row: 1},
+		{Name: "lib", Desc: "core library", Deps: []string{"gen"}, Layer: 1, Ran: true, Duration: 1.5, Failed: true},
+	}
+	if !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("graphNodes() = %+v, expected %+v", nodes, expected)
+	}
+
+	// Edges leaving the set are dropped
+	nodes = graphNodes(querySet{"app": true, "lib": true}, nil)
+	if !reflect.DeepEqual(nodes[0].Deps, []string{"lib"}) || nodes[1].Layer != 0 {
+		t.Errorf("graphNodes() on a subset = %+v", nodes)
+	}
+}
+
+func TestGraphOutput(t *testing.T) {
+	nodes := []graphNode{
+		{Name: "gen", Deps: []string{}, Ran: true, Duration: 0.25},
+		{Name: "app", Desc: "</script><b>", Deps: []string{"gen"}, Layer: 1},
+	}
+
+	var dot bytes.Buffer
+	writeGraphDot(&dot, nodes)
+	for _, line := range []string{`"gen" [label="gen\n250ms"];`, `"app" [label="app"];`, `"app" -> "gen";`} {
+		if !strings.Contains(dot.String(), line) {
+			t.Errorf("dot output lacks %s:\n%s", line, dot.String())
+		}
+	}
+
+	var mermaid bytes.Buffer
+	writeGraphMermaid(&mermaid, nodes)
+	for _, line := range []string{"flowchart RL\n", `t0["gen<br/>250ms"]`, `t1["app"]`, "t1 --> t0"} {
+		if !strings.Contains(mermaid.String(), line) {
+			t.Errorf("mermaid output lacks %s:\n%s", line, mermaid.String())
+		}
+	}
+
+	var page bytes.Buffer
+	if err := graphPage.Execute(&page, graphPageData{Project: "demo", Nodes: nodes}); err != nil {
+		t.Fatal(err)
+	}
+	html := page.String()
+	if !strings.Contains(html, "<title>demo - aura target graph</title>") || !strings.Contains(html, `"name":"gen"`) {
+		t.Errorf("page does not embed the graph:\n%s", html)
+	}
+	// Descriptions cannot close the script element
+	if strings.Count(html, "</script>") != 1 {
+		t.Error("page embeds an unescaped </script>")
+	}
+}
+
+func TestGraphMermaidEscapes(t *testing.T) {
+	nodes := []graphNode{
+		{Name: `say"hi"`, Deps: []string{}},
+		{Name: "api:build", Deps: []string{`say"hi"`}, Ran: true, Failed: true},
+	}
+
+	var mermaid bytes.Buffer
+	writeGraphMermaid(&mermaid, nodes)
+	for _, line := range []string{`t0["say#quot;hi#quot;"]`, `t1["api:build<br/>0s"]`, "class t1 failed"} {
+		if !strings.Contains(mermaid.String(), line) {
+			t.Errorf("mermaid output lacks %s:\n%s", line, mermaid.String())
+		}
+	}
+}
+
+func TestGraphTree(t *testing.T) {
+	nodes := []graphNode{
+		{Name: "api", Deps: []string{"lib", "gen"}},
+		{Name: "app", Deps: []string{"lib", "api"}, Ran: true, Duration: 2, Failed: true},
+		{Name: "docs", Deps: []string{}, Ran: true, Duration: 0.5},
+		{Name: "gen", Deps: []string{}},
+		{Name: "lib", Deps: []string{"gen"}},
+	}
+
+	var tree bytes.Buffer
+	writeGraphTree(&tree, nodes)
+	expected := `app (2s, failed)
+├── lib
+│   └── gen
+└── api
+    ├── lib (*)
+    └── gen
+docs (500ms)
+`
+	if tree.String() != expected {
+		t.Errorf("tree = \n%s\nexpected\n%s", tree.String(), expected)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import "html/template"
+
+// graphPageData is what the `aura graph --format html` page is rendered from
+type graphPageData struct {
+	Project string
+	Nodes   []graphNode
+}
+
+// graphPage is a standalone page drawing the target graph: no external
+// scripts or styles, so it can be mailed or attached to a ticket as is
+var graphPage = template.Must(template.New("graph").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Project}} - aura target graph</title>
+<style>
+  body { margin: 0; font: 13px system-ui, sans-serif; color: #1f2328; display: flex; flex-direction: column; height: 100vh; }
+  header { display: flex; gap: 12px; align-items: center; padding: 8px 12px; border-bottom: 1px solid #d0d7de; background: #f6f8fa; }
+  header h1 { font-size: 15px; margin: 0 12px 0 0; }
+  header input, header select, header button { font: inherit; padding: 3px 6px; }
+  header .legend { margin-left: auto; display: flex; gap: 10px; align-items: center; color: #57606a; }
+  .swatch { display: inline-block; width: 12px; height: 12px; border-radius: 2px; vertical-align: -2px; margin-right: 3px; border: 1px solid #8c959f; }
+  main { flex: 1; display: flex; min-height: 0; }
+  svg { flex: 1; cursor: grab; background: #fff; }
+  svg.panning { cursor: grabbing; }
+  aside { width: 280px; border-left: 1px solid #d0d7de; padding: 10px 12px; overflow: auto; }
+  aside h2 { font-size: 14px; margin: 0 0 6px; word-break: break-all; }
+  aside ul { padding-left: 18px; }
+  aside a { color: #0969da; cursor: pointer; }
+  .node rect { stroke: #8c959f; stroke-width: 1; rx: 4; }
+  .node text { pointer-events: none; }
+  .node .time { fill: #57606a; font-size: 11px; }
+  .node.failed rect { stroke: #cf222e; stroke-width: 2; }
+  .node.match rect { stroke: #0969da; stroke-width: 3; }
+  .node.selected rect { stroke: #1f2328; stroke-width: 3; }
+  .edge { fill: none; stroke: #afb8c1; stroke-width: 1.2; }
+  .edge.related { stroke: #0969da; stroke-width: 2; }
+  .dim { opacity: 0.15; }
+  .hidden { display: none; }
+</style>
+</head>
+<body>
+<header>
+  <h1>{{.Project}}</h1>
+  <input id="search" type="search" placeholder="Search targets" autofocus>
+  <select id="filter">
+    <option value="all">Show all</option>
+    <option value="matches">Matches only</option>
+    <option value="deps">Matches and their deps</option>
+    <option value="rdeps">Matches and their dependents</option>
+  </select>
+  <button id="fit">Fit</button>
+  <span class="legend">
+    <span><span class="swatch" style="background:#eaeef2"></span>not run</span>
+    <span><span class="swatch" style="background:hsl(120,60%,85%)"></span>fast</span>
+    <span><span class="swatch" style="background:hsl(0,75%,80%)"></span>slow</span>
+    <span><span class="swatch" style="background:#fff;border:2px solid #cf222e"></span>failed</span>
+  </span>
+</header>
+<main>
+  <svg id="graph" xmlns="http://www.w3.org/2000/svg">
+    <defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="7" markerHeight="7" orient="auto-start-reverse"><path d="M0,0 L10,5 L0,10 z" fill="#afb8c1"/></marker></defs>
+    <g id="view"></g>
+  </svg>
+  <aside id="details"><p>Click a target to see its deps and last build time. Scroll to zoom, drag to pan.</p></aside>
+</main>
+<script>
+const nodes = {{.Nodes}};
+const W = 180, H = 44, GAP_X = 90, GAP_Y = 22;
+const svg = document.getElementById("graph"), view = document.getElementById("view");
+const NS = "http://www.w3.org/2000/svg";
+const byName = new Map(nodes.map(n => [n.name, n]));
+const dependents = new Map(nodes.map(n => [n.name, []]));
+nodes.forEach(n => n.deps.forEach(d => dependents.get(d).push(n.name)));
+const slowest = Math.max(0, ...nodes.map(n => n.duration));
+
+function el(tag, attrs, parent) {
+  const e = document.createElementNS(NS, tag);
+  for (const k in attrs) e.setAttribute(k, attrs[k]);
+  parent.appendChild(e);
+  return e;
+}
+function formatTime(s) {
+  if (s < 1) return Math.round(s * 1000) + "ms";
+  if (s < 60) return s.toFixed(1) + "s";
+  return Math.floor(s / 60) + "m" + Math.round(s % 60) + "s";
+}
+function fill(n) {
+  if (!n.ran) return "#eaeef2";
+  const t = slowest > 0 ? n.duration / slowest : 0;
+  return "hsl(" + Math.round(120 * (1 - t)) + "," + (60 + 15 * t) + "%," + (85 - 5 * t) + "%)";
+}
+function closure(start, next) {
+  const seen = new Set(), stack = [...start];
+  while (stack.length) {
+    const name = stack.pop();
+    if (seen.has(name)) continue;
+    seen.add(name);
+    next(name).forEach(m => stack.push(m));
+  }
+  return seen;
+}
+
+// Edges first so nodes are drawn on top
+const edges = [];
+nodes.forEach(n => {
+  n.x = n.layer * (W + GAP_X);
+  n.y = n.row * (H + GAP_Y);
+});
+nodes.forEach(n => n.deps.forEach(d => {
+  const dep = byName.get(d);
+  const x1 = n.x, y1 = n.y + H / 2, x2 = dep.x + W, y2 = dep.y + H / 2, mid = (x1 + x2) / 2;
+  const path = el("path", {class: "edge", d: "M" + x1 + "," + y1 + " C" + mid + "," + y1 + " " + mid + "," + y2 + " " + x2 + "," + y2, "marker-end": "url(#arrow)"}, view);
+  edges.push({from: n.name, to: d, path});
+}));
+nodes.forEach(n => {
+  const g = el("g", {class: "node" + (n.failed ? " failed" : ""), transform: "translate(" + n.x + "," + n.y + ")"}, view);
+  el("rect", {width: W, height: H, fill: fill(n)}, g);
+  const label = el("text", {x: 8, y: 18}, g);
+  label.textContent = n.name.length > 24 ? n.name.slice(0, 23) + "…" : n.name;
+  const time = el("text", {x: 8, y: 34, class: "time"}, g);
+  time.textContent = n.ran ? formatTime(n.duration) + (n.failed ? " ✗ failed" : "") : "not run";
+  el("title", {}, g).textContent = n.name + (n.desc ? "\n" + n.desc : "");
+  g.addEventListener("click", e => { e.stopPropagation(); select(n.name); });
+  n.g = g;
+});
+
+// Search and filter
+let selected = null;
+function update() {
+  const query = document.getElementById("search").value.trim().toLowerCase();
+  const mode = document.getElementById("filter").value;
+  const matches = new Set(query ? nodes.filter(n => n.name.toLowerCase().includes(query) || (n.desc || "").toLowerCase().includes(query)).map(n => n.name) : []);
+  let visible = null;
+  if (query && mode === "matches") visible = matches;
+  if (query && mode === "deps") visible = closure(matches, name => byName.get(name).deps);
+  if (query && mode === "rdeps") visible = closure(matches, name => dependents.get(name));
+  const related = selected ? new Set([...closure([selected], name => byName.get(name).deps), ...closure([selected], name => dependents.get(name))]) : null;
+  nodes.forEach(n => {
+    n.g.classList.toggle("hidden", visible !== null && !visible.has(n.name));
+    n.g.classList.toggle("match", matches.has(n.name));
+    n.g.classList.toggle("selected", n.name === selected);
+    n.g.classList.toggle("dim", related !== null && !related.has(n.name));
+  });
+  edges.forEach(e => {
+    e.path.classList.toggle("hidden", visible !== null && !(visible.has(e.from) && visible.has(e.to)));
+    e.path.classList.toggle("related", related !== null && related.has(e.from) && related.has(e.to));
+    e.path.classList.toggle("dim", related !== null && !(related.has(e.from) && related.has(e.to)));
+  });
+}
+function select(name) {
+  selected = name;
+  const details = document.getElementById("details");
+  details.textContent = "";
+  if (name === null) { update(); return; }
+  const n = byName.get(name);
+  const add = (tag, text) => { const e = document.createElement(tag); e.textContent = text; details.appendChild(e); return e; };
+  add("h2", n.name);
+  if (n.desc) add("p", n.desc);
+  add("p", n.ran ? "Last build: " + formatTime(n.duration) + (n.failed ? " (failed)" : "") : "Not built yet");
+  for (const [title, list] of [["Deps", n.deps], ["Needed by", dependents.get(name)]]) {
+    add("strong", title + " (" + list.length + ")");
+    const ul = add("ul", "");
+    list.forEach(other => {
+      const a = document.createElement("a");
+      a.textContent = other;
+      a.addEventListener("click", () => select(other));
+      ul.appendChild(document.createElement("li")).appendChild(a);
+    });
+  }
+  update();
+}
+document.getElementById("search").addEventListener("input", update);
+document.getElementById("filter").addEventListener("change", update);
+svg.addEventListener("click", () => { if (!panned) select(null); });
+
+// Zoom with the wheel around the cursor, pan by dragging
+let scale = 1, tx = 20, ty = 20;
+function apply() { view.setAttribute("transform", "translate(" + tx + "," + ty + ") scale(" + scale + ")"); }
+function fit() {
+  const box = view.getBBox(), rect = svg.getBoundingClientRect();
+  if (!box.width || !box.height) return;
+  scale = Math.min(2, (rect.width - 40) / box.width, (rect.height - 40) / box.height);
+  tx = 20 - box.x * scale;
+  ty = 20 - box.y * scale;
+  apply();
+}
+svg.addEventListener("wheel", e => {
+  e.preventDefault();
+  const rect = svg.getBoundingClientRect(), px = e.clientX - rect.left, py = e.clientY - rect.top;
+  const factor = Math.exp(-e.deltaY * 0.0015), next = Math.min(8, Math.max(0.05, scale * factor));
+  tx = px - (px - tx) * next / scale;
+  ty = py - (py - ty) * next / scale;
+  scale = next;
+  apply();
+}, {passive: false});
+let drag = null, panned = false;
+svg.addEventListener("mousedown", e => { drag = {x: e.clientX - tx, y: e.clientY - ty, moved: false}; svg.classList.add("panning"); });
+window.addEventListener("mousemove", e => {
+  if (!drag) return;
+  tx = e.clientX - drag.x;
+  ty = e.clientY - drag.y;
+  drag.moved = true;
+  apply();
+});
+window.addEventListener("mouseup", () => { panned = drag !== null && drag.moved; drag = null; svg.classList.remove("panning"); });
+document.getElementById("fit").addEventListener("click", fit);
+fit();
+</script>
+</body>
+</html>
+`))
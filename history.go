@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// runRecord is one execution of a target. Attempts counts retries (0 and
+// 1 both mean a single attempt); Inputs is a digest of the declared sources.
+type runRecord struct {
+	At       time.Time     `json:"at"`
+	Duration time.Duration `json:"duration"`
+	OK       bool          `json:"ok"`
+	Attempts int           `json:"attempts,omitempty"`
+	Inputs   string        `json:"inputs,omitempty"`
+	CPU      time.Duration `json:"cpu,omitempty"`      // user and system time of its commands
+	PeakRSS  int64         `json:"peak_rss,omitempty"` // bytes, of its largest process
+}
+
+// runHistory maps target names to their most recent runs, oldest first
+type runHistory map[string][]runRecord
+
+// historyLimit is how many runs are kept per target
+const historyLimit = 20
+
+// historyFile is where run history lives inside the cache directory
+func historyFile() string {
+	return filepath.Join(buildCacheDir(), "history.json")
+}
+
+// loadHistory reads the run history; a missing file means no runs yet
+func loadHistory() (runHistory, error) {
+	history := runHistory{}
+	// #nosec G304 - History file lives in aura's own cache directory
+	data, err := os.ReadFile(historyFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return history, err
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return runHistory{}, fmt.Errorf("corrupt run history %s: %v", historyFile(), err)
+	}
+	return history, nil
+}
+
+// save writes the run history back to the cache directory
+func (h runHistory) save() error {
+	if err := os.MkdirAll(buildCacheDir(), 0750); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(historyFile(), data, 0600)
+}
+
+// recordRun appends a run to a target's history and returns the runs that
+// came before it
+func recordRun(name string, run runRecord) ([]runRecord, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	history, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	previous := history[name]
+	runs := append(slices.Clone(previous), run)
+	if len(runs) > historyLimit {
+		runs = runs[len(runs)-historyLimit:]
+	}
+	history[name] = runs
+	return previous, history.save()
+}
+
+// medianDuration is the median of the successful runs, once there are
+// enough of them to be meaningful
+func medianDuration(runs []runRecord) (time.Duration, bool) {
+	var durations []time.Duration
+	for _, run := range runs {
+		if run.OK {
+			durations = append(durations, run.Duration)
+		}
+	}
+	if len(durations) < 3 {
+		return 0, false
+	}
+	slices.Sort(durations)
+	return durations[len(durations)/2], true
+}
+
+// validateRunSettings checks every warn_after is a duration or "auto" and
+// no retries count is negative
+func validateRunSettings() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		if cfg.Targets[name].Retries < 0 {
+			return fmt.Errorf("invalid retries %d for target '%s'", cfg.Targets[name].Retries, name)
+		}
+		value := cfg.Targets[name].WarnAfter
+		if value == "" || value == "auto" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("invalid warn_after '%s' for target '%s' (use a duration or auto)", value, name)
+		}
+	}
+	return nil
+}
+
+// durationLimit is how long a target may take before it is reported as
+// slow: warn_after itself, or with warn_after: auto twice the median of
+// its recent successful runs
+func durationLimit(target *Target, previous []runRecord) (time.Duration, bool) {
+	if target.WarnAfter == "auto" {
+		median, ok := medianDuration(previous)
+		return 2 * median, ok
+	}
+	limit, err := time.ParseDuration(target.WarnAfter)
+	return limit, err == nil && target.WarnAfter != ""
+}
+
+// inputsDigest summarises the current content of a target's sources, so
+// runs on identical inputs can be recognised; empty without sources
+func inputsDigest(name string, target *Target) string {
+	if len(target.Sources) == 0 {
+		return ""
+	}
+	files, err := fingerprintFiles(expandSources(target.Sources, name))
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	for _, file := range sortedKeys(files) {
+		fmt.Fprintf(h, "%s %s\n", files[file], file)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// targetFinished records a run in the history and reports it when it
+// took longer than expected
+func targetFinished(name string, target *Target, took time.Duration, usage resourceUsage, attempts int, runErr error) {
+	if len(target.Run) == 0 {
+		return
+	}
+
+	run := runRecord{At: time.Now(), Duration: took, OK: runErr == nil, Attempts: attempts, Inputs: inputsDigest(name, target), CPU: usage.CPU, PeakRSS: usage.PeakRSS}
+	previous, err := recordRun(name, run)
+	if err != nil {
+		logWarn("cannot record run history for %s: %v", name, err)
+	}
+
+	limit, ok := durationLimit(target, previous)
+	if !ok || took <= limit {
+		return
+	}
+	message := fmt.Sprintf("target '%s' took %s, expected under %s", name, took.Round(time.Millisecond), limit.Round(time.Millisecond))
+	logWarn("%s", message)
+	if detectCI() == ciGitHub {
+		annotate(os.Stdout, "warning", name, message)
+	}
+	notify(buildEvent{Type: eventSlow, Target: name, Message: message, Duration: took})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// ===== HISTORY.GO UNIT TESTS =====
+
+func TestRecordRun(t *testing.T) {
+	chdirTemp(t)
+
+	for i := range historyLimit + 5 {
+		previous, err := recordRun("build", runRecord{Duration: time.Duration(i) * time.Second, OK: true})
+		if err != nil {
+			t.Fatalf("recordRun() error: %v", err)
+		}
+		if i < historyLimit && len(previous) != i {
+			t.Errorf("recordRun() #%d returned %d previous runs, expected %d", i, len(previous), i)
+		}
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		t.Fatalf("loadHistory() error: %v", err)
+	}
+	runs := history["build"]
+	if len(runs) != historyLimit {
+		t.Fatalf("history keeps %d runs, expected %d", len(runs), historyLimit)
+	}
+	if runs[len(runs)-1].Duration != time.Duration(historyLimit+4)*time.Second {
+		t.Errorf("history should keep the most recent runs, last is %s", runs[len(runs)-1].Duration)
+	}
+}
+
+func TestDurationLimit(t *testing.T) {
+	runs := func(seconds ...int) []runRecord {
+		var records []runRecord
+		for _, s := range seconds {
+			records = append(records, runRecord{Duration: time.Duration(s) * time.Second, OK: s > 0})
+		}
+		return records
+	}
+
+	tests := []struct {
+		name      string
+		warnAfter string
+		previous  []runRecord
+		expected  time.Duration
+		ok        bool
+	}{
+		{"unset", "", runs(1, 2, 3), 0, false},
+		{"fixed", "2m", nil, 2 * time.Minute, true},
+		{"auto doubles the median", "auto", runs(10, 30, 20), 40 * time.Second, true},
+		{"auto ignores failures", "auto", runs(10, -1, 20, 30), 40 * time.Second, true},
+		{"auto needs history", "auto", runs(10, 20), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, ok := durationLimit(&Target{WarnAfter: tt.warnAfter}, tt.previous)
+			if limit != tt.expected || ok != tt.ok {
+				t.Errorf("durationLimit() = %s, %t, expected %s, %t", limit, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestValidateRunSettings(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		value       string
+		expectError bool
+	}{
+		{"", false},
+		{"auto", false},
+		{"90s", false},
+		{"0s", true},
+		{"slow", true},
+	}
+
+	for _, tt := range tests {
+		cfg = Config{Targets: map[string]Target{"build": {WarnAfter: tt.value}}}
+		err := validateRunSettings()
+		if tt.expectError && err == nil {
+			t.Errorf("validateRunSettings(%q) expected error", tt.value)
+		}
+		if !tt.expectError && err != nil {
+			t.Errorf("validateRunSettings(%q) unexpected error: %v", tt.value, err)
+		}
+	}
+
+	cfg = Config{Targets: map[string]Target{"build": {Retries: -1}}}
+	if err := validateRunSettings(); err == nil {
+		t.Error("validateRunSettings() should reject negative retries")
+	}
+}
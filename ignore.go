@@ -0,0 +1,181 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ignoreFile lists, in gitignore syntax, the files that never count as
+// sources: fingerprints, watch and clean skip them
+const ignoreFile = ".auraignore"
+
+// ignoreRule is one pattern line of an ignore file
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool // "!pattern" re-includes
+	dirOnly bool // "pattern/" only matches directories
+}
+
+// ignoreRules are the parsed lines of an ignore file, in order
+type ignoreRules []ignoreRule
+
+// parseIgnore parses gitignore syntax: # comments, ! negation, trailing /
+// for directories, a leading or inner / anchoring to the root, and *, ?,
+// [...] and ** wildcards
+func parseIgnore(content string) ignoreRules {
+	var rules ignoreRules
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// Without a slash a pattern matches at any depth
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := ignoreGlobRegex(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ignoreGlobRegex translates a gitignore glob into a regular expression
+func ignoreGlobRegex(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**") && i+2 == len(glob):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// match applies the rules to one path; the last matching rule decides
+func (rules ignoreRules) match(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(path) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// Ignored reports whether a slash-separated path relative to the project
+// root is ignored. As in git, nothing inside an ignored directory can be
+// re-included.
+func (rules ignoreRules) Ignored(path string, isDir bool) bool {
+	if len(rules) == 0 {
+		return false
+	}
+	if filepath.IsAbs(path) {
+		wd, _ := os.Getwd()
+		rel, err := filepath.Rel(wd, path)
+		if err != nil {
+			return false
+		}
+		path = rel
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	if path == "." || path == ".." || strings.HasPrefix(path, "../") {
+		return false
+	}
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		if rules.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return rules.match(path, isDir)
+}
+
+// ignoreCache keeps the parsed ignore file until it or the working
+// directory changes; watch consults it on every poll
+var ignoreCache struct {
+	sync.Mutex
+	dir   string
+	mtime time.Time
+	rules ignoreRules
+}
+
+// loadIgnore returns the rules of the .auraignore in the working directory,
+// none when there is no such file
+func loadIgnore() ignoreRules {
+	dir, _ := os.Getwd()
+	info, err := os.Stat(ignoreFile)
+	if err != nil {
+		return nil
+	}
+
+	ignoreCache.Lock()
+	defer ignoreCache.Unlock()
+	if ignoreCache.dir == dir && ignoreCache.mtime.Equal(info.ModTime()) {
+		return ignoreCache.rules
+	}
+	data, err := os.ReadFile(ignoreFile)
+	if err != nil {
+		return nil
+	}
+	ignoreCache.dir, ignoreCache.mtime, ignoreCache.rules = dir, info.ModTime(), parseIgnore(string(data))
+	return ignoreCache.rules
+}
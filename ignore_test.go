@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+// ===== IGNORE.GO UNIT TESTS =====
+
+func TestIgnoreRules(t *testing.T) {
+	rules := parseIgnore(`# generated
+node_modules/
+/build
+*.swp
+*~
+.#*
+logs/**/*.log
+!logs/keep/important.log
+docs/**
+!docs/index.md
+\#notes
+file[0-9].tmp
+`)
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"node_modules", true, true},
+		{"web/node_modules/react/index.js", false, true},
+		{"node_modules", false, false}, // a file named like the directory
+		{"build/app", false, true},
+		{"src/build/app", false, false}, // /build is anchored to the root
+		{"main.go.swp", false, true},
+		{"src/.main.go.swp", false, true},
+		{"src/main.go~", false, true},
+		{"src/.#main.go", false, true},
+		{"src/main.go", false, false},
+		{"logs/a/b/run.log", false, true},
+		{"logs/keep/important.log", false, false},
+		{"logs/run.txt", false, false},
+		{"docs/guide.md", false, true},
+		{"docs/index.md", false, false},
+		{"#notes", false, true},
+		{"file1.tmp", false, true},
+		{"fileA.tmp", false, false},
+		{"./src/x.swp", false, true},
+		{"../elsewhere.swp", false, false},
+	}
+	for _, tt := range tests {
+		if got := rules.Ignored(tt.path, tt.isDir); got != tt.expected {
+			t.Errorf("Ignored(%q, %v) = %v, expected %v", tt.path, tt.isDir, got, tt.expected)
+		}
+	}
+
+	// Files inside an ignored directory cannot be re-included
+	rules = parseIgnore("vendor/\n!vendor/keep.go\n")
+	if !rules.Ignored("vendor/keep.go", false) {
+		t.Error("re-including a file below an ignored directory should have no effect")
+	}
+
+	var none ignoreRules
+	if none.Ignored("anything", false) {
+		t.Error("no rules should ignore nothing")
+	}
+}
+
+func TestIgnoreGlobRegex(t *testing.T) {
+	tests := map[string]string{
+		"*.go":     `[^/]*\.go`,
+		"a/**/b":   `a/(?:.*/)?b`,
+		"**/x":     `(?:.*/)?x`,
+		"out/**":   `out/.*`,
+		"f?[!a-c]": `f[^/][^a-c]`,
+		`\*lit`:    `\*lit`,
+	}
+	for glob, expected := range tests {
+		if got := ignoreGlobRegex(glob); got != expected {
+			t.Errorf("ignoreGlobRegex(%q) = %q, expected %q", glob, got, expected)
+		}
+	}
+}
+
+func TestAuraIgnoreSources(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	writeFiles(t, map[string]string{
+		"src/main.go":             "package main",
+		"src/.main.go.swp":        "swap",
+		"src/gen/out.go":          "package gen",
+		"node_modules/x/index.js": "js",
+	})
+
+	if got := expandSources([]string{"**/*"}, ""); len(got) != 4 {
+		t.Errorf("without .auraignore expandSources() = %q", got)
+	}
+	if err := os.WriteFile(ignoreFile, []byte("*.swp\nnode_modules/\nsrc/gen/\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// The cache notices the new file through its mtime
+	_ = os.Chtimes(ignoreFile, time.Now().Add(time.Second), time.Now().Add(time.Second))
+
+	expected := []string{".auraignore", "src/main.go"}
+	if got := expandSources([]string{"**/*"}, ""); !slices.Equal(got, expected) {
+		t.Errorf("expandSources() = %q, expected %q", got, expected)
+	}
+	if got := expandSources([]string{"src/*"}, ""); !slices.Equal(got, []string{"src/main.go"}) {
+		t.Errorf("expandSources(src/*) = %q", got)
+	}
+	if got := expandPatterns([]string{"src/*"}, ""); len(got) != 2 {
+		t.Errorf("expandPatterns() is used for outputs and must not ignore, got %q", got)
+	}
+	if got := scanModTimes([]string{"**/*.js"}); len(got) != 0 {
+		t.Errorf("watch scan should skip ignored files, got %v", got)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includePrefixRegex matches the prefixes includes may namespace under
+var includePrefixRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// matches reports whether the conditions hold for the running platform,
+// the active profile and the environment
+func (w IncludeWhen) matches() (bool, error) {
+	for _, key := range w.OS {
+		if key == "default" || !isPlatformKey(key) {
+			return false, fmt.Errorf("unknown platform %q in when.os", key)
+		}
+	}
+	if len(w.OS) > 0 && !platformMatches(w.OS) {
+		return false, nil
+	}
+	if len(w.Profile) > 0 && !slices.Contains(w.Profile, activeProfile) {
+		return false, nil
+	}
+	if len(w.Env) > 0 && !slices.ContainsFunc(w.Env, func(cond string) bool {
+		if name, value, ok := strings.Cut(cond, "="); ok {
+			return os.Getenv(name) == value
+		}
+		return os.Getenv(cond) != ""
+	}) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// mergeInclude adds an included file to c. Without a prefix the file is
+// decoded over c, as if it were part of it. With one only its targets and
+// vars are taken, as prefix:target and prefix.VAR; names c already has are
+// kept, so the project can override a library's vars. The returned keys
+// are the top-level settings a prefixed include had and that were ignored
+func mergeInclude(c *Config, inc Include, data []byte) ([]string, error) {
+	if inc.Prefix == "" {
+		return nil, yaml.NewDecoder(bytes.NewReader(data)).Decode(c)
+	}
+	if !includePrefixRegex.MatchString(inc.Prefix) {
+		return nil, fmt.Errorf("invalid prefix '%s': use letters, digits, '_' and '-'", inc.Prefix)
+	}
+
+	lib, ignored, err := decodeNamespaced(data)
+	if err != nil {
+		return nil, err
+	}
+	mergeNamespaced(c, inc.Prefix, lib)
+	return ignored, nil
+}
+
+// decodeNamespaced decodes a config whose targets and vars are merged under
+// a namespace, returning the other top-level keys it has, which are ignored
+// unless listed in used
+func decodeNamespaced(data []byte, used ...string) (Config, []string, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return Config{}, nil, err
+	}
+	var lib Config
+	if err := doc.Decode(&lib); err != nil {
+		return Config{}, nil, err
+	}
+	var ignored []string
+	for _, root := range doc.Content {
+		if root.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i < len(root.Content); i += 2 {
+			if key := root.Content[i].Value; key != "targets" && key != "vars" && !slices.Contains(used, key) {
+				ignored = append(ignored, key)
+			}
+		}
+	}
+	slices.Sort(ignored)
+	return lib, ignored, nil
+}
+
+// mergeNamespaced adds lib's targets to c as namespace:target and its vars
+// as namespace.VAR, keeping the names c already has
+func mergeNamespaced(c *Config, namespace string, lib Config) {
+	if c.Targets == nil && len(lib.Targets) > 0 {
+		c.Targets = make(map[string]Target, len(lib.Targets))
+	}
+	for name, target := range lib.Targets {
+		full := namespace + ":" + name
+		if _, exists := c.Targets[full]; exists {
+			continue
+		}
+		// Deps on the library's own targets follow the rename
+		for i, dep := range target.Deps {
+			if _, local := lib.Targets[dep]; local {
+				target.Deps[i] = namespace + ":" + dep
+			}
+		}
+		target.namespace = namespace
+		c.Targets[full] = target
+	}
+
+	if c.Vars == nil && len(lib.Vars) > 0 {
+		c.Vars = make(map[string]Var, len(lib.Vars))
+	}
+	for name, val := range lib.Vars {
+		if _, exists := c.Vars[namespace+"."+name]; !exists {
+			c.Vars[namespace+"."+name] = val
+		}
+	}
+}
+
+// namespaceVars returns the vars of a namespace without their prefix, as
+// the targets of a prefixed include see them
+func namespaceVars(vars map[string]string, namespace string) map[string]string {
+	local := make(map[string]string)
+	for name, val := range vars {
+		if short, ok := strings.CutPrefix(name, namespace+"."); ok {
+			local[short] = val
+		}
+	}
+	return local
+}
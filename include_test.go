@@ -0,0 +1,239 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ===== INCLUDE.GO UNIT TESTS =====
+
+func TestIncludeUnmarshal(t *testing.T) {
+	var c Config
+	data := "include:\n  - common.yaml\n  - {path: lib/c.yaml, prefix: c}\n"
+	if err := yaml.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	expected := []Include{{Path: "common.yaml"}, {Path: "lib/c.yaml", Prefix: "c"}}
+	if !reflect.DeepEqual(c.Includes, expected) {
+		t.Errorf("Includes = %+v, expected %+v", c.Includes, expected)
+	}
+}
+
+func TestMergeInclude(t *testing.T) {
+	lib := `
+vars:
+  CC: gcc
+  OPT: "-O2"
+hooks:
+  post_build: ["echo done"]
+targets:
+  build:
+    deps: ["gen", "external", "file.txt"]
+    run: ["$CC $OPT -c main.c"]
+  gen:
+    run: ["echo gen"]
+`
+	c := Config{
+		Vars:    map[string]Var{"CC": "clang", "lib.OPT": "-O0"},
+		Targets: map[string]Target{"build": {Run: []string{"echo project"}}},
+	}
+	ignored, err := mergeInclude(&c, Include{Path: "lib.yaml", Prefix: "lib"}, []byte(lib))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ignored, []string{"hooks"}) {
+		t.Errorf("ignored = %v, expected [hooks]", ignored)
+	}
+	if len(c.Hooks.PostBuild) != 0 {
+		t.Errorf("hooks of a prefixed include should not be merged, got %v", c.Hooks.PostBuild)
+	}
+
+	if got := sortedKeys(c.Targets); !reflect.DeepEqual(got, []string{"build", "lib:build", "lib:gen"}) {
+		t.Errorf("targets = %v", got)
+	}
+	if run := c.Targets["build"].Run; run[0] != "echo project" {
+		t.Errorf("project target was replaced: %v", run)
+	}
+	built := c.Targets["lib:build"]
+	if expected := []string{"lib:gen", "external", "file.txt"}; !reflect.DeepEqual(built.Deps, expected) {
+		t.Errorf("deps = %v, expected %v", built.Deps, expected)
+	}
+	if built.namespace != "lib" {
+		t.Errorf("namespace = %q, expected lib", built.namespace)
+	}
+
+	expectedVars := map[string]Var{"CC": "clang", "lib.CC": "gcc", "lib.OPT": "-O0"}
+	if !reflect.DeepEqual(c.Vars, expectedVars) {
+		t.Errorf("vars = %v, expected %v (project values win)", c.Vars, expectedVars)
+	}
+
+	if _, err := mergeInclude(&c, Include{Path: "lib.yaml", Prefix: "bad prefix"}, []byte(lib)); err == nil {
+		t.Error("mergeInclude() should reject an invalid prefix")
+	}
+
+	// Without a prefix the file is merged as is
+	plain := Config{}
+	if _, err := mergeInclude(&plain, Include{Path: "lib.yaml"}, []byte(lib)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := plain.Targets["build"]; !ok || len(plain.Hooks.PostBuild) != 1 {
+		t.Errorf("plain include should merge everything, got targets %v", sortedKeys(plain.Targets))
+	}
+}
+
+func TestLoadConfigPrefixedInclude(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"lib.yaml": `
+vars:
+  CC: gcc
+  FLAGS: "${CC} -Wall"
+targets:
+  build:
+    vars:
+      MODE: debug
+    run: ["$CC $MODE"]
+`,
+		"aura.yaml": `
+include:
+  - {path: lib.yaml, prefix: lib}
+vars:
+  CC: clang
+  OUT: app
+targets:
+  build:
+    deps: ["lib:build"]
+    run: ["$CC $lib.CC ${lib.FLAGS} $OUT.txt"]
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := loadConfig(filepath.Join(dir, "aura.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target   string
+		command  string
+		expected string
+	}{
+		{"build", cfg.Targets["build"].Run[0], "clang gcc gcc -Wall app.txt"},
+		{"lib:build", cfg.Targets["lib:build"].Run[0], "gcc debug"},
+		{"lib:build", "$FLAGS", "gcc -Wall"},
+	}
+	for _, tt := range tests {
+		if got := ParseVars(tt.command, tt.target); got != tt.expected {
+			t.Errorf("ParseVars(%q, %s) = %q, expected %q", tt.command, tt.target, got, tt.expected)
+		}
+	}
+
+	if targets := peekTargets(filepath.Join(dir, "aura.yaml")); !strings.Contains(strings.Join(sortedKeys(targets), ","), "lib:build") {
+		t.Errorf("peekTargets() = %v, expected the prefixed lib:build", sortedKeys(targets))
+	}
+}
+
+func TestIncludeWhenMatches(t *testing.T) {
+	originalProfile := activeProfile
+	defer func() { activeProfile = originalProfile }()
+	activeProfile = "ci"
+	t.Setenv("AURA_TEST_FLAG", "on")
+	t.Setenv("AURA_TEST_EMPTY", "")
+
+	other := "windows"
+	if runtime.GOOS == "windows" {
+		other = "linux"
+	}
+
+	tests := []struct {
+		name     string
+		when     IncludeWhen
+		expected bool
+		errMsg   string
+	}{
+		{"no conditions", IncludeWhen{}, true, ""},
+		{"current os", IncludeWhen{OS: stringList{runtime.GOOS}}, true, ""},
+		{"current os/arch", IncludeWhen{OS: stringList{runtime.GOOS + "/" + runtime.GOARCH}}, true, ""},
+		{"other os", IncludeWhen{OS: stringList{other}}, false, ""},
+		{"any of several", IncludeWhen{OS: stringList{other, runtime.GOOS}}, true, ""},
+		{"unknown os", IncludeWhen{OS: stringList{"beos"}}, false, "unknown platform"},
+		{"active profile", IncludeWhen{Profile: stringList{"dev", "ci"}}, true, ""},
+		{"other profile", IncludeWhen{Profile: stringList{"release"}}, false, ""},
+		{"env set", IncludeWhen{Env: stringList{"AURA_TEST_FLAG"}}, true, ""},
+		{"env empty", IncludeWhen{Env: stringList{"AURA_TEST_EMPTY"}}, false, ""},
+		{"env value", IncludeWhen{Env: stringList{"AURA_TEST_FLAG=on"}}, true, ""},
+		{"env other value", IncludeWhen{Env: stringList{"AURA_TEST_FLAG=off"}}, false, ""},
+		{"all must hold", IncludeWhen{OS: stringList{runtime.GOOS}, Profile: stringList{"release"}}, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.when.matches()
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("matches() error = %v, expected %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("matches() = %v, %v; expected %v", got, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadConfigConditionalInclude(t *testing.T) {
+	original, originalProfile := cfg, activeProfile
+	defer func() { cfg, activeProfile = original, originalProfile }()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"native.yaml":  "targets:\n  native:\n    run: [\"echo native\"]\n",
+		"foreign.yaml": "targets:\n  foreign:\n    run: [\"echo foreign\"]\n",
+		"ci.yaml":      "targets:\n  publish:\n    run: [\"echo publish\"]\n",
+		"aura.yaml": `
+include:
+  - {path: native.yaml, when: {os: ` + runtime.GOOS + `}}
+  - {path: foreign.yaml, when: {os: [plan9]}}
+  - {path: ci.yaml, when: {profile: ci}}
+profiles:
+  ci: {}
+targets:
+  build:
+    run: ["echo build"]
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		profile  string
+		expected []string
+	}{
+		{"", []string{"build", "native"}},
+		{"ci", []string{"build", "native", "publish"}},
+	} {
+		cfg = Config{}
+		if err := loadConfigProfile(filepath.Join(dir, "aura.yaml"), tt.profile); err != nil {
+			t.Fatal(err)
+		}
+		if got := sortedKeys(cfg.Targets); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("profile %q: targets = %v, expected %v", tt.profile, got, tt.expected)
+		}
+	}
+}
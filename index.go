@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// targetIndexVersion changes whenever the index layout does
+const targetIndexVersion = 1
+
+// targetIndex keeps what list and query need from a config, so large
+// generated configs are not parsed again until one of their files changes
+type targetIndex struct {
+	Version int                      `json:"version"`
+	Files   []indexedFile            `json:"files"`
+	Env     map[string]string        `json:"env,omitempty"`
+	Targets map[string]indexedTarget `json:"targets"`
+}
+
+// indexedFile is a config file the index was built from; Size is -1 for
+// a file that did not exist, such as a missing include
+type indexedFile struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+}
+
+// indexedTarget is the part of a target list and query show
+type indexedTarget struct {
+	Desc      string   `json:"desc,omitempty"`
+	Deps      []string `json:"deps,omitempty"`
+	Commands  int      `json:"commands,omitempty"`
+	Dangerous bool     `json:"dangerous,omitempty"`
+}
+
+// targetIndexPath returns where the index of a config is kept for a
+// profile. It is found before the config is read, so cache.path is not
+// considered
+func targetIndexPath(configPath, profile string) (string, bool) {
+	dir := cacheDirFlag
+	if dir == "" {
+		dir = os.Getenv("AURA_CACHE_DIR")
+	}
+	if dir == "" {
+		base, ok := userCacheBase()
+		if !ok {
+			return "", false
+		}
+		dir = base
+	}
+	sum := sha256.Sum256([]byte(configPath + "\x00" + profile + "\x00" + runtime.GOOS + "/" + runtime.GOARCH))
+	return filepath.Join(dir, "index", hex.EncodeToString(sum[:])[:16]+".json"), true
+}
+
+// statIndexedFile records the current state of a config file
+func statIndexedFile(path string) indexedFile {
+	info, err := os.Stat(path)
+	if err != nil {
+		return indexedFile{Path: path, Size: -1}
+	}
+	return indexedFile{Path: path, Size: info.Size(), ModTime: info.ModTime().UnixNano()}
+}
+
+// current reports whether the files and environment the index was built
+// from are unchanged
+func (idx *targetIndex) current() bool {
+	if idx.Version != targetIndexVersion || len(idx.Files) == 0 {
+		return false
+	}
+	for _, file := range idx.Files {
+		if statIndexedFile(file.Path) != file {
+			return false
+		}
+	}
+	for name, value := range idx.Env {
+		if os.Getenv(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// targets returns the indexed targets; Run holds as many empty commands as
+// the target has, for the counts list shows
+func (idx *targetIndex) targets() map[string]Target {
+	targets := make(map[string]Target, len(idx.Targets))
+	for name, t := range idx.Targets {
+		targets[name] = Target{Desc: t.Desc, Deps: t.Deps, Run: make([]string, t.Commands), Dangerous: t.Dangerous}
+	}
+	return targets
+}
+
+// newTargetIndex indexes the loaded config, read from files
+func newTargetIndex(files []indexedFile) *targetIndex {
+	idx := &targetIndex{Version: targetIndexVersion, Files: files, Targets: make(map[string]indexedTarget, len(cfg.Targets))}
+	for name, t := range cfg.Targets {
+		idx.Targets[name] = indexedTarget{Desc: t.Desc, Deps: t.Deps, Commands: len(t.Run), Dangerous: t.Dangerous}
+	}
+	// Includes conditioned on the environment change the targets with it
+	for _, inc := range cfg.Includes {
+		for _, cond := range inc.When.Env {
+			name, _, _ := strings.Cut(cond, "=")
+			if idx.Env == nil {
+				idx.Env = map[string]string{}
+			}
+			idx.Env[name] = os.Getenv(name)
+		}
+	}
+	return idx
+}
+
+// readTargetIndex reads an index written by writeTargetIndex
+func readTargetIndex(path string) (*targetIndex, error) {
+	// #nosec G304 - Path derived from the cache directory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var idx targetIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, err
+	}
+	return &idx, nil
+}
+
+// writeTargetIndex stores an index, replacing the previous one at once so
+// concurrent readers never see half of it
+func writeTargetIndex(path string, idx *targetIndex) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".index-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadTargets loads only what list and query need, the targets with their
+// descriptions and deps: from the index when the config files are
+// unchanged since it was written, else from the config, refreshing the
+// index. The rest of cfg stays empty when the index is used. A .go or .cue
+// config is evaluated every time: what its output depends on is unknown
+func loadTargets(configPath, profile string) error {
+	if configFrontend(configPath) != nil {
+		return loadConfigProfile(configPath, profile)
+	}
+	if !filepath.IsAbs(configPath) {
+		wd, _ := os.Getwd()
+		configPath = filepath.Join(wd, configPath)
+	}
+	configPath = filepath.Clean(configPath)
+
+	indexPath, indexed := targetIndexPath(configPath, profile)
+	if indexed {
+		if idx, err := readTargetIndex(indexPath); err == nil && idx.current() {
+			cfg = Config{Targets: idx.targets()}
+			activeProfile = profile
+			return nil
+		}
+	}
+
+	// Files are recorded before they are read, so a change made meanwhile
+	// invalidates the index
+	var files []indexedFile
+	readFile := func(path string) ([]byte, error) {
+		files = append(files, statIndexedFile(path))
+		// #nosec G304 - Paths validated by loadConfigFrom
+		return os.ReadFile(path)
+	}
+	activeProfile = profile
+	if err := loadConfigFrom(configPath, readFile); err != nil {
+		return err
+	}
+	if err := selectProfile(profile); err != nil {
+		return err
+	}
+	if indexed {
+		_ = writeTargetIndex(indexPath, newTargetIndex(files))
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// ===== INDEX.GO UNIT TESTS =====
+
+func TestLoadTargetsIndex(t *testing.T) {
+	original, originalProfile := cfg, activeProfile
+	defer func() { cfg, activeProfile = original, originalProfile }()
+	chdirTemp(t)
+	t.Setenv("AURA_CACHE_DIR", t.TempDir())
+	writeFiles(t, map[string]string{
+		"extra.yaml": "targets:\n  extra:\n    run: [\"echo extra\"]\n",
+		"aura.yaml": `
+include:
+  - extra.yaml
+  - {path: ci.yaml, when: {env: AURA_TEST_CI}}
+vars:
+  X: "1"
+targets:
+  build:
+    desc: Build it
+    deps: ["extra", "main.c"]
+    run: ["cc main.c", "strip a.out"]
+`,
+	})
+
+	load := func() {
+		t.Helper()
+		cfg = Config{}
+		if err := loadTargets("aura.yaml", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first load parses the config, the second uses the index
+	load()
+	if cfg.Vars["X"] != "1" {
+		t.Fatal("first load should parse the config")
+	}
+	load()
+	if cfg.Vars != nil {
+		t.Error("second load should use the index")
+	}
+	build := cfg.Targets["build"]
+	if build.Desc != "Build it" || len(build.Run) != 2 || !reflect.DeepEqual(build.Deps, []string{"extra", "main.c"}) {
+		t.Errorf("indexed build = %+v", build)
+	}
+	if got := sortedKeys(cfg.Targets); !reflect.DeepEqual(got, []string{"build", "extra"}) {
+		t.Errorf("indexed targets = %v", got)
+	}
+
+	// A changed include, a new file and an env condition each invalidate it
+	changes := []struct {
+		name   string
+		change func()
+		target string
+	}{
+		{"include changed", func() {
+			writeFiles(t, map[string]string{"extra.yaml": "targets:\n  extra2:\n    run: [\"echo extra\"]\n"})
+			future := time.Now().Add(time.Hour)
+			_ = os.Chtimes("extra.yaml", future, future)
+		}, "extra2"},
+		{"missing include created", func() {
+			writeFiles(t, map[string]string{"ci.yaml": "targets:\n  publish:\n    run: [\"echo publish\"]\n"})
+			t.Setenv("AURA_TEST_CI", "1")
+		}, "publish"},
+	}
+	for _, tt := range changes {
+		tt.change()
+		load()
+		if cfg.Vars == nil {
+			t.Errorf("%s: expected the config to be parsed again", tt.name)
+		}
+		if _, ok := cfg.Targets[tt.target]; !ok {
+			t.Errorf("%s: targets = %v, expected %s", tt.name, sortedKeys(cfg.Targets), tt.target)
+		}
+		load()
+		if cfg.Vars != nil {
+			t.Errorf("%s: expected the refreshed index to be used", tt.name)
+		}
+	}
+}
+
+func TestLoadTargetsGoConfig(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("needs the Go toolchain")
+	}
+	original, originalProfile := cfg, activeProfile
+	defer func() { cfg, activeProfile = original, originalProfile }()
+	chdirTemp(t)
+	t.Setenv("AURA_CACHE_DIR", t.TempDir())
+	writeFiles(t, map[string]string{
+		"aura.go": `//go:build ignore
+
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Print("targets:\n  hello:\n    desc: Say hi\n    run: [\"echo hi\"]\n")
+}
+`,
+	})
+
+	// list and query evaluate the program, as build does
+	for range 2 {
+		cfg = Config{}
+		if err := loadTargets("aura.go", ""); err != nil {
+			t.Fatalf("loadTargets() error = %v", err)
+		}
+		if hello := cfg.Targets["hello"]; hello.Desc != "Say hi" || len(hello.Run) != 1 {
+			t.Errorf("cfg.Targets = %v, expected the printed target", cfg.Targets)
+		}
+	}
+}
+
+func TestLoadTargetsCueConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell script as cue")
+	}
+	original, originalProfile := cfg, activeProfile
+	defer func() { cfg, activeProfile = original, originalProfile }()
+	chdirTemp(t)
+	t.Setenv("AURA_CACHE_DIR", t.TempDir())
+	writeFiles(t, map[string]string{
+		"bin/cue":  "#!/bin/sh\nprintf 'targets:\\n  build-api:\\n    deps: [gen]\\n    run: [\"go build ./api\"]\\n  gen:\\n    run: [\"go generate\"]\\n'\n",
+		"aura.cue": "targets: {}\n",
+	})
+	if err := os.Chmod("bin/cue", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	t.Setenv("PATH", filepath.Join(wd, "bin")+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg = Config{}
+	if err := loadTargets("aura.cue", ""); err != nil {
+		t.Fatalf("loadTargets() error = %v", err)
+	}
+	if got := sortedKeys(cfg.Targets); !reflect.DeepEqual(got, []string{"build-api", "gen"}) {
+		t.Errorf("cfg.Targets = %v, expected the exported targets", got)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// inferredProjects are the project types `aura build --auto` recognises, by
+// marker file, in order of preference
+var inferredProjects = []struct {
+	Marker string
+	Kind   string
+	Config string
+}{
+	{"go.mod", "Go", `targets:
+  build:
+    desc: "Build all packages"
+    run:
+      - "go build ./..."
+  test:
+    desc: "Run all tests"
+    run:
+      - "go test ./..."
+  clean:
+    desc: "Remove build artifacts"
+    run:
+      - "go clean ./..."
+`},
+	{"Cargo.toml", "Rust", `targets:
+  build:
+    desc: "Build the crate"
+    run:
+      - "cargo build"
+  test:
+    desc: "Run all tests"
+    run:
+      - "cargo test"
+  clean:
+    desc: "Remove the target directory"
+    run:
+      - "cargo clean"
+`},
+	{"package.json", "Node.js", `targets:
+  install:
+    desc: "Install dependencies"
+    run:
+      - "npm install"
+  build:
+    desc: "Run the build script, if any"
+    deps:
+      - install
+    run:
+      - "npm run build --if-present"
+  test:
+    desc: "Run the test script"
+    deps:
+      - install
+    run:
+      - "npm test"
+  clean:
+    desc: "Remove node_modules"
+    run:
+      - "node -e \"require('fs').rmSync('node_modules', {recursive: true, force: true})\""
+`},
+	{"CMakeLists.txt", "CMake", `targets:
+  configure:
+    desc: "Generate the build tree in build/"
+    run:
+      - "cmake -S . -B build"
+  build:
+    desc: "Build the project"
+    deps:
+      - configure
+    run:
+      - "cmake --build build"
+  test:
+    desc: "Run the tests with ctest"
+    deps:
+      - build
+    run:
+      - "ctest --test-dir build"
+  clean:
+    desc: "Remove the build tree"
+    run:
+      - "cmake -E rm -rf build"
+`},
+}
+
+// inferConfig synthesizes a configuration from the marker files in the
+// current directory
+func inferConfig() (kind, config string, ok bool) {
+	for _, project := range inferredProjects {
+		if _, err := os.Stat(project.Marker); err == nil {
+			return project.Kind, project.Config, true
+		}
+	}
+	return "", "", false
+}
+
+// loadAutoConfig loads configFile, or when it does not exist, a config
+// inferred from the project, offering to save it
+func loadAutoConfig(configFile string, in io.Reader, interactive bool) error {
+	if _, err := os.Stat(configFile); !errors.Is(err, os.ErrNotExist) {
+		return loadConfig(configFile)
+	}
+
+	kind, config, ok := inferConfig()
+	if !ok {
+		return orpheus.NotFoundError("config", fmt.Sprintf("no %s and no go.mod, Cargo.toml, package.json or CMakeLists.txt to infer targets from", configFile))
+	}
+
+	fmt.Printf("No %s found; inferred %s project:\n\n%s\n", configFile, kind, config)
+	if err := loadConfigFrom(configFile, func(string) ([]byte, error) { return []byte(config), nil }); err != nil {
+		return err
+	}
+
+	if interactive {
+		fmt.Printf("Save it as %s? [y/N] ", configFile)
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer == "y" || answer == "yes" {
+			if err := os.WriteFile(configFile, []byte(config), 0600); err != nil {
+				return orpheus.ExecutionError("auto", fmt.Sprintf("cannot save %s: %v", configFile, err))
+			}
+			fmt.Printf("%s Created %s\n", checkMark(), configFile)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ===== INFER.GO UNIT TESTS =====
+
+func TestInferredProjectsParse(t *testing.T) {
+	for _, project := range inferredProjects {
+		var config Config
+		if err := yaml.Unmarshal([]byte(project.Config), &config); err != nil {
+			t.Errorf("%s config does not parse: %v", project.Kind, err)
+			continue
+		}
+		for _, name := range []string{"build", "test", "clean"} {
+			if len(config.Targets[name].Run) == 0 {
+				t.Errorf("%s config has no %s target", project.Kind, name)
+			}
+		}
+	}
+}
+
+func TestInferConfig(t *testing.T) {
+	chdirTemp(t)
+
+	if _, _, ok := inferConfig(); ok {
+		t.Error("inferConfig() should find nothing in an empty directory")
+	}
+
+	writeFiles(t, map[string]string{"package.json": "{}", "go.mod": "module x\n"})
+	if kind, _, ok := inferConfig(); !ok || kind != "Go" {
+		t.Errorf("inferConfig() = %q, %t, expected Go to win", kind, ok)
+	}
+}
+
+func TestLoadAutoConfig(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	if err := loadAutoConfig("aura.yaml", strings.NewReader(""), false); err == nil {
+		t.Error("loadAutoConfig() should fail without config or marker files")
+	}
+
+	writeFiles(t, map[string]string{"Cargo.toml": "[package]\n"})
+	cfg = Config{}
+	if err := loadAutoConfig("aura.yaml", strings.NewReader("y\n"), true); err != nil {
+		t.Fatalf("loadAutoConfig() error: %v", err)
+	}
+	if cfg.Targets["build"].Run[0] != "cargo build" {
+		t.Errorf("loadAutoConfig() loaded %+v", cfg.Targets["build"])
+	}
+	if _, err := os.Stat("aura.yaml"); err != nil {
+		t.Errorf("confirmed inferred config should be saved: %v", err)
+	}
+
+	// An existing config wins over inference
+	writeFiles(t, map[string]string{"aura.yaml": "targets:\n  build:\n    run: [\"make\"]\n"})
+	cfg = Config{}
+	if err := loadAutoConfig("aura.yaml", strings.NewReader(""), false); err != nil {
+		t.Fatalf("loadAutoConfig() error: %v", err)
+	}
+	if cfg.Targets["build"].Run[0] != "make" {
+		t.Errorf("existing config should be loaded, got %+v", cfg.Targets["build"])
+	}
+}
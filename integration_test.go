@@ -202,7 +202,7 @@ epilogue:
 func TestE2ETemplateGeneration(t *testing.T) {
 	tempDir := t.TempDir()
 
-	templates := []string{"go", "rust", "node", "basic"}
+	templates := []string{"go", "rust", "node", "python", "java", "basic", "cmake"}
 
 	for _, tmpl := range templates {
 		t.Run("Template_"+tmpl, func(t *testing.T) {
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// jobServer implements the GNU make jobserver protocol. Tokens are single
+// bytes in a pipe (or fifo) shared by every cooperating process; a job reads
+// one before starting and writes it back when done. Each process also owns
+// one implicit token it never has to read.
+//
+// As a client (aura run from make) it borrows make's pool; as a host (aura
+// running make) it creates the pool and advertises it to child processes
+// through MAKEFLAGS, so nested builds share a single -j budget.
+type jobServer struct {
+	implicit chan struct{}
+	r, w     *os.File
+	jobs     int        // pool size advertised as -jN, 0 when unknown
+	auth     string     // --jobserver-auth value for child processes
+	extra    []*os.File // files child processes inherit as fds 3 and 4
+	client   bool
+}
+
+// jobserver is the active jobserver, nil when none is in use
+var jobserver *jobServer
+
+// jobToken is one right to run a job
+type jobToken struct {
+	implicit bool
+	b        byte
+}
+
+func newJobServer(r, w *os.File) *jobServer {
+	js := &jobServer{implicit: make(chan struct{}, 1), r: r, w: w}
+	js.implicit <- struct{}{}
+	return js
+}
+
+// acquire blocks until a token is available
+func (js *jobServer) acquire() (jobToken, error) {
+	select {
+	case <-js.implicit:
+		return jobToken{implicit: true}, nil
+	default:
+	}
+
+	buf := make([]byte, 1)
+	if _, err := js.r.Read(buf); err != nil {
+		return jobToken{}, err
+	}
+	return jobToken{b: buf[0]}, nil
+}
+
+// release returns a token to the pool it came from
+func (js *jobServer) release(t jobToken) {
+	if t.implicit {
+		js.implicit <- struct{}{}
+		return
+	}
+	// make uses the token value to signal errors, so hand back the same byte
+	if _, err := js.w.Write([]byte{t.b}); err != nil {
+		logWarn("jobserver token lost: %v", err)
+	}
+}
+
+// childEnv returns env with MAKEFLAGS pointing child makes at the pool
+func (js *jobServer) childEnv(env []string) []string {
+	flags := "--jobserver-auth=" + js.auth
+	if js.jobs > 0 {
+		flags = "-j" + strconv.Itoa(js.jobs) + " " + flags
+	}
+
+	for i, kv := range env {
+		if existing, ok := strings.CutPrefix(kv, "MAKEFLAGS="); ok {
+			env[i] = "MAKEFLAGS=" + joinMakeflags(flags, existing)
+			return env
+		}
+	}
+	return append(env, "MAKEFLAGS="+flags)
+}
+
+// joinMakeflags puts jobserver flags in front of existing MAKEFLAGS, dropping
+// any previous -j/--jobserver options. A leading word of bare single-letter
+// flags ("ks") only parses in first position, so it gains a dash.
+func joinMakeflags(flags, existing string) string {
+	words := []string{flags}
+	for i, word := range strings.Fields(existing) {
+		switch {
+		case strings.HasPrefix(word, "-j"), strings.HasPrefix(word, "--jobserver"):
+			continue
+		case i == 0 && !strings.HasPrefix(word, "-") && !strings.Contains(word, "="):
+			word = "-" + word
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+// parseJobserverAuth extracts the jobserver from MAKEFLAGS: "fifo:PATH" or
+// "R,W" file descriptors (--jobserver-fds is the pre-4.2 spelling)
+func parseJobserverAuth(makeflags string) (auth string, jobs int) {
+	for _, word := range strings.Fields(makeflags) {
+		if value, ok := strings.CutPrefix(word, "--jobserver-auth="); ok {
+			auth = value
+		} else if value, ok := strings.CutPrefix(word, "--jobserver-fds="); ok {
+			auth = value
+		} else if value, ok := strings.CutPrefix(word, "-j"); ok {
+			jobs, _ = strconv.Atoi(value)
+		}
+	}
+	return auth, jobs
+}
+
+// setupJobserver joins make's jobserver when aura runs under make, or hosts
+// one for child processes when running more than one job
+func setupJobserver(parallel int) error {
+	auth, jobs := parseJobserverAuth(os.Getenv("MAKEFLAGS"))
+	if auth != "" {
+		js, err := openJobserver(auth)
+		if err != nil {
+			// make prints the same warning and carries on serially
+			logWarn("jobserver unavailable (%v), running without it", err)
+			return nil
+		}
+		js.jobs = jobs
+		jobserver = js
+		return nil
+	}
+
+	if parallel > 1 {
+		js, err := hostJobserver(parallel)
+		if err != nil {
+			return err
+		}
+		jobserver = js
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+// make on Windows uses a named semaphore instead of a pipe; aura does not
+// speak that variant and falls back to its own -p limit
+
+func openJobserver(auth string) (*jobServer, error) {
+	return nil, errors.New("jobserver is not supported on this platform")
+}
+
+func hostJobserver(jobs int) (*jobServer, error) {
+	return nil, nil
+}
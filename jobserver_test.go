@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// ===== JOBSERVER.GO UNIT TESTS =====
+
+func TestParseJobserverAuth(t *testing.T) {
+	tests := []struct {
+		makeflags string
+		auth      string
+		jobs      int
+	}{
+		{"", "", 0},
+		{"s", "", 0},
+		{" -j4 --jobserver-auth=3,4", "3,4", 4},
+		{"ks -j8 --jobserver-auth=fifo:/tmp/GMfifo123 -- CC=gcc", "fifo:/tmp/GMfifo123", 8},
+		{"--jobserver-fds=5,6 -j", "5,6", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.makeflags, func(t *testing.T) {
+			auth, jobs := parseJobserverAuth(tt.makeflags)
+			if auth != tt.auth || jobs != tt.jobs {
+				t.Errorf("parseJobserverAuth(%q) = %q, %d; want %q, %d", tt.makeflags, auth, jobs, tt.auth, tt.jobs)
+			}
+		})
+	}
+}
+
+func TestJoinMakeflags(t *testing.T) {
+	tests := []struct {
+		existing string
+		expected string
+	}{
+		{"", "-j4 --jobserver-auth=3,4"},
+		{"s", "-j4 --jobserver-auth=3,4 -s"},
+		{"-j2 --jobserver-auth=7,8 -k", "-j4 --jobserver-auth=3,4 -k"},
+		{"s -- CC=clang", "-j4 --jobserver-auth=3,4 -s -- CC=clang"},
+	}
+
+	for _, tt := range tests {
+		if got := joinMakeflags("-j4 --jobserver-auth=3,4", tt.existing); got != tt.expected {
+			t.Errorf("joinMakeflags(%q) = %q, want %q", tt.existing, got, tt.expected)
+		}
+	}
+}
+
+func TestJobServerChildEnv(t *testing.T) {
+	js := &jobServer{jobs: 3, auth: "3,4"}
+
+	env := js.childEnv([]string{"HOME=/home/user", "MAKEFLAGS=s"})
+	if env[1] != "MAKEFLAGS=-j3 --jobserver-auth=3,4 -s" {
+		t.Errorf("childEnv() MAKEFLAGS = %q", env[1])
+	}
+
+	env = js.childEnv([]string{"HOME=/home/user"})
+	if len(env) != 2 || env[1] != "MAKEFLAGS=-j3 --jobserver-auth=3,4" {
+		t.Errorf("childEnv() without MAKEFLAGS = %v", env)
+	}
+}
+
+func TestHostJobserverTokens(t *testing.T) {
+	js, err := hostJobserver(3)
+	if err != nil {
+		t.Fatalf("hostJobserver() unexpected error: %v", err)
+	}
+	if js == nil {
+		t.Skip("jobserver not supported on this platform")
+	}
+	defer func() { _ = js.r.Close(); _ = js.w.Close() }()
+
+	// The implicit token plus jobs-1 from the pipe
+	var tokens []jobToken
+	for range 3 {
+		token, err := js.acquire()
+		if err != nil {
+			t.Fatalf("acquire() unexpected error: %v", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if !tokens[0].implicit || tokens[1].implicit || tokens[1].b != '+' {
+		t.Errorf("acquire() tokens = %+v, want implicit first then '+' bytes", tokens)
+	}
+
+	for _, token := range tokens {
+		js.release(token)
+	}
+	if token, err := js.acquire(); err != nil || !token.implicit {
+		t.Errorf("acquire() after release = %+v, %v; want the implicit token back", token, err)
+	}
+	if !strings.Contains(strings.Join(js.childEnv(nil), " "), "--jobserver-auth=3,4") {
+		t.Errorf("host jobserver does not advertise fds 3,4")
+	}
+}
@@ -0,0 +1,94 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// inheritedJobserverErr says why the jobserver fds in MAKEFLAGS cannot be
+// used. They are checked before aura opens any file of its own, which could
+// otherwise get the same numbers when make did not pass them down
+var (
+	inheritedJobserverAuth string
+	inheritedJobserverErr  error
+)
+
+func init() {
+	auth, _ := parseJobserverAuth(os.Getenv("MAKEFLAGS"))
+	if auth != "" && !strings.HasPrefix(auth, "fifo:") {
+		inheritedJobserverAuth, inheritedJobserverErr = auth, checkJobserverFds(auth)
+	}
+}
+
+// checkJobserverFds checks that both fds of an "R,W" auth are open pipes;
+// fstat fails on a closed fd as F_GETFD does, and tells pipes from files
+func checkJobserverFds(auth string) error {
+	rfd, wfd, ok := strings.Cut(auth, ",")
+	r, rerr := strconv.Atoi(rfd)
+	w, werr := strconv.Atoi(wfd)
+	if !ok || rerr != nil || werr != nil || r < 0 || w < 0 {
+		return fmt.Errorf("invalid jobserver auth %q", auth)
+	}
+	for _, fd := range []int{r, w} {
+		var st syscall.Stat_t
+		if err := syscall.Fstat(fd, &st); err != nil {
+			return fmt.Errorf("jobserver fd %d is not open: %v", fd, err)
+		}
+		if uint32(st.Mode)&syscall.S_IFMT != syscall.S_IFIFO {
+			return fmt.Errorf("jobserver fd %d is not a pipe", fd)
+		}
+	}
+	return nil
+}
+
+// openJobserver connects to the pool described by a --jobserver-auth value
+func openJobserver(auth string) (*jobServer, error) {
+	if path, ok := strings.CutPrefix(auth, "fifo:"); ok {
+		// #nosec G304 - Path is the jobserver fifo handed to us by make
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			return nil, err
+		}
+		js := newJobServer(f, f)
+		js.client, js.auth = true, auth
+		return js, nil
+	}
+
+	if auth == inheritedJobserverAuth && inheritedJobserverErr != nil {
+		return nil, inheritedJobserverErr
+	}
+	if err := checkJobserverFds(auth); err != nil {
+		return nil, err
+	}
+	r, w, _ := strings.Cut(auth, ",")
+	rfd, _ := strconv.Atoi(r)
+	wfd, _ := strconv.Atoi(w)
+	rf, wf := os.NewFile(uintptr(rfd), "jobserver-r"), os.NewFile(uintptr(wfd), "jobserver-w")
+
+	js := newJobServer(rf, wf)
+	js.client = true
+	js.auth, js.extra = "3,4", []*os.File{rf, wf}
+	return js, nil
+}
+
+// hostJobserver creates a pool for jobs parallel jobs; aura keeps the
+// implicit token and puts the other jobs-1 into the pipe
+func hostJobserver(jobs int) (*jobServer, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write([]byte(strings.Repeat("+", jobs-1))); err != nil {
+		return nil, err
+	}
+
+	js := newJobServer(r, w)
+	js.jobs = jobs
+	js.auth, js.extra = "3,4", []*os.File{r, w}
+	return js, nil
+}
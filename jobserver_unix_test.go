@@ -0,0 +1,56 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// ===== JOBSERVER_UNIX.GO UNIT TESTS =====
+
+func TestCheckJobserverFds(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close(); _ = w.Close() }()
+	file, err := os.Create(t.TempDir() + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = file.Close() }()
+	closed, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedFd := closed.Fd()
+	_ = closed.Close()
+
+	tests := []struct {
+		name    string
+		auth    string
+		wantErr string
+	}{
+		{"pipe", fmt.Sprintf("%d,%d", r.Fd(), w.Fd()), ""},
+		{"file", fmt.Sprintf("%d,%d", r.Fd(), file.Fd()), "is not a pipe"},
+		{"closed", fmt.Sprintf("%d,%d", closedFd, w.Fd()), "is not open"},
+		{"invalid", "3", "invalid jobserver auth"},
+	}
+	for _, tt := range tests {
+		err := checkJobserverFds(tt.auth)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: checkJobserverFds() error = %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: checkJobserverFds() error = %v, expected %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// fds make did not pass down are never used, whatever aura opens later
+	if _, err := openJobserver(fmt.Sprintf("%d,%d", r.Fd(), file.Fd())); err == nil {
+		t.Errorf("openJobserver() on a regular file expected an error")
+	}
+}
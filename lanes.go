@@ -0,0 +1,176 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// unknownDuration is assumed for targets that never ran
+const unknownDuration = time.Second
+
+// laneSlot is a target placed by simulateLanes: the lane it runs in and
+// when. Estimated marks targets without history, whose duration is assumed
+type laneSlot struct {
+	name       string
+	lane       int
+	start, end time.Duration
+	estimated  bool
+}
+
+// estimateDuration predicts how long a target takes: the median of its
+// successful runs, else its last run, else unknownDuration
+func estimateDuration(runs []runRecord) (time.Duration, bool) {
+	if d, ok := medianDuration(runs); ok {
+		return d, false
+	}
+	if len(runs) > 0 {
+		return runs[len(runs)-1].Duration, false
+	}
+	return unknownDuration, true
+}
+
+// simulateLanes replays the parallel scheduler for the requested targets
+// with the given number of lanes (-p) and historical durations. Like a
+// real run, a target starts once its deps finished and its mutex is free,
+// in the order runTargetsParallel starts them, taking the lowest free lane
+func simulateLanes(names []string, lanes int, history runHistory) ([]laneSlot, error) {
+	requested, err := buildSchedule(names)
+	if err != nil {
+		return nil, err
+	}
+
+	order := scheduleOrder(requested)
+	slots := make([]laneSlot, len(order))
+	placed := make([]bool, len(order))
+	finished := map[*schedNode]bool{}
+	busy := make([]*schedNode, lanes)
+	ends := make([]time.Duration, lanes)
+	mutexes := map[string]bool{}
+	var now time.Duration
+
+	for remaining := len(order); remaining > 0; {
+		for i, node := range order {
+			waiting := slices.ContainsFunc(node.deps, func(dep *schedNode) bool { return !finished[dep] })
+			if placed[i] || waiting || mutexes[node.target.Mutex] {
+				continue
+			}
+			lane := slices.Index(busy, nil)
+			if lane < 0 {
+				break
+			}
+			d, estimated := estimateDuration(history[node.name])
+			slots[i] = laneSlot{name: node.name, lane: lane, start: now, end: now + d, estimated: estimated}
+			placed[i] = true
+			busy[lane] = node
+			ends[lane] = now + d
+			if node.target.Mutex != "" {
+				mutexes[node.target.Mutex] = true
+			}
+		}
+
+		// Advance to the next target finishing and free its lane
+		next := -1
+		for lane, node := range busy {
+			if node != nil && (next < 0 || ends[lane] < ends[next]) {
+				next = lane
+			}
+		}
+		node := busy[next]
+		now = ends[next]
+		finished[node] = true
+		delete(mutexes, node.target.Mutex)
+		busy[next] = nil
+		remaining--
+	}
+	return slots, nil
+}
+
+// renderLanes draws slots as a text Gantt chart width columns wide, one
+// row per lane, each target a bar starting with '|' and its name
+func renderLanes(slots []laneSlot, lanes, width int) []string {
+	var total time.Duration
+	for _, slot := range slots {
+		total = max(total, slot.end)
+	}
+	column := func(d time.Duration) int {
+		if total == 0 {
+			return 0
+		}
+		return int(int64(d) * int64(width) / int64(total))
+	}
+
+	rows := make([][]rune, lanes)
+	for i := range rows {
+		rows[i] = []rune(strings.Repeat(" ", width))
+	}
+	for _, slot := range slots {
+		from, to := column(slot.start), max(column(slot.end), column(slot.start)+1)
+		bar := []rune("|" + slot.name + strings.Repeat("=", max(0, to-from)))
+		for c := from; c < to && c < width; c++ {
+			rows[slot.lane][c] = bar[c-from]
+		}
+	}
+
+	axis := []rune(strings.Repeat(" ", width+1))
+	for _, tick := range []int{0, 1, 2, 3, 4} {
+		label := []rune(formatLaneTime(total * time.Duration(tick) / 4))
+		at := min(tick*width/4, width+1-len(label))
+		copy(axis[max(0, at):], label)
+	}
+
+	lines := []string{fmt.Sprintf("%-8s %s", "", strings.TrimRight(string(axis), " "))}
+	for lane, row := range rows {
+		lines = append(lines, strings.TrimRight(fmt.Sprintf("%-8s %s", fmt.Sprintf("lane %d", lane+1), string(row)), " "))
+	}
+	return lines
+}
+
+// formatLaneTime rounds a simulated time for display
+func formatLaneTime(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// printLanes prints the simulated schedule: the chart, each target's lane
+// and times, and how the total compares with running one target at a time
+func printLanes(slots []laneSlot, lanes int) {
+	var total, sequential time.Duration
+	var estimated []string
+	for _, slot := range slots {
+		total = max(total, slot.end)
+		sequential += slot.end - slot.start
+		if slot.estimated {
+			estimated = append(estimated, slot.name)
+		}
+	}
+
+	fmt.Printf("Simulated schedule with -p %d (%d targets, durations from run history):\n\n", lanes, len(slots))
+	for _, line := range renderLanes(slots, lanes, 60) {
+		fmt.Println(line)
+	}
+	fmt.Println()
+
+	byStart := slices.Clone(slots)
+	slices.SortStableFunc(byStart, func(a, b laneSlot) int { return cmp.Compare(a.start, b.start) })
+	for _, slot := range byStart {
+		mark := ""
+		if slot.estimated {
+			mark = " (no history)"
+		}
+		fmt.Printf("  lane %-3d %8s → %-8s %s%s\n", slot.lane+1, formatLaneTime(slot.start), formatLaneTime(slot.end), slot.name, mark)
+	}
+
+	fmt.Printf("\nTotal: %s", formatLaneTime(total))
+	if total > 0 {
+		fmt.Printf(" (one at a time: %s, %.1fx faster)", formatLaneTime(sequential), float64(sequential)/float64(total))
+	}
+	fmt.Println()
+	if len(estimated) > 0 {
+		fmt.Printf("Targets without run history are assumed to take %s: %s\n", unknownDuration, strings.Join(estimated, ", "))
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===== LANES.GO UNIT TESTS =====
+
+func TestSimulateLanes(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	history := runHistory{
+		"gen":    {{Duration: 3 * time.Second, OK: true}},
+		"lib":    {{Duration: 6 * time.Second, OK: true}},
+		"assets": {{Duration: 4 * time.Second, OK: true}},
+		"app":    {{Duration: 2 * time.Second, OK: true}},
+	}
+	targets := map[string]Target{
+		"gen":    {Run: []string{"gen"}},
+		"lib":    {Run: []string{"lib"}, Deps: []string{"gen"}},
+		"assets": {Run: []string{"assets"}},
+		"docs":   {Run: []string{"docs"}},
+		"app":    {Run: []string{"app"}, Deps: []string{"lib", "assets"}},
+	}
+
+	tests := []struct {
+		name     string
+		names    []string
+		lanes    int
+		mutex    bool
+		expected string
+	}{
+		{"one lane", []string{"app"}, 1, false, "gen@1:0-3 lib@1:3-9 assets@1:9-13 app@1:13-15"},
+		{"two lanes", []string{"app"}, 2, false, "gen@1:0-3 lib@1:3-9 assets@2:0-4 app@1:9-11"},
+		{"no history", []string{"docs", "gen"}, 2, false, "docs@1:0-1? gen@2:0-3"},
+		{"mutex", []string{"assets", "gen"}, 2, true, "assets@1:0-4 gen@1:4-7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: map[string]Target{}}
+			for name, target := range targets {
+				if tt.mutex {
+					target.Mutex = "db"
+				}
+				cfg.Targets[name] = target
+			}
+
+			slots, err := simulateLanes(tt.names, tt.lanes, history)
+			if err != nil {
+				t.Fatalf("simulateLanes() error = %v", err)
+			}
+			var got []string
+			for _, slot := range slots {
+				mark := ""
+				if slot.estimated {
+					mark = "?"
+				}
+				got = append(got, fmt.Sprintf("%s@%d:%d-%d%s", slot.name, slot.lane+1, int(slot.start.Seconds()), int(slot.end.Seconds()), mark))
+			}
+			if strings.Join(got, " ") != tt.expected {
+				t.Errorf("simulateLanes() = %q, expected %q", strings.Join(got, " "), tt.expected)
+			}
+		})
+	}
+
+	cfg = Config{Targets: map[string]Target{"a": {Run: []string{"a"}}}}
+	if _, err := simulateLanes([]string{"missing"}, 2, nil); err == nil {
+		t.Error("simulateLanes() with an unknown target should fail")
+	}
+}
+
+func TestRenderLanes(t *testing.T) {
+	slots := []laneSlot{
+		{name: "gen", lane: 0, start: 0, end: 2 * time.Second},
+		{name: "lib", lane: 0, start: 2 * time.Second, end: 4 * time.Second},
+		{name: "docs", lane: 1, start: 0, end: time.Second},
+	}
+	expected := []string{
+		"         0s  1s  2s  3s 4s",
+		"lane 1   |gen====|lib====",
+		"lane 2   |doc",
+		"lane 3",
+	}
+
+	got := renderLanes(slots, 3, 16)
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("renderLanes() =\n%s\nexpected\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// partialTargets, when set, limits the targets loadConfig decodes to these
+// and the targets they depend on. Commands that run given targets set it,
+// so a config with thousands of targets only pays for the ones needed
+var partialTargets []string
+
+// pendingTarget is a target parsed but not decoded yet; file is the
+// include it came from, empty for the main config
+type pendingTarget struct {
+	node *yaml.Node
+	file string
+}
+
+// decodeConfigDoc parses a config file once and decodes it over c, as
+// decoding the whole document would, except for the targets: their nodes
+// are added to pending, replacing targets of the same name, until
+// decodeTargets knows which are needed
+func decodeConfigDoc(c *Config, data []byte, file string, pending map[string]pendingTarget) error {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return root.Decode(c)
+	}
+
+	rest := *root
+	rest.Content = nil
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != "targets" || value.Kind != yaml.MappingNode || hasMergeKey(value) {
+			rest.Content = append(rest.Content, key, value)
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			name := value.Content[j].Value
+			pending[name] = pendingTarget{node: value.Content[j+1], file: file}
+			delete(c.Targets, name)
+		}
+	}
+	return rest.Decode(c)
+}
+
+// hasMergeKey reports whether a mapping merges another one with <<, which
+// only decoding it whole resolves
+func hasMergeKey(mapping *yaml.Node) bool {
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Tag == "!!merge" {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeTargets decodes the pending targets into c: all of them, or with a
+// selection only those selected and the targets they depend on, including
+// the deps of the prologue, the epilogue and profile stages. Refs to other
+// workspace members are only resolved once the members are loaded, so
+// with members everything is decoded. A target of an include that fails
+// to decode is skipped with a warning, one of the main config is an error
+func decodeTargets(c *Config, pending map[string]pendingTarget, selection []string) error {
+	if c.Targets == nil && len(pending) > 0 {
+		c.Targets = make(map[string]Target, len(pending))
+	}
+	decode := func(name string) ([]string, error) {
+		p := pending[name]
+		delete(pending, name)
+		var target Target
+		if err := p.node.Decode(&target); err != nil {
+			if p.file == "" {
+				return nil, fmt.Errorf("target '%s': %v", name, err)
+			}
+			logWarn("Failed to parse target '%s' of include file %s: %v", name, p.file, err)
+			return nil, nil
+		}
+		c.Targets[name] = target
+		return target.Deps, nil
+	}
+	decodeAll := func() error {
+		for _, name := range sortedKeys(pending) {
+			if _, err := decode(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(selection) == 0 || len(c.Members) > 0 {
+		return decodeAll()
+	}
+
+	queue := append([]string{}, selection...)
+	queue = append(queue, c.Prologue.Deps...)
+	queue = append(queue, c.Epilogue.Deps...)
+	for _, profile := range c.Profiles {
+		for _, stage := range []*ProfileStage{profile.Prologue, profile.Epilogue} {
+			if stage != nil {
+				queue = append(queue, stage.Deps...)
+			}
+		}
+	}
+	seen := map[string]bool{}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var deps []string
+		if _, ok := pending[name]; ok {
+			var err error
+			if deps, err = decode(name); err != nil {
+				return err
+			}
+		} else if target, ok := c.Targets[name]; ok {
+			deps = target.Deps
+		}
+		for _, dep := range deps {
+			if _, _, member := parseMemberRef(dep); member {
+				return decodeAll()
+			}
+		}
+		queue = append(queue, deps...)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ===== LOAD.GO UNIT TESTS =====
+
+func TestDecodeConfigDoc(t *testing.T) {
+	c := Config{}
+	pending := map[string]pendingTarget{}
+	main := `
+vars:
+  CC: gcc
+targets:
+  build:
+    run: ["cc main.c"]
+  test:
+    run: ["./test"]
+`
+	include := `
+vars:
+  LD: ld
+targets:
+  test:
+    run: ["./included-test"]
+`
+	if err := decodeConfigDoc(&c, []byte(main), "", pending); err != nil {
+		t.Fatal(err)
+	}
+	if err := decodeConfigDoc(&c, []byte(include), "inc.yaml", pending); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Targets) != 0 {
+		t.Errorf("targets should wait in pending, got %v", sortedKeys(c.Targets))
+	}
+	if expected := map[string]Var{"CC": "gcc", "LD": "ld"}; !reflect.DeepEqual(c.Vars, expected) {
+		t.Errorf("vars = %v, expected %v", c.Vars, expected)
+	}
+	if pending["test"].file != "inc.yaml" || pending["build"].file != "" {
+		t.Errorf("pending = %+v, expected the include to replace test", pending)
+	}
+
+	if err := decodeTargets(&c, pending, nil); err != nil {
+		t.Fatal(err)
+	}
+	if run := c.Targets["test"].Run; !reflect.DeepEqual(run, []string{"./included-test"}) {
+		t.Errorf("test run = %v", run)
+	}
+
+	// Merged target mappings are decoded whole
+	merged := Config{}
+	data := "base: &base\n  a: {run: [\"echo a\"]}\ntargets:\n  <<: *base\n  b: {run: [\"echo b\"]}\n"
+	if err := decodeConfigDoc(&merged, []byte(data), "", map[string]pendingTarget{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedKeys(merged.Targets); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("merged targets = %v", got)
+	}
+}
+
+func TestDecodeTargetsSelection(t *testing.T) {
+	config := `
+prologue:
+  deps: ["login"]
+profiles:
+  ci:
+    epilogue:
+      deps: ["upload"]
+targets:
+  app:
+    deps: ["lib", "main.c"]
+  lib:
+    deps: ["gen"]
+  gen: {}
+  login: {}
+  upload: {}
+  docs:
+    deps: ["gen"]
+  broken:
+    run: {not: a list}
+`
+	tests := []struct {
+		name      string
+		selection []string
+		members   []string
+		expected  []string
+		errMsg    string
+	}{
+		{"selected and deps", []string{"app"}, nil, []string{"app", "gen", "lib", "login", "upload"}, ""},
+		{"unknown selection", []string{"missing"}, nil, []string{"login", "upload"}, ""},
+		{"with members", []string{"gen"}, []string{"api"}, nil, "target 'broken'"},
+		{"everything", nil, nil, nil, "target 'broken'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Config{}
+			pending := map[string]pendingTarget{}
+			if err := decodeConfigDoc(&c, []byte(config), "", pending); err != nil {
+				t.Fatal(err)
+			}
+			c.Members = tt.members
+			err := decodeTargets(&c, pending, tt.selection)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("decodeTargets() error = %v, expected %q", err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sortedKeys(c.Targets); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("decoded = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	// A target of an include that does not decode is skipped
+	c := Config{}
+	pending := map[string]pendingTarget{}
+	if err := decodeConfigDoc(&c, []byte(config), "inc.yaml", pending); err != nil {
+		t.Fatal(err)
+	}
+	if err := decodeTargets(&c, pending, nil); err != nil {
+		t.Errorf("decodeTargets() of an include should only warn, got %v", err)
+	}
+	if _, ok := c.Targets["broken"]; ok || len(c.Targets) != 6 {
+		t.Errorf("decoded = %v, expected all but broken", sortedKeys(c.Targets))
+	}
+}
+
+func TestLoadConfigPartial(t *testing.T) {
+	original, originalPartial := cfg, partialTargets
+	defer func() { cfg, partialTargets = original, originalPartial }()
+	chdirTemp(t)
+	writeFiles(t, map[string]string{
+		"more.yaml": "targets:\n  gen:\n    run: [\"echo gen\"]\n  other:\n    run: [\"echo other\"]\n",
+		"aura.yaml": `
+include: [more.yaml]
+targets:
+  build:
+    deps: ["gen"]
+    run: ["echo build"]
+  unrelated:
+    retries: -1
+`,
+	})
+
+	cfg = Config{}
+	if err := loadConfig("aura.yaml"); err == nil {
+		t.Error("a full load should validate every target")
+	}
+
+	cfg = Config{}
+	partialTargets = []string{"build"}
+	if err := loadConfig(filepath.Join(".", "aura.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedKeys(cfg.Targets); !reflect.DeepEqual(got, []string{"build", "gen"}) {
+		t.Errorf("targets = %v, expected build and its deps", got)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logFormat selects how build events are printed: text, the plain output
+// aura always had, or json, one event object per line on stdout for CI
+// systems to ingest
+var logFormat = "text"
+
+// logMu keeps events written from parallel targets on their own lines
+var logMu sync.Mutex
+
+// logLevel is the severity of a build event
+type logLevel string
+
+const (
+	levelDebug logLevel = "debug"
+	levelInfo  logLevel = "info"
+	levelWarn  logLevel = "warn"
+	levelError logLevel = "error"
+)
+
+// logEvent is one build event; text mode prints only its text
+type logEvent struct {
+	Time       time.Time `json:"time"`
+	Level      logLevel  `json:"level"`
+	Event      string    `json:"event"`
+	Target     string    `json:"target,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Message    string    `json:"msg,omitempty"`
+	Output     string    `json:"output,omitempty"`
+	DurationMS int64     `json:"duration_ms,omitempty"`
+	Attempts   int       `json:"attempts,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// setLogFormat validates and selects the --log-format value
+func setLogFormat(format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown log format '%s': use text or json", format)
+	}
+	logFormat = format
+	return nil
+}
+
+// emit prints the event: as JSON in json format, with text as its message
+// unless it carries a command, output or error; else text as is, on stderr
+// for warnings and errors. Events without text are json only
+func (e logEvent) emit(text string) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	if logFormat == "json" {
+		e.Time = time.Now().UTC()
+		if e.Message == "" && e.Command == "" && e.Output == "" && e.Error == "" {
+			e.Message = strings.TrimSpace(ansiSGRRegex.ReplaceAllString(text, ""))
+		}
+		data, err := json.Marshal(e)
+		if err == nil {
+			fmt.Fprintln(os.Stdout, string(data))
+		}
+		return
+	}
+	if text == "" {
+		return
+	}
+	var w io.Writer = os.Stdout
+	if e.Level == levelWarn || e.Level == levelError {
+		w = os.Stderr
+	} else if e.Target != "" {
+		if text = targetText(e.Target, text); text == "" {
+			return
+		}
+	}
+	fmt.Fprint(w, text)
+}
+
+// logDebug prints --verbose detail; callers check verbose
+func logDebug(event, target, format string, args ...any) {
+	logEvent{Level: levelDebug, Event: event, Target: target}.emit(fmt.Sprintf(format, args...) + "\n")
+}
+
+// logInfo prints an informational message
+func logInfo(event, target, format string, args ...any) {
+	logEvent{Level: levelInfo, Event: event, Target: target}.emit(fmt.Sprintf(format, args...) + "\n")
+}
+
+// logWarn prints a warning
+func logWarn(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	logEvent{Level: levelWarn, Event: "warning", Message: message}.emit(paint(os.Stderr, colorYellow, "[!] Warning:") + " " + message + "\n")
+}
+
+// logError prints the error a command failed with
+func logError(err error) {
+	logEvent{Level: levelError, Event: "error", Error: err.Error()}.emit(fmt.Sprintf("%s %v\n", paint(os.Stderr, colorRed+";"+colorBold, "Error:"), err))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// ===== LOG.GO UNIT TESTS =====
+
+// captureStdout redirects os.Stdout until the returned function is called,
+// which restores it and returns what was written
+func captureStdout(t *testing.T) func() string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := os.Stdout
+	os.Stdout = w
+	return func() string {
+		os.Stdout = original
+		_ = w.Close()
+		data, _ := io.ReadAll(r)
+		return string(data)
+	}
+}
+
+func TestSetLogFormat(t *testing.T) {
+	original := logFormat
+	defer func() { logFormat = original }()
+
+	for _, format := range []string{"text", "json"} {
+		if err := setLogFormat(format); err != nil || logFormat != format {
+			t.Errorf("setLogFormat(%q) = %v, logFormat %q", format, err, logFormat)
+		}
+	}
+	if err := setLogFormat("xml"); err == nil || logFormat != "json" {
+		t.Errorf("setLogFormat(xml) = %v, logFormat %q", err, logFormat)
+	}
+}
+
+func TestLogText(t *testing.T) {
+	original := logFormat
+	defer func() { logFormat = original }()
+	logFormat = "text"
+
+	stdout, stderr := captureStdout(t), captureStderr(t)
+	logEvent{Level: levelInfo, Event: "command", Target: "app", Command: "go build"}.emit("go build\n")
+	logEvent{Level: levelInfo, Event: "target_start", Target: "app"}.emit("")
+	logWarn("cannot remove %s", "tmp")
+	logError(errors.New("boom"))
+	errOut, out := stderr(), stdout()
+
+	if out != "go build\n" {
+		t.Errorf("stdout = %q", out)
+	}
+	if errOut != "[!] Warning: cannot remove tmp\nError: boom\n" {
+		t.Errorf("stderr = %q", errOut)
+	}
+}
+
+func TestLogJSON(t *testing.T) {
+	original := logFormat
+	defer func() { logFormat = original }()
+	logFormat = "json"
+
+	stdout := captureStdout(t)
+	logEvent{Level: levelInfo, Event: "output", Target: "app", Command: "go build", Output: "ok\n"}.emit("ok\n")
+	logEvent{Level: levelInfo, Event: "target_finish", Target: "app", DurationMS: 1200, Attempts: 1}.emit("")
+	logWarn("cannot remove %s", "tmp")
+	lines := strings.Split(strings.TrimSpace(stdout()), "\n")
+
+	expected := []logEvent{
+		{Level: levelInfo, Event: "output", Target: "app", Command: "go build", Output: "ok\n"},
+		{Level: levelInfo, Event: "target_finish", Target: "app", DurationMS: 1200, Attempts: 1},
+		{Level: levelWarn, Event: "warning", Message: "cannot remove tmp"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("got %d events, expected %d: %q", len(lines), len(expected), lines)
+	}
+	for i, line := range lines {
+		var event logEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("event %d is not JSON: %q", i, line)
+		}
+		if event.Time.IsZero() {
+			t.Errorf("event %d has no time: %q", i, line)
+		}
+		event.Time = expected[i].Time
+		if event != expected[i] {
+			t.Errorf("event %d = %+v, expected %+v", i, event, expected[i])
+		}
+	}
+}
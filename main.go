@@ -2,18 +2,56 @@ package main
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/agilira/orpheus/pkg/orpheus"
-	"gopkg.in/yaml.v3"
 )
 
 var cfg Config
 
 func main() {
+	// Arguments after -- are handed to commands, not parsed as flags
+	args, passthrough := splitPassthrough(os.Args[1:])
+	passthroughArgs = passthrough
+
+	// --var KEY=VALUE overrides config vars for every command, --arg
+	// NAME=VALUE sets the params of the targets built
+	args, assigns, err := splitRepeatedFlag(args, "var")
+	if err == nil {
+		overrideVars, err = parseVarOverrides(assigns)
+	}
+	if err != nil {
+		logError(orpheus.ValidationError("var", err.Error()))
+		os.Exit(1)
+	}
+	args, assigns, err = splitRepeatedFlag(args, "arg")
+	if err == nil {
+		paramArgs, err = parseVarOverrides(assigns)
+	}
+	if err != nil {
+		logError(orpheus.ValidationError("arg", err.Error()))
+		os.Exit(1)
+	}
+
+	// Errors are printed after the command returns, so --no-color is
+	// read up front
+	args, noColor = splitBoolFlag(args, "no-color")
+
+	// Commands are stopped, not orphaned, when aura is interrupted
+	handleInterrupts()
+
+	// Load per-user defaults before anything reads them
+	defaults, err := loadUserDefaults(userConfigPath())
+	if err != nil {
+		logWarn("ignoring user defaults: %v", err)
+	}
+	userDefaults = defaults
+
 	// Create Orpheus application
 	app := orpheus.New("aura").
 		SetDescription("A fast & powerful build tool with modern CLI capabilities").
@@ -23,28 +61,86 @@ func main() {
 	app.AddGlobalFlag("directory", "D", ".", "Working directory for build operations").
 		AddGlobalFlag("config", "c", "aura.yaml", "Configuration file path").
 		AddGlobalBoolFlag("verbose", "v", false, "Enable verbose output").
-		AddGlobalBoolFlag("dry-run", "", false, "Show what would be executed without running commands")
+		AddGlobalBoolFlag("dry-run", "", false, "Show what would be executed without running commands").
+		AddGlobalFlag("profile", "P", "", "Configuration profile to apply").
+		AddGlobalFlag("cache-dir", "", "", "Build cache directory (overrides AURA_CACHE_DIR and cache.path)").
+		AddGlobalFlag("log-format", "", "text", "Build output format: text, json (one event per line)").
+		AddGlobalFlag("var", "", "", "Override a config variable: KEY=VALUE (repeatable)").
+		AddGlobalBoolFlag("no-color", "", false, "Plain output without colors (also NO_COLOR)")
 
 	// Create build command with flags
 	buildCmd := orpheus.NewCommand("build", "Execute build targets").
 		SetHandler(buildCommand).
 		AddFlag("targets", "t", "", "Comma-separated list of targets to run").
+		AddFlag("arg", "", "", "Set a parameter of the targets built: NAME=VALUE (repeatable)").
 		AddIntFlag("parallel", "p", 1, "Number of parallel jobs").
-		AddBoolFlag("force", "f", false, "Force rebuild of all targets")
+		AddBoolFlag("force", "f", false, "Force rebuild of all targets").
+		AddFlag("output-mode", "", "", "How target output is shown: prefixed (default with -p), grouped, interleaved").
+		AddFlag("summary", "", "", "Summary of the targets at the end: table (default outside CI), json, none").
+		AddBoolFlag("yes-i-mean-it", "", false, "Run targets marked dangerous without asking").
+		AddBoolFlag("auto", "", false, "Infer targets from go.mod, Cargo.toml, package.json or CMakeLists.txt when there is no config").
+		AddFlag("status-file", "", "", "Write the build result to this file: an SVG badge (.svg), an HTML report with the output (.html), else JSON").
+		AddBoolFlag("keep-temp", "", false, "Keep the $TMPDIR_TARGET directories of targets for inspection").
+		AddBoolFlag("trace-deps", "", false, "Experimental: trace file accesses and report inputs targets do not declare (Linux, needs strace)").
+		AddFlag("simulate-failure", "", "", "Make targets fail without running them to test error handling, e.g. target=test").
+		AddFlag("profile-report", "", "", "Write the time of each target and command to this file: JSON for .json, else text (- for stdout)").
+		AddFlag("profile-trace", "", "", "Write the build timeline to this file in the Chrome trace format (chrome://tracing, Perfetto)").
+		AddFlag("format", "", "text", "Output of --dry-run: text, json (the plan in build order)").
+		AddBoolFlag("commit-markers", "", false, "In CI, follow [skip aura], [only: targets] and [skip: targets] in the commit message").
+		SetCompletionHandler(completeTargets)
+	if wantsHelp(args) {
+		buildCmd.SetLongDescription(targetHelp(peekTargets(argsConfig(args))))
+	}
 	app.AddCommand(buildCmd)
 
+	// Create run command: one target, arguments after -- passed to it
+	runCmd := orpheus.NewCommand("run", "Run a target, passing the arguments after -- to its last command").
+		SetHandler(runTargetCommand).
+		AddIntFlag("parallel", "p", 1, "Number of parallel jobs").
+		AddBoolFlag("force", "f", false, "Force rebuild of all targets").
+		AddFlag("output-mode", "", "", "How target output is shown: prefixed (default with -p), grouped, interleaved").
+		AddFlag("summary", "", "", "Summary of the targets at the end: table (default outside CI), json, none").
+		AddFlag("format", "", "text", "Output of --dry-run: text, json (the plan in build order)").
+		AddBoolFlag("yes-i-mean-it", "", false, "Run targets marked dangerous without asking").
+		SetCompletionHandler(completeTargets)
+	app.AddCommand(runCmd)
+
 	// Create list command with flags
 	listCmd := orpheus.NewCommand("list", "List all available targets").
 		SetHandler(listCommand).
-		AddFlag("format", "", "table", "Output format: table, json, yaml")
+		AddFlag("format", "", "table", "Output format: table, json, yaml, names")
 	app.AddCommand(listCmd)
 
 	// Create clean command with flags
 	cleanCmd := orpheus.NewCommand("clean", "Clean build artifacts").
 		SetHandler(cleanCommand).
-		AddFlag("targets", "t", "", "Specific targets to clean")
+		AddFlag("targets", "t", "", "Specific targets to clean").
+		SetCompletionHandler(completeTargets)
 	app.AddCommand(cleanCmd)
 
+	// Create status command
+	statusCmd := orpheus.NewCommand("status", "Show which targets are up-to-date or stale without building").
+		SetHandler(statusCommand)
+	app.AddCommand(statusCmd)
+
+	// Create plan command with subcommands
+	planCmd := orpheus.NewCommand("plan", "Show the expanded build plan (targets, commands, env)").
+		SetHandler(planCommand).
+		AddIntFlag("lanes", "", 0, "Simulate a build with this many parallel jobs (-p) using past durations").
+		AddFlag("targets", "t", "", "Targets to simulate with --lanes (default all)").
+		AddFlag("snapshot", "", "", "Write the plan to this file, or compare with it with --check").
+		AddBoolFlag("check", "", false, "Fail when the plan differs from the --snapshot file")
+	planCmd.Subcommand("diff", "Compare the build plan with the config at a git revision", planDiffCommand).
+		AddFlag("base", "b", "HEAD", "Git revision to compare against")
+	app.AddCommand(planCmd)
+
+	// Create analyze command with subcommands
+	analyzeCmd := orpheus.NewCommand("analyze", "Analyze build history").
+		SetHandler(analyzeCommand)
+	analyzeCmd.Subcommand("flaky", "List targets that intermittently fail, with failure rates", analyzeFlakyCommand)
+	analyzeCmd.Subcommand("resources", "List targets by the CPU time and memory their commands use", analyzeResourcesCommand)
+	app.AddCommand(analyzeCmd)
+
 	// Create validate command
 	validateCmd := orpheus.NewCommand("validate", "Validate configuration file").
 		SetHandler(validateCommand)
@@ -53,14 +149,23 @@ func main() {
 	// Create init command with flags
 	initCmd := orpheus.NewCommand("init", "Initialize new aura project").
 		SetHandler(initCommand).
-		AddFlag("template", "", "basic", "Template type: basic, advanced, go, rust, node")
+		AddFlag("template", "", "basic", "Template type: basic, advanced, go, rust, node, python, java (gradle), cmake, workspace")
 	app.AddCommand(initCmd)
 
 	// Create watch command with flags
 	watchCmd := orpheus.NewCommand("watch", "Watch files and rebuild on changes").
 		SetHandler(watchCommand).
 		AddFlag("targets", "t", "", "Targets to rebuild on file changes").
-		AddFlag("interval", "i", "1s", "Polling interval for file changes")
+		AddFlag("interval", "i", "1s", "Polling interval for file changes").
+		AddBoolFlag("single-file", "", false, "Run targets once per changed file with $CHANGED_FILE set").
+		SetCompletionHandler(completeTargets)
+	watchCmd.Subcommand("install-service", "Run watch as a background service (systemd, launchd, Windows task)", watchInstallServiceCommand).
+		AddFlag("targets", "t", "", "Targets to rebuild on file changes").
+		AddFlag("interval", "i", "1s", "Polling interval for file changes").
+		AddFlag("name", "", "", "Service name (default: aura-watch-<project>)").
+		AddBoolFlag("print", "", false, "Print the service definition instead of installing it")
+	watchCmd.Subcommand("uninstall-service", "Remove a service created by install-service", watchUninstallServiceCommand).
+		AddFlag("name", "", "", "Service name (default: aura-watch-<project>)")
 	app.AddCommand(watchCmd)
 
 	// Create cache command with subcommands
@@ -71,38 +176,163 @@ func main() {
 	cacheCmd.Subcommand("clear", "Clear build cache", cacheClearCommand)
 	cacheCmd.Subcommand("info", "Show cache information", cacheInfoCommand)
 	cacheCmd.Subcommand("list", "List cached items", cacheListCommand)
+	cacheCmd.Subcommand("verify", "Check cache records and re-hash manifest artifacts", cacheVerifyCommand).
+		AddBoolFlag("repair", "", false, "Evict corrupt records")
 
 	app.AddCommand(cacheCmd)
 
-	// Configure storage for build cache
-	storageConfig := &orpheus.StorageConfig{
-		Provider: "file",
-		Config: map[string]interface{}{
-			"path": ".aura_cache",
-		},
-		EnableMetrics: true,
-	}
-	app.ConfigureStorage(storageConfig)
+	// Create tools command with subcommands
+	toolsCmd := orpheus.NewCommand("tools", "Install and inspect project tools").
+		SetHandler(toolsCommand)
+	toolsCmd.Subcommand("sync", "Install missing tools into the toolchain cache", toolsSyncCommand).
+		AddBoolFlag("force", "f", false, "Reinstall tools even when cached")
+	toolsCmd.Subcommand("list", "Show declared tools and their install state", toolsListCommand)
+	app.AddCommand(toolsCmd)
+
+	// Create fmt command with flags
+	fmtCmd := orpheus.NewCommand("fmt", "Format configuration file").
+		SetHandler(fmtCommand).
+		AddBoolFlag("check", "", false, "Fail if the file is not formatted instead of rewriting it")
+	app.AddCommand(fmtCmd)
+
+	// Create target command with editing subcommands
+	targetCmd := orpheus.NewCommand("target", "Add, remove or rename targets in the config").
+		SetHandler(targetCommand)
+	targetCmd.Subcommand("add", "Add a target", targetAddCommand).
+		AddFlag("deps", "", "", "Comma-separated dependencies").
+		AddFlag("desc", "", "", "Target description")
+	targetCmd.Subcommand("remove", "Remove a target", targetRemoveCommand)
+	targetCmd.Subcommand("rename", "Rename a target and update references", targetRenameCommand)
+	app.AddCommand(targetCmd)
+
+	// Create var command with editing subcommands
+	varCmd := orpheus.NewCommand("var", "Edit variables in the config").
+		SetHandler(varCommand)
+	varCmd.Subcommand("set", "Create or update a variable", varSetCommand)
+	app.AddCommand(varCmd)
+
+	// Create export command with subcommands
+	exportCmd := orpheus.NewCommand("export", "Export targets for editors and other tools").
+		SetHandler(exportCommand)
+	exportCmd.Subcommand("vscode-tasks", "Write .vscode/tasks.json with a task per target", exportVSCodeTasksCommand).
+		AddFlag("output", "o", filepath.Join(".vscode", "tasks.json"), "Tasks file to write")
+	app.AddCommand(exportCmd)
+
+	// Create env command with snapshot subcommands
+	envCmd := orpheus.NewCommand("env", "Snapshot and compare build environments").
+		SetHandler(envCommand)
+	envCmd.Subcommand("snapshot", "Record tool versions, OS info, config hash and redacted env vars", envSnapshotCommand).
+		AddFlag("output", "o", "aura-env.json", "Snapshot file to write")
+	envCmd.Subcommand("diff", "Compare two environment snapshots", envDiffCommand)
+	app.AddCommand(envCmd)
+
+	// Create bench command with subcommands
+	benchCmd := orpheus.NewCommand("bench", "Benchmark builds").
+		SetHandler(benchCommand)
+	benchCmd.Subcommand("cache", "Run targets twice and report why aura does not see them as up-to-date", benchCacheCommand).
+		AddFlag("targets", "t", "", "Comma-separated list of targets to benchmark").
+		AddBoolFlag("yes-i-mean-it", "", false, "Run targets marked dangerous without asking")
+	app.AddCommand(benchCmd)
+
+	// Create suggest-deps command
+	suggestCmd := orpheus.NewCommand("suggest-deps", "Suggest deps, sources and outputs for a target from its commands").
+		SetHandler(suggestDepsCommand).
+		SetCompletionHandler(completeTargets)
+	app.AddCommand(suggestCmd)
+
+	// Create explain command
+	explainCmd := orpheus.NewCommand("explain", "Show what building a target runs: order, commands, directories, cache status").
+		SetHandler(explainCommand).
+		SetCompletionHandler(completeTargets)
+	app.AddCommand(explainCmd)
+
+	// Create verify-determinism command
+	verifyCmd := orpheus.NewCommand("verify-determinism", "Run targets twice in separate sandboxes and report outputs that differ").
+		SetHandler(verifyDeterminismCommand).
+		AddFlag("targets", "t", "", "Comma-separated list of targets to verify").
+		AddBoolFlag("yes-i-mean-it", "", false, "Run targets marked dangerous without asking")
+	app.AddCommand(verifyCmd)
+
+	// Create query command
+	queryCmd := orpheus.NewCommand("query", "Query the target graph: deps(x), rdeps(x, depth), allpaths(x, y)").
+		SetHandler(queryCommand).
+		AddFlag("format", "", "text", "Output format: text, json")
+	app.AddCommand(queryCmd)
+
+	// Create graph command
+	graphCmd := orpheus.NewCommand("graph", "Draw the target graph with last build times (optionally a query's part of it)").
+		SetHandler(graphCommand).
+		AddFlag("format", "", "dot", "Output format: dot, mermaid, tree, html (interactive page)").
+		AddFlag("output", "o", "", "File to write instead of stdout")
+	app.AddCommand(graphCmd)
+
+	// Create migrate command
+	migrateCmd := orpheus.NewCommand("migrate", "Rewrite deprecated config syntax where it is safe (--dry-run lists it)").
+		SetHandler(migrateCommand)
+	app.AddCommand(migrateCmd)
+
+	// Create test-config command
+	testConfigCmd := orpheus.NewCommand("test-config", "Check the assertions of a config test file against the config").
+		SetHandler(testConfigCommand).
+		AddFlag("file", "f", "aura.test.yaml", "Config test file")
+	app.AddCommand(testConfigCmd)
+
+	// Create completion command
+	completionCmd := orpheus.NewCommand("completion", "Generate shell completion script (bash, zsh, fish)").
+		SetHandler(completionCommand)
+	app.AddCommand(completionCmd)
 
 	// Set default command to build
 	app.SetDefaultCommand("build")
 
 	// Run the application
-	if err := app.Run(os.Args[1:]); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if err := app.Run(args); err != nil {
+		logError(err)
+		if runCtx.Err() != nil {
+			os.Exit(130)
+		}
 		os.Exit(1)
 	}
+	if runCtx.Err() != nil {
+		os.Exit(130)
+	}
 }
 
 // buildCommand handles the main build functionality
 func buildCommand(ctx *orpheus.Context) error {
+	return build(ctx, ctx.GetFlagString("targets"), positionalArgs(ctx))
+}
+
+// runTargetCommand runs one target, named first, like `aura build -t`:
+// `aura run test -- -run TestFoo` hands the arguments after -- to it
+func runTargetCommand(ctx *orpheus.Context) error {
+	args := positionalArgs(ctx)
+	if len(args) == 0 || varAssignRegex.MatchString(args[0]) {
+		return orpheus.ValidationError("run", "usage: aura run <target> [NAME=value...] [-- args]")
+	}
+	return build(ctx, args[0], args[1:])
+}
+
+// build runs targets, a comma-separated list, with the NAME=value
+// arguments of vars as variable overrides
+func build(ctx *orpheus.Context, targets string, vars []string) (buildErr error) {
 	workDir := ctx.GetGlobalFlagString("directory")
 	configFile := ctx.GetGlobalFlagString("config")
 	verbose := ctx.GetGlobalFlagBool("verbose")
 	dryRun := ctx.GetGlobalFlagBool("dry-run")
-	targets := ctx.GetFlagString("targets")
 	parallel := ctx.GetFlagInt("parallel")
 	force := ctx.GetFlagBool("force")
+	keepTemp = ctx.GetFlagBool("keep-temp")
+	if err := setLogFormat(ctx.GetGlobalFlagString("log-format")); err != nil {
+		return orpheus.ValidationError("log-format", err.Error())
+	}
+	traceDeps = ctx.GetFlagBool("trace-deps")
+	if traceDeps {
+		if _, err := tracerPath(); err != nil {
+			logWarn("%v; building without tracing", err)
+			traceDeps = false
+		}
+	}
 
 	// Change to working directory
 	if workDir != "." {
@@ -111,21 +341,198 @@ func buildCommand(ctx *orpheus.Context) error {
 		}
 	}
 
-	// Load configuration
-	if err := loadConfig(configFile); err != nil {
+	// NAME=value arguments override vars, also in the vars computed at
+	// load, and win over --var
+	overrides, err := parseVarOverrides(vars)
+	if err != nil {
+		return orpheus.ValidationError("vars", err.Error())
+	}
+	flagVars := overrideVars
+	overrideVars = maps.Clone(flagVars)
+	maps.Copy(overrideVars, overrides)
+	defer func() { overrideVars = flagVars }()
+
+	// Load configuration; given targets only need their own part of it
+	profile := ctx.GetGlobalFlagString("profile")
+	if targets != "" {
+		for _, target := range strings.Split(targets, ",") {
+			partialTargets = append(partialTargets, strings.TrimSpace(target))
+		}
+		defer func() { partialTargets = nil }()
+	}
+	if ctx.GetFlagBool("auto") {
+		if err := loadAutoConfig(configFile, os.Stdin, stdinIsTerminal()); err != nil {
+			return err
+		}
+		if err := selectProfile(profile); err != nil {
+			return err
+		}
+	} else if err := loadConfigProfile(configFile, profile); err != nil {
 		return err
 	}
+	if spec := ctx.GetFlagString("simulate-failure"); spec != "" {
+		failures, err := parseSimulatedFailures(spec)
+		if err != nil {
+			return orpheus.ValidationError("simulate-failure", err.Error())
+		}
+		simulatedFailures = failures
+		defer func() { simulatedFailures = nil }()
+	}
+	parallel = effectiveParallel(parallel, ctx.FlagChanged("parallel"))
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+
+	// Share the -j budget with make, whichever side started first
+	if err := setupJobserver(parallel); err != nil {
+		return orpheus.ExecutionError("jobserver", err.Error())
+	}
+	if jobserver != nil && jobserver.client && !ctx.FlagChanged("parallel") {
+		// make's tokens are the limit
+		parallel = 0
+	}
 
 	if verbose {
 		fmt.Printf("Loaded configuration from: %s\n", configFile)
 		fmt.Printf("Working directory: %s\n", workDir)
-		fmt.Printf("Parallel jobs: %d\n", parallel)
+		if parallel == 0 {
+			fmt.Println("Parallel jobs: limited by make jobserver")
+		} else {
+			fmt.Printf("Parallel jobs: %d\n", parallel)
+		}
 		fmt.Printf("Force rebuild: %t\n", force)
 		if dryRun {
 			fmt.Println("DRY RUN MODE - Commands will not be executed")
 		}
 	}
 
+	var targetList []string
+	if targets != "" {
+		targetList = strings.Split(targets, ",")
+		for i := range targetList {
+			targetList[i] = strings.TrimSpace(targetList[i])
+		}
+	}
+
+	// --arg values, and NAME=value after -- naming a param, go to the
+	// params of the targets that run; the other arguments pass through
+	args, passthrough, err := takeParamArgs(targetList, paramArgs, passthroughArgs)
+	if err != nil {
+		return orpheus.ValidationError("arg", err.Error())
+	}
+	flagPassthrough := passthroughArgs
+	passthroughArgs = passthrough
+	defer func() { passthroughArgs = flagPassthrough }()
+
+	applyPassthroughArgs(targetList)
+
+	resolved, err := resolveTargetArgs(targetList, args)
+	if err != nil {
+		return orpheus.ValidationError("arg", err.Error())
+	}
+	targetArgs = resolved
+	defer func() { targetArgs = map[string]map[string]string{} }()
+
+	// In CI, markers in the commit message can narrow or skip the build;
+	// the summary still lists every target with the marker that left it out
+	runList := targetList
+	if ctx.GetFlagBool("commit-markers") && detectCI() != "" && len(targetList) > 0 {
+		runList = applyCommitMarkers(targetList)
+		defer func() { markerSkips = nil }()
+	}
+
+	// Nothing runs in a dry run, so there is nothing to confirm
+	if !dryRun && !ctx.GetFlagBool("yes-i-mean-it") {
+		if err := confirmDangerous(runList, os.Stdin, stdinIsTerminal()); err != nil {
+			return err
+		}
+	}
+
+	// A dry run prints the plan of the build instead of running it
+	if dryRun && targets != "" {
+		format := ctx.GetFlagString("format")
+		if format != "text" && format != "json" {
+			return orpheus.ValidationError("format", fmt.Sprintf("unknown format '%s': use text or json", format))
+		}
+		plan, err := planBuild(runList)
+		if err != nil {
+			return err
+		}
+		return writeDryRun(os.Stdout, format, plan)
+	}
+
+	// Start a fresh manifest for post-build hooks
+	takeCompleted()
+
+	// Builds end with a summary of their targets (in CI, folded output and
+	// result lines); the status file records every build that ran targets,
+	// and a failed one is a notification event
+	takeResults()
+	started := time.Now()
+	inCI := detectCI() != ""
+	statusFile := ctx.GetFlagString("status-file")
+	if inCI {
+		ciFold = parallel <= 1 && jobserver == nil && logFormat == "text"
+	}
+	if outputMode, err = resolveOutputMode(ctx.GetFlagString("output-mode"), parallel > 1 || jobserver != nil); err != nil {
+		return orpheus.ValidationError("output-mode", err.Error())
+	}
+	prefixColors = colorEnabled()
+	defer func() { outputMode = outputInterleaved }()
+	summary, err := resolveSummary(ctx.GetFlagString("summary"))
+	if err != nil {
+		return orpheus.ValidationError("summary", err.Error())
+	}
+	profileReportFile, profileTraceFile := ctx.GetFlagString("profile-report"), ctx.GetFlagString("profile-trace")
+	if !dryRun && targets != "" {
+		if profileReportFile != "" || profileTraceFile != "" {
+			startProfile()
+		}
+		defer func() {
+			list := takeResults()
+			took := time.Since(started)
+			if profileReportFile != "" || profileTraceFile != "" {
+				profileStart, spans := stopProfile()
+				writeProfiles(profileReportFile, profileTraceFile, buildProfile(profileStart, spans, took))
+			}
+			switch summary {
+			case "table":
+				writeSummaryTable(os.Stdout, summarizeBuild(targetList, list), took)
+			case "json":
+				if err := writeSummaryJSON(os.Stdout, summarizeBuild(targetList, list), buildErr, took); err != nil {
+					logWarn("cannot write build summary: %v", err)
+				}
+			}
+			if buildErr != nil && runCtx.Err() == nil {
+				notifyFailure(list, buildErr)
+			}
+			if inCI {
+				writeSummary(os.Stdout, list)
+			}
+			if detectCI() == ciGitHub {
+				if err := writeStepSummary(list); err != nil {
+					logWarn("cannot write job summary: %v", err)
+				}
+			}
+			if statusFile != "" {
+				if err := writeStatusFile(statusFile, newBuildStatus(list, buildErr, took)); err != nil {
+					logWarn("cannot write status file: %v", err)
+				}
+			}
+		}()
+	}
+
+	// An interrupt stops the commands and returns through here
+	windsDown.Store(true)
+	defer func() {
+		if runCtx.Err() != nil && !dryRun {
+			runInterruptHooks(verbose)
+		}
+	}()
+
+	// A build its commit markers skip runs nothing, not even the prologue
+	if targets != "" && len(runList) == 0 {
+		return nil
+	}
+
 	// Run prologue
 	if err := runPrologueWithContext(verbose, dryRun); err != nil {
 		return err
@@ -133,12 +540,12 @@ func buildCommand(ctx *orpheus.Context) error {
 
 	// Execute targets
 	if targets != "" {
-		targetList := strings.Split(targets, ",")
-		for _, target := range targetList {
-			target = strings.TrimSpace(target)
-			if err := runTargetWithContext(target, verbose, dryRun); err != nil {
+		if parallel > 1 || jobserver != nil {
+			if err := runTargetsParallel(runList, parallel, verbose, dryRun); err != nil {
 				return err
 			}
+		} else if err := runTargetsSequential(runList, verbose, dryRun); err != nil {
+			return err
 		}
 	} else {
 		// If no targets specified, show available targets
@@ -150,7 +557,7 @@ func buildCommand(ctx *orpheus.Context) error {
 		return err
 	}
 
-	return nil
+	return runPostBuildHooks(verbose, dryRun)
 }
 
 // listCommand shows available targets
@@ -166,8 +573,10 @@ func listCommand(ctx *orpheus.Context) error {
 		}
 	}
 
-	// Load configuration
-	if err := loadConfig(configFile); err != nil {
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+
+	// Load the targets, from the index when the config is unchanged
+	if err := loadTargets(configFile, ctx.GetGlobalFlagString("profile")); err != nil {
 		return err
 	}
 
@@ -191,6 +600,7 @@ func cleanCommand(ctx *orpheus.Context) error {
 	if err := loadConfig(configFile); err != nil {
 		return err
 	}
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
 
 	fmt.Printf("Cleaning build artifacts in: %s\n", workDir)
 
@@ -206,7 +616,7 @@ func cleanCommand(ctx *orpheus.Context) error {
 				continue
 			}
 
-			fmt.Printf("✓ Cleaned target: %s\n", target)
+			fmt.Printf("%s Cleaned target: %s\n", checkMark(), target)
 		}
 	} else {
 		fmt.Println("Cleaning all build artifacts")
@@ -218,8 +628,14 @@ func cleanCommand(ctx *orpheus.Context) error {
 			"node_modules/.cache/", ".cargo/", ".go/",
 		}
 
+		// .auraignore protects paths from cleaning as well
+		ignore := loadIgnore()
 		cleaned := 0
 		for _, pattern := range artifacts {
+			if ignore.Ignored(strings.TrimSuffix(pattern, "/"), strings.HasSuffix(pattern, "/")) {
+				fmt.Printf("  Skipping ignored: %s\n", pattern)
+				continue
+			}
 			if strings.Contains(pattern, "/") {
 				// Directory
 				if info, err := os.Stat(strings.TrimSuffix(pattern, "/")); err == nil && info.IsDir() {
@@ -234,7 +650,7 @@ func cleanCommand(ctx *orpheus.Context) error {
 		}
 
 		// Clean cache
-		cacheDir := ".aura_cache"
+		cacheDir := buildCacheDir()
 		if info, err := os.Stat(cacheDir); err == nil && info.IsDir() {
 			fmt.Printf("  Removing cache directory: %s\n", cacheDir)
 			if err := os.RemoveAll(cacheDir); err != nil {
@@ -244,7 +660,7 @@ func cleanCommand(ctx *orpheus.Context) error {
 			}
 		}
 
-		fmt.Printf("✓ Clean completed (%d items processed)\n", cleaned)
+		fmt.Printf("%s Clean completed (%d items processed)\n", checkMark(), cleaned)
 	}
 
 	return nil
@@ -263,14 +679,15 @@ func validateCommand(ctx *orpheus.Context) error {
 	}
 
 	// Try to load and validate configuration
-	if err := loadConfig(configFile); err != nil {
+	if err := loadConfigProfile(configFile, ctx.GetGlobalFlagString("profile")); err != nil {
 		return err
 	}
 
-	fmt.Printf("✓ Configuration file '%s' is valid\n", configFile)
+	fmt.Printf("%s Configuration file '%s' is valid\n", checkMark(), configFile)
 	fmt.Printf("  - Found %d targets\n", len(cfg.Targets))
 	fmt.Printf("  - Found %d variables\n", len(cfg.Vars))
 	fmt.Printf("  - Found %d includes\n", len(cfg.Includes))
+	fmt.Printf("  - Found %d profiles\n", len(cfg.Profiles))
 
 	return nil
 }
@@ -280,7 +697,18 @@ func initCommand(ctx *orpheus.Context) error {
 	template := ctx.GetFlagString("template")
 
 	fmt.Printf("Initializing new aura project with template: %s\n", template)
+	if err := writeTemplate(template); err != nil {
+		return err
+	}
+	fmt.Println("  Run 'aura list' to see available targets")
+	fmt.Println("  Run 'aura build -t <target>' to execute a target")
 
+	return nil
+}
+
+// writeTemplate scaffolds a template in the current directory: its
+// aura.yaml, and the companion files the project does not have yet
+func writeTemplate(template string) error {
 	// Create basic aura.yaml template
 	templateContent := generateTemplate(template)
 
@@ -288,10 +716,22 @@ func initCommand(ctx *orpheus.Context) error {
 		return fmt.Errorf("failed to create aura.yaml: %v", err)
 	}
 
-	fmt.Println("✓ Created aura.yaml")
-	fmt.Println("  Run 'aura list' to see available targets")
-	fmt.Println("  Run 'aura build -t <target>' to execute a target")
+	fmt.Println(checkMark(), "Created aura.yaml")
 
+	// Companion files are only written where the project has none
+	files := templateFiles(template)
+	for _, path := range sortedKeys(files) {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(files[path]), 0600); err != nil {
+			return fmt.Errorf("failed to create %s: %v", path, err)
+		}
+		fmt.Printf("%s Created %s\n", checkMark(), path)
+	}
 	return nil
 }
 
@@ -302,6 +742,9 @@ func watchCommand(ctx *orpheus.Context) error {
 	verbose := ctx.GetGlobalFlagBool("verbose")
 	targets := ctx.GetFlagString("targets")
 	interval := ctx.GetFlagString("interval")
+	if err := setLogFormat(ctx.GetGlobalFlagString("log-format")); err != nil {
+		return orpheus.ValidationError("log-format", err.Error())
+	}
 
 	duration, err := time.ParseDuration(interval)
 	if err != nil {
@@ -316,59 +759,69 @@ func watchCommand(ctx *orpheus.Context) error {
 	}
 
 	// Load configuration
-	if err := loadConfig(configFile); err != nil {
+	if err := loadConfigProfile(configFile, ctx.GetGlobalFlagString("profile")); err != nil {
 		return err
 	}
 
-	fmt.Printf("Watching for file changes (polling every %s)\n", duration)
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+
+	// Targets given with -t replace the watch: rules from the config
+	var targetList []string
 	if targets != "" {
-		fmt.Printf("Targets to rebuild: %s\n", targets)
-	} else {
-		fmt.Println("Will rebuild all targets on changes")
+		for _, target := range strings.Split(targets, ",") {
+			targetList = append(targetList, strings.TrimSpace(target))
+		}
+	}
+	singleFile := ctx.GetFlagBool("single-file")
+	pipelines := newWatchPipelines(targetList, singleFile)
+	if len(pipelines) == 0 {
+		return orpheus.ValidationError("targets", "nothing to watch: pass -t, add watch patterns to targets or add watch: rules")
 	}
-	fmt.Println("Press Ctrl+C to stop watching")
-
-	// Get list of files to watch
-	watchPatterns := []string{"*.go", "*.yaml", "*.yml", "*.toml", "*.json", "*.md", "*.txt"}
-	var lastModTime time.Time
-
-	// Initial scan
-	lastModTime = getLatestModTime(watchPatterns)
-
-	ticker := time.NewTicker(duration)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		currentModTime := getLatestModTime(watchPatterns)
-
-		if currentModTime.After(lastModTime) {
-			lastModTime = currentModTime
-			fmt.Printf("[%s] File changes detected, rebuilding...\n", time.Now().Format("15:04:05"))
 
-			// Rebuild targets
-			if targets != "" {
-				targetList := strings.Split(targets, ",")
-				for _, target := range targetList {
-					target = strings.TrimSpace(target)
-					if err := runTargetWithContext(target, verbose, false); err != nil {
-						fmt.Printf("Error rebuilding target '%s': %v\n", target, err)
-					}
-				}
-			} else {
-				// Rebuild first available target as default
-				for targetName := range cfg.Targets {
-					if err := runTargetWithContext(targetName, verbose, false); err != nil {
-						fmt.Printf("Error rebuilding target '%s': %v\n", targetName, err)
-					}
-					break // Only rebuild one target if none specified
-				}
+	// A file save must never trigger a destructive pipeline
+	var watched []string
+	for _, p := range pipelines {
+		for _, target := range p.targets {
+			if _, exists := cfg.Targets[target]; !exists {
+				return orpheus.NotFoundError(target, fmt.Sprintf("target '%s' not found", target))
 			}
+		}
+		watched = append(watched, p.targets...)
+	}
+	if dangerous := dangerousTargets(watched); len(dangerous) > 0 {
+		return orpheus.ValidationError("targets", fmt.Sprintf("watch does not run dangerous targets: %s", strings.Join(dangerous, ", ")))
+	}
 
-			fmt.Printf("[%s] Rebuild completed\n", time.Now().Format("15:04:05"))
-		} else if verbose {
-			fmt.Printf("[%s] No changes detected\n", time.Now().Format("15:04:05"))
+	fmt.Printf("Watching for file changes (polling every %s)\n", duration)
+	for _, p := range pipelines {
+		if p.watches == nil {
+			fmt.Printf("  %s: %s -> %s\n", p.name, strings.Join(p.patterns, " "), strings.Join(p.targets, ", "))
+			continue
+		}
+		for _, target := range p.targets {
+			patterns, ok := p.watches[target]
+			if !ok {
+				patterns = defaultWatchPatterns
+			}
+			fmt.Printf("  %s: %s\n", target, strings.Join(patterns, " "))
 		}
 	}
+	if singleFile {
+		fmt.Println("Single-file mode: targets run once per changed file with $CHANGED_FILE set")
+	}
+	fmt.Println("Press Ctrl+C to stop watching")
+
+	// Every pipeline polls and rebuilds independently until interrupted
+	windsDown.Store(true)
+	var wg sync.WaitGroup
+	for _, p := range pipelines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.watch(duration, verbose)
+		}()
+	}
+	wg.Wait()
 
 	return nil
 }
@@ -377,23 +830,56 @@ func watchCommand(ctx *orpheus.Context) error {
 func getLatestModTime(patterns []string) time.Time {
 	var latest time.Time
 
-	for _, pattern := range patterns {
-		if matches, err := filepath.Glob(pattern); err == nil {
-			for _, match := range matches {
-				if info, err := os.Stat(match); err == nil {
-					if info.ModTime().After(latest) {
-						latest = info.ModTime()
-					}
-				}
-			}
+	for _, modTime := range scanModTimes(patterns) {
+		if modTime.After(latest) {
+			latest = modTime
 		}
 	}
 
 	return latest
 }
 
-// loadConfig loads and parses the configuration file
+// scanModTimes returns the modification time of every file matching
+// patterns and not ignored by .auraignore
+func scanModTimes(patterns []string) map[string]time.Time {
+	files := map[string]time.Time{}
+	ignore := loadIgnore()
+	for _, pattern := range patterns {
+		for _, match := range globFilesIgnoring(pattern, ignore) {
+			if info, err := os.Stat(match); err == nil {
+				files[match] = info.ModTime()
+			}
+		}
+	}
+	return files
+}
+
+// loadConfig loads and parses the configuration file; a .go or .cue file
+// is evaluated to the configuration first (see frontend.go)
 func loadConfig(configPath string) error {
+	if evaluate := configFrontend(configPath); evaluate != nil {
+		data, err := evaluate(configPath)
+		if err != nil {
+			return orpheus.ExecutionError("config", err.Error())
+		}
+		program, _ := filepath.Abs(configPath)
+		return loadConfigFrom(configPath, func(path string) ([]byte, error) {
+			if path == program {
+				return data, nil
+			}
+			return os.ReadFile(path) // #nosec G304 - includes of the configuration
+		})
+	}
+	if err := loadConfigFrom(configPath, os.ReadFile); err != nil {
+		return err
+	}
+	warnDeprecations(configPath)
+	return nil
+}
+
+// loadConfigFrom loads the configuration reading files through readFile,
+// which lets other revisions of the config be loaded from git
+func loadConfigFrom(configPath string, readFile func(string) ([]byte, error)) error {
 	// Make path absolute
 	if !filepath.IsAbs(configPath) {
 		wd, _ := os.Getwd()
@@ -405,52 +891,182 @@ func loadConfig(configPath string) error {
 	if strings.Contains(configPath, "..") {
 		return orpheus.ValidationError("config", "invalid configuration path: contains '..'")
 	}
+	configDir = filepath.Dir(configPath)
 
 	// Check if config file exists
-	// #nosec G304 - We validate the path above
-	f, err := os.Open(configPath)
+	data, err := readFile(configPath)
 	if err != nil {
 		cd, _ := os.Getwd()
 		return orpheus.NotFoundError("config", fmt.Sprintf("configuration file not found in '%s'", cd))
 	}
-	defer func() { _ = f.Close() }()
 
-	// Decode main file
-	if err := yaml.NewDecoder(f).Decode(&cfg); err != nil {
+	// Decode main file; targets wait until all files are read
+	pending := map[string]pendingTarget{}
+	if err := decodeConfigDoc(&cfg, data, "", pending); err != nil {
 		return orpheus.ValidationError("config", fmt.Sprintf("failed to parse configuration: %v", err))
 	}
 
 	// Load includes
 	for _, inc := range cfg.Includes {
-		incPath := inc
+		if load, err := inc.When.matches(); err != nil {
+			logWarn("Skipping include %s: %v", inc.Path, err)
+			continue
+		} else if !load {
+			continue
+		}
+
+		incPath := inc.Path
 		if !filepath.IsAbs(incPath) {
-			incPath = filepath.Join(filepath.Dir(configPath), inc)
+			incPath = filepath.Join(filepath.Dir(configPath), inc.Path)
 		}
 
 		// Security: Validate include path
 		incPath = filepath.Clean(incPath)
 		if strings.Contains(incPath, "..") {
-			fmt.Fprintf(os.Stderr, "[!] Warning: Skipping invalid include path %s (contains '..')\n", inc)
+			logWarn("Skipping invalid include path %s (contains '..')", inc.Path)
 			continue
 		}
 
-		// #nosec G304 - We validate the path above
-		incFile, err := os.Open(incPath)
+		incData, err := readFile(incPath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "[!] Warning: Cannot load include file %s: %v\n", inc, err)
+			logWarn("Cannot load include file %s: %v", inc.Path, err)
 			continue
 		}
 
-		if err := yaml.NewDecoder(incFile).Decode(&cfg); err != nil {
-			fmt.Fprintf(os.Stderr, "[!] Warning: Failed to parse include file %s: %v\n", inc, err)
+		if inc.Prefix == "" {
+			if err := decodeConfigDoc(&cfg, incData, inc.Path, pending); err != nil {
+				logWarn("Failed to parse include file %s: %v", inc.Path, err)
+			}
+			continue
 		}
+		ignored, err := mergeInclude(&cfg, inc, incData)
+		if err != nil {
+			logWarn("Failed to parse include file %s: %v", inc.Path, err)
+		} else if len(ignored) > 0 {
+			logWarn("Include %s has prefix '%s', so only its targets and vars are used; ignoring %s", inc.Path, inc.Prefix, strings.Join(ignored, ", "))
+		}
+	}
+	if err := decodeTargets(&cfg, pending, partialTargets); err != nil {
+		return orpheus.ValidationError("config", fmt.Sprintf("failed to parse configuration: %v", err))
+	}
+
+	// Workspace members named by deps join the same graph
+	problems, err := loadMembers(&cfg, filepath.Dir(configPath), readFile)
+	for _, problem := range problems {
+		logWarn("%v", problem)
+	}
+	if err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+
+	// Tool paths are relative to the config file, not to wherever commands cd
+	for i, dir := range cfg.ToolPaths {
+		if !filepath.IsAbs(dir) {
+			cfg.ToolPaths[i] = filepath.Join(filepath.Dir(configPath), dir)
+		}
+	}
+
+	// Snapshot the environment so the whole build sees the same values
+	var envTTL time.Duration
+	if cfg.EnvCacheTTL != "" {
+		envTTL, err = time.ParseDuration(cfg.EnvCacheTTL)
+		if err != nil {
+			return orpheus.ValidationError("config", fmt.Sprintf("invalid env_cache_ttl '%s': %v", cfg.EnvCacheTTL, err))
+		}
+	}
+	buildEnv.Snapshot(envTTL)
+
+	if err := validateWatch(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateRunSettings(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateShells(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateNotifiers(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validatePlatforms(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateNetwork(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateKillSettings(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateParams(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateOutputEncodings(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateOutputFilters(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateProblemMatchers(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateOutputModes(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateArchives(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateCacheHash(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateCacheProbes(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateSandboxes(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateProfileStages(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+	if err := validateDeps(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+
+	if err := validateTools(); err != nil {
+		return orpheus.ValidationError("config", err.Error())
+	}
+
+	// The $(command)s of vars run anew once a target needs them
+	resetCommandOutputs()
 
-		_ = incFile.Close()
+	// Check computed variables once everything is merged
+	if err := checkVars(); err != nil {
+		return orpheus.ValidationError("config", fmt.Sprintf("invalid variable: %v", err))
 	}
 
 	return nil
 }
 
+// loadConfigProfile loads the configuration for a profile, which includes
+// conditioned on a profile already see, and then activates it
+func loadConfigProfile(configPath, profile string) error {
+	activeProfile = profile
+	if err := loadConfig(configPath); err != nil {
+		return err
+	}
+	return selectProfile(profile)
+}
+
+// selectProfile activates a profile from the loaded configuration
+func selectProfile(name string) error {
+	if name != "" {
+		if _, exists := cfg.Profiles[name]; !exists {
+			return orpheus.NotFoundError("profile", fmt.Sprintf("profile '%s' not found", name))
+		}
+	}
+	activeProfile = name
+	return nil
+}
+
 // generateTemplate creates a template configuration based on type
 func generateTemplate(templateType string) string {
 	switch templateType {
@@ -521,6 +1137,153 @@ targets:
   start:
     run:
       - "$NPM start"
+`
+	case "cmake":
+		return `vars:
+  PRESET: "dev"
+
+profiles:
+  release:
+    vars:
+      PRESET: "release"
+
+targets:
+  configure:
+    desc: "Configure build/$PRESET with the CMake preset"
+    run:
+      - "cmake --preset $PRESET"
+
+  build:
+    desc: "Build with the CMake preset"
+    deps:
+      - configure
+    run:
+      - "cmake --build --preset $PRESET"
+
+  compile-commands:
+    desc: "Copy compile_commands.json to the project root for clangd and other tools"
+    deps:
+      - configure
+    outputs:
+      - "compile_commands.json"
+    run:
+      - "cmake -E copy build/$PRESET/compile_commands.json compile_commands.json"
+
+  test:
+    desc: "Run the tests with ctest"
+    deps:
+      - build
+    run:
+      - "ctest --preset $PRESET --output-on-failure"
+
+  clean:
+    desc: "Remove the build trees"
+    run:
+      - "cmake -E rm -rf build compile_commands.json"
+`
+	case "python":
+		return `vars:
+  PYTHON:
+    windows: "python"
+    default: "python3"
+  VENV: ".venv"
+  BIN:
+    windows: ".venv/Scripts"
+    default: ".venv/bin"
+
+targets:
+  venv:
+    desc: "Create the virtual environment"
+    run:
+      - "$PYTHON -m venv $VENV"
+
+  install:
+    desc: "Install the project and the dev tools into the virtual environment"
+    deps:
+      - venv
+    run:
+      - "$BIN/python -m pip install -e ."
+      - "$BIN/python -m pip install pytest ruff"
+
+  test:
+    desc: "Run the tests with pytest"
+    deps:
+      - install
+    run:
+      - "$BIN/python -m pytest"
+
+  lint:
+    desc: "Check the code and its formatting with ruff"
+    deps:
+      - install
+    run:
+      - "$BIN/python -m ruff check ."
+      - "$BIN/python -m ruff format --check ."
+
+  format:
+    desc: "Format the code with ruff"
+    deps:
+      - install
+    run:
+      - "$BIN/python -m ruff format ."
+
+  clean:
+    desc: "Remove the virtual environment and the tool caches"
+    run:
+      - "$PYTHON -c \"import shutil; [shutil.rmtree(p, ignore_errors=True) for p in ('$VENV', '.pytest_cache', '.ruff_cache')]\""
+`
+	case "java", "gradle":
+		return `vars:
+  GRADLE:
+    windows: "gradlew.bat"
+    default: "./gradlew"
+  GRADLE_FLAGS: "--console=plain"
+
+targets:
+  build:
+    desc: "Compile and package with the Gradle wrapper"
+    run:
+      - "$GRADLE $GRADLE_FLAGS assemble"
+
+  test:
+    desc: "Run the tests"
+    run:
+      - "$GRADLE $GRADLE_FLAGS test"
+
+  check:
+    desc: "Run the tests and every verification task"
+    run:
+      - "$GRADLE $GRADLE_FLAGS check"
+
+  run:
+    desc: "Run the application (needs the application plugin)"
+    deps:
+      - build
+    run:
+      - "$GRADLE $GRADLE_FLAGS run"
+
+  clean:
+    desc: "Remove the build outputs"
+    run:
+      - "$GRADLE $GRADLE_FLAGS clean"
+`
+	case "workspace":
+		return `# Workspace root: members are directories with their own aura.yaml,
+# whose targets are named after them (app:build) and run there
+members:
+  - lib
+  - app
+
+targets:
+  build:
+    desc: "Build every member"
+    deps:
+      - "app:build"
+
+  test:
+    desc: "Test every member"
+    deps:
+      - "app:test"
 `
 	default: // basic
 		return `vars:
@@ -555,6 +1318,91 @@ epilogue:
 	}
 }
 
+// templateFiles returns the files a template creates besides aura.yaml,
+// by path
+func templateFiles(templateType string) map[string]string {
+	switch templateType {
+	case "cmake":
+		return map[string]string{"CMakePresets.json": cmakePresets}
+	case "workspace":
+		return map[string]string{"lib/aura.yaml": workspaceLib, "app/aura.yaml": workspaceApp}
+	}
+	return nil
+}
+
+// workspaceLib is the member the workspace template's app depends on; its
+// vars are visible to the workspace as lib.NAME
+const workspaceLib = `vars:
+  VERSION: "0.1.0"
+
+targets:
+  build:
+    desc: "Build the library"
+    run:
+      - "echo Building lib $VERSION"
+
+  test:
+    desc: "Test the library"
+    deps:
+      - build
+    run:
+      - "echo Testing lib"
+`
+
+// workspaceApp is the workspace template's member depending on lib, with
+// deps written relative to its own directory
+const workspaceApp = `targets:
+  build:
+    desc: "Build the app against the library"
+    deps:
+      - "../lib:build"
+    run:
+      - "echo Building app with lib ${lib.VERSION}"
+
+  test:
+    desc: "Test the app and the library it uses"
+    deps:
+      - build
+      - "../lib:test"
+    run:
+      - "echo Testing app"
+`
+
+// cmakePresets is the CMakePresets.json `aura init --template cmake` writes
+// when the project has none: a debug and a release build tree under build/,
+// both exporting compile_commands.json
+const cmakePresets = `{
+  "version": 3,
+  "configurePresets": [
+    {
+      "name": "dev",
+      "displayName": "Debug",
+      "binaryDir": "${sourceDir}/build/${presetName}",
+      "cacheVariables": {
+        "CMAKE_BUILD_TYPE": "Debug",
+        "CMAKE_EXPORT_COMPILE_COMMANDS": "ON"
+      }
+    },
+    {
+      "name": "release",
+      "displayName": "Release",
+      "inherits": "dev",
+      "cacheVariables": {
+        "CMAKE_BUILD_TYPE": "Release"
+      }
+    }
+  ],
+  "buildPresets": [
+    { "name": "dev", "configurePreset": "dev" },
+    { "name": "release", "configurePreset": "release" }
+  ],
+  "testPresets": [
+    { "name": "dev", "configurePreset": "dev" },
+    { "name": "release", "configurePreset": "release" }
+  ]
+}
+`
+
 // cacheCommand handles the main cache functionality
 func cacheCommand(ctx *orpheus.Context) error {
 	fmt.Println("Build cache management")
@@ -562,11 +1410,16 @@ func cacheCommand(ctx *orpheus.Context) error {
 	fmt.Println("  clear  - Clear build cache")
 	fmt.Println("  info   - Show cache information")
 	fmt.Println("  list   - List cached items")
+	fmt.Println("  verify - Check cache records for corruption")
 	return nil
 }
 
 // cacheClearCommand clears the build cache
 func cacheClearCommand(ctx *orpheus.Context) error {
+	if err := cacheSetup(ctx); err != nil {
+		return err
+	}
+
 	verbose := ctx.GetGlobalFlagBool("verbose")
 
 	if verbose {
@@ -574,23 +1427,23 @@ func cacheClearCommand(ctx *orpheus.Context) error {
 	}
 
 	cleared := false
-	storage := ctx.Storage()
+	storage := cacheStorage(ctx)
 	if storage != nil {
 		// Clear cache using storage
 		if verbose {
-			fmt.Println("✓ Cache cleared via storage backend")
+			fmt.Println(checkMark(), "Cache cleared via storage backend")
 		}
 		cleared = true
 	}
 
 	// Also clear local cache directory
-	cacheDir := ".aura_cache"
+	cacheDir := buildCacheDir()
 	if info, err := os.Stat(cacheDir); err == nil && info.IsDir() {
 		if err := os.RemoveAll(cacheDir); err != nil {
 			return fmt.Errorf("failed to clear local cache: %v", err)
 		}
 		if verbose {
-			fmt.Printf("✓ Removed local cache directory: %s\n", cacheDir)
+			fmt.Printf("%s Removed local cache directory: %s\n", checkMark(), cacheDir)
 		}
 		cleared = true
 	}
@@ -598,7 +1451,7 @@ func cacheClearCommand(ctx *orpheus.Context) error {
 	if !cleared {
 		fmt.Println("No cache found to clear")
 	} else {
-		fmt.Println("✓ Cache cleared successfully")
+		fmt.Println(checkMark(), "Cache cleared successfully")
 	}
 
 	return nil
@@ -606,21 +1459,25 @@ func cacheClearCommand(ctx *orpheus.Context) error {
 
 // cacheInfoCommand shows cache information
 func cacheInfoCommand(ctx *orpheus.Context) error {
+	if err := cacheSetup(ctx); err != nil {
+		return err
+	}
+
 	fmt.Println("Build cache information:")
 
-	storage := ctx.Storage()
+	storage := cacheStorage(ctx)
 	if storage != nil {
-		fmt.Println("✓ Storage backend: configured and available")
+		fmt.Println(checkMark(), "Storage backend: configured and available")
 		fmt.Println("  Type: Orpheus storage system")
 		fmt.Println("  Features: metrics enabled")
 	} else {
-		fmt.Println("✗ Storage backend: not configured")
+		fmt.Println(crossMark(), "Storage backend: not configured")
 		fmt.Println("  Using local cache fallback")
 	}
 
-	cacheDir := ".aura_cache"
+	cacheDir := buildCacheDir()
 	if info, err := os.Stat(cacheDir); err == nil && info.IsDir() {
-		fmt.Printf("✓ Local cache directory: %s\n", cacheDir)
+		fmt.Printf("%s Local cache directory: %s\n", checkMark(), cacheDir)
 
 		// Count cache entries
 		if entries, err := os.ReadDir(cacheDir); err == nil {
@@ -636,7 +1493,7 @@ func cacheInfoCommand(ctx *orpheus.Context) error {
 			fmt.Printf("  Size: %d bytes\n", totalSize)
 		}
 	} else {
-		fmt.Printf("✗ Local cache directory: not found (%s)\n", cacheDir)
+		fmt.Printf("%s Local cache directory: not found (%s)\n", crossMark(), cacheDir)
 	}
 
 	return nil
@@ -644,22 +1501,26 @@ func cacheInfoCommand(ctx *orpheus.Context) error {
 
 // cacheListCommand lists cached items
 func cacheListCommand(ctx *orpheus.Context) error {
+	if err := cacheSetup(ctx); err != nil {
+		return err
+	}
+
 	verbose := ctx.GetGlobalFlagBool("verbose")
 
 	fmt.Println("Cached build artifacts:")
 
-	storage := ctx.Storage()
+	storage := cacheStorage(ctx)
 	if storage != nil {
-		fmt.Println("✓ Storage backend entries:")
+		fmt.Println(checkMark(), "Storage backend entries:")
 		if verbose {
 			fmt.Println("  (Storage backend listing not implemented)")
 		}
 	}
 
 	// List local cache
-	cacheDir := ".aura_cache"
+	cacheDir := buildCacheDir()
 	if entries, err := os.ReadDir(cacheDir); err == nil {
-		fmt.Println("✓ Local cache entries:")
+		fmt.Println(checkMark(), "Local cache entries:")
 
 		if len(entries) == 0 {
 			fmt.Println("  (no items)")
@@ -681,7 +1542,7 @@ func cacheListCommand(ctx *orpheus.Context) error {
 			}
 		}
 	} else {
-		fmt.Printf("✗ Cannot access cache directory: %v\n", err)
+		fmt.Printf("%s Cannot access cache directory: %v\n", crossMark(), err)
 	}
 
 	return nil
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -18,10 +19,17 @@ func TestMain(m *testing.M) {
 		Vars:    make(map[string]Var),
 	}
 
+	// Keep build state and run history out of the user's cache
+	cacheDir, err := os.MkdirTemp("", "aura-test-cache")
+	if err == nil {
+		_ = os.Setenv("AURA_CACHE_DIR", cacheDir)
+	}
+
 	// Run tests
 	code := m.Run()
 
 	// Clean up
+	_ = os.RemoveAll(cacheDir)
 	os.Exit(code)
 }
 
@@ -287,6 +295,35 @@ func TestGenerateTemplate(t *testing.T) {
 			shouldContain:    []string{"NPM:", "npm", "install", "build", "start"},
 			shouldNotContain: []string{"go", "cargo"},
 		},
+		{
+			name:             "Python template",
+			templateType:     "python",
+			shouldContain:    []string{"-m venv $VENV", "pip install", "-m pytest", "ruff check", "windows:"},
+			shouldNotContain: []string{"cargo", "npm", "gradle"},
+		},
+		{
+			name:             "Java template",
+			templateType:     "java",
+			shouldContain:    []string{"./gradlew", "gradlew.bat", "assemble", "test", "check"},
+			shouldNotContain: []string{"cargo", "npm", "pip"},
+		},
+		{
+			name:          "Gradle is the Java template",
+			templateType:  "gradle",
+			shouldContain: []string{"./gradlew"},
+		},
+		{
+			name:             "Workspace template",
+			templateType:     "workspace",
+			shouldContain:    []string{"members:", "lib", "app:build", "app:test"},
+			shouldNotContain: []string{"cargo", "npm", "gcc"},
+		},
+		{
+			name:             "CMake template",
+			templateType:     "cmake",
+			shouldContain:    []string{"cmake --preset $PRESET", "cmake --build --preset", "ctest --preset", "compile_commands.json", "release"},
+			shouldNotContain: []string{"gcc", "cargo", "npm"},
+		},
 		{
 			name:             "Basic template",
 			templateType:     "basic",
@@ -325,6 +362,73 @@ func TestGenerateTemplate(t *testing.T) {
 	}
 }
 
+func TestWorkspaceTemplate(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	files := templateFiles("workspace")
+	files["aura.yaml"] = generateTemplate("workspace")
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg = Config{}
+	if err := loadConfig("aura.yaml"); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	// The app depends on the library across members and sees its vars
+	app := cfg.Targets["app:test"]
+	if !slices.Equal(app.Deps, []string{"app:build", "lib:test"}) {
+		t.Errorf("app:test deps = %q, expected app:build and lib:test", app.Deps)
+	}
+	build := cfg.Targets["app:build"]
+	if got := planContext("app:build", build).Expand(build.Run[0]); got != "echo Building app with lib 0.1.0" {
+		t.Errorf("app:build command = %q, expected the library version", got)
+	}
+}
+
+func TestWorkspaceTemplateBuilds(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	// Scaffold the workspace as aura init does, then build it untouched
+	scaffold := captureStdout(t)
+	err := writeTemplate("workspace")
+	scaffold()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// From the root, and from the app member, which reaches lib as ../lib
+	for _, dir := range []string{".", "app"} {
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		cfg = Config{}
+		if err := loadConfig("aura.yaml"); err != nil {
+			t.Fatalf("loadConfig() in %s error = %v", dir, err)
+		}
+		stdout := captureStdout(t)
+		err := runTargetWithContext("test", false, false)
+		output := stdout()
+		if err != nil {
+			t.Fatalf("aura build -t test in %s error = %v\n%s", dir, err, output)
+		}
+		for _, expected := range []string{"Building lib 0.1.0", "Building app with lib 0.1.0", "Testing lib", "Testing app"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("output in %s lacks %q:\n%s", dir, expected, output)
+			}
+		}
+	}
+}
+
 // ===== CONFIG LOADING TESTS =====
 
 func TestLoadConfig(t *testing.T) {
@@ -462,6 +566,15 @@ func TestBuildCommandIntegration(t *testing.T) {
 	}
 }
 
+func TestRunTargetCommandUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"VERSION=1.2"}} {
+		err := runTargetCommand(&orpheus.Context{Args: args})
+		if err == nil || !strings.Contains(err.Error(), "usage: aura run <target>") {
+			t.Errorf("runTargetCommand(%q) error = %v, expected the usage", args, err)
+		}
+	}
+}
+
 // ===== COMMAND HANDLER TESTS =====
 
 // Test command handlers by calling their functionality directly
@@ -684,6 +797,28 @@ targets:
 	}
 }
 
+func TestLoadConfigToolPaths(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	dir, tools := t.TempDir(), t.TempDir()
+	configPath := filepath.Join(dir, "aura.yaml")
+	content := fmt.Sprintf("toolpaths:\n  - ./bin\n  - %q\ntargets:\n  build:\n    run: [\"tool\"]\n", tools)
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg = Config{}
+	if err := loadConfig(configPath); err != nil {
+		t.Fatalf("loadConfig() unexpected error: %v", err)
+	}
+
+	expected := []string{filepath.Join(dir, "bin"), tools}
+	if !slices.Equal(cfg.ToolPaths, expected) {
+		t.Errorf("ToolPaths = %v, want %v", cfg.ToolPaths, expected)
+	}
+}
+
 func TestCleanCommandLogic(t *testing.T) {
 	// Test the clean logic without Context dependencies
 	// Create temp directory
@@ -724,6 +859,27 @@ func TestCleanCommandLogic(t *testing.T) {
 	}
 }
 
+func TestSelectProfile(t *testing.T) {
+	original := cfg
+	originalProfile := activeProfile
+	defer func() {
+		cfg = original
+		activeProfile = originalProfile
+	}()
+
+	cfg = Config{Profiles: map[string]Profile{"release": {}}}
+
+	if err := selectProfile("release"); err != nil || activeProfile != "release" {
+		t.Errorf("selectProfile(release) = %v, active %q", err, activeProfile)
+	}
+	if err := selectProfile("missing"); err == nil {
+		t.Errorf("selectProfile(missing) expected error")
+	}
+	if err := selectProfile(""); err != nil || activeProfile != "" {
+		t.Errorf("selectProfile(\"\") = %v, active %q", err, activeProfile)
+	}
+}
+
 func TestValidateCommandLogic(t *testing.T) {
 	// Test validation logic without Context dependencies
 	tempDir, err := os.MkdirTemp("", "TestValidateCommandLogic")
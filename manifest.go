@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// artifact is one produced file in the build manifest
+type artifact struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// manifestTarget lists the outputs of a target that ran in this build
+type manifestTarget struct {
+	Name    string     `json:"name"`
+	Outputs []artifact `json:"outputs"`
+}
+
+// buildManifest describes what a build produced; post-build hooks receive
+// it as JSON on stdin and in the file named by $AURA_MANIFEST
+type buildManifest struct {
+	Project    string           `json:"project"`
+	Profile    string           `json:"profile,omitempty"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Targets    []manifestTarget `json:"targets"`
+}
+
+// completed tracks the targets that succeeded during the current build
+var completed struct {
+	sync.Mutex
+	names []string
+}
+
+func markCompleted(name string) {
+	completed.Lock()
+	defer completed.Unlock()
+	if !slices.Contains(completed.names, name) {
+		completed.names = append(completed.names, name)
+	}
+}
+
+// takeCompleted returns the completed targets and starts a new build
+func takeCompleted() []string {
+	completed.Lock()
+	defer completed.Unlock()
+	names := completed.names
+	completed.names = nil
+	return names
+}
+
+// newBuildManifest collects the declared outputs of the given targets
+func newBuildManifest(names []string) (buildManifest, error) {
+	wd, _ := os.Getwd()
+	manifest := buildManifest{
+		Project:    wd,
+		Profile:    activeProfile,
+		FinishedAt: time.Now().UTC(),
+		Targets:    []manifestTarget{},
+	}
+
+	for _, name := range names {
+		entry := manifestTarget{Name: name, Outputs: []artifact{}}
+		for _, file := range expandPatterns(GetTarget(name).Outputs, name) {
+			info, err := os.Stat(file)
+			if err != nil {
+				return manifest, err
+			}
+			sum, err := hashFile(file)
+			if err != nil {
+				return manifest, err
+			}
+			entry.Outputs = append(entry.Outputs, artifact{Path: file, Size: info.Size(), SHA256: sum})
+		}
+		manifest.Targets = append(manifest.Targets, entry)
+	}
+	return manifest, nil
+}
+
+// runPostBuildHooks hands the manifest of the finished build to every
+// hooks.post_build command; a failing hook fails the build
+func runPostBuildHooks(verbose, dryRun bool) error {
+	names := takeCompleted()
+	if len(cfg.Hooks.PostBuild) == 0 {
+		return nil
+	}
+
+	if dryRun {
+		ectx := newExpandContext("", map[string]string{"AURA_MANIFEST": filepath.Join(buildCacheDir(), "manifest.json")})
+		for _, hook := range cfg.Hooks.PostBuild {
+			fmt.Printf("  [DRY RUN] Would run post-build hook: %s\n", ectx.Expand(hook))
+		}
+		return nil
+	}
+
+	manifest, err := newBuildManifest(names)
+	if err != nil {
+		return orpheus.ExecutionError("post_build", fmt.Sprintf("cannot build manifest: %v", err))
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return orpheus.ExecutionError("post_build", err.Error())
+	}
+
+	if err := os.MkdirAll(buildCacheDir(), 0750); err != nil {
+		return orpheus.ExecutionError("post_build", err.Error())
+	}
+	manifestPath, err := filepath.Abs(filepath.Join(buildCacheDir(), "manifest.json"))
+	if err != nil {
+		return orpheus.ExecutionError("post_build", err.Error())
+	}
+	if err := os.WriteFile(manifestPath, data, 0600); err != nil {
+		return orpheus.ExecutionError("post_build", fmt.Sprintf("cannot write manifest: %v", err))
+	}
+
+	ectx := newExpandContext("", map[string]string{"AURA_MANIFEST": manifestPath})
+	for _, hook := range cfg.Hooks.PostBuild {
+		hook = ectx.Expand(hook)
+		if verbose {
+			fmt.Printf("→ post-build hook: %s\n", hook)
+		}
+
+		cmd := shellCommand("", hook)
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, "AURA_MANIFEST="+manifestPath)
+		cmd.Stdin = bytes.NewReader(data)
+		out, err := cmd.CombinedOutput()
+		if len(bytes.TrimSpace(out)) > 0 {
+			fmt.Print(string(out))
+		}
+		if err != nil {
+			return orpheus.ExecutionError("post_build", fmt.Sprintf("hook '%s' failed: %v", hook, err))
+		}
+	}
+	return nil
+}
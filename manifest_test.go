@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// ===== MANIFEST.GO UNIT TESTS =====
+
+func TestMarkCompleted(t *testing.T) {
+	takeCompleted()
+	markCompleted("lib")
+	markCompleted("app")
+	markCompleted("lib")
+
+	if got := takeCompleted(); !slices.Equal(got, []string{"lib", "app"}) {
+		t.Errorf("takeCompleted() = %v, want [lib app]", got)
+	}
+	if got := takeCompleted(); got != nil {
+		t.Errorf("takeCompleted() after take = %v, want nil", got)
+	}
+}
+
+func TestNewBuildManifest(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"dist/app.js": "console.log(1)", "dist/app.css": "body{}"})
+
+	cfg = Config{Targets: map[string]Target{
+		"web":  {Outputs: []string{"dist/*"}, Run: []string{"echo"}},
+		"lint": {Run: []string{"echo"}},
+	}}
+
+	manifest, err := newBuildManifest([]string{"web", "lint"})
+	if err != nil {
+		t.Fatalf("newBuildManifest() unexpected error: %v", err)
+	}
+	if len(manifest.Targets) != 2 || len(manifest.Targets[0].Outputs) != 2 || len(manifest.Targets[1].Outputs) != 0 {
+		t.Fatalf("newBuildManifest() = %+v", manifest)
+	}
+	css := manifest.Targets[0].Outputs[0]
+	if css.Path != "dist/app.css" || css.Size != 6 || len(css.SHA256) != 64 {
+		t.Errorf("artifact = %+v", css)
+	}
+}
+
+func TestRunPostBuildHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook command uses POSIX shell syntax")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"bin/app": "binary"})
+
+	cfg = Config{
+		Targets: map[string]Target{"build": {Outputs: []string{"bin/app"}, Run: []string{"echo"}}},
+		Hooks:   Hooks{PostBuild: []string{"cat > stdin.json && cp $AURA_MANIFEST file.json"}},
+	}
+
+	takeCompleted()
+	markCompleted("build")
+	if err := runPostBuildHooks(false, false); err != nil {
+		t.Fatalf("runPostBuildHooks() unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"stdin.json", "file.json"} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("hook did not receive the manifest via %s: %v", name, err)
+		}
+		var manifest buildManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("%s is not a manifest: %v", name, err)
+		}
+		if len(manifest.Targets) != 1 || manifest.Targets[0].Outputs[0].Path != "bin/app" {
+			t.Errorf("%s manifest = %+v", name, manifest)
+		}
+	}
+
+	cfg.Hooks.PostBuild = []string{"exit 3"}
+	markCompleted("build")
+	if err := runPostBuildHooks(false, false); err == nil {
+		t.Errorf("runPostBuildHooks() expected error for failing hook")
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// memberConfigName is the config file of a workspace member directory
+const memberConfigName = "aura.yaml"
+
+// parseMemberRef splits a dep on a target of another directory, such as
+// "../lib:build", into the directory and the target name
+func parseMemberRef(dep string) (dir, target string, ok bool) {
+	slash := strings.LastIndex(dep, "/")
+	if slash < 0 {
+		return "", "", false
+	}
+	colon := strings.Index(dep[slash:], ":")
+	if colon < 0 {
+		return "", "", false
+	}
+	dir, target = dep[:slash+colon], dep[slash+colon+1:]
+	if target == "" || path.IsAbs(dir) || filepath.IsAbs(dir) {
+		return "", "", false
+	}
+	return dir, target, true
+}
+
+// memberDir returns the directory of the member at dir as a clean
+// slash-separated path relative to the main config; the main config itself
+// has none
+func memberDir(dir string) string {
+	cleaned := path.Clean(filepath.ToSlash(dir))
+	if cleaned == "." {
+		return ""
+	}
+	return cleaned
+}
+
+// memberNamespace returns the namespace of the member at dir, its base
+// name, so "../lib" gives targets such as lib:build and vars such as
+// lib.VERSION
+func memberNamespace(dir string) string {
+	return path.Base(dir)
+}
+
+// resolveMemberDeps rewrites the refs to other directories among deps,
+// which are relative to the member base, to the names their targets have
+// in the merged config, and returns the member directories they name
+func resolveMemberDeps(deps []string, base string) []string {
+	var members []string
+	for i, dep := range deps {
+		dir, target, ok := parseMemberRef(dep)
+		if !ok {
+			continue
+		}
+		member := memberDir(path.Join(base, dir))
+		if member == "" {
+			deps[i] = target
+			continue
+		}
+		deps[i] = memberNamespace(member) + ":" + target
+		members = append(members, member)
+	}
+	return members
+}
+
+// memberPaths makes a member's relative sources and outputs relative to
+// the main config
+func memberPaths(member string, paths []string) []string {
+	if paths == nil {
+		return nil
+	}
+	joined := make([]string, len(paths))
+	for i, p := range paths {
+		if path.IsAbs(p) || filepath.IsAbs(p) {
+			joined[i] = p
+		} else {
+			joined[i] = path.Join(member, p)
+		}
+	}
+	return joined
+}
+
+// loadMembers merges the workspace members c lists under members:, or
+// that deps such as "../lib:build" name, with the members those name in
+// turn. Only a member's targets and vars are taken, as member:target and
+// member.VAR, where member is the base name of its directory, with its
+// prologue and epilogue and the changes its profiles make to them. Its
+// targets and stages run in that directory. The returned problems are members that could not
+// be loaded, and settings that were ignored; the error is a namespace that
+// is not a valid name or that two members, or a member and an include,
+// would share
+func loadMembers(c *Config, root string, readFile func(string) ([]byte, error)) ([]error, error) {
+	var queue []string
+	for _, member := range c.Members {
+		if dir := memberDir(member); dir != "" {
+			queue = append(queue, dir)
+		}
+	}
+	queue = append(queue, resolveMemberDeps(c.Prologue.Deps, "")...)
+	queue = append(queue, resolveMemberDeps(c.Epilogue.Deps, "")...)
+	for _, name := range sortedKeys(c.Profiles) {
+		for _, change := range []*ProfileStage{c.Profiles[name].Prologue, c.Profiles[name].Epilogue} {
+			if change != nil {
+				queue = append(queue, resolveMemberDeps(change.Deps, "")...)
+			}
+		}
+	}
+	for _, name := range sortedKeys(c.Targets) {
+		queue = append(queue, resolveMemberDeps(c.Targets[name].Deps, "")...)
+	}
+
+	owners := map[string]string{}
+	for _, inc := range c.Includes {
+		if inc.Prefix != "" {
+			owners[inc.Prefix] = "include " + inc.Path
+		}
+	}
+
+	var problems []error
+	loaded := map[string]bool{}
+	for len(queue) > 0 {
+		member := queue[0]
+		queue = queue[1:]
+		if loaded[member] {
+			continue
+		}
+		loaded[member] = true
+
+		namespace := memberNamespace(member)
+		if !includePrefixRegex.MatchString(namespace) {
+			return problems, fmt.Errorf("workspace member %s: its directory name '%s' is not a valid namespace: use letters, digits, '_' and '-'", member, namespace)
+		}
+		if owner, taken := owners[namespace]; taken {
+			return problems, fmt.Errorf("workspace member %s: namespace '%s' is already used by %s", member, namespace, owner)
+		}
+		owners[namespace] = "workspace member " + member
+
+		data, err := readFile(filepath.Join(root, filepath.FromSlash(member), memberConfigName))
+		if err != nil {
+			problems = append(problems, fmt.Errorf("workspace member %s: cannot load %s: %v", member, memberConfigName, err))
+			continue
+		}
+		lib, ignored, err := decodeNamespaced(data, "prologue", "epilogue", "profiles")
+		if err != nil {
+			problems = append(problems, fmt.Errorf("workspace member %s: failed to parse %s: %v", member, memberConfigName, err))
+			continue
+		}
+		for _, name := range sortedKeys(lib.Profiles) {
+			if len(lib.Profiles[name].Vars) > 0 {
+				ignored = append(ignored, "profiles."+name+".vars")
+			}
+		}
+		if len(ignored) > 0 {
+			problems = append(problems, fmt.Errorf("workspace member %s: only its targets, vars, prologue and epilogue are used; ignoring %s", member, strings.Join(ignored, ", ")))
+		}
+
+		// Local deps are renamed first, so a ref to a root target stays one
+		resolveDeps := func(deps []string) {
+			for i, dep := range deps {
+				if _, local := lib.Targets[dep]; local {
+					deps[i] = namespace + ":" + dep
+				}
+			}
+			queue = append(queue, resolveMemberDeps(deps, member)...)
+		}
+		for _, name := range sortedKeys(lib.Targets) {
+			target := lib.Targets[name]
+			resolveDeps(target.Deps)
+			target.Sources = memberPaths(member, target.Sources)
+			target.Outputs = memberPaths(member, target.Outputs)
+			target.dir = filepath.FromSlash(member)
+			lib.Targets[name] = target
+		}
+
+		// The member's prologue and epilogue run around every build, as
+		// member:prologue and member:epilogue
+		stage := memberStage{namespace: namespace, dir: filepath.FromSlash(member), prologue: lib.Prologue, epilogue: lib.Epilogue}
+		used := map[string]bool{
+			"prologue": len(lib.Prologue.Run) > 0 || len(lib.Prologue.Deps) > 0,
+			"epilogue": len(lib.Epilogue.Run) > 0 || len(lib.Epilogue.Deps) > 0,
+		}
+		resolveDeps(stage.prologue.Deps)
+		resolveDeps(stage.epilogue.Deps)
+		for _, name := range sortedKeys(lib.Profiles) {
+			profile := lib.Profiles[name]
+			if profile.Prologue == nil && profile.Epilogue == nil {
+				continue
+			}
+			for _, change := range []struct {
+				key   string
+				stage *ProfileStage
+			}{{"prologue", profile.Prologue}, {"epilogue", profile.Epilogue}} {
+				if change.stage != nil {
+					resolveDeps(change.stage.Deps)
+					used[change.key] = true
+				}
+			}
+			if stage.profiles == nil {
+				stage.profiles = map[string]Profile{}
+			}
+			stage.profiles[name] = Profile{Prologue: profile.Prologue, Epilogue: profile.Epilogue}
+		}
+		for _, key := range sortedKeys(used) {
+			if _, clash := lib.Targets[key]; clash && used[key] {
+				return problems, fmt.Errorf("workspace member %s: target '%s' has the name its %s runs under", member, key, key)
+			}
+		}
+		if used["prologue"] || used["epilogue"] {
+			c.memberStages = append(c.memberStages, stage)
+		}
+		mergeNamespaced(c, namespace, lib)
+	}
+	return problems, nil
+}
+
+// isFileDep reports whether a dep names a file rather than a target
+func isFileDep(dep string) bool {
+	if _, ok := cfg.Targets[dep]; ok {
+		return false
+	}
+	return strings.Contains(dep, ".")
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== MEMBERS.GO UNIT TESTS =====
+
+func TestParseMemberRef(t *testing.T) {
+	tests := []struct {
+		dep    string
+		dir    string
+		target string
+		ok     bool
+	}{
+		{"../lib:build", "../lib", "build", true},
+		{"./api:test", "./api", "test", true},
+		{"libs/core:gen:proto", "libs/core", "gen:proto", true},
+		{"../:build", "../", "build", true},
+		{"build", "", "", false},
+		{"lib:build", "", "", false},
+		{"src/main.c", "", "", false},
+		{"../lib:", "", "", false},
+		{"/abs/lib:build", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dep, func(t *testing.T) {
+			dir, target, ok := parseMemberRef(tt.dep)
+			if dir != tt.dir || target != tt.target || ok != tt.ok {
+				t.Errorf("parseMemberRef(%q) = %q, %q, %v; expected %q, %q, %v", tt.dep, dir, target, ok, tt.dir, tt.target, tt.ok)
+			}
+		})
+	}
+}
+
+func TestResolveMemberDeps(t *testing.T) {
+	deps := []string{"../lib:build", "local", "file.txt", "./gen:run", "../:setup"}
+	members := resolveMemberDeps(deps, "api")
+
+	if expected := []string{"lib:build", "local", "file.txt", "gen:run", "setup"}; !reflect.DeepEqual(deps, expected) {
+		t.Errorf("deps = %v, expected %v", deps, expected)
+	}
+	if expected := []string{"lib", "api/gen"}; !reflect.DeepEqual(members, expected) {
+		t.Errorf("members = %v, expected %v", members, expected)
+	}
+}
+
+func TestLoadMembers(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"api/aura.yaml": `
+targets:
+  build:
+    deps: ["../lib:build", "gen"]
+    sources: ["src/*.go"]
+    outputs: ["bin/api"]
+    run: ["go build -o bin/api"]
+  gen:
+    run: ["echo gen"]
+`,
+		"lib/aura.yaml": `
+hooks:
+  post_build: ["echo done"]
+vars:
+  LEVEL: "2"
+targets:
+  build:
+    deps: ["../:setup"]
+    run: ["echo lib"]
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := Config{
+		Members: []string{"./api"},
+		Targets: map[string]Target{
+			"setup": {Run: []string{"echo setup"}},
+			"all":   {Deps: []string{"./missing:build"}},
+		},
+	}
+	problems, err := loadMembers(&c, root, os.ReadFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sortedKeys(c.Targets); !reflect.DeepEqual(got, []string{"all", "api:build", "api:gen", "lib:build", "setup"}) {
+		t.Errorf("targets = %v", got)
+	}
+	api := c.Targets["api:build"]
+	if expected := []string{"lib:build", "api:gen"}; !reflect.DeepEqual(api.Deps, expected) {
+		t.Errorf("api:build deps = %v, expected %v", api.Deps, expected)
+	}
+	if !reflect.DeepEqual(api.Sources, []string{"api/src/*.go"}) || !reflect.DeepEqual(api.Outputs, []string{"api/bin/api"}) {
+		t.Errorf("api:build paths = %v, %v; expected them below api", api.Sources, api.Outputs)
+	}
+	if api.dir != "api" || api.namespace != "api" {
+		t.Errorf("api:build dir = %q, namespace = %q", api.dir, api.namespace)
+	}
+	if deps := c.Targets["lib:build"].Deps; !reflect.DeepEqual(deps, []string{"setup"}) {
+		t.Errorf("lib:build deps = %v, expected the root setup", deps)
+	}
+	if deps := c.Targets["all"].Deps; !reflect.DeepEqual(deps, []string{"missing:build"}) {
+		t.Errorf("all deps = %v", deps)
+	}
+	if c.Vars["lib.LEVEL"] != "2" {
+		t.Errorf("lib vars = %v", c.Vars)
+	}
+
+	var messages []string
+	for _, err := range problems {
+		messages = append(messages, err.Error())
+	}
+	joined := strings.Join(messages, "\n")
+	if len(problems) != 2 || !strings.Contains(joined, "workspace member missing: cannot load") || !strings.Contains(joined, "workspace member lib: only its targets, vars, prologue and epilogue are used; ignoring hooks") {
+		t.Errorf("problems = %v", messages)
+	}
+}
+
+func TestLoadMembersOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	writeMember := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeMember("lib/aura.yaml", "vars:\n  VERSION: \"1.0\"\ntargets:\n  build:\n    outputs: [out]\n")
+
+	c := Config{Targets: map[string]Target{"app": {Deps: []string{"../lib:build"}}}}
+	if _, err := loadMembers(&c, filepath.Join(root, "app"), os.ReadFile); err != nil {
+		t.Fatal(err)
+	}
+	if deps := c.Targets["app"].Deps; !reflect.DeepEqual(deps, []string{"lib:build"}) {
+		t.Errorf("app deps = %v, expected lib:build", deps)
+	}
+	lib, ok := c.Targets["lib:build"]
+	if !ok || lib.dir != filepath.FromSlash("../lib") || lib.namespace != "lib" || !reflect.DeepEqual(lib.Outputs, []string{"../lib/out"}) {
+		t.Errorf("lib:build = %+v, %v; expected it named lib and run in ../lib", lib, ok)
+	}
+	if c.Vars["lib.VERSION"] != "1.0" {
+		t.Errorf("vars = %v, expected lib.VERSION", c.Vars)
+	}
+}
+
+func TestLoadMembersNamespaceErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		member   string
+		expected string
+	}{
+		{
+			name:     "shared base name",
+			config:   Config{Members: []string{"a/lib", "b/lib"}},
+			expected: "workspace member b/lib: namespace 'lib' is already used by workspace member a/lib",
+		},
+		{
+			name: "include prefix",
+			config: Config{
+				Members:  []string{"lib"},
+				Includes: []Include{{Path: "lib.yaml", Prefix: "lib"}},
+			},
+			expected: "workspace member lib: namespace 'lib' is already used by include lib.yaml",
+		},
+		{
+			name:     "stage name",
+			config:   Config{Members: []string{"lib"}},
+			member:   "prologue: {run: [echo setup]}\ntargets: {prologue: {}}\n",
+			expected: "workspace member lib: target 'prologue' has the name its prologue runs under",
+		},
+		{
+			name:     "invalid name",
+			config:   Config{Members: []string{"my lib"}},
+			expected: "workspace member my lib: its directory name 'my lib' is not a valid namespace",
+		},
+		{
+			name:     "parent directory",
+			config:   Config{Targets: map[string]Target{"all": {Deps: []string{"../:build"}}}},
+			expected: "workspace member ..: its directory name '..' is not a valid namespace",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readFile := func(string) ([]byte, error) {
+				if tt.member != "" {
+					return []byte(tt.member), nil
+				}
+				return []byte("targets: {build: {}}\n"), nil
+			}
+			_, err := loadMembers(&tt.config, t.TempDir(), readFile)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("loadMembers() error = %v, expected %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadMemberStages(t *testing.T) {
+	files := map[string]string{
+		"lib/aura.yaml": `
+profiles:
+  ci:
+    vars: {LEVEL: "3"}
+    epilogue: {mode: replace, run: ["echo ci"]}
+prologue:
+  deps: [gen, "../:setup"]
+  run: ["echo setup"]
+targets:
+  gen:
+    run: ["echo gen"]
+`,
+		"app/aura.yaml": "targets:\n  build:\n    run: [echo app]\n",
+	}
+	readFile := func(path string) ([]byte, error) {
+		return []byte(files[filepath.ToSlash(path)]), nil
+	}
+
+	c := Config{Members: []string{"app", "lib"}}
+	problems, err := loadMembers(&c, ".", readFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "workspace member lib: only its targets, vars, prologue and epilogue are used; ignoring profiles.ci.vars") {
+		t.Errorf("problems = %v", problems)
+	}
+
+	// Members without a prologue or epilogue have no stages
+	if len(c.memberStages) != 1 {
+		t.Fatalf("member stages = %+v, expected lib's only", c.memberStages)
+	}
+	lib := c.memberStages[0]
+	if lib.namespace != "lib" || lib.dir != "lib" {
+		t.Errorf("lib stage namespace = %q, dir = %q", lib.namespace, lib.dir)
+	}
+	if expected := []string{"lib:gen", "setup"}; !reflect.DeepEqual(lib.prologue.Deps, expected) {
+		t.Errorf("lib prologue deps = %v, expected %v", lib.prologue.Deps, expected)
+	}
+	if change := lib.profiles["ci"].Epilogue; change == nil || change.Mode != stageReplace || lib.profiles["ci"].Vars != nil {
+		t.Errorf("lib ci profile = %+v, expected its epilogue only", lib.profiles["ci"])
+	}
+}
+
+func TestWorkspaceStageOrder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{}
+	chdirTemp(t)
+	writeFiles(t, map[string]string{
+		"aura.yaml": `
+members: [lib, app]
+prologue:
+  run: ["echo setup >> order.txt"]
+epilogue:
+  run: ["echo teardown >> order.txt"]
+targets:
+  all:
+    deps: ["app:build"]
+`,
+		"app/aura.yaml": `
+prologue:
+  run: ["echo app setup >> ../order.txt"]
+epilogue:
+  run: ["echo app teardown >> ../order.txt"]
+targets:
+  build:
+    deps: ["../lib:build"]
+    run: ["echo app build >> ../order.txt"]
+`,
+		"lib/aura.yaml": `
+vars:
+  NAME: lib
+prologue:
+  run: ['echo $NAME setup in $(basename "$(pwd)") >> ../order.txt']
+epilogue:
+  run: ["echo lib teardown >> ../order.txt"]
+targets:
+  build:
+    run: ["echo lib build >> ../order.txt"]
+`,
+	})
+	if err := loadConfig("aura.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.RunPrologueWithContext(false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := runTargetWithContext("all", false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.RunEpilogueWithContext(false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile("order.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "setup\nlib setup in lib\napp setup\nlib build\napp build\napp teardown\nlib teardown\nteardown\n"
+	if string(data) != expected {
+		t.Errorf("order.txt = %q, expected %q", data, expected)
+	}
+}
+
+func TestIsFileDep(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{"../lib:build": {}, "lib:build": {}}}
+
+	tests := map[string]bool{
+		"main.c":       true,
+		"build":        false,
+		"lib:build":    false,
+		"../lib:build": false,
+	}
+	for dep, expected := range tests {
+		if got := isFileDep(dep); got != expected {
+			t.Errorf("isFileDep(%q) = %v, expected %v", dep, got, expected)
+		}
+	}
+}
+
+func TestWorkspaceBuildOrder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{}
+	chdirTemp(t)
+	writeFiles(t, map[string]string{
+		"aura.yaml": "members: [api]\n",
+		"api/aura.yaml": `
+targets:
+  build:
+    deps: ["../lib:build"]
+    run: ["cat ../lib/out.txt > out.txt"]
+`,
+		"lib/aura.yaml": `
+targets:
+  build:
+    run: ["echo lib > out.txt"]
+`,
+	})
+	if err := loadConfig("aura.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runTargetWithContext("api:build", false, false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join("api", "out.txt"))
+	if err != nil || strings.TrimSpace(string(data)) != "lib" {
+		t.Errorf("api/out.txt = %q, %v; expected lib built first", data, err)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// Values of a target's network: setting
+const (
+	networkAllow = "allow" // the default
+	networkNone  = "none"
+)
+
+// blackholeProxy is where commands of network: none targets send proxied
+// traffic when they cannot get a namespace of their own: the discard port,
+// closed on nearly every machine
+const blackholeProxy = "http://127.0.0.1:9"
+
+// proxyVars are the proxy settings tools commonly honour
+var proxyVars = []string{"http_proxy", "https_proxy", "ftp_proxy", "all_proxy", "HTTP_PROXY", "HTTPS_PROXY", "FTP_PROXY", "ALL_PROXY"}
+
+// warnedNoNamespace makes the fallback warning show once per run
+var warnedNoNamespace sync.Once
+
+// validateNetwork checks the network: settings of the targets
+func validateNetwork() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		switch network := cfg.Targets[name].Network; network {
+		case "", networkAllow, networkNone:
+		default:
+			return fmt.Errorf("unknown network '%s' in target '%s' (use none or allow)", network, name)
+		}
+	}
+	return nil
+}
+
+// cutNetwork keeps a command of a network: none target off the network: in
+// a network namespace with nothing but a loopback that is down on Linux, else
+// behind a proxy that goes nowhere, which stops the tools that honour
+// proxy settings only
+func cutNetwork(cmd *exec.Cmd) {
+	if isolateNetwork(cmd) {
+		return
+	}
+	warnedNoNamespace.Do(func() {
+		logWarn("network: none cannot isolate commands here; they get a proxy that goes nowhere, which tools ignoring proxy settings bypass")
+	})
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	for _, name := range proxyVars {
+		cmd.Env = append(cmd.Env, name+"="+blackholeProxy)
+	}
+	cmd.Env = append(cmd.Env, "no_proxy=", "NO_PROXY=")
+}
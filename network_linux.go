@@ -0,0 +1,42 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+// namespaces reports once whether this system lets aura start processes
+// in a user and network namespace of their own, which unprivileged users
+// may not be allowed
+var namespaces = sync.OnceValue(func() bool {
+	cmd := exec.Command("/bin/sh", "-c", "exit 0")
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	joinNetworkNamespace(cmd.SysProcAttr)
+	return cmd.Run() == nil
+})
+
+// isolateNetwork starts the command in a new network namespace, reached
+// through a user namespace mapping aura's own user, so no privileges are
+// needed and files keep their owner. It reports false when namespaces are
+// not available
+func isolateNetwork(cmd *exec.Cmd) bool {
+	if !namespaces() {
+		return false
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	joinNetworkNamespace(cmd.SysProcAttr)
+	return true
+}
+
+// joinNetworkNamespace sets attr up for a new user and network namespace
+func joinNetworkNamespace(attr *syscall.SysProcAttr) {
+	attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+}
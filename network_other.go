@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"os/exec"
+)
+
+// isolateNetwork reports that commands cannot get a network namespace:
+// only Linux has them
+func isolateNetwork(cmd *exec.Cmd) bool {
+	return false
+}
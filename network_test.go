@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ===== NETWORK.GO UNIT TESTS =====
+
+func TestValidateNetwork(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		network string
+		wantErr bool
+	}{
+		{"", false},
+		{"none", false},
+		{"allow", false},
+		{"off", true},
+	}
+
+	for _, tt := range tests {
+		cfg = Config{Targets: map[string]Target{"fetch": {Network: tt.network}}}
+		if err := validateNetwork(); (err != nil) != tt.wantErr {
+			t.Errorf("validateNetwork(%q) error = %v, wantErr %v", tt.network, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTargetLimitsOffline(t *testing.T) {
+	if targetLimits(&Target{}).offline || !targetLimits(&Target{Network: "none"}).offline {
+		t.Error("targetLimits() should only cut the network of network: none targets")
+	}
+}
+
+func TestCutNetwork(t *testing.T) {
+	cmd := exec.Command("true")
+	cutNetwork(cmd)
+
+	if isolateNetwork(exec.Command("true")) {
+		if cmd.SysProcAttr == nil {
+			t.Fatal("cutNetwork() should start the command in a network namespace")
+		}
+		return
+	}
+	// Without namespaces proxies go nowhere and nothing bypasses them
+	for _, want := range []string{"https_proxy=" + blackholeProxy, "HTTP_PROXY=" + blackholeProxy, "NO_PROXY="} {
+		if !slices.Contains(cmd.Env, want) {
+			t.Errorf("environment lacks %s", want)
+		}
+	}
+}
+
+func TestOfflineTarget(t *testing.T) {
+	if !isolateNetwork(exec.Command("true")) {
+		t.Skip("needs Linux network namespaces")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{}
+
+	// Only the loopback interface is left, and it is down
+	target := Target{Network: "none", Run: []string{"cat /proc/net/dev"}}
+	stdout := captureStdout(t)
+	err := ExecuteAllWithContext("fetch", &target, false, false)
+	output := stdout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(output, "eth") || !strings.Contains(output, "lo:") {
+		t.Errorf("interfaces = %q, expected loopback only", output)
+	}
+}
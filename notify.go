@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Build event types that notifiers can subscribe to
+const (
+	eventSlow   = "slow"   // a target exceeded warn_after
+	eventFailed = "failed" // a build failed
+)
+
+// buildEvent is what notifiers receive, as JSON on stdin or in the body
+type buildEvent struct {
+	Type     string        `json:"type"`
+	Target   string        `json:"target,omitempty"`
+	Message  string        `json:"message"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Summary  string        `json:"summary,omitempty"`
+	Time     time.Time     `json:"time"`
+}
+
+// notifyClient bounds how long a webhook may hold up the build
+var notifyClient = &http.Client{Timeout: 10 * time.Second}
+
+// notify hands an event to every notifier subscribed to it. Notifications
+// are best effort: failures are warnings, never build errors.
+func notify(event buildEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	for _, n := range cfg.Notify {
+		if len(n.Events) > 0 && !slices.Contains(n.Events, event.Type) {
+			continue
+		}
+		if err := sendNotification(n, event); err != nil {
+			logWarn("notification for %s event failed: %v", event.Type, err)
+		}
+	}
+}
+
+// subscribed reports whether any notifier receives events of a type
+func subscribed(eventType string) bool {
+	for _, n := range cfg.Notify {
+		if len(n.Events) == 0 || slices.Contains(n.Events, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyFailure sends the failed event of a build, with the summary of
+// the targets that ran
+func notifyFailure(list []targetResult, buildErr error) {
+	event := buildEvent{Type: eventFailed, Message: buildErr.Error()}
+	var duration time.Duration
+	for _, r := range list {
+		duration += r.Duration
+		if r.Err != nil && event.Target == "" {
+			event.Target = r.Name
+		}
+	}
+	event.Duration = duration
+	var summary strings.Builder
+	writeSummary(&summary, list)
+	event.Summary = summary.String()
+	notify(event)
+}
+
+// sendNotification delivers an event through one notifier
+func sendNotification(n Notifier, event buildEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if n.Command != "" {
+		// $AURA_MESSAGE expands shell quoted, the environment has it verbatim
+		ectx := newExpandContext(event.Target, map[string]string{
+			"AURA_EVENT":   event.Type,
+			"AURA_TARGET":  event.Target,
+			"AURA_MESSAGE": quoteArgs([]string{event.Message}),
+		})
+		cmd := shellCommand("", ectx.Expand(n.Command))
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, "AURA_EVENT="+event.Type, "AURA_TARGET="+event.Target, "AURA_MESSAGE="+event.Message)
+		cmd.Stdin = bytes.NewReader(data)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%v: %s", err, bytes.TrimSpace(out))
+		}
+	}
+
+	if n.Webhook != "" {
+		resp, err := notifyClient.Post(n.Webhook, "application/json", bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returned %s", resp.Status)
+		}
+	}
+
+	if n.Email != nil {
+		if err := sendEmail(n.Email, event); err != nil {
+			return fmt.Errorf("email: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateNotifiers checks the event types notifiers subscribe to and
+// their email settings, so a typo fails at load rather than staying silent
+func validateNotifiers() error {
+	for i, n := range cfg.Notify {
+		for _, event := range n.Events {
+			if event != eventSlow && event != eventFailed {
+				return fmt.Errorf("notifier %d: unknown event '%s' (expected %s or %s)", i+1, event, eventSlow, eventFailed)
+			}
+		}
+		if n.Email != nil {
+			if err := validateEmail(n.Email); err != nil {
+				return fmt.Errorf("notifier %d: email: %v", i+1, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ===== NOTIFY.GO UNIT TESTS =====
+
+func TestNotifyWebhook(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	var mu sync.Mutex
+	var received []buildEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event buildEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("webhook body is not an event: %v", err)
+		}
+		mu.Lock()
+		received = append(received, event)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	cfg = Config{Notify: []Notifier{
+		{Webhook: server.URL},
+		{Webhook: server.URL, Events: []string{"other"}},
+	}}
+	notify(buildEvent{Type: eventSlow, Target: "test", Message: "too slow", Duration: time.Minute})
+
+	if len(received) != 1 {
+		t.Fatalf("webhook received %d events, expected 1 (the other notifier is not subscribed)", len(received))
+	}
+	if received[0].Type != eventSlow || received[0].Target != "test" || received[0].Time.IsZero() {
+		t.Errorf("webhook received %+v", received[0])
+	}
+}
+
+func TestSendNotificationErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if err := sendNotification(Notifier{Webhook: server.URL}, buildEvent{Type: eventSlow}); err == nil {
+		t.Error("sendNotification() should fail on an error status")
+	}
+	if err := sendNotification(Notifier{Command: "exit 3"}, buildEvent{Type: eventSlow}); err == nil {
+		t.Error("sendNotification() should fail when the command fails")
+	}
+}
+
+func TestNotifyFailure(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	var received []buildEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event buildEvent
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		received = append(received, event)
+	}))
+	defer server.Close()
+
+	cfg = Config{Notify: []Notifier{{Webhook: server.URL, Events: []string{eventFailed}}}}
+	if !subscribed(eventFailed) || subscribed(eventSlow) {
+		t.Fatal("subscribed() should report only the failed event")
+	}
+	notifyFailure([]targetResult{
+		{Name: "build", Duration: time.Second},
+		{Name: "test", Duration: time.Second, Err: errors.New("exit status 1")},
+	}, errors.New("target 'test' failed"))
+
+	if len(received) != 1 {
+		t.Fatalf("webhook received %d events, expected 1", len(received))
+	}
+	event := received[0]
+	if event.Type != eventFailed || event.Target != "test" || event.Duration != 2*time.Second {
+		t.Errorf("event = %+v, expected the failed target and the build's duration", event)
+	}
+	if !strings.Contains(event.Summary, "targets=2 failed=1") || !strings.Contains(event.Summary, "target=test status=failed") {
+		t.Errorf("event summary = %q", event.Summary)
+	}
+}
+
+func TestValidateNotifiers(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{Notify: []Notifier{{Events: []string{eventSlow, eventFailed}, Command: "true"}}}
+	if err := validateNotifiers(); err != nil {
+		t.Errorf("validateNotifiers() error = %v", err)
+	}
+	cfg.Notify = append(cfg.Notify, Notifier{Events: []string{"fail"}})
+	if err := validateNotifiers(); err == nil || !strings.Contains(err.Error(), "notifier 2: unknown event 'fail'") {
+		t.Errorf("validateNotifiers() error = %v, expected the unknown event", err)
+	}
+	cfg.Notify = []Notifier{{Email: &EmailNotifier{SMTP: "localhost:25"}}}
+	if err := validateNotifiers(); err == nil || !strings.Contains(err.Error(), "notifier 1: email: from is required") {
+		t.Errorf("validateNotifiers() error = %v, expected the email settings", err)
+	}
+}
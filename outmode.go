@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// OutputMode normalizes a target's outputs once it succeeds, so packaging
+// gets the same permissions and timestamps whatever the umask and clock
+type OutputMode struct {
+	File  string `yaml:"file"`  // octal mode for files, e.g. "0644"; executables keep x
+	Dir   string `yaml:"dir"`   // octal mode for directories named in outputs
+	Mtime string `yaml:"mtime"` // RFC 3339 time, Unix seconds or SOURCE_DATE_EPOCH
+}
+
+// parseFileMode parses an octal permission string such as "0755"
+func parseFileMode(value string) (fs.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o7777 {
+		return 0, fmt.Errorf("invalid mode '%s' (use octal, e.g. 0644)", value)
+	}
+	return fs.FileMode(mode & 0o777), nil
+}
+
+// parseMtime resolves an mtime setting; SOURCE_DATE_EPOCH reads the
+// variable of the reproducible builds convention
+func parseMtime(value string) (time.Time, error) {
+	if value == "SOURCE_DATE_EPOCH" {
+		value = buildEnv.Getenv("SOURCE_DATE_EPOCH")
+		if value == "" {
+			return time.Time{}, fmt.Errorf("mtime is SOURCE_DATE_EPOCH but the variable is not set")
+		}
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid mtime '%s' (use RFC 3339, Unix seconds or SOURCE_DATE_EPOCH)", value)
+	}
+	return t, nil
+}
+
+// validateOutputModes checks the output_mode settings; the mtime is only
+// checked for its format since SOURCE_DATE_EPOCH may be set later
+func validateOutputModes() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		om := cfg.Targets[name].OutputMode
+		for _, mode := range []string{om.File, om.Dir} {
+			if mode == "" {
+				continue
+			}
+			if _, err := parseFileMode(mode); err != nil {
+				return fmt.Errorf("output_mode of target '%s': %v", name, err)
+			}
+		}
+		if om.Mtime != "" && om.Mtime != "SOURCE_DATE_EPOCH" {
+			if _, err := parseMtime(om.Mtime); err != nil {
+				return fmt.Errorf("output_mode of target '%s': %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeOutputs applies output_mode to the files matched by a target's
+// outputs and to everything inside output directories
+func normalizeOutputs(name string, target *Target) error {
+	om := target.OutputMode
+	if om == (OutputMode{}) {
+		return nil
+	}
+
+	var fileMode, dirMode fs.FileMode
+	var err error
+	if om.File != "" {
+		if fileMode, err = parseFileMode(om.File); err != nil {
+			return err
+		}
+	}
+	if om.Dir != "" {
+		if dirMode, err = parseFileMode(om.Dir); err != nil {
+			return err
+		}
+	}
+	var mtime time.Time
+	if om.Mtime != "" {
+		if mtime, err = parseMtime(om.Mtime); err != nil {
+			return err
+		}
+	}
+
+	apply := func(path string, dir bool) error {
+		mode := fileMode
+		if dir {
+			mode = dirMode
+		} else if info, err := os.Stat(path); err == nil && info.Mode()&0o100 != 0 {
+			// Executables stay executable wherever they are readable, like git
+			mode |= (mode & 0o444) >> 2
+		}
+		if mode != 0 {
+			if err := os.Chmod(path, mode); err != nil {
+				return err
+			}
+		}
+		if !mtime.IsZero() {
+			return os.Chtimes(path, mtime, mtime)
+		}
+		return nil
+	}
+
+	for _, pattern := range target.Outputs {
+		path := ParseVars(pattern, name)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			// Children first, so a read-only dir mode cannot lock the walk out
+			var dirs []string
+			if err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() {
+					dirs = append(dirs, p)
+					return nil
+				}
+				return apply(p, false)
+			}); err != nil {
+				return err
+			}
+			for i := len(dirs) - 1; i >= 0; i-- {
+				if err := apply(dirs[i], true); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for _, file := range globFiles(path) {
+			if err := apply(filepath.FromSlash(file), false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
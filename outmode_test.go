@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===== OUTMODE.GO UNIT TESTS =====
+
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected os.FileMode
+		wantErr  bool
+	}{
+		{"0644", 0o644, false},
+		{"755", 0o755, false},
+		{"0600", 0o600, false},
+		{"0999", 0, true},
+		{"rw-r--r--", 0, true},
+		{"17777", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			mode, err := parseFileMode(tt.value)
+			if (err != nil) != tt.wantErr || mode != tt.expected {
+				t.Errorf("parseFileMode(%q) = %o, %v", tt.value, mode, err)
+			}
+		})
+	}
+}
+
+func TestParseMtime(t *testing.T) {
+	epoch := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	t.Setenv("SOURCE_DATE_EPOCH", "946684800")
+	buildEnv.Snapshot(0)
+
+	for _, value := range []string{"946684800", "2000-01-01T00:00:00Z", "SOURCE_DATE_EPOCH"} {
+		got, err := parseMtime(value)
+		if err != nil || !got.Equal(epoch) {
+			t.Errorf("parseMtime(%q) = %v, %v", value, got, err)
+		}
+	}
+	if _, err := parseMtime("yesterday"); err == nil {
+		t.Error("parseMtime() should reject unknown formats")
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	buildEnv.Snapshot(0)
+	if _, err := parseMtime("SOURCE_DATE_EPOCH"); err == nil || !strings.Contains(err.Error(), "not set") {
+		t.Errorf("parseMtime() without SOURCE_DATE_EPOCH error = %v", err)
+	}
+}
+
+func TestValidateOutputModes(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		mode    OutputMode
+		wantErr bool
+	}{
+		{"unset", OutputMode{}, false},
+		{"valid", OutputMode{File: "0644", Dir: "0755", Mtime: "SOURCE_DATE_EPOCH"}, false},
+		{"bad file mode", OutputMode{File: "0888"}, true},
+		{"bad dir mode", OutputMode{Dir: "x"}, true},
+		{"bad mtime", OutputMode{Mtime: "now"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: map[string]Target{"pkg": {OutputMode: tt.mode}}}
+			if err := validateOutputModes(); (err != nil) != tt.wantErr {
+				t.Errorf("validateOutputModes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeOutputs(t *testing.T) {
+	dir := t.TempDir()
+	mustWrite := func(path string, mode os.FileMode) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustWrite(filepath.Join(dir, "dist", "sub", "data.txt"), 0o600)
+	mustWrite(filepath.Join(dir, "dist", "tool"), 0o700)
+	mustWrite(filepath.Join(dir, "app.bin"), 0o600)
+	mustWrite(filepath.Join(dir, "other.bin"), 0o600)
+
+	mtime := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	target := &Target{
+		Outputs:    []string{filepath.ToSlash(filepath.Join(dir, "dist")), filepath.ToSlash(filepath.Join(dir, "app.*"))},
+		OutputMode: OutputMode{File: "0644", Dir: "0755", Mtime: "946684800"},
+	}
+	if err := normalizeOutputs("pkg", target); err != nil {
+		t.Fatalf("normalizeOutputs() error: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		mode     os.FileMode
+		mtimeSet bool
+	}{
+		{"dist", 0o755, true},
+		{"dist/sub", 0o755, true},
+		{"dist/sub/data.txt", 0o644, true},
+		{"dist/tool", 0o755, true},
+		{"app.bin", 0o644, true},
+		{"other.bin", 0o600, false},
+	}
+	for _, tt := range tests {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(tt.path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.ModTime().Equal(mtime) != tt.mtimeSet {
+			t.Errorf("%s mtime = %v", tt.path, info.ModTime())
+		}
+		// Windows only has a read-only bit
+		if runtime.GOOS != "windows" && info.Mode().Perm() != tt.mode {
+			t.Errorf("%s mode = %o, expected %o", tt.path, info.Mode().Perm(), tt.mode)
+		}
+	}
+}
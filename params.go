@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+)
+
+// paramArgs are the --arg NAME=VALUE values of the command line
+var paramArgs = map[string]string{}
+
+// targetArgs holds the parameter values of the targets of the current
+// build, by target, from --arg or the params' defaults
+var targetArgs = map[string]map[string]string{}
+
+// paramRegex matches a params entry: a name, optionally with =default
+var paramRegex = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(?:=(.*))?$`)
+
+// parseParam splits a params entry such as "channel=stable" into the name,
+// the default and whether it has one
+func parseParam(param string) (name, value string, hasDefault bool) {
+	m := paramRegex.FindStringSubmatch(param)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], strings.Contains(param, "=")
+}
+
+// validateParams checks the params of every target are names, optionally
+// with a default, declared once
+func validateParams() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		seen := map[string]bool{}
+		for _, param := range cfg.Targets[name].Params {
+			key, _, _ := parseParam(param)
+			if key == "" {
+				return fmt.Errorf("invalid param '%s' for target '%s' (use NAME or NAME=default)", param, name)
+			}
+			if seen[key] {
+				return fmt.Errorf("param '%s' of target '%s' is declared twice", key, name)
+			}
+			seen[key] = true
+		}
+	}
+	return nil
+}
+
+// resolveTargetArgs gives the targets a build runs, names and their deps,
+// their parameter values: the --arg with the param's name, else its
+// default. A command line override of the name (`aura run release
+// version=1.2.3`) also sets it. A param with no value, or an --arg no
+// target takes, is an error
+func resolveTargetArgs(names []string, args map[string]string) (map[string]map[string]string, error) {
+	set := querySet{}
+	for _, name := range names {
+		set[name] = true
+	}
+	resolved := map[string]map[string]string{}
+	used := map[string]bool{}
+	for _, name := range sortedKeys(reachable(set, -1, targetEdges)) {
+		for _, param := range cfg.Targets[name].Params {
+			key, value, hasDefault := parseParam(param)
+			if arg, ok := args[key]; ok {
+				value = arg
+				used[key] = true
+			} else if override, ok := overrideVars[key]; ok {
+				value = override
+			} else if !hasDefault {
+				return nil, fmt.Errorf("target '%s' needs the parameter '%s': pass --arg %s=VALUE, or %s=VALUE after --", name, key, key, key)
+			}
+			if resolved[name] == nil {
+				resolved[name] = map[string]string{}
+			}
+			resolved[name][key] = value
+		}
+	}
+	for _, key := range sortedKeys(args) {
+		if !used[key] {
+			return nil, fmt.Errorf("no target of the build takes the parameter '%s'", key)
+		}
+	}
+	return resolved, nil
+}
+
+// takeParamArgs moves the NAME=value arguments after `--` that name a param
+// of the targets a build runs into the --arg values, so `aura run release
+// -- version=1.2.3` sets the param instead of passing the text on. The
+// other arguments are returned as the passthrough ones. A param given both
+// ways is an error
+func takeParamArgs(names []string, args map[string]string, passthrough []string) (map[string]string, []string, error) {
+	set := querySet{}
+	for _, name := range names {
+		set[name] = true
+	}
+	params := map[string]bool{}
+	for name := range reachable(set, -1, targetEdges) {
+		for _, param := range cfg.Targets[name].Params {
+			key, _, _ := parseParam(param)
+			params[key] = true
+		}
+	}
+
+	taken := maps.Clone(args)
+	var rest []string
+	for _, arg := range passthrough {
+		m := varAssignRegex.FindStringSubmatch(arg)
+		if m == nil || !params[m[1]] {
+			rest = append(rest, arg)
+			continue
+		}
+		if _, ok := args[m[1]]; ok {
+			return nil, nil, fmt.Errorf("parameter '%s' is given both with --arg and after --", m[1])
+		}
+		if taken == nil {
+			taken = map[string]string{}
+		}
+		taken[m[1]] = m[2]
+	}
+	return taken, rest, nil
+}
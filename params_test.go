@@ -0,0 +1,235 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// ===== PARAMS.GO UNIT TESTS =====
+
+func TestParseParam(t *testing.T) {
+	tests := []struct {
+		param      string
+		name       string
+		value      string
+		hasDefault bool
+	}{
+		{"version", "version", "", false},
+		{"channel=stable", "channel", "stable", true},
+		{"flags=", "flags", "", true},
+		{"opts=-a=1", "opts", "-a=1", true},
+		{"1st", "", "", false},
+		{"a.b", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			name, value, hasDefault := parseParam(tt.param)
+			if name != tt.name || value != tt.value || hasDefault != tt.hasDefault {
+				t.Errorf("parseParam(%q) = %q, %q, %v, expected %q, %q, %v", tt.param, name, value, hasDefault, tt.name, tt.value, tt.hasDefault)
+			}
+		})
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		params  []string
+		wantErr string
+	}{
+		{"valid", []string{"version", "channel=stable"}, ""},
+		{"bad name", []string{"my-version"}, "invalid param 'my-version'"},
+		{"twice", []string{"version", "version=1"}, "declared twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: map[string]Target{"release": {Params: tt.params}}}
+			err := validateParams()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("validateParams() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validateParams() error = %v, expected %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveTargetArgs(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{
+		"tag":     {Params: []string{"version"}},
+		"release": {Deps: []string{"tag"}, Params: []string{"version", "channel=stable"}},
+		"docs":    {},
+	}}
+
+	tests := []struct {
+		name     string
+		targets  []string
+		args     map[string]string
+		expected map[string]map[string]string
+		wantErr  string
+	}{
+		{"defaults and deps", []string{"release"}, map[string]string{"version": "1.2.3"}, map[string]map[string]string{
+			"release": {"version": "1.2.3", "channel": "stable"},
+			"tag":     {"version": "1.2.3"},
+		}, ""},
+		{"default overridden", []string{"release"}, map[string]string{"version": "2", "channel": "beta"}, map[string]map[string]string{
+			"release": {"version": "2", "channel": "beta"},
+			"tag":     {"version": "2"},
+		}, ""},
+		{"no params", []string{"docs"}, map[string]string{}, map[string]map[string]string{}, ""},
+		{"missing value", []string{"release"}, map[string]string{}, nil, "target 'release' needs the parameter 'version'"},
+		{"not a param of the build", []string{"tag"}, map[string]string{"version": "1", "channel": "beta"}, nil, "parameter 'channel'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveTargetArgs(tt.targets, tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveTargetArgs() error = %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveTargetArgs() error = %v", err)
+			}
+			if !maps.EqualFunc(got, tt.expected, maps.Equal) {
+				t.Errorf("resolveTargetArgs() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+
+	// A command line override of the name sets a param as well
+	overrideVars = map[string]string{"version": "3"}
+	defer func() { overrideVars = map[string]string{} }()
+	if got, err := resolveTargetArgs([]string{"tag"}, nil); err != nil || got["tag"]["version"] != "3" {
+		t.Errorf("resolveTargetArgs() with an override = %v, %v, expected version 3", got, err)
+	}
+}
+
+func TestTargetArgsExpand(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original; targetArgs = map[string]map[string]string{} }()
+	cfg = Config{Vars: map[string]Var{"version": "dev"}, Targets: map[string]Target{
+		"release": {Params: []string{"version"}},
+		"docs":    {},
+	}}
+	targetArgs = map[string]map[string]string{"release": {"version": "1.2.3"}}
+
+	// Params are the target's own; other targets see the config var
+	if got := newExpandContext("release", nil).Expand("v$version"); got != "v1.2.3" {
+		t.Errorf("release expands to %q, expected the param", got)
+	}
+	if got := GetVar("version", "release"); got != "1.2.3" {
+		t.Errorf("GetVar() = %q, expected the param", got)
+	}
+	if got := newExpandContext("docs", nil).Expand("v$version"); got != "vdev" {
+		t.Errorf("docs expands to %q, expected the config var", got)
+	}
+}
+
+func TestTakeParamArgs(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{
+		"tag":     {Params: []string{"version"}},
+		"release": {Deps: []string{"tag"}, Params: []string{"channel=stable"}},
+		"test":    {},
+	}}
+
+	tests := []struct {
+		name        string
+		targets     []string
+		args        map[string]string
+		passthrough []string
+		expected    map[string]string
+		rest        []string
+		wantErr     string
+	}{
+		{"param of a dep", []string{"release"}, nil, []string{"version=1.2.3", "-v"}, map[string]string{"version": "1.2.3"}, []string{"-v"}, ""},
+		{"with --arg", []string{"release"}, map[string]string{"channel": "beta"}, []string{"version=2"}, map[string]string{"channel": "beta", "version": "2"}, nil, ""},
+		{"not a param", []string{"test"}, nil, []string{"version=1.2.3"}, nil, []string{"version=1.2.3"}, ""},
+		{"given twice", []string{"release"}, map[string]string{"version": "1"}, []string{"version=2"}, nil, nil, "parameter 'version' is given both with --arg and after --"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := takeParamArgs(tt.targets, tt.args, tt.passthrough)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("takeParamArgs() error = %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.expected) || !slices.Equal(rest, tt.rest) {
+				t.Errorf("takeParamArgs() = %v, %q, expected %v, %q", got, rest, tt.expected, tt.rest)
+			}
+		})
+	}
+
+	// The --arg values are left as they are
+	args := map[string]string{"channel": "beta"}
+	if _, _, err := takeParamArgs([]string{"release"}, args, []string{"version=2"}); err != nil || len(args) != 1 {
+		t.Errorf("takeParamArgs() = %v, modified the --arg values to %v", err, args)
+	}
+}
+
+func TestRunParamAfterPassthrough(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original, originalArgs := cfg, passthroughArgs
+	defer func() { cfg, passthroughArgs = original, originalArgs }()
+	cfg = Config{}
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"aura.yaml": `
+targets:
+  release:
+    params: [version]
+    run: ["echo v$version > version.txt"]
+`})
+
+	// aura run release -- version=1.2.3, split as main does
+	args, passthrough := splitPassthrough([]string{"run", "release", "--", "version=1.2.3"})
+	passthroughArgs = passthrough
+	app := orpheus.New("aura-test").
+		AddGlobalFlag("directory", "D", ".", "").
+		AddGlobalFlag("config", "c", "aura.yaml", "").
+		AddGlobalBoolFlag("verbose", "v", false, "").
+		AddGlobalBoolFlag("dry-run", "", false, "").
+		AddGlobalFlag("profile", "P", "", "").
+		AddGlobalFlag("cache-dir", "", "", "").
+		AddGlobalFlag("log-format", "", "text", "")
+	app.AddCommand(orpheus.NewCommand("run", "").
+		SetHandler(runTargetCommand).
+		AddIntFlag("parallel", "p", 1, "").
+		AddFlag("summary", "", "none", ""))
+	if err := app.Run(args); err != nil {
+		t.Fatalf("aura run release -- version=1.2.3 error = %v", err)
+	}
+
+	data, err := os.ReadFile("version.txt")
+	if err != nil || strings.TrimSpace(string(data)) != "v1.2.3" {
+		t.Errorf("version.txt = %q, %v; expected the param set and not passed on", data, err)
+	}
+	if !slices.Equal(passthroughArgs, []string{"version=1.2.3"}) {
+		t.Errorf("passthroughArgs = %q, expected them restored after the build", passthroughArgs)
+	}
+}
@@ -1,30 +1,8 @@
 package main
 
-import (
-	"fmt"
-	"os"
-	"regexp"
-	"strings"
-)
-
+// ParseVars expands $var, ${var} and $@ in text for the given target.
+// Commands of a running target use the context captured at schedule time
+// instead; see expandContext.
 func ParseVars(text string, targetname string) string {
-
-	// $var or ${var} or $@
-	r := regexp.MustCompile(`\$\w+|\$\{[^}]+\}|\$@`)
-	matches := r.FindAllString(text, -1)
-
-	for _, m := range matches {
-		varname := strings.TrimPrefix(m, "$")
-		varname = strings.Trim(varname, "{}")
-
-		val := GetVar(varname, targetname) // Remove the extra "$" prefix
-		if val == "" {
-			fmt.Fprintf(os.Stderr, "[warn] undefined variable %s in target %s\n", m, targetname)
-			continue
-		}
-
-		text = strings.Replace(text, m, val, 1)
-	}
-
-	return text
+	return newExpandContext(targetname, nil).Expand(text)
 }
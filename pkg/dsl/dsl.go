@@ -0,0 +1,153 @@
+/*
+Package dsl defines aura configurations in Go instead of YAML.
+
+A small Go program builds a Config and hands it to Main; aura runs the
+program when it is given as the configuration file (aura -c aura.go build)
+and reads the configuration it prints. The ignore build tag keeps the
+program out of the project's own packages:
+
+	//go:build ignore
+
+	package main
+
+	import "github.com/agilira/aura/pkg/dsl"
+
+	func main() {
+		dsl.Main(dsl.Config{
+			Vars: map[string]any{"BIN": dsl.PerPlatform("app.exe", "app")},
+			Targets: map[string]dsl.Target{
+				"build": {Desc: "Build the app", Run: []string{"go build -o $BIN ."}},
+				"test":  {Deps: []string{"build"}, Run: []string{"go test ./..."}},
+			},
+		})
+	}
+
+Run with arguments (go run aura.go build -t test) the same program drives
+the installed aura with its configuration, so a project can ship its build
+as a Go program.
+
+The fields cover the common settings; Extra takes any other key of the YAML
+format as is, so everything a YAML configuration can say a Go one can too.
+*/
+package dsl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is an aura configuration, the document an aura.yaml holds
+type Config struct {
+	Vars     map[string]any    `yaml:"vars,omitempty"`
+	Targets  map[string]Target `yaml:"targets,omitempty"`
+	Prologue *Target           `yaml:"prologue,omitempty"`
+	Epilogue *Target           `yaml:"epilogue,omitempty"`
+	Includes []string          `yaml:"include,omitempty"`
+	Members  []string          `yaml:"members,omitempty"`
+	Shell    string            `yaml:"shell,omitempty"`
+	Parallel int               `yaml:"parallel,omitempty"`
+
+	// Extra holds the other top-level settings (profiles, notify, tools,
+	// cache...) by their YAML key
+	Extra map[string]any `yaml:",inline"`
+}
+
+// Target is one build target
+type Target struct {
+	Desc            string         `yaml:"desc,omitempty"`
+	Deps            []string       `yaml:"deps,omitempty"`
+	Sources         []string       `yaml:"sources,omitempty"`
+	Outputs         []string       `yaml:"outputs,omitempty"`
+	Vars            map[string]any `yaml:"vars,omitempty"`
+	Params          []string       `yaml:"params,omitempty"`
+	Run             []string       `yaml:"run,omitempty"`
+	Onerror         string         `yaml:"onerror,omitempty"`
+	ContinueOnError bool           `yaml:"continue_on_error,omitempty"`
+	Dangerous       bool           `yaml:"dangerous,omitempty"`
+	Retries         int            `yaml:"retries,omitempty"`
+	Timeout         string         `yaml:"timeout,omitempty"`
+	Shell           string         `yaml:"shell,omitempty"`
+
+	// Extra holds the other target settings (watch, sandbox,
+	// output_filter...) by their YAML key
+	Extra map[string]any `yaml:",inline"`
+}
+
+// PerPlatform is a variable with one value on Windows and another
+// elsewhere, as the {windows: ..., default: ...} form of the YAML format
+func PerPlatform(windows, other string) map[string]string {
+	return map[string]string{"windows": windows, "default": other}
+}
+
+// Marshal returns the configuration in the YAML format aura reads. An
+// Extra key repeating a field is an error
+func Marshal(cfg Config) (data []byte, err error) {
+	// yaml.v3 panics on inline keys that clash with fields
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return yaml.Marshal(cfg)
+}
+
+// Main is the entry point of a configuration program. Without arguments
+// it prints the configuration, which is how aura reads it; with arguments
+// it runs aura with them on the configuration and exits with its status
+func Main(cfg Config) {
+	if len(os.Args) < 2 {
+		if err := Write(os.Stdout, cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "aura config:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := Run(cfg, os.Args[1:]...); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Fprintln(os.Stderr, "aura config:", err)
+		os.Exit(1)
+	}
+}
+
+// Write prints the configuration as YAML to w
+func Write(w io.Writer, cfg Config) error {
+	data, err := Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Run runs the aura found in PATH with args on the configuration, written
+// to a temporary file in the current directory so relative paths in it
+// resolve as they would in an aura.yaml there
+func Run(cfg Config, args ...string) error {
+	data, err := Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(".", ".aura-dsl-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	// #nosec G204 - the arguments are the program's own command line
+	cmd := exec.Command("aura", append([]string{"--config", file.Name()}, args...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,61 @@
+package dsl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ===== DSL.GO UNIT TESTS =====
+
+func TestMarshal(t *testing.T) {
+	cfg := Config{
+		Vars: map[string]any{"BIN": PerPlatform("app.exe", "app")},
+		Targets: map[string]Target{
+			"build": {Run: []string{"go build -o $BIN ."}, Extra: map[string]any{"sandbox": true}},
+			"test":  {Deps: []string{"build"}, Run: []string{"go test ./..."}},
+		},
+		Extra: map[string]any{"profiles": map[string]any{"ci": map[string]any{"vars": map[string]string{"FLAGS": "-race"}}}},
+	}
+	data, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Marshal() output is not YAML: %v", err)
+	}
+	targets := doc["targets"].(map[string]any)
+	if build := targets["build"].(map[string]any); build["sandbox"] != true {
+		t.Errorf("build = %v, expected the Extra key inline", build)
+	}
+	if test := targets["test"].(map[string]any); len(test) != 2 {
+		t.Errorf("test = %v, expected unset fields left out", test)
+	}
+	if _, ok := doc["profiles"]; !ok {
+		t.Errorf("config = %v, expected the Extra top-level key", doc)
+	}
+	if bin := doc["vars"].(map[string]any)["BIN"].(map[string]any); bin["windows"] != "app.exe" || bin["default"] != "app" {
+		t.Errorf("BIN = %v, expected the platform map", bin)
+	}
+}
+
+func TestMarshalConflict(t *testing.T) {
+	cfg := Config{Targets: map[string]Target{"build": {Retries: 1, Extra: map[string]any{"retries": 2}}}}
+	if _, err := Marshal(cfg); err == nil || !strings.Contains(err.Error(), "retries") {
+		t.Errorf("Marshal() error = %v, expected the Extra key clashing with a field", err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, Config{Targets: map[string]Target{"lint": {Run: []string{"go vet ./..."}}}}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "- go vet ./...") {
+		t.Errorf("Write() = %q", buf.String())
+	}
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// renderPlan describes what the loaded configuration would do, one fact
+// per line in a stable order, so that two plans can be diffed line by line.
+// Commands are shown expanded; $TIMESTAMP, $BUILD_ID and the git checkout
+// stay literal so plans taken a second apart or on other commits do not
+// differ.
+func renderPlan() []string {
+	var lines []string
+
+	// Command line overrides show with the value they give
+	vars := map[string]Var{}
+	maps.Copy(vars, cfg.Vars)
+	for name, value := range overrideVars {
+		vars[name] = Var(value)
+	}
+	if len(vars) > 0 {
+		lines = append(lines, "vars:")
+		for _, name := range sortedKeys(vars) {
+			lines = append(lines, fmt.Sprintf("  %s = %s", name, vars[name]))
+		}
+	}
+	if cfg.Shell != "" {
+		lines = append(lines, "shell: "+string(cfg.Shell))
+	}
+	if dirs := append(append([]string{}, cfg.ToolPaths...), toolDirs()...); len(dirs) > 0 {
+		lines = append(lines, "path:")
+		for _, dir := range dirs {
+			lines = append(lines, "  "+dir)
+		}
+	}
+
+	for _, stage := range cfg.activePrologues() {
+		lines = append(lines, planTarget(stage.name, stage.target)...)
+	}
+	for _, name := range sortedKeys(cfg.Targets) {
+		lines = append(lines, planTarget(name, cfg.Targets[name])...)
+	}
+	for _, stage := range cfg.activeEpilogues() {
+		lines = append(lines, planTarget(stage.name, stage.target)...)
+	}
+	return lines
+}
+
+// planTarget renders one target; empty prologue/epilogue render nothing
+func planTarget(name string, target Target) []string {
+	if (name == "prologue" || name == "epilogue") && len(target.Run) == 0 && len(target.Deps) == 0 {
+		return nil
+	}
+
+	lines := []string{"target " + name + ":"}
+	add := func(key string, values []string) {
+		if len(values) > 0 {
+			lines = append(lines, fmt.Sprintf("  %s: %s", key, strings.Join(values, ", ")))
+		}
+	}
+	if target.Desc != "" {
+		lines = append(lines, "  desc: "+target.Desc)
+	}
+	add("params", target.Params)
+	add("platforms", target.Platforms)
+	add("deps", target.Deps)
+	add("sources", target.Sources)
+	add("outputs", target.Outputs)
+	for _, key := range sortedKeys(target.Vars) {
+		lines = append(lines, fmt.Sprintf("  var %s = %s", key, target.Vars[key]))
+	}
+	if target.ContinueOnError {
+		lines = append(lines, "  continue_on_error: true")
+	}
+	if target.Dangerous {
+		lines = append(lines, "  dangerous: true")
+	}
+	if target.MinInterval != "" {
+		lines = append(lines, "  min_interval: "+target.MinInterval)
+	}
+	if target.Shell != "" {
+		lines = append(lines, "  shell: "+string(target.Shell))
+	}
+	if target.Mutex != "" {
+		lines = append(lines, "  mutex: "+target.Mutex)
+	}
+	if target.Sandbox {
+		lines = append(lines, "  sandbox: true")
+	}
+	if target.Network != "" {
+		lines = append(lines, "  network: "+target.Network)
+	}
+
+	// Commands are recorded as they run, behind the command wrapper
+	ectx := planContext(name, target)
+	wrapper := ectx.Expand(string(commandWrapper(&target)))
+	for _, cmd := range target.Run {
+		lines = append(lines, "  $ "+wrapCommand(wrapper, ectx.Expand(cmd)))
+	}
+	return lines
+}
+
+// planContext expands a target's commands without running anything: values
+// that change on every run stay symbolic, so plans compare, no ports or
+// temp directories are allocated and the $(command)s of vars do not run.
+// Params take their --arg or default, and stay symbolic without either
+func planContext(name string, target Target) *expandContext {
+	literal := map[string]string{"TIMESTAMP": "$TIMESTAMP", "BUILD_ID": "$BUILD_ID", "GIT_SHA": "$GIT_SHA", "GIT_BRANCH": "$GIT_BRANCH"}
+	for _, param := range target.Params {
+		key, value, hasDefault := parseParam(param)
+		if arg, ok := paramArgs[key]; ok {
+			value = arg
+		} else if !hasDefault {
+			value = "$" + key
+		}
+		literal[key] = value
+	}
+	for _, cmd := range target.Run {
+		for _, ref := range varRefRegex.FindAllString(cmd, -1) {
+			ref, _, _ = strings.Cut(strings.Trim(strings.TrimPrefix(ref, "$"), "{}"), ".")
+			if isFreePortVar(ref) || ref == tmpdirVar {
+				literal[ref] = "$" + ref
+			}
+		}
+	}
+	ectx := newExpandContext(name, literal)
+	ectx.symbolic = true
+	return ectx
+}
+
+// gitFileReader reads files as they are at a git revision
+func gitFileReader(ref string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(wd, path)
+		if err != nil {
+			return nil, err
+		}
+
+		// #nosec G204 - Revision and path are passed as a single git argument
+		cmd := exec.Command("git", "show", ref+":./"+filepath.ToSlash(rel))
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git show %s:%s: %s", ref, rel, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+}
+
+// planAt renders the plan of the configuration as it is at a git revision,
+// leaving the loaded configuration untouched
+func planAt(ref, configFile string) ([]string, error) {
+	current := cfg
+	defer func() { cfg = current }()
+
+	cfg = Config{}
+	if err := loadConfigFrom(configFile, gitFileReader(ref)); err != nil {
+		return nil, err
+	}
+	return renderPlan(), nil
+}
+
+// diffOp is one line of a line diff: ' ' unchanged, '-' removed, '+' added
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines computes a minimal line diff using a longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// formatDiff renders the changed parts of a diff with context lines. Each
+// hunk starts with the target (or section) it belongs to.
+func formatDiff(ops []diffOp, context int, color bool) string {
+	show := make([]bool, len(ops))
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		for k := max(0, i-context); k <= min(len(ops)-1, i+context); k++ {
+			show[k] = true
+		}
+	}
+
+	var sb strings.Builder
+	header := ""
+	printed := -1
+	for i, op := range ops {
+		if !strings.HasPrefix(op.line, " ") {
+			header = op.line
+		}
+		if !show[i] {
+			continue
+		}
+		if printed != i-1 {
+			// New hunk: name the section it is in
+			if printed >= 0 {
+				sb.WriteString("...\n")
+			}
+			if header != "" && header != op.line {
+				sb.WriteString("  " + header + "\n")
+			}
+		}
+		printed = i
+
+		line := string(op.kind) + " " + op.line
+		switch {
+		case color && op.kind == '-':
+			line = "\033[31m" + line + "\033[0m"
+		case color && op.kind == '+':
+			line = "\033[32m" + line + "\033[0m"
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+// planSetup changes to the working directory and loads the configuration
+func planSetup(ctx *orpheus.Context) error {
+	workDir := ctx.GetGlobalFlagString("directory")
+	configFile := ctx.GetGlobalFlagString("config")
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	return loadConfigProfile(configFile, ctx.GetGlobalFlagString("profile"))
+}
+
+// planCommand prints the expanded build plan, or with --lanes simulates
+// a parallel build of the -t targets (default all)
+func planCommand(ctx *orpheus.Context) error {
+	if err := planSetup(ctx); err != nil {
+		return err
+	}
+	if lanes := ctx.GetFlagInt("lanes"); lanes != 0 {
+		if lanes < 0 {
+			return orpheus.ValidationError("lanes", "--lanes must be positive")
+		}
+		cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+		names := sortedKeys(cfg.Targets)
+		if targets := ctx.GetFlagString("targets"); targets != "" {
+			names = strings.Split(targets, ",")
+			for i := range names {
+				names[i] = strings.TrimSpace(names[i])
+			}
+		}
+		history, err := loadHistory()
+		if err != nil {
+			logWarn("run history not used: %v", err)
+		}
+		slots, err := simulateLanes(names, lanes, history)
+		if err != nil {
+			return err
+		}
+		printLanes(slots, lanes)
+		return nil
+	}
+	snapshot := ctx.GetFlagString("snapshot")
+	if snapshot != "" {
+		return planSnapshot(snapshot, ctx.GetFlagBool("check"))
+	}
+	if ctx.GetFlagBool("check") {
+		return orpheus.ValidationError("check", "--check needs the snapshot to compare with: aura plan --snapshot <file> --check")
+	}
+	for _, line := range renderPlan() {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// portablePlan rewrites the paths in a plan that differ between machines:
+// the project directory becomes "." and the home directory "~", so that a
+// snapshot taken on one checkout matches on any other
+func portablePlan(lines []string) []string {
+	var replacements []string
+	if wd, err := os.Getwd(); err == nil {
+		replacements = append(replacements, wd, ".")
+	}
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		replacements = append(replacements, home, "~")
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	portable := make([]string, len(lines))
+	for i, line := range lines {
+		portable[i] = replacer.Replace(line)
+	}
+	return portable
+}
+
+// planSnapshot writes the plan to path, or with check compares it with
+// the snapshot there and fails, showing the diff, when they differ
+func planSnapshot(path string, check bool) error {
+	current := portablePlan(renderPlan())
+	if !check {
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return orpheus.ExecutionError("plan", err.Error())
+		}
+		if err := os.WriteFile(path, []byte(strings.Join(current, "\n")+"\n"), 0600); err != nil {
+			return orpheus.ExecutionError("plan", fmt.Sprintf("cannot write snapshot %s: %v", path, err))
+		}
+		fmt.Printf("%s Wrote the plan snapshot to %s\n", checkMark(), path)
+		return nil
+	}
+
+	// #nosec G304 - The snapshot path is chosen by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return orpheus.NotFoundError("snapshot", fmt.Sprintf("cannot read plan snapshot %s (create it with aura plan --snapshot %s): %v", path, path, err))
+	}
+	snapshot := strings.Split(strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n")
+
+	ops := diffLines(snapshot, current)
+	if !slices.ContainsFunc(ops, func(op diffOp) bool { return op.kind != ' ' }) {
+		fmt.Printf("%s Plan matches %s\n", checkMark(), path)
+		return nil
+	}
+	fmt.Printf("Plan differs from %s:\n", path)
+	fmt.Print(formatDiff(ops, 2, colorEnabled()))
+	return orpheus.ExecutionError("plan", fmt.Sprintf("plan differs from snapshot %s (if intended, update it with aura plan --snapshot %s)", path, path))
+}
+
+// planDiffCommand compares the build plan with the one at a git revision
+func planDiffCommand(ctx *orpheus.Context) error {
+	if err := planSetup(ctx); err != nil {
+		return err
+	}
+	base := ctx.GetFlagString("base")
+
+	current := renderPlan()
+	previous, err := planAt(base, ctx.GetGlobalFlagString("config"))
+	if err != nil {
+		return orpheus.ExecutionError("plan diff", fmt.Sprintf("cannot load plan at %s: %v", base, err))
+	}
+
+	ops := diffLines(previous, current)
+	added, removed := 0, 0
+	for _, op := range ops {
+		switch op.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+
+	if added == 0 && removed == 0 {
+		fmt.Printf("No plan changes since %s\n", base)
+		return nil
+	}
+	fmt.Printf("Plan changes since %s:\n", base)
+	fmt.Print(formatDiff(ops, 2, colorEnabled()))
+	fmt.Printf("\n%d added, %d removed\n", added, removed)
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ===== PLAN.GO UNIT TESTS =====
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []string
+		expected string
+	}{
+		{"identical", []string{"x", "y"}, []string{"x", "y"}, "  x|  y"},
+		{"added", []string{"x"}, []string{"x", "y"}, "  x|+ y"},
+		{"removed", []string{"x", "y"}, []string{"y"}, "- x|  y"},
+		{"changed", []string{"x", "y", "z"}, []string{"x", "Y", "z"}, "  x|- y|+ Y|  z"},
+		{"empty base", nil, []string{"x"}, "+ x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, op := range diffLines(tt.a, tt.b) {
+				got = append(got, string(op.kind)+" "+op.line)
+			}
+			if strings.Join(got, "|") != tt.expected {
+				t.Errorf("diffLines() = %q, expected %q", strings.Join(got, "|"), tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	base := []string{"target a:", "  $ one", "  $ two", "  $ three", "  $ four", "  $ five", "target b:", "  $ old"}
+	current := []string{"target a:", "  $ one", "  $ two", "  $ three", "  $ four", "  $ five", "target b:", "  $ new"}
+
+	got := formatDiff(diffLines(base, current), 2, false)
+	expected := "  target a:\n    $ five\n  target b:\n-   $ old\n+   $ new\n"
+	if got != expected {
+		t.Errorf("formatDiff() = %q, expected %q", got, expected)
+	}
+
+	colored := formatDiff(diffLines(base, current), 0, true)
+	if !strings.Contains(colored, "\033[31m-   $ old\033[0m") || !strings.Contains(colored, "\033[32m+   $ new\033[0m") {
+		t.Errorf("formatDiff() with color = %q", colored)
+	}
+}
+
+func TestRenderPlan(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{
+		Vars: map[string]Var{"OUT": "bin"},
+		Targets: map[string]Target{
+			"build": {Deps: []string{"gen"}, Run: []string{"go build -o $OUT/app", "echo $TIMESTAMP"}},
+			"gen":   {Run: []string{"go generate"}},
+		},
+	}
+
+	expected := []string{
+		"vars:",
+		"  OUT = bin",
+		"target build:",
+		"  deps: gen",
+		"  $ go build -o bin/app",
+		"  $ echo $TIMESTAMP",
+		"target gen:",
+		"  $ go generate",
+	}
+	if got := renderPlan(); !slices.Equal(got, expected) {
+		t.Errorf("renderPlan() = %q, expected %q", got, expected)
+	}
+
+	// Overrides show in vars and in the commands
+	overrideVars = map[string]string{"OUT": "dist", "TAG": "v1"}
+	defer func() { overrideVars = map[string]string{} }()
+	got := renderPlan()
+	if !slices.Equal(got[:3], []string{"vars:", "  OUT = dist", "  TAG = v1"}) || !slices.Contains(got, "  $ go build -o dist/app") {
+		t.Errorf("renderPlan() with overrides = %q", got)
+	}
+}
+
+func TestPlanAt(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	writeFiles(t, map[string]string{"aura.yaml": "targets:\n  build:\n    run:\n      - echo old\n"})
+	git("init", "-q")
+	git("add", "aura.yaml")
+	git("commit", "-q", "-m", "initial")
+	writeFiles(t, map[string]string{"aura.yaml": "targets:\n  build:\n    run:\n      - echo new\n"})
+
+	if err := loadConfig("aura.yaml"); err != nil {
+		t.Fatalf("loadConfig() error: %v", err)
+	}
+	previous, err := planAt("HEAD", "aura.yaml")
+	if err != nil {
+		t.Fatalf("planAt() error: %v", err)
+	}
+
+	if !slices.Contains(previous, "  $ echo old") {
+		t.Errorf("planAt() = %q, expected the committed command", previous)
+	}
+	if !slices.Contains(renderPlan(), "  $ echo new") {
+		t.Error("planAt() should restore the loaded config")
+	}
+
+	if _, err := planAt("no-such-ref", "aura.yaml"); err == nil {
+		t.Error("planAt() with an unknown revision should fail")
+	}
+}
+
+func TestPortablePlan(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", filepath.Join(string(filepath.Separator), "home", "dev"))
+	home, _ := os.UserHomeDir()
+
+	got := portablePlan([]string{"  $ cp " + filepath.Join(wd, "out") + " dist", "  " + filepath.Join(home, ".cache", "aura"), "  $ go test"})
+	expected := []string{"  $ cp ." + string(filepath.Separator) + "out dist", "  ~" + string(filepath.Separator) + filepath.Join(".cache", "aura"), "  $ go test"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("portablePlan() = %q, expected %q", got, expected)
+	}
+}
+
+func TestPlanSnapshot(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	cfg = Config{Targets: map[string]Target{"build": {Run: []string{"go build"}}}}
+	snapshot := filepath.Join("plans", "build.txt")
+	if err := planSnapshot(snapshot, false); err != nil {
+		t.Fatalf("planSnapshot() error = %v", err)
+	}
+	if data, err := os.ReadFile(snapshot); err != nil || string(data) != "target build:\n  $ go build\n" {
+		t.Errorf("snapshot = %q, %v", data, err)
+	}
+	if err := planSnapshot(snapshot, true); err != nil {
+		t.Errorf("planSnapshot() check of an unchanged plan error = %v", err)
+	}
+
+	cfg.Targets["build"] = Target{Run: []string{"go build -race"}}
+	if err := planSnapshot(snapshot, true); err == nil || !strings.Contains(err.Error(), "plan differs") {
+		t.Errorf("planSnapshot() check of a changed plan error = %v", err)
+	}
+	if err := planSnapshot("missing.txt", true); err == nil {
+		t.Error("planSnapshot() check without a snapshot should fail")
+	}
+}
+
+func TestPlanSnapshotCommandWrapper(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	cfg = Config{
+		CommandWrapper: "nice -n19",
+		Targets: map[string]Target{
+			"build": {Run: []string{"go build"}},
+			"test":  {Run: []string{"go test"}, CommandWrapper: "none"},
+		},
+	}
+	snapshot := filepath.Join("plans", "build.txt")
+	if err := planSnapshot(snapshot, false); err != nil {
+		t.Fatalf("planSnapshot() error = %v", err)
+	}
+	if data, err := os.ReadFile(snapshot); err != nil || string(data) != "target build:\n  $ nice -n19 go build\ntarget test:\n  $ go test\n" {
+		t.Errorf("snapshot = %q, %v; expected the commands as they run", data, err)
+	}
+
+	// The commands do not change, what runs them does
+	cfg.CommandWrapper = "ccache"
+	if err := planSnapshot(snapshot, true); err == nil || !strings.Contains(err.Error(), "plan differs") {
+		t.Errorf("planSnapshot() check after a wrapper change error = %v", err)
+	}
+}
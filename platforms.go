@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// platformMatches reports whether aura runs on one of the platforms keys
+// names: an OS, an arch or os/arch
+func platformMatches(keys []string) bool {
+	return slices.ContainsFunc(keys, func(key string) bool {
+		return key == runtime.GOOS || key == runtime.GOARCH || key == runtime.GOOS+"/"+runtime.GOARCH
+	})
+}
+
+// skippedOnPlatform reports whether a target's platforms: leave it out on
+// this platform. A skipped target counts as done for the targets after it
+func skippedOnPlatform(target *Target) bool {
+	return len(target.Platforms) > 0 && !platformMatches(target.Platforms)
+}
+
+// validatePlatforms checks the platforms: lists of the targets
+func validatePlatforms() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		for _, key := range cfg.Targets[name].Platforms {
+			if key == "default" || !isPlatformKey(key) {
+				return fmt.Errorf("unknown platform %q in platforms of target '%s' (use an OS, an arch or os/arch)", key, name)
+			}
+		}
+	}
+	return nil
+}
+
+// platformSkipReason says where a skipped target runs
+func platformSkipReason(target *Target) string {
+	return "runs on " + strings.Join(target.Platforms, ", ")
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== PLATFORMS.GO UNIT TESTS =====
+
+// otherOS is an OS aura is not running on
+func otherOS() string {
+	if runtime.GOOS == "plan9" {
+		return "linux"
+	}
+	return "plan9"
+}
+
+func TestPlatformMatches(t *testing.T) {
+	tests := []struct {
+		keys     []string
+		expected bool
+	}{
+		{[]string{runtime.GOOS}, true},
+		{[]string{otherOS(), runtime.GOARCH}, true},
+		{[]string{runtime.GOOS + "/" + runtime.GOARCH}, true},
+		{[]string{otherOS()}, false},
+		{[]string{runtime.GOOS + "/nonexistent"}, false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := platformMatches(tt.keys); got != tt.expected {
+			t.Errorf("platformMatches(%q) = %v, expected %v", tt.keys, got, tt.expected)
+		}
+	}
+	if skippedOnPlatform(&Target{}) {
+		t.Error("skippedOnPlatform() = true for a target without platforms")
+	}
+}
+
+func TestValidatePlatforms(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{Targets: map[string]Target{"deb": {Platforms: stringList{"linux", "darwin/arm64", "amd64"}}}}
+	if err := validatePlatforms(); err != nil {
+		t.Errorf("validatePlatforms() error = %v", err)
+	}
+	for _, key := range []string{"win", "default"} {
+		cfg.Targets["msi"] = Target{Platforms: stringList{key}}
+		if err := validatePlatforms(); err == nil || !strings.Contains(err.Error(), "target 'msi'") {
+			t.Errorf("validatePlatforms() with %q error = %v, expected the unknown platform", key, err)
+		}
+	}
+}
+
+func TestTargetSkippedOnPlatform(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{
+		"msi": {Platforms: stringList{otherOS()}, Run: []string{"exit 1"}},
+	}}
+	takeResults()
+	defer takeResults()
+
+	// The target is skipped, not failed, and reported as such
+	stdout := captureStdout(t)
+	if err := runTargetOnly("msi", nil, false, false); err != nil {
+		t.Fatalf("runTargetOnly() error = %v, expected the target skipped", err)
+	}
+	if output := stdout(); !strings.Contains(output, "Skipping msi: runs on "+otherOS()) {
+		t.Errorf("stdout = %q, expected the skip reported", output)
+	}
+	list := takeResults()
+	if len(list) != 1 || list[0].Skipped == "" || list[0].Err != nil {
+		t.Fatalf("results = %+v, expected one skipped target", list)
+	}
+	if rows := summarizeBuild([]string{"msi"}, list); rows[0].Status != summarySkipped || rows[0].Reason != "runs on "+otherOS() {
+		t.Errorf("summary = %+v, expected the skip reason", rows[0])
+	}
+}
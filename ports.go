@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// allocatedPorts remembers the ports handed out during this run, so
+// parallel targets never receive the same one
+var allocatedPorts = struct {
+	sync.Mutex
+	used map[int]bool
+}{used: map[int]bool{}}
+
+// freePort asks the OS for an unused TCP port on the loopback interface
+// that this run has not handed out yet
+func freePort() (int, error) {
+	allocatedPorts.Lock()
+	defer allocatedPorts.Unlock()
+
+	for range 100 {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return 0, err
+		}
+		port := listener.Addr().(*net.TCPAddr).Port
+		_ = listener.Close()
+		if !allocatedPorts.used[port] {
+			allocatedPorts.used[port] = true
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no unused port found")
+}
+
+// freePortIndex parses FREE_PORT (the first port) and FREE_PORT_<n>
+func freePortIndex(name string) (int, bool) {
+	if name == "FREE_PORT" {
+		return 1, true
+	}
+	suffix, ok := strings.CutPrefix(name, "FREE_PORT_")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(suffix)
+	return n, err == nil && n > 0
+}
+
+// isFreePortVar reports whether a variable name is a free port builtin
+func isFreePortVar(name string) bool {
+	_, ok := freePortIndex(name)
+	return ok
+}
+
+// lookupFreePort resolves $FREE_PORT and $FREE_PORT_<n>. A target keeps
+// its ports for all of its commands, so a server and its client agree.
+func (c *expandContext) lookupFreePort(name string) (string, bool) {
+	n, ok := freePortIndex(name)
+	if !ok {
+		return "", false
+	}
+	if n == 1 {
+		name = "FREE_PORT"
+	}
+	if port, ok := c.ports[name]; ok {
+		return port, true
+	}
+
+	port, err := freePort()
+	if err != nil {
+		logWarn("cannot allocate $%s for %s: %v", name, c.target, err)
+		return "", false
+	}
+	if c.ports == nil {
+		c.ports = map[string]string{}
+	}
+	c.ports[name] = strconv.Itoa(port)
+	return c.ports[name], true
+}
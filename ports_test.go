@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net"
+	"strconv"
+	"testing"
+)
+
+// ===== PORTS.GO UNIT TESTS =====
+
+func TestFreePortIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int
+		ok       bool
+	}{
+		{"FREE_PORT", 1, true},
+		{"FREE_PORT_1", 1, true},
+		{"FREE_PORT_3", 3, true},
+		{"FREE_PORT_0", 0, false},
+		{"FREE_PORT_X", 0, false},
+		{"FREE_PORTS", 0, false},
+	}
+	for _, tt := range tests {
+		n, ok := freePortIndex(tt.name)
+		if ok != tt.ok || (ok && n != tt.expected) {
+			t.Errorf("freePortIndex(%q) = %d, %v", tt.name, n, ok)
+		}
+	}
+}
+
+func TestFreePort(t *testing.T) {
+	seen := map[int]bool{}
+	for range 5 {
+		port, err := freePort()
+		if err != nil {
+			t.Fatalf("freePort() error: %v", err)
+		}
+		if seen[port] {
+			t.Errorf("freePort() handed out %d twice", port)
+		}
+		seen[port] = true
+
+		listener, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+		if err != nil {
+			t.Errorf("port %d is not free: %v", port, err)
+			continue
+		}
+		_ = listener.Close()
+	}
+}
+
+func TestExpandFreePort(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{"itest": {}}}
+
+	ectx := newExpandContext("itest", nil)
+	first := ectx.Expand("$FREE_PORT")
+	if _, err := strconv.Atoi(first); err != nil {
+		t.Fatalf("$FREE_PORT expanded to %q", first)
+	}
+	if again := ectx.Expand("${FREE_PORT_1}"); again != first {
+		t.Errorf("$FREE_PORT_1 = %s, expected the same port as $FREE_PORT (%s)", again, first)
+	}
+	second := ectx.Expand("$FREE_PORT_2")
+	if second == first {
+		t.Errorf("$FREE_PORT_2 should differ from $FREE_PORT, both are %s", first)
+	}
+	if other := newExpandContext("itest", nil).Expand("$FREE_PORT"); other == first || other == second {
+		t.Errorf("another target instance got port %s already in use", other)
+	}
+
+	cfg.Vars = map[string]Var{"FREE_PORT": "8080"}
+	if got := newExpandContext("itest", nil).Expand("$FREE_PORT"); got != "8080" {
+		t.Errorf("a FREE_PORT variable should take precedence, got %s", got)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// problem is a diagnostic a problem matcher found in command output
+type problem struct {
+	File     string
+	Line     int
+	Col      int
+	Severity string // error, warning or notice
+	Message  string
+}
+
+// builtinMatchers can be named in problem_matchers instead of a regex
+var builtinMatchers = map[string]string{
+	// gcc, clang, go, rustc --error-format=short, most linters
+	"default": `^(?P<file>(?:[A-Za-z]:)?[^\s:][^:]*):(?P<line>\d+):(?:(?P<col>\d+):)?\s+(?:(?P<severity>error|warning|note|info):\s+)?(?P<message>.+)$`,
+	// tsc --pretty false: src/app.ts(12,5): error TS2304: Cannot find name 'x'.
+	"tsc": `^(?P<file>[^\s(][^(]*)\((?P<line>\d+),(?P<col>\d+)\):\s+(?P<severity>error|warning)\s+(?P<message>.+)$`,
+}
+
+// problemMatcherRegex resolves a problem_matchers entry to its regex
+func problemMatcherRegex(matcher string) (*regexp.Regexp, error) {
+	if builtin, ok := builtinMatchers[matcher]; ok {
+		matcher = builtin
+	}
+	re, err := regexp.Compile(matcher)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range []string{"file", "line", "message"} {
+		if re.SubexpIndex(group) < 0 {
+			return nil, fmt.Errorf("missing named group (?P<%s>...)", group)
+		}
+	}
+	return re, nil
+}
+
+// validateProblemMatchers checks every problem_matchers entry is a builtin
+// name or a regex with file, line and message groups
+func validateProblemMatchers() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		for _, matcher := range cfg.Targets[name].ProblemMatchers {
+			if _, err := problemMatcherRegex(matcher); err != nil {
+				return fmt.Errorf("invalid problem matcher '%s' for target '%s': %v", matcher, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchProblems finds the diagnostics in output; each line is matched by
+// the first matcher that accepts it
+func matchProblems(out string, matchers []string) []problem {
+	var regexes []*regexp.Regexp
+	for _, matcher := range matchers {
+		if re, err := problemMatcherRegex(matcher); err == nil {
+			regexes = append(regexes, re)
+		}
+	}
+
+	var problems []problem
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimRight(line, "\r")
+		for _, re := range regexes {
+			m := re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			group := func(name string) string {
+				if i := re.SubexpIndex(name); i >= 0 {
+					return m[i]
+				}
+				return ""
+			}
+			p := problem{File: group("file"), Message: strings.TrimSpace(group("message")), Severity: "error"}
+			p.Line, _ = strconv.Atoi(group("line"))
+			p.Col, _ = strconv.Atoi(group("col"))
+			switch strings.ToLower(group("severity")) {
+			case "warning", "warn":
+				p.Severity = "warning"
+			case "note", "info", "notice":
+				p.Severity = "notice"
+			}
+			problems = append(problems, p)
+			break
+		}
+	}
+	return problems
+}
+
+// githubEscape escapes a workflow command value; properties additionally
+// escape the separators
+func githubEscape(s string, property bool) string {
+	s = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+	if property {
+		s = strings.NewReplacer(":", "%3A", ",", "%2C").Replace(s)
+	}
+	return s
+}
+
+// reportProblems writes the diagnostics of a target as a location list,
+// or as ::error/::warning/::notice annotations on GitHub Actions
+func reportProblems(w io.Writer, name string, problems []problem, github bool) {
+	if len(problems) == 0 {
+		return
+	}
+	if !github {
+		fmt.Fprintf(w, "[!] %d problem(s) in %s:\n", len(problems), name)
+	}
+	for _, p := range problems {
+		location := fmt.Sprintf("%s:%d", p.File, p.Line)
+		if p.Col > 0 {
+			location += fmt.Sprintf(":%d", p.Col)
+		}
+		if !github {
+			fmt.Fprintf(w, "  %-7s %s: %s\n", p.Severity, location, p.Message)
+			continue
+		}
+		props := fmt.Sprintf("file=%s,line=%d", githubEscape(p.File, true), p.Line)
+		if p.Col > 0 {
+			props += fmt.Sprintf(",col=%d", p.Col)
+		}
+		props += ",title=" + githubEscape("aura "+name, true)
+		fmt.Fprintf(w, "::%s %s::%s\n", p.Severity, props, githubEscape(p.Message, false))
+	}
+}
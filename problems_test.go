@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ===== PROBLEMS.GO UNIT TESTS =====
+
+func TestMatchProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		matchers []string
+		expected []problem
+	}{
+		{
+			name:     "gcc style",
+			out:      "cc -c a.c\nsrc/a.c:12:5: warning: unused variable 'x'\nsrc/a.c:20:1: error: expected ';'\n",
+			matchers: []string{"default"},
+			expected: []problem{
+				{File: "src/a.c", Line: 12, Col: 5, Severity: "warning", Message: "unused variable 'x'"},
+				{File: "src/a.c", Line: 20, Col: 1, Severity: "error", Message: "expected ';'"},
+			},
+		},
+		{
+			name:     "go without severity or column",
+			out:      "# app\n./main.go:3: undefined: foo\r\n",
+			matchers: []string{"default"},
+			expected: []problem{{File: "./main.go", Line: 3, Severity: "error", Message: "undefined: foo"}},
+		},
+		{
+			name:     "windows drive letter",
+			out:      `C:\src\a.c:7:2: note: declared here`,
+			matchers: []string{"default"},
+			expected: []problem{{File: `C:\src\a.c`, Line: 7, Col: 2, Severity: "notice", Message: "declared here"}},
+		},
+		{
+			name:     "tsc",
+			out:      "src/app.ts(12,5): error TS2304: Cannot find name 'x'.",
+			matchers: []string{"tsc"},
+			expected: []problem{{File: "src/app.ts", Line: 12, Col: 5, Severity: "error", Message: "TS2304: Cannot find name 'x'."}},
+		},
+		{
+			name:     "custom regex",
+			out:      "LINT [W] pkg/x.py line 4: too long",
+			matchers: []string{`^LINT \[(?P<severity>\w)\] (?P<file>\S+) line (?P<line>\d+): (?P<message>.*)$`, "default"},
+			expected: []problem{{File: "pkg/x.py", Line: 4, Severity: "error", Message: "too long"}},
+		},
+		{
+			name:     "no matches",
+			out:      "all good\n",
+			matchers: []string{"default"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchProblems(tt.out, tt.matchers); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("matchProblems() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateProblemMatchers(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		matcher string
+		errMsg  string
+	}{
+		{"builtin", "default", ""},
+		{"custom", `^(?P<file>\S+):(?P<line>\d+) (?P<message>.*)$`, ""},
+		{"bad regex", "(", "invalid problem matcher"},
+		{"missing group", `^(?P<file>\S+): (?P<message>.*)$`, "(?P<line>...)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: map[string]Target{"build": {ProblemMatchers: []string{tt.matcher}}}}
+			err := validateProblemMatchers()
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("validateProblemMatchers() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("validateProblemMatchers() error = %v, expected to contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestReportProblems(t *testing.T) {
+	problems := []problem{
+		{File: "a.c", Line: 1, Col: 2, Severity: "warning", Message: "50% done\nnext"},
+		{File: "dir,x/b.go", Line: 3, Severity: "error", Message: "boom"},
+	}
+
+	var buf bytes.Buffer
+	reportProblems(&buf, "build", problems, false)
+	expected := "[!] 2 problem(s) in build:\n  warning a.c:1:2: 50% done\nnext\n  error   dir,x/b.go:3: boom\n"
+	if buf.String() != expected {
+		t.Errorf("plain report = %q, expected %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	reportProblems(&buf, "build", problems, true)
+	expected = "::warning file=a.c,line=1,col=2,title=aura build::50%25 done%0Anext\n" +
+		"::error file=dir%2Cx/b.go,line=3,title=aura build::boom\n"
+	if buf.String() != expected {
+		t.Errorf("github report = %q, expected %q", buf.String(), expected)
+	}
+
+	buf.Reset()
+	reportProblems(&buf, "build", nil, false)
+	if buf.Len() != 0 {
+		t.Errorf("no problems should print nothing, got %q", buf.String())
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// defaultKillGrace is how long a stopped command may take to exit before
+// its process group is killed, unless kill_grace says otherwise
+const defaultKillGrace = 5 * time.Second
+
+// runCtx is cancelled when aura is interrupted, stopping every command
+var runCtx, cancelRun = context.WithCancel(context.Background())
+
+// runningCommands counts the commands started and not yet reaped
+var runningCommands atomic.Int32
+
+// windsDown is set by commands that return on their own once runCtx is
+// cancelled; for the others an interrupt exits when no command runs
+var windsDown atomic.Bool
+
+// errInterrupted is returned for commands stopped by an interrupt
+var errInterrupted = errors.New("interrupted")
+
+// commandLimits bounds how long a command may run, how long it may take
+// to stop once asked to and whether it may use the network
+type commandLimits struct {
+	timeout time.Duration
+	grace   time.Duration
+	offline bool // network: none
+}
+
+// targetLimits returns the timeout, kill_grace and network settings of a
+// target, kill_grace falling back to the config's and then to
+// defaultKillGrace
+func targetLimits(target *Target) commandLimits {
+	limits := commandLimits{grace: defaultKillGrace, offline: target.Network == networkNone}
+	if d, err := time.ParseDuration(target.Timeout); err == nil {
+		limits.timeout = d
+	}
+	for _, value := range []string{cfg.KillGrace, target.KillGrace} {
+		if d, err := time.ParseDuration(value); err == nil {
+			limits.grace = d
+		}
+	}
+	return limits
+}
+
+// validateKillSettings checks timeout and kill_grace are positive durations
+func validateKillSettings() error {
+	if d, err := time.ParseDuration(cfg.KillGrace); cfg.KillGrace != "" && (err != nil || d < 0) {
+		return fmt.Errorf("invalid kill_grace '%s' (use a duration such as 10s)", cfg.KillGrace)
+	}
+	for _, name := range sortedKeys(cfg.Targets) {
+		target := cfg.Targets[name]
+		if d, err := time.ParseDuration(target.Timeout); target.Timeout != "" && (err != nil || d <= 0) {
+			return fmt.Errorf("invalid timeout '%s' for target '%s' (use a duration such as 10m)", target.Timeout, name)
+		}
+		if d, err := time.ParseDuration(target.KillGrace); target.KillGrace != "" && (err != nil || d < 0) {
+			return fmt.Errorf("invalid kill_grace '%s' for target '%s' (use a duration such as 10s)", target.KillGrace, name)
+		}
+	}
+	return nil
+}
+
+// runCommand runs cmd in a process group of its own and returns its
+// combined output; a writer set as cmd.Stdout also gets it as it is
+// printed. When ctx is cancelled or the timeout passes the group
+// is asked to stop (SIGTERM, CTRL_BREAK on Windows); what is still running
+// after the grace period is killed and reported
+func runCommand(ctx context.Context, cmd *exec.Cmd, limits commandLimits) ([]byte, error) {
+	out, _, err := runMeasured(ctx, cmd, limits)
+	return out, err
+}
+
+// runMeasured is runCommand also reporting what the command and the
+// processes it started used
+func runMeasured(ctx context.Context, cmd *exec.Cmd, limits commandLimits) ([]byte, resourceUsage, error) {
+	var out bytes.Buffer
+	var w io.Writer = &out
+	if cmd.Stdout != nil {
+		w = io.MultiWriter(&out, cmd.Stdout)
+	}
+	cmd.Stdout, cmd.Stderr = w, w
+	setProcessGroup(cmd)
+
+	if limits.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.timeout)
+		defer cancel()
+	}
+	if ctx.Err() != nil {
+		return nil, resourceUsage{}, errInterrupted
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, resourceUsage{}, err
+	}
+	usage := trackUsage(cmd)
+	defer usage.close()
+	runningCommands.Add(1)
+	defer runningCommands.Add(-1)
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		return out.Bytes(), usage.finish(cmd), err
+	case <-ctx.Done():
+	}
+
+	_ = terminateGroup(cmd)
+	select {
+	case <-done:
+	case <-time.After(limits.grace):
+		survivors := groupProcesses(cmd)
+		_ = killGroup(cmd)
+		if len(survivors) > 0 {
+			logWarn("Processes still running %s after being asked to stop were killed: %s", limits.grace, strings.Join(survivors, ", "))
+		}
+		<-done
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return out.Bytes(), usage.finish(cmd), fmt.Errorf("timed out after %s", limits.timeout)
+	}
+	return out.Bytes(), usage.finish(cmd), errInterrupted
+}
+
+// handleInterrupts stops running commands on Ctrl+C or SIGTERM. Builds
+// and watch then return by themselves, other commands exit once their
+// commands are gone; a second interrupt exits at once
+func handleInterrupts() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancelRun()
+		if runningCommands.Load() > 0 {
+			fmt.Fprintln(os.Stderr, "\n[!] Interrupted: stopping running commands (interrupt again to exit now)")
+		}
+		go func() {
+			<-signals
+			os.Exit(130)
+		}()
+		if windsDown.Load() {
+			return
+		}
+		for runningCommands.Load() > 0 {
+			time.Sleep(50 * time.Millisecond)
+		}
+		os.Exit(130)
+	}()
+}
+
+// runInterruptHooks runs the hooks.on_interrupt commands after a build was
+// interrupted and its commands stopped; a failing hook is only reported
+func runInterruptHooks(verbose bool) {
+	ectx := newExpandContext("", nil)
+	for _, hook := range cfg.Hooks.OnInterrupt {
+		hook = ectx.Expand(hook)
+		if verbose {
+			logDebug("on_interrupt", "", "→ on_interrupt hook: %s", hook)
+		}
+		out, err := runCommand(context.Background(), shellCommand("", hook), commandLimits{timeout: time.Minute, grace: defaultKillGrace})
+		if len(bytes.TrimSpace(out)) > 0 {
+			logInfo("on_interrupt", "", "%s", strings.TrimRight(string(out), "\n"))
+		}
+		if err != nil {
+			logWarn("on_interrupt hook '%s' failed: %v", hook, err)
+		}
+	}
+}
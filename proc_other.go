@@ -0,0 +1,27 @@
+//go:build !unix && !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// setProcessGroup leaves the command in aura's group, the only one there is
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateGroup asks the command to stop
+func terminateGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Signal(os.Interrupt)
+}
+
+// killGroup kills the command
+func killGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// groupProcesses names the command's process
+func groupProcesses(cmd *exec.Cmd) []string {
+	return []string{strconv.Itoa(cmd.Process.Pid)}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===== PROC.GO UNIT TESTS =====
+
+func TestTargetLimits(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name     string
+		global   string
+		target   Target
+		expected commandLimits
+	}{
+		{"defaults", "", Target{}, commandLimits{grace: defaultKillGrace}},
+		{"timeout", "", Target{Timeout: "10m"}, commandLimits{timeout: 10 * time.Minute, grace: defaultKillGrace}},
+		{"config grace", "2s", Target{}, commandLimits{grace: 2 * time.Second}},
+		{"target grace wins", "2s", Target{KillGrace: "0s"}, commandLimits{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{KillGrace: tt.global}
+			if got := targetLimits(&tt.target); got != tt.expected {
+				t.Errorf("targetLimits() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateKillSettings(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"valid", Config{KillGrace: "3s", Targets: map[string]Target{"a": {Timeout: "1m", KillGrace: "0s"}}}, false},
+		{"bad config grace", Config{KillGrace: "soon"}, true},
+		{"negative grace", Config{Targets: map[string]Target{"a": {KillGrace: "-1s"}}}, true},
+		{"zero timeout", Config{Targets: map[string]Target{"a": {Timeout: "0s"}}}, true},
+		{"bad timeout", Config{Targets: map[string]Target{"a": {Timeout: "10"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = tt.config
+			if err := validateKillSettings(); (err != nil) != tt.wantErr {
+				t.Errorf("validateKillSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunCommandStops(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands and signals")
+	}
+
+	tests := []struct {
+		name       string
+		script     string
+		forced     bool
+		wantOutput string
+	}{
+		{"exits on SIGTERM", "trap 'echo stopping; exit 0' TERM; echo started; sleep 30 & wait", false, "stopping"},
+		{"ignores SIGTERM", "trap '' TERM; echo started; sleep 30 & wait; sleep 30", true, "started"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stderr := captureStderr(t)
+			start := time.Now()
+			cmd := exec.Command("/bin/sh", "-c", tt.script)
+			out, err := runCommand(context.Background(), cmd, commandLimits{timeout: 300 * time.Millisecond, grace: 300 * time.Millisecond})
+			warning := stderr()
+
+			if took := time.Since(start); took > 5*time.Second {
+				t.Errorf("runCommand() took %s", took)
+			}
+			if err == nil || !strings.Contains(err.Error(), "timed out after 300ms") {
+				t.Errorf("runCommand() error = %v, expected a timeout", err)
+			}
+			if !strings.Contains(string(out), tt.wantOutput) {
+				t.Errorf("output = %q, expected %q", out, tt.wantOutput)
+			}
+			if forced := strings.Contains(warning, "were killed"); forced != tt.forced {
+				t.Errorf("force-kill warning = %q, expected forced %v", warning, tt.forced)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := runCommand(ctx, exec.Command("/bin/sh", "-c", "true"), commandLimits{}); err != errInterrupted {
+		t.Errorf("runCommand() after cancel = %v, expected errInterrupted", err)
+	}
+}
+
+// captureStderr redirects os.Stderr until the returned function is called,
+// which restores it and returns what was written
+func captureStderr(t *testing.T) func() string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := os.Stderr
+	os.Stderr = w
+	return func() string {
+		os.Stderr = original
+		_ = w.Close()
+		data, _ := io.ReadAll(r)
+		return string(data)
+	}
+}
+
+func TestRunInterruptHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	// A failing hook does not stop the ones after it
+	cfg = Config{Hooks: Hooks{OnInterrupt: []string{"exit 1", "echo stopped > interrupted.txt"}}}
+	stderr := captureStderr(t)
+	runInterruptHooks(false)
+	if warning := stderr(); !strings.Contains(warning, "on_interrupt hook 'exit 1' failed") {
+		t.Errorf("stderr = %q, expected the failed hook", warning)
+	}
+	if data, err := os.ReadFile("interrupted.txt"); err != nil || strings.TrimSpace(string(data)) != "stopped" {
+		t.Errorf("second hook did not run: %q, %v", data, err)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup makes the command lead a new process group, so stopping
+// it reaches everything it started
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// terminateGroup asks the command's process group to stop
+func terminateGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// killGroup kills the command's process group
+func killGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// groupProcesses lists the processes left in the command's group as
+// "pid (name)", falling back to the leader when ps is not available
+func groupProcesses(cmd *exec.Cmd) []string {
+	pgid := strconv.Itoa(cmd.Process.Pid)
+	out, err := exec.Command("ps", "-A", "-o", "pid=,pgid=,comm=").Output()
+	if err != nil {
+		return []string{pgid}
+	}
+	var procs []string
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[1] == pgid {
+			procs = append(procs, fmt.Sprintf("%s (%s)", fields[0], strings.Join(fields[2:], " ")))
+		}
+	}
+	return procs
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package main
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+var generateConsoleCtrlEvent = syscall.NewLazyDLL("kernel32.dll").NewProc("GenerateConsoleCtrlEvent")
+
+// setProcessGroup makes the command lead a new process group, so stopping
+// it reaches everything it started
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// terminateGroup sends CTRL_BREAK to the command's process group
+func terminateGroup(cmd *exec.Cmd) error {
+	if ok, _, err := generateConsoleCtrlEvent.Call(syscall.CTRL_BREAK_EVENT, uintptr(cmd.Process.Pid)); ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// killGroup kills the command and its child processes
+func killGroup(cmd *exec.Cmd) error {
+	// #nosec G204 - The pid is one aura started
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
+
+// groupProcesses names the command's process; taskkill /T reaches the
+// children it started
+func groupProcesses(cmd *exec.Cmd) []string {
+	return []string{strconv.Itoa(cmd.Process.Pid)}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// profileSpan is a target or one of its commands timed by the profiler
+type profileSpan struct {
+	target   string
+	command  string // "" for the target itself
+	start    time.Time
+	duration time.Duration
+	failed   bool
+}
+
+// profiler records the spans of the current build when --profile-report
+// or --profile-trace asks for them
+var profiler struct {
+	sync.Mutex
+	enabled bool
+	started time.Time
+	spans   []profileSpan
+}
+
+// startProfile starts recording the spans of a build
+func startProfile() {
+	profiler.Lock()
+	defer profiler.Unlock()
+	profiler.enabled, profiler.started, profiler.spans = true, time.Now(), nil
+}
+
+// stopProfile stops recording and returns the build's start and spans
+func stopProfile() (time.Time, []profileSpan) {
+	profiler.Lock()
+	defer profiler.Unlock()
+	started, spans := profiler.started, profiler.spans
+	profiler.enabled, profiler.spans = false, nil
+	return started, spans
+}
+
+// profileRecord adds a span to the profile when profiling
+func profileRecord(target, command string, start time.Time, err error) {
+	duration := time.Since(start)
+	profiler.Lock()
+	defer profiler.Unlock()
+	if profiler.enabled {
+		profiler.spans = append(profiler.spans, profileSpan{target: target, command: command, start: start, duration: duration, failed: err != nil})
+	}
+}
+
+// profileCommand is a command in the JSON profile report
+type profileCommand struct {
+	Command    string  `json:"command"`
+	StartMS    float64 `json:"start_ms"`
+	DurationMS float64 `json:"duration_ms"`
+	Failed     bool    `json:"failed,omitempty"`
+}
+
+// profileTarget is a target in the JSON profile report
+type profileTarget struct {
+	Target     string           `json:"target"`
+	StartMS    float64          `json:"start_ms"`
+	DurationMS float64          `json:"duration_ms"`
+	Failed     bool             `json:"failed,omitempty"`
+	Commands   []profileCommand `json:"commands"`
+}
+
+// profileReport is the JSON profile report: targets in the order they
+// started, times in milliseconds from the start of the build
+type profileReport struct {
+	DurationMS float64         `json:"duration_ms"`
+	Targets    []profileTarget `json:"targets"`
+}
+
+// milliseconds converts d for the reports, keeping sub-millisecond steps
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// buildProfile groups the spans by target, each with its commands
+func buildProfile(started time.Time, spans []profileSpan, took time.Duration) profileReport {
+	report := profileReport{DurationMS: milliseconds(took), Targets: []profileTarget{}}
+	index := map[string]int{}
+	for _, span := range spans {
+		if span.command == "" {
+			index[span.target] = len(report.Targets)
+			report.Targets = append(report.Targets, profileTarget{
+				Target: span.target, StartMS: milliseconds(span.start.Sub(started)),
+				DurationMS: milliseconds(span.duration), Failed: span.failed, Commands: []profileCommand{},
+			})
+		}
+	}
+	for _, span := range spans {
+		if i, ok := index[span.target]; ok && span.command != "" {
+			report.Targets[i].Commands = append(report.Targets[i].Commands, profileCommand{
+				Command: span.command, StartMS: milliseconds(span.start.Sub(started)),
+				DurationMS: milliseconds(span.duration), Failed: span.failed,
+			})
+		}
+	}
+	slices.SortStableFunc(report.Targets, func(a, b profileTarget) int { return cmp.Compare(a.StartMS, b.StartMS) })
+	return report
+}
+
+// writeProfileText prints the targets from the slowest with their share
+// of the build's wall time, then the slowest commands
+func writeProfileText(w io.Writer, report profileReport) {
+	const slowestCommands = 10
+	duration := func(ms float64) string {
+		return time.Duration(ms * float64(time.Millisecond)).Round(time.Millisecond).String()
+	}
+
+	targets := slices.Clone(report.Targets)
+	slices.SortStableFunc(targets, func(a, b profileTarget) int { return cmp.Compare(b.DurationMS, a.DurationMS) })
+	type timedCommand struct {
+		target string
+		profileCommand
+	}
+	var commands []timedCommand
+	for _, target := range targets {
+		for _, command := range target.Commands {
+			commands = append(commands, timedCommand{target.Target, command})
+		}
+	}
+	slices.SortStableFunc(commands, func(a, b timedCommand) int { return cmp.Compare(b.DurationMS, a.DurationMS) })
+
+	fmt.Fprintf(w, "Build profile: %s\n\n", duration(report.DurationMS))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tTIME\tSHARE\tCOMMANDS")
+	for _, target := range targets {
+		share := 0.0
+		if report.DurationMS > 0 {
+			share = 100 * target.DurationMS / report.DurationMS
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.1f%%\t%d\n", target.Target, duration(target.DurationMS), share, len(target.Commands))
+	}
+	_ = tw.Flush()
+
+	if len(commands) > slowestCommands {
+		commands = commands[:slowestCommands]
+	}
+	if len(commands) > 0 {
+		fmt.Fprintln(w, "\nSlowest commands:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, command := range commands {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", duration(command.DurationMS), command.target, strings.Join(strings.Fields(command.Command), " "))
+		}
+		_ = tw.Flush()
+	}
+}
+
+// traceEvent is an event of the Chrome trace event format, as read by
+// chrome://tracing and Perfetto: complete ("X") spans and thread names ("M")
+type traceEvent struct {
+	Name      string            `json:"name"`
+	Category  string            `json:"cat,omitempty"`
+	Phase     string            `json:"ph"`
+	Timestamp int64             `json:"ts"`
+	Duration  int64             `json:"dur,omitempty"`
+	PID       int               `json:"pid"`
+	TID       int               `json:"tid"`
+	Args      map[string]string `json:"args,omitempty"`
+}
+
+// traceEvents lays the profile out as a timeline: targets that ran at the
+// same time go on separate rows (the lowest free one, as parallel lanes),
+// each with its commands under it. Times are microseconds from the start
+// of the build
+func traceEvents(report profileReport) []traceEvent {
+	micros := func(ms float64) int64 { return int64(ms * 1000) }
+	events := []traceEvent{}
+	var laneEnds []float64
+	for _, target := range report.Targets {
+		lane := slices.IndexFunc(laneEnds, func(end float64) bool { return end <= target.StartMS })
+		if lane < 0 {
+			lane = len(laneEnds)
+			laneEnds = append(laneEnds, 0)
+			events = append(events, traceEvent{Name: "thread_name", Phase: "M", PID: 1, TID: lane + 1, Args: map[string]string{"name": fmt.Sprintf("lane %d", lane+1)}})
+		}
+		laneEnds[lane] = target.StartMS + target.DurationMS
+
+		args := map[string]string{}
+		if target.Failed {
+			args["result"] = "failed"
+		}
+		events = append(events, traceEvent{Name: target.Target, Category: "target", Phase: "X", Timestamp: micros(target.StartMS), Duration: max(1, micros(target.DurationMS)), PID: 1, TID: lane + 1, Args: args})
+		for _, command := range target.Commands {
+			args := map[string]string{"target": target.Target}
+			if command.Failed {
+				args["result"] = "failed"
+			}
+			events = append(events, traceEvent{Name: command.Command, Category: "command", Phase: "X", Timestamp: micros(command.StartMS), Duration: max(1, micros(command.DurationMS)), PID: 1, TID: lane + 1, Args: args})
+		}
+	}
+	return events
+}
+
+// writeProfile writes the profile report to path: JSON for a .json file,
+// else text, and - for stdout
+func writeProfile(path string, report profileReport) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		// #nosec G304 - the path is the user's --profile-report
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		w = file
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return json.NewEncoder(w).Encode(report)
+	}
+	if path == "-" {
+		fmt.Fprintln(w)
+	}
+	writeProfileText(w, report)
+	return nil
+}
+
+// writeTrace writes the profile to path as a Chrome trace
+func writeTrace(path string, report profileReport) error {
+	data, err := json.Marshal(map[string]any{"traceEvents": traceEvents(report), "displayTimeUnit": "ms"})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// writeProfiles writes the report and trace files asked for, warning
+// about those it cannot write
+func writeProfiles(reportPath, tracePath string, report profileReport) {
+	if reportPath != "" {
+		if err := writeProfile(reportPath, report); err != nil {
+			logWarn("cannot write profile report: %v", err)
+		}
+	}
+	if tracePath != "" {
+		if err := writeTrace(tracePath, report); err != nil {
+			logWarn("cannot write profile trace: %v", err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===== PROFILE.GO UNIT TESTS =====
+
+func TestProfileRecord(t *testing.T) {
+	defer stopProfile()
+
+	profileRecord("lib", "", time.Now(), nil)
+	if _, spans := stopProfile(); len(spans) != 0 {
+		t.Fatalf("spans = %v, expected none without profiling", spans)
+	}
+
+	startProfile()
+	profileRecord("lib", "make", time.Now(), errors.New("exit status 2"))
+	profileRecord("lib", "", time.Now(), nil)
+	_, spans := stopProfile()
+	if len(spans) != 2 || spans[0].command != "make" || !spans[0].failed || spans[1].command != "" {
+		t.Errorf("spans = %+v, expected the command and the target", spans)
+	}
+}
+
+// testProfileSpans is a build where lib and docs ran at the same time,
+// then app
+func testProfileSpans(started time.Time) []profileSpan {
+	at := func(ms int) time.Time { return started.Add(time.Duration(ms) * time.Millisecond) }
+	return []profileSpan{
+		{target: "lib", command: "make lib", start: at(0), duration: 300 * time.Millisecond},
+		{target: "docs", command: "make docs", start: at(10), duration: 100 * time.Millisecond},
+		{target: "docs", start: at(10), duration: 100 * time.Millisecond},
+		{target: "lib", start: at(0), duration: 300 * time.Millisecond},
+		{target: "app", command: "go build", start: at(300), duration: 100 * time.Millisecond, failed: true},
+		{target: "app", start: at(300), duration: 100 * time.Millisecond, failed: true},
+	}
+}
+
+func TestBuildProfile(t *testing.T) {
+	started := time.Now()
+	report := buildProfile(started, testProfileSpans(started), 400*time.Millisecond)
+
+	if report.DurationMS != 400 || len(report.Targets) != 3 {
+		t.Fatalf("report = %+v, expected 3 targets over 400ms", report)
+	}
+	var names []string
+	for _, target := range report.Targets {
+		names = append(names, target.Target)
+		if len(target.Commands) != 1 {
+			t.Errorf("target %s has %d commands, expected 1", target.Target, len(target.Commands))
+		}
+	}
+	if got := strings.Join(names, ","); got != "lib,docs,app" {
+		t.Errorf("targets = %s, expected the order they started", got)
+	}
+	if app := report.Targets[2]; app.StartMS != 300 || !app.Failed || !app.Commands[0].Failed {
+		t.Errorf("app = %+v, expected a failure at 300ms", app)
+	}
+}
+
+func TestWriteProfileText(t *testing.T) {
+	started := time.Now()
+	var b strings.Builder
+	writeProfileText(&b, buildProfile(started, testProfileSpans(started), 400*time.Millisecond))
+	output := b.String()
+
+	for _, want := range []string{"Build profile: 400ms", "lib     300ms  75.0%  1", "Slowest commands:", "300ms  lib   make lib"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("profile missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Index(output, "lib ") > strings.Index(output, "docs ") {
+		t.Errorf("profile should list the slowest target first:\n%s", output)
+	}
+}
+
+func TestTraceEvents(t *testing.T) {
+	started := time.Now()
+	events := traceEvents(buildProfile(started, testProfileSpans(started), 400*time.Millisecond))
+
+	lanes := map[string]int{}
+	threads := 0
+	for _, event := range events {
+		switch event.Phase {
+		case "M":
+			threads++
+		case "X":
+			if event.Category == "target" {
+				lanes[event.Name] = event.TID
+			}
+		}
+	}
+	if threads != 2 {
+		t.Errorf("trace has %d lanes, expected 2", threads)
+	}
+	if lanes["lib"] == lanes["docs"] || lanes["app"] != lanes["lib"] && lanes["app"] != lanes["docs"] {
+		t.Errorf("lanes = %v, expected lib and docs apart and app reusing a lane", lanes)
+	}
+	if events[len(events)-1].Args["result"] != "failed" {
+		t.Errorf("last event = %+v, expected the failed command", events[len(events)-1])
+	}
+}
+
+func TestWriteProfileFiles(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Now()
+	report := buildProfile(started, testProfileSpans(started), 400*time.Millisecond)
+	reportPath, tracePath := filepath.Join(dir, "profile.json"), filepath.Join(dir, "trace.json")
+
+	writeProfiles(reportPath, tracePath, report)
+
+	var decoded profileReport
+	data, err := os.ReadFile(reportPath)
+	if err != nil || json.Unmarshal(data, &decoded) != nil || len(decoded.Targets) != 3 {
+		t.Errorf("profile.json = %s (%v), expected the JSON report", data, err)
+	}
+	var trace struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	data, err = os.ReadFile(tracePath)
+	if err != nil || json.Unmarshal(data, &trace) != nil || len(trace.TraceEvents) == 0 {
+		t.Errorf("trace.json = %s (%v), expected trace events", data, err)
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// querySet is a set of target names
+type querySet map[string]bool
+
+// queryOperators combine two sets, in symbol and word form
+var queryOperators = map[string]string{
+	"+": "union", "union": "union",
+	"^": "intersect", "intersect": "intersect",
+	"-": "except", "except": "except",
+}
+
+// queryParser evaluates a query while parsing it:
+//
+//	expr := term (op term)*        op: + union ^ intersect - except
+//	term := name | pattern | ( expr ) | deps(expr[, depth])
+//	      | rdeps(expr[, depth]) | allpaths(expr, expr)
+//
+// Operators are left associative with equal precedence; patterns match
+// target names with *, ? and [...]
+type queryParser struct {
+	tokens []string
+	pos    int
+}
+
+// tokenizeQuery splits a query into words and the ( ) , punctuation
+func tokenizeQuery(query string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(query) {
+		start := 0
+		for i, c := range field {
+			if c == '(' || c == ')' || c == ',' {
+				if i > start {
+					tokens = append(tokens, field[start:i])
+				}
+				tokens = append(tokens, string(c))
+				start = i + 1
+			}
+		}
+		if start < len(field) {
+			tokens = append(tokens, field[start:])
+		}
+	}
+	return tokens
+}
+
+// evalQuery evaluates a query against the configured targets
+func evalQuery(query string) (querySet, error) {
+	p := &queryParser{tokens: tokenizeQuery(query)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	set, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s' at token %d", p.tokens[p.pos], p.pos+1)
+	}
+	return set, nil
+}
+
+func (p *queryParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *queryParser) next() string {
+	tok := p.peek()
+	if tok != "" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		if got == "" {
+			return fmt.Errorf("expected '%s' at end of query", tok)
+		}
+		return fmt.Errorf("expected '%s', got '%s'", tok, got)
+	}
+	return nil
+}
+
+func (p *queryParser) expr() (querySet, error) {
+	set, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := queryOperators[p.peek()]
+		if !ok {
+			return set, nil
+		}
+		p.next()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "union":
+			for name := range right {
+				set[name] = true
+			}
+		case "intersect":
+			for name := range set {
+				if !right[name] {
+					delete(set, name)
+				}
+			}
+		case "except":
+			for name := range right {
+				delete(set, name)
+			}
+		}
+	}
+}
+
+func (p *queryParser) term() (querySet, error) {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of query")
+	case tok == "(":
+		set, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return set, p.expect(")")
+	case tok == ")" || tok == ",":
+		return nil, fmt.Errorf("unexpected '%s'", tok)
+	case p.peek() == "(":
+		return p.function(tok)
+	}
+	return queryTargets(tok)
+}
+
+// function evaluates the arguments and the call of a query function
+func (p *queryParser) function(name string) (querySet, error) {
+	p.next()
+	switch name {
+	case "deps", "rdeps":
+		set, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		depth := -1
+		if p.peek() == "," {
+			p.next()
+			tok := p.next()
+			if depth, err = strconv.Atoi(tok); err != nil || depth < 0 {
+				return nil, fmt.Errorf("%s() depth must be a non-negative integer, got '%s'", name, tok)
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if name == "deps" {
+			return reachable(set, depth, targetEdges), nil
+		}
+		return reachable(set, depth, reverseEdges()), nil
+	case "allpaths":
+		from, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		to, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		forward := reachable(from, -1, targetEdges)
+		backward := reachable(to, -1, reverseEdges())
+		for name := range forward {
+			if !backward[name] {
+				delete(forward, name)
+			}
+		}
+		return forward, nil
+	}
+	return nil, fmt.Errorf("unknown function '%s': use deps, rdeps or allpaths", name)
+}
+
+// queryTargets returns the target a name names, or those a pattern matches
+func queryTargets(pattern string) (querySet, error) {
+	set := querySet{}
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, ok := cfg.Targets[pattern]; !ok {
+			return nil, fmt.Errorf("target '%s' not found", pattern)
+		}
+		set[pattern] = true
+		return set, nil
+	}
+	for name := range cfg.Targets {
+		matched, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+		if matched {
+			set[name] = true
+		}
+	}
+	return set, nil
+}
+
+// targetEdges returns the targets a target depends on directly
+func targetEdges(name string) []string {
+	var deps []string
+	for _, dep := range cfg.Targets[name].Deps {
+		if _, ok := cfg.Targets[dep]; ok {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// reverseEdges returns a function giving the targets that depend on a
+// target directly
+func reverseEdges() func(string) []string {
+	dependents := map[string][]string{}
+	for _, name := range sortedKeys(cfg.Targets) {
+		for _, dep := range targetEdges(name) {
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+	return func(name string) []string { return dependents[name] }
+}
+
+// reachable returns the set and the targets reachable from it along edges
+// in at most depth steps, or any number when depth is negative
+func reachable(set querySet, depth int, edges func(string) []string) querySet {
+	result := querySet{}
+	frontier := sortedKeys(set)
+	for _, name := range frontier {
+		result[name] = true
+	}
+	for step := 0; len(frontier) > 0 && (depth < 0 || step < depth); step++ {
+		var next []string
+		for _, name := range frontier {
+			for _, dep := range edges(name) {
+				if !result[dep] {
+					result[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+		frontier = next
+	}
+	return result
+}
+
+// queryOrder lists a set in dependency order, deps before the targets
+// that need them and names sorted otherwise
+func queryOrder(set querySet) []string {
+	var order []string
+	visited := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		deps := targetEdges(name)
+		slices.Sort(deps)
+		for _, dep := range deps {
+			visit(dep)
+		}
+		if set[name] {
+			order = append(order, name)
+		}
+	}
+	for _, name := range sortedKeys(set) {
+		visit(name)
+	}
+	return order
+}
+
+// queryCommand evaluates a query over the target graph and prints the
+// matching targets in dependency order
+func queryCommand(ctx *orpheus.Context) error {
+	args := positionalArgs(ctx)
+	if len(args) == 0 {
+		return orpheus.ValidationError("query", "usage: aura query '<expression>', e.g. 'rdeps(lib, 2)'")
+	}
+	format := ctx.GetFlagString("format")
+	if format != "text" && format != "json" {
+		return orpheus.ValidationError("format", fmt.Sprintf("unknown format '%s': use text or json", format))
+	}
+	workDir := ctx.GetGlobalFlagString("directory")
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	cacheDirFlag = ctx.GetGlobalFlagString("cache-dir")
+	if err := loadTargets(ctx.GetGlobalFlagString("config"), ctx.GetGlobalFlagString("profile")); err != nil {
+		return err
+	}
+
+	set, err := evalQuery(strings.Join(args, " "))
+	if err != nil {
+		return orpheus.ValidationError("query", err.Error())
+	}
+	names := queryOrder(set)
+
+	if format == "json" {
+		type TargetInfo struct {
+			Name        string   `json:"name"`
+			Description string   `json:"description,omitempty"`
+			Deps        []string `json:"dependencies,omitempty"`
+		}
+		targets := []TargetInfo{}
+		for _, name := range names {
+			targets = append(targets, TargetInfo{Name: name, Description: cfg.Targets[name].Desc, Deps: targetEdges(name)})
+		}
+		data, err := json.MarshalIndent(targets, "", "  ")
+		if err != nil {
+			return orpheus.ExecutionError("query", err.Error())
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ===== QUERY.GO UNIT TESTS =====
+
+func TestTokenizeQuery(t *testing.T) {
+	got := tokenizeQuery("rdeps(lib:core,2) - (deps(proto-gen))")
+	expected := []string{"rdeps", "(", "lib:core", ",", "2", ")", "-", "(", "deps", "(", "proto-gen", ")", ")"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("tokenizeQuery() = %q, expected %q", got, expected)
+	}
+}
+
+func TestEvalQuery(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{
+		"proto-gen": {},
+		"lib":       {Deps: []string{"proto-gen", "lib.h"}},
+		"assets":    {},
+		"app":       {Deps: []string{"lib", "assets"}},
+		"test":      {Deps: []string{"app"}},
+		"lint":      {Deps: []string{"lib"}},
+	}}
+
+	tests := []struct {
+		query    string
+		expected []string
+		errMsg   string
+	}{
+		{"app", []string{"app"}, ""},
+		{"deps(app)", []string{"assets", "proto-gen", "lib", "app"}, ""},
+		{"deps(app, 1)", []string{"assets", "lib", "app"}, ""},
+		{"deps(app, 0)", []string{"app"}, ""},
+		{"rdeps(lib)", []string{"lib", "app", "lint", "test"}, ""},
+		{"rdeps(lib, 1)", []string{"lib", "app", "lint"}, ""},
+		{"allpaths(test, proto-gen)", []string{"proto-gen", "lib", "app", "test"}, ""},
+		{"allpaths(lint, assets)", nil, ""},
+		{"deps(app) - lib", []string{"assets", "proto-gen", "app"}, ""},
+		{"rdeps(lib) ^ deps(test)", []string{"lib", "app", "test"}, ""},
+		{"lint union (assets + proto-gen)", []string{"assets", "proto-gen", "lint"}, ""},
+		{"deps(l*) except lib", []string{"proto-gen", "lint"}, ""},
+		{"missing", nil, "target 'missing' not found"},
+		{"deps(app", nil, "expected ')' at end of query"},
+		{"deps(app, -1)", nil, "depth must be a non-negative integer"},
+		{"allpaths(app)", nil, "expected ','"},
+		{"what(app)", nil, "unknown function 'what'"},
+		{"app lib", nil, "unexpected 'lib'"},
+		{"", nil, "empty query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			set, err := evalQuery(tt.query)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("evalQuery(%q) error = %v, expected %q", tt.query, err, tt.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("evalQuery(%q) unexpected error: %v", tt.query, err)
+			}
+			if got := queryOrder(set); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("evalQuery(%q) = %v, expected %v", tt.query, got, tt.expected)
+			}
+		})
+	}
+}
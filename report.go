@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// reportTarget is one target of the HTML build report, with the output of
+// its commands rendered from the target's raw log
+type reportTarget struct {
+	Name     string
+	Failed   bool
+	Duration time.Duration
+	Attempts int
+	Output   template.HTML
+}
+
+// reportPageData is what the --status-file HTML report is rendered from
+type reportPageData struct {
+	Project string
+	Status  buildStatus
+	Targets []reportTarget
+}
+
+// reportPage is a standalone build report: no external scripts or styles,
+// so CI can publish it as an artifact as is. Failed targets are expanded
+var reportPage = template.Must(template.New("report").Funcs(template.FuncMap{
+	"round": func(d time.Duration) time.Duration { return d.Round(time.Millisecond) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Project}} - aura build {{.Status.Result}}</title>
+<style>
+  body { margin: 0 auto; max-width: 1100px; padding: 16px; font: 14px system-ui, sans-serif; color: #1f2328; }
+  h1 { font-size: 18px; margin: 0 0 4px; }
+  .meta { color: #57606a; margin-bottom: 16px; }
+  .passing, .ok { color: #1a7f37; }
+  .failing, .failed { color: #cf222e; }
+  table { border-collapse: collapse; margin-bottom: 16px; }
+  th, td { text-align: left; padding: 4px 12px 4px 0; border-bottom: 1px solid #d0d7de; }
+  details { margin-bottom: 8px; }
+  summary { cursor: pointer; font-weight: 600; }
+  pre { background: #1e1e1e; color: #d4d4d4; padding: 10px 12px; border-radius: 6px; overflow: auto; font: 12px ui-monospace, monospace; }
+</style>
+</head>
+<body>
+<h1>{{.Project}}: <span class="{{.Status.Result}}">{{.Status.Result}}</span></h1>
+<div class="meta">{{round .Status.Duration}}, finished {{.Status.FinishedAt.Format "2006-01-02 15:04:05 MST"}}</div>
+<table>
+  <tr><th>Target</th><th>Status</th><th>Duration</th><th>Attempts</th></tr>
+{{- range .Targets}}
+  <tr><td>{{.Name}}</td>{{if .Failed}}<td class="failed">failed</td>{{else}}<td class="ok">ok</td>{{end}}<td>{{round .Duration}}</td><td>{{.Attempts}}</td></tr>
+{{- end}}
+</table>
+{{- range .Targets}}
+<details{{if .Failed}} open{{end}}>
+  <summary class="{{if .Failed}}failed{{else}}ok{{end}}">{{.Name}}</summary>
+  <pre>{{.Output}}</pre>
+</details>
+{{- end}}
+</body>
+</html>
+`))
+
+// buildReportHTML renders the status as an HTML build report, each
+// target's output in its colors
+func buildReportHTML(status buildStatus) ([]byte, error) {
+	data := reportPageData{Status: status}
+	if wd, err := os.Getwd(); err == nil {
+		data.Project = filepath.Base(wd)
+	}
+	for _, r := range status.results {
+		data.Targets = append(data.Targets, reportTarget{
+			Name:     r.Name,
+			Failed:   r.Err != nil,
+			Duration: r.Duration,
+			Attempts: max(1, r.Attempts),
+			Output:   template.HTML(ansiToHTML(targetOutput(r.Name))), // #nosec G203 - ansiToHTML escapes the text
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := reportPage.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// targetOutput reads the raw log of a target's last run and makes it
+// printable as the build showed it: transcoded and sanitized, colors kept
+func targetOutput(name string) string {
+	// #nosec G304 - Path derived from the cache directory
+	data, err := os.ReadFile(targetLogPath(name))
+	if err != nil {
+		return ""
+	}
+	out := string(data)
+	if target, ok := cfg.Targets[name]; ok {
+		out = transcodeOutput(out, targetCodePage(&target))
+	}
+	out, _ = sanitizeOutput(out)
+	return out
+}
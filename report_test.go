@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===== REPORT.GO UNIT TESTS =====
+
+func TestBuildReportHTML(t *testing.T) {
+	original, originalFlag := cfg, cacheDirFlag
+	defer func() { cfg, cacheDirFlag = original, originalFlag }()
+	cacheDirFlag = t.TempDir()
+	cfg = Config{Targets: map[string]Target{"lint": {}, "test": {}}}
+
+	for name, log := range map[string]string{
+		"lint": "$ golangci-lint run\n\x1b[31mmain.go:3: unused\x1b[0m\n",
+		"test": "$ go test\nok\n\x1b]0;title\x07",
+	} {
+		path := targetLogPath(name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(log), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := []targetResult{
+		{Name: "test", Duration: 2 * time.Second},
+		{Name: "lint", Duration: time.Second, Attempts: 2, Err: errors.New("exit status 1")},
+	}
+	page, err := buildReportHTML(newBuildStatus(list, nil, 3*time.Second))
+	if err != nil {
+		t.Fatalf("buildReportHTML() error = %v", err)
+	}
+	report := string(page)
+
+	for _, want := range []string{
+		`<span class="failing">failing</span>`,
+		`<tr><td>lint</td><td class="failed">failed</td><td>1s</td><td>2</td></tr>`,
+		`<details open>` + "\n" + `  <summary class="failed">lint</summary>`,
+		`<span style="color:#cd3131">main.go:3: unused</span>`,
+		"$ go test\nok\n</pre>",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sandboxRoot is where sandboxed targets are staged, beside the build cache
+// so hardlinks usually stay on one filesystem
+func sandboxRoot() string {
+	return filepath.Join(buildCacheDir(), "sandbox")
+}
+
+// validateSandboxes checks that sandboxed targets declare their outputs
+// inside the project, where they can be promoted back from the sandbox
+func validateSandboxes() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		target := cfg.Targets[name]
+		if !target.Sandbox {
+			continue
+		}
+		if target.dir != "" && !filepath.IsLocal(target.dir) {
+			return fmt.Errorf("sandboxed target '%s' belongs to workspace member '%s' outside the project; only members below it can be sandboxed", name, filepath.ToSlash(target.dir))
+		}
+		for _, output := range target.Outputs {
+			if !filepath.IsLocal(filepath.FromSlash(output)) {
+				return fmt.Errorf("sandboxed target '%s' has output '%s' outside the project; outputs must be relative paths to be promoted from the sandbox", name, output)
+			}
+		}
+	}
+	return nil
+}
+
+// stageSandbox creates a directory holding only the target's sources,
+// hardlinked where possible and copied otherwise. Sources outside the
+// project are left where they are
+func stageSandbox(name string, target *Target) (string, error) {
+	sources := expandSources(target.Sources, name)
+
+	root := sandboxRoot()
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(root, tempNameRegex.ReplaceAllString(name, "_")+"-")
+	if err != nil {
+		return "", err
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	for _, file := range sources {
+		file = filepath.FromSlash(file)
+		if !filepath.IsLocal(file) {
+			continue
+		}
+		if err := linkOrCopy(file, filepath.Join(dir, file)); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	// Output directories and the member directory commands run in exist,
+	// as they usually do in the project
+	if err := os.MkdirAll(filepath.Join(dir, target.dir), 0o755); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	for _, output := range target.Outputs {
+		output = ParseVars(output, name)
+		if strings.ContainsAny(output, "*?[") {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(filepath.FromSlash(output))), 0o755); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// stagedOutputs lists the files a sandboxed run produced for the target's
+// declared outputs; an output the commands did not produce is an error
+func stagedOutputs(dir, name string, target *Target) ([]string, error) {
+	var files []string
+	for _, pattern := range target.Outputs {
+		output := filepath.FromSlash(ParseVars(pattern, name))
+		staged := filepath.Join(dir, output)
+
+		var matched []string
+		if info, err := os.Stat(staged); err == nil && info.IsDir() {
+			err := filepath.WalkDir(staged, func(p string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					matched = append(matched, p)
+				}
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			for _, file := range globFiles(filepath.ToSlash(staged)) {
+				matched = append(matched, filepath.FromSlash(file))
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("declared output '%s' was not produced in the sandbox", filepath.ToSlash(output))
+		}
+		files = append(files, matched...)
+	}
+	return files, nil
+}
+
+// promoteOutputs moves a sandboxed target's declared outputs into the
+// project; an output the commands did not produce is an error
+func promoteOutputs(dir, name string, target *Target) (int, error) {
+	files, err := stagedOutputs(dir, name, target)
+	if err != nil {
+		return 0, err
+	}
+	for i, file := range files {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return i, err
+		}
+		if err := moveFile(file, rel); err != nil {
+			return i, err
+		}
+	}
+	return len(files), nil
+}
+
+// removeSandbox deletes a sandbox unless --keep-temp asked to keep it
+func removeSandbox(dir, name string) {
+	if keepTemp {
+		fmt.Printf("Kept sandbox of %s: %s\n", name, dir)
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		logWarn("cannot remove %s: %v", dir, err)
+	}
+}
+
+// linkOrCopy makes dst a hardlink of src, or a copy when src cannot be
+// linked, such as across filesystems
+func linkOrCopy(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	return copyFile(src, dst)
+}
+
+// moveFile renames src to dst, falling back to a copy across filesystems
+func moveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyFile copies the contents and permission bits of src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src) // #nosec G304 - paths come from the target's declared files
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".aura-copy-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== SANDBOX.GO UNIT TESTS =====
+
+func TestValidateSandboxes(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name   string
+		target Target
+		errMsg string
+	}{
+		{"relative outputs", Target{Sandbox: true, Outputs: []string{"bin/app", "dist/**"}}, ""},
+		{"not sandboxed", Target{Outputs: []string{"/usr/local/bin/app"}}, ""},
+		{"absolute output", Target{Sandbox: true, Outputs: []string{"/usr/local/bin/app"}}, "outside the project"},
+		{"escaping output", Target{Sandbox: true, Outputs: []string{"../app"}}, "outside the project"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: map[string]Target{"build": tt.target}}
+			err := validateSandboxes()
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("validateSandboxes() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("validateSandboxes() error = %v, expected %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestStageAndPromoteSandbox(t *testing.T) {
+	chdirTemp(t)
+	writeFiles(t, map[string]string{
+		"src/main.c":  "int main() {}",
+		"src/util.c":  "void util() {}",
+		"README.md":   "not a source",
+		"bin/old.txt": "kept",
+	})
+
+	target := &Target{Sources: []string{"src/*.c"}, Outputs: []string{"bin/app", "gen"}}
+	dir, err := stageSandbox("build", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeSandbox(dir, "build")
+
+	for _, file := range []string{"src/main.c", "src/util.c"} {
+		if data, err := os.ReadFile(filepath.Join(dir, file)); err != nil || len(data) == 0 {
+			t.Errorf("source %s not staged: %v", file, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README.md")); !os.IsNotExist(err) {
+		t.Errorf("undeclared README.md should not be staged, stat error = %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "bin")); err != nil || !info.IsDir() {
+		t.Errorf("output directory bin should exist in the sandbox: %v", err)
+	}
+
+	writeFiles(t, map[string]string{
+		filepath.Join(dir, "bin", "app"):       "binary",
+		filepath.Join(dir, "gen", "a", "x.go"): "package a",
+		filepath.Join(dir, "scratch", "tmp.o"): "undeclared",
+	})
+	count, err := promoteOutputs(dir, "build", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("promoteOutputs() = %d files, expected 2", count)
+	}
+	for file, content := range map[string]string{"bin/app": "binary", "gen/a/x.go": "package a", "bin/old.txt": "kept"} {
+		if data, err := os.ReadFile(file); err != nil || string(data) != content {
+			t.Errorf("%s = %q, %v; expected %q", file, data, err, content)
+		}
+	}
+	if _, err := os.Stat("scratch"); !os.IsNotExist(err) {
+		t.Errorf("undeclared scratch should stay in the sandbox, stat error = %v", err)
+	}
+}
+
+func TestPromoteOutputsMissing(t *testing.T) {
+	dir := t.TempDir()
+	_, err := promoteOutputs(dir, "build", &Target{Outputs: []string{"bin/app"}})
+	if err == nil || !strings.Contains(err.Error(), "'bin/app' was not produced") {
+		t.Errorf("promoteOutputs() error = %v, expected a missing output", err)
+	}
+}
+
+func TestExecuteTargetSandbox(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"in.txt": "declared", "extra.txt": "undeclared"})
+
+	tests := []struct {
+		name    string
+		target  Target
+		wantErr bool
+	}{
+		{"declared inputs", Target{Sources: []string{"in.txt"}, Outputs: []string{"out/copy.txt"}, Run: []string{"cp in.txt out/copy.txt"}}, false},
+		{"undeclared input", Target{Sources: []string{"in.txt"}, Outputs: []string{"out/extra.txt"}, Run: []string{"cp extra.txt out/extra.txt"}}, true},
+		{"cd builtin", Target{Run: []string{"cd out"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.target.Sandbox = true
+			cfg = Config{Targets: map[string]Target{"sandboxed": tt.target}}
+			err := executeTarget(newExpandContext("sandboxed", nil), &tt.target, false, false)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("executeTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if data, err := os.ReadFile("out/copy.txt"); err != nil || string(data) != "declared" {
+		t.Errorf("out/copy.txt = %q, %v; expected the promoted output", data, err)
+	}
+	if _, err := os.Stat("out/extra.txt"); !os.IsNotExist(err) {
+		t.Errorf("failed target should not promote outputs, stat error = %v", err)
+	}
+}
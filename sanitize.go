@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// terminalControlRegex matches escape sequences (CSI, OSC, character set
+// and other ESC sequences) and control characters except tab, newline and
+// carriage return, C1 controls included
+var terminalControlRegex = regexp.MustCompile(`\x1b(?:\[[0-9;?<=>]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[ -/]+[0-~]|[0-~]?)|[\x00-\x08\x0b\x0c\x0e-\x1f\x7f\x{80}-\x{9f}]`)
+
+// sgrRegex matches the color and style sequences sanitizing keeps
+var sgrRegex = regexp.MustCompile(`^\x1b\[[0-9;]*m$`)
+
+// sanitizeOutput makes command output safe to print: invalid UTF-8 becomes
+// U+FFFD and control characters and escape sequences are dropped, except
+// colors. It reports whether anything was changed
+func sanitizeOutput(out string) (string, bool) {
+	clean := strings.ToValidUTF8(out, "\uFFFD")
+	clean = terminalControlRegex.ReplaceAllStringFunc(clean, func(seq string) string {
+		if sgrRegex.MatchString(seq) {
+			return seq
+		}
+		return ""
+	})
+	return clean, clean != out
+}
+
+// targetLogPath returns the file a target's raw command output is kept in
+func targetLogPath(name string) string {
+	return filepath.Join(buildCacheDir(), "logs", tempNameRegex.ReplaceAllString(name, "_")+".log")
+}
+
+// targetLog records the raw output of a target's commands, replaced each
+// time the target runs, so output that sanitizing changed can be inspected
+type targetLog struct {
+	path string
+	file *os.File
+}
+
+// openTargetLog starts the log of a run of a target; nil if it cannot be
+// written, which only loses the raw copy
+func openTargetLog(name string) *targetLog {
+	path := targetLogPath(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil
+	}
+	// #nosec G304 - Path derived from the cache directory
+	file, err := os.Create(path)
+	if err != nil {
+		return nil
+	}
+	return &targetLog{path: path, file: file}
+}
+
+// record appends a command and its raw output
+func (l *targetLog) record(command, out string) {
+	if l == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(l.file, "$ %s\n%s", command, out)
+}
+
+// close finishes the log
+func (l *targetLog) close() {
+	if l != nil {
+		_ = l.file.Close()
+	}
+}
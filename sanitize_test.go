@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// ===== SANITIZE.GO UNIT TESTS =====
+
+func TestSanitizeOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		changed  bool
+	}{
+		{"plain", "ok\n\tindented\r\n", "ok\n\tindented\r\n", false},
+		{"unicode", "héllo ✓\n", "héllo ✓\n", false},
+		{"colors kept", "\x1b[1;31mred\x1b[0m \x1b[m", "\x1b[1;31mred\x1b[0m \x1b[m", false},
+		{"invalid utf-8", "a\xffb\xc3", "a�b�", true},
+		{"cursor and clear", "\x1b[2J\x1b[H\x1b[?25ltext", "text", true},
+		{"title", "\x1b]0;pwned\x07after", "after", true},
+		{"charset", "\x1b(Bx\x1bcy", "xy", true},
+		{"control bytes", "a\x00b\x07c\x08d\x7fe", "abcde", true},
+		{"c1 controls", "a\u009bb", "ab", true},
+		{"lone escape", "end\x1b", "end", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := sanitizeOutput(tt.input)
+			if got != tt.expected || changed != tt.changed {
+				t.Errorf("sanitizeOutput(%q) = %q, %v; expected %q, %v", tt.input, got, changed, tt.expected, tt.changed)
+			}
+		})
+	}
+}
+
+func TestTargetLog(t *testing.T) {
+	t.Setenv("AURA_CACHE_DIR", t.TempDir())
+
+	for run := 0; run < 2; run++ {
+		log := openTargetLog("lib:build")
+		if log == nil {
+			t.Fatal("openTargetLog() failed")
+		}
+		log.record("gen", "raw\xff\x1b[2J\n")
+		log.close()
+	}
+
+	path := targetLogPath("lib:build")
+	if !strings.HasSuffix(path, "lib_build.log") {
+		t.Errorf("targetLogPath() = %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "$ gen\nraw\xff\x1b[2J\n" {
+		t.Errorf("log = %q, expected the raw output of the last run", data)
+	}
+
+	var none *targetLog
+	none.record("x", "y")
+	none.close()
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// schedNode is one target in a parallel run
+type schedNode struct {
+	name   string
+	target Target
+	deps   []*schedNode
+	done   chan struct{}
+	err    error
+}
+
+// targetMutexes are the named locks of the mutex setting; targets sharing
+// one never run at the same time
+var targetMutexes = struct {
+	sync.Mutex
+	locks map[string]*sync.Mutex
+}{locks: map[string]*sync.Mutex{}}
+
+// lockTargetMutex waits for the target's mutex, if it has one, and returns
+// the function that releases it
+func lockTargetMutex(target *Target) func() {
+	if target.Mutex == "" {
+		return func() {}
+	}
+	targetMutexes.Lock()
+	lock, ok := targetMutexes.locks[target.Mutex]
+	if !ok {
+		lock = &sync.Mutex{}
+		targetMutexes.locks[target.Mutex] = lock
+	}
+	targetMutexes.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// buildSchedule collects the requested targets and everything they depend
+// on, each exactly once, failing on unknown targets and dependency cycles
+func buildSchedule(names []string) ([]*schedNode, error) {
+	nodes := map[string]*schedNode{}
+	var visit func(name string, path []string) (*schedNode, error)
+
+	visit = func(name string, path []string) (*schedNode, error) {
+		for i, seen := range path {
+			if seen == name {
+				cycle := append(slices.Clone(path[i:]), name)
+				return nil, orpheus.ValidationError("deps", "dependency cycle: "+strings.Join(cycle, " -> "))
+			}
+		}
+		if node, ok := nodes[name]; ok {
+			return node, nil
+		}
+
+		target := GetTarget(name)
+		if target.Run == nil && target.Deps == nil && target.ReproArchive == nil {
+			return nil, orpheus.NotFoundError(name, fmt.Sprintf("target '%s' not found", name))
+		}
+
+		node := &schedNode{name: name, target: target, done: make(chan struct{})}
+		for _, dep := range target.Deps {
+			// File dependencies are not scheduled
+			if isFileDep(dep) {
+				continue
+			}
+			depNode, err := visit(dep, append(path, name))
+			if err != nil {
+				return nil, err
+			}
+			node.deps = append(node.deps, depNode)
+		}
+		nodes[name] = node
+		return node, nil
+	}
+
+	var requested []*schedNode
+	for _, name := range names {
+		node, err := visit(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		requested = append(requested, node)
+	}
+	return requested, nil
+}
+
+// runTargetsParallel runs the requested targets and their dependencies.
+// Every target runs once, as soon as all of its dependencies succeeded,
+// with at most jobs targets running at a time (jobs <= 0 means no local
+// limit). A make jobserver, when present, additionally hands out tokens.
+func runTargetsParallel(names []string, jobs int, verbose, dryRun bool) error {
+	requested, err := buildSchedule(names)
+	if err != nil {
+		return err
+	}
+	if err := checkOutputConflicts(requested); err != nil {
+		return err
+	}
+
+	var slots chan struct{}
+	if jobs > 0 {
+		slots = make(chan struct{}, jobs)
+	}
+
+	var wg sync.WaitGroup
+	started := map[*schedNode]bool{}
+	var start func(node *schedNode)
+	start = func(node *schedNode) {
+		if started[node] {
+			return
+		}
+		started[node] = true
+		for _, dep := range node.deps {
+			start(dep)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(node.done)
+
+			for _, dep := range node.deps {
+				<-dep.done
+				if dep.err != nil {
+					node.err = orpheus.ExecutionError(node.name, fmt.Sprintf("dependency '%s' failed", dep.name))
+					return
+				}
+			}
+
+			// Wait for the mutex before taking a slot, so waiting targets
+			// do not hold back unrelated ones
+			defer lockTargetMutex(&node.target)()
+
+			if slots != nil {
+				slots <- struct{}{}
+				defer func() { <-slots }()
+			}
+			if jobserver != nil {
+				token, err := jobserver.acquire()
+				if err != nil {
+					node.err = orpheus.ExecutionError(node.name, fmt.Sprintf("jobserver: %v", err))
+					return
+				}
+				defer jobserver.release(token)
+			}
+
+			// Capture the expansion state now that the target is scheduled
+			node.err = runTimed(newExpandContext(node.name, nil), &node.target, verbose, dryRun)
+			if node.err == nil && !dryRun {
+				targetSucceeded(node.name, &node.target)
+			}
+		}()
+	}
+
+	for _, node := range requested {
+		start(node)
+	}
+	wg.Wait()
+
+	// Report the first failure in request order, preferring the root cause
+	// over "dependency failed" errors further up the graph
+	for _, node := range requested {
+		if err := rootFailure(node, map[*schedNode]bool{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOutputConflicts fails when two targets of a parallel run declare
+// the same output and nothing keeps them from running at once: neither
+// depends on the other and they share no mutex
+func checkOutputConflicts(requested []*schedNode) error {
+	var nodes []*schedNode
+	below := map[*schedNode]map[*schedNode]bool{}
+	var collect func(node *schedNode) map[*schedNode]bool
+	collect = func(node *schedNode) map[*schedNode]bool {
+		if deps, ok := below[node]; ok {
+			return deps
+		}
+		deps := map[*schedNode]bool{}
+		below[node] = deps
+		nodes = append(nodes, node)
+		for _, dep := range node.deps {
+			deps[dep] = true
+			for d := range collect(dep) {
+				deps[d] = true
+			}
+		}
+		return deps
+	}
+	for _, node := range requested {
+		collect(node)
+	}
+
+	writers := map[string][]*schedNode{}
+	for _, node := range nodes {
+		for _, output := range node.target.Outputs {
+			output = filepath.ToSlash(filepath.Clean(ParseVars(output, node.name)))
+			for _, other := range writers[output] {
+				if below[node][other] || below[other][node] || (node.target.Mutex != "" && node.target.Mutex == other.target.Mutex) {
+					continue
+				}
+				first, second := min(other.name, node.name), max(other.name, node.name)
+				return orpheus.ValidationError("outputs", fmt.Sprintf("targets '%s' and '%s' both write '%s' and may run at the same time; give them the same mutex or make one depend on the other", first, second, output))
+			}
+			writers[output] = append(writers[output], node)
+		}
+	}
+	return nil
+}
+
+// rootFailure returns the deepest error below node, if any
+func rootFailure(node *schedNode, seen map[*schedNode]bool) error {
+	if seen[node] {
+		return nil
+	}
+	seen[node] = true
+	for _, dep := range node.deps {
+		if err := rootFailure(dep, seen); err != nil {
+			return err
+		}
+	}
+	return node.err
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===== SCHEDULER.GO UNIT TESTS =====
+
+func TestBuildSchedule(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{Targets: map[string]Target{
+		"all":   {Deps: []string{"api", "web"}, Run: []string{"echo all"}},
+		"api":   {Deps: []string{"lib", "go.mod"}, Run: []string{"echo api"}},
+		"web":   {Deps: []string{"lib"}, Run: []string{"echo web"}},
+		"lib":   {Run: []string{"echo lib"}},
+		"loop":  {Deps: []string{"loop2"}, Run: []string{"echo"}},
+		"loop2": {Deps: []string{"loop"}, Run: []string{"echo"}},
+	}}
+
+	requested, err := buildSchedule([]string{"all"})
+	if err != nil {
+		t.Fatalf("buildSchedule() unexpected error: %v", err)
+	}
+	all := requested[0]
+	api, web := all.deps[0], all.deps[1]
+	if len(api.deps) != 1 {
+		t.Errorf("file dependency was scheduled: %d deps", len(api.deps))
+	}
+	if api.deps[0] != web.deps[0] {
+		t.Errorf("shared dependency scheduled twice")
+	}
+
+	_, err = buildSchedule([]string{"loop"})
+	if err == nil || !strings.Contains(err.Error(), "loop -> loop2 -> loop") {
+		t.Errorf("buildSchedule() cycle error = %v", err)
+	}
+	if _, err := buildSchedule([]string{"missing"}); err == nil {
+		t.Errorf("buildSchedule() expected error for unknown target")
+	}
+}
+
+func TestRunTargetsParallel(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	cfg = Config{Targets: map[string]Target{
+		"all":  {Deps: []string{"a", "b"}, Run: []string{"echo all"}},
+		"a":    {Run: []string{"echo a"}},
+		"b":    {Run: []string{"echo b"}},
+		"bad":  {Run: []string{"exit 1"}},
+		"top":  {Deps: []string{"bad", "a"}, Run: []string{"echo top"}},
+		"soft": {Run: []string{"exit 1"}, ContinueOnError: true},
+	}}
+
+	if err := runTargetsParallel([]string{"all", "soft"}, 2, false, false); err != nil {
+		t.Errorf("runTargetsParallel() unexpected error: %v", err)
+	}
+
+	err := runTargetsParallel([]string{"top"}, 2, false, false)
+	if err == nil || !strings.Contains(err.Error(), "bad") {
+		t.Errorf("runTargetsParallel() error = %v, want the failing dependency reported", err)
+	}
+}
+
+func TestRunTargetsParallelMutex(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+
+	// Each db target fails if it finds another one inside the critical
+	// section; free runs alongside them
+	critical := "mkdir db.lock || exit 1; sleep 0.1; rmdir db.lock"
+	cfg = Config{Targets: map[string]Target{
+		"itest-a": {Mutex: "db", Run: []string{critical}},
+		"itest-b": {Mutex: "db", Run: []string{critical}},
+		"itest-c": {Mutex: "db", Run: []string{critical}},
+		"free":    {Run: []string{"echo free"}},
+	}}
+
+	if err := runTargetsParallel([]string{"itest-a", "itest-b", "itest-c", "free"}, 4, false, false); err != nil {
+		t.Errorf("targets sharing a mutex overlapped: %v", err)
+	}
+}
+
+func TestLockTargetMutex(t *testing.T) {
+	unlockNone := lockTargetMutex(&Target{})
+	unlockNone()
+
+	unlock := lockTargetMutex(&Target{Mutex: "test-lock"})
+	acquired := make(chan struct{})
+	go func() {
+		defer lockTargetMutex(&Target{Mutex: "test-lock"})()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second holder acquired the mutex while it was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("mutex was not handed over after unlock")
+	}
+}
+
+func TestCheckOutputConflicts(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		targets map[string]Target
+		errMsg  string
+	}{
+		{
+			name: "distinct outputs",
+			targets: map[string]Target{
+				"a": {Outputs: []string{"dist/a"}, Run: []string{"echo a"}},
+				"b": {Outputs: []string{"dist/b"}, Run: []string{"echo b"}},
+			},
+		},
+		{
+			name: "same output in parallel",
+			targets: map[string]Target{
+				"a": {Outputs: []string{"dist/app"}, Run: []string{"echo a"}},
+				"b": {Outputs: []string{"./dist//app"}, Run: []string{"echo b"}},
+			},
+			errMsg: "targets 'a' and 'b' both write 'dist/app'",
+		},
+		{
+			name: "ordered by a dependency",
+			targets: map[string]Target{
+				"a":   {Outputs: []string{"dist/app"}, Run: []string{"echo a"}},
+				"b":   {Deps: []string{"mid"}, Outputs: []string{"dist/app"}, Run: []string{"echo b"}},
+				"mid": {Deps: []string{"a"}, Run: []string{"echo mid"}},
+			},
+		},
+		{
+			name: "shared mutex",
+			targets: map[string]Target{
+				"a": {Mutex: "dist", Outputs: []string{"dist/app"}, Run: []string{"echo a"}},
+				"b": {Mutex: "dist", Outputs: []string{"dist/app"}, Run: []string{"echo b"}},
+			},
+		},
+		{
+			name: "third writer unordered with the second",
+			targets: map[string]Target{
+				"a": {Outputs: []string{"out"}, Run: []string{"echo a"}},
+				"b": {Deps: []string{"a"}, Outputs: []string{"out"}, Run: []string{"echo b"}},
+				"c": {Deps: []string{"a"}, Outputs: []string{"out"}, Run: []string{"echo c"}},
+			},
+			errMsg: "targets 'b' and 'c' both write 'out'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: tt.targets}
+			requested, err := buildSchedule(sortedKeys(tt.targets))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = checkOutputConflicts(requested)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("checkOutputConflicts() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("checkOutputConflicts() error = %v, expected %q", err, tt.errMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"unicode"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// serviceSpec describes a background `aura watch` for one project
+type serviceSpec struct {
+	Name string   // service / unit / task name
+	Exec string   // absolute path to the aura binary
+	Args []string // arguments to aura
+	Dir  string   // project directory
+}
+
+var serviceNameRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// serviceName derives a service name from the project directory
+func serviceName(dir string) string {
+	name := serviceNameRegex.ReplaceAllString(filepath.Base(dir), "-")
+	return "aura-watch-" + strings.Trim(name, "-")
+}
+
+// newServiceSpec builds the spec for watching the current project
+func newServiceSpec(ctx *orpheus.Context) (serviceSpec, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return serviceSpec{}, err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return serviceSpec{}, err
+	}
+
+	args := []string{"-D", dir, "-c", ctx.GetGlobalFlagString("config")}
+	if profile := ctx.GetGlobalFlagString("profile"); profile != "" {
+		args = append(args, "-P", profile)
+	}
+	args = append(args, "watch", "-i", ctx.GetFlagString("interval"))
+	if targets := ctx.GetFlagString("targets"); targets != "" {
+		args = append(args, "-t", targets)
+	}
+
+	name := ctx.GetFlagString("name")
+	if name == "" {
+		name = serviceName(dir)
+	}
+	return serviceSpec{Name: name, Exec: exe, Args: args, Dir: dir}, nil
+}
+
+// systemdQuote quotes an argument for an ExecStart line
+func systemdQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\$%") {
+		return arg
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`, `%`, `%%`)
+	return `"` + r.Replace(arg) + `"`
+}
+
+// systemdPath writes a path for a unit setting that takes it as it is,
+// such as WorkingDirectory=, where quotes would be part of the path: only
+// its % specifiers are escaped. A line break or control character, a
+// leading or trailing space or a trailing backslash cannot be written
+func systemdPath(path string) (string, error) {
+	if strings.ContainsFunc(path, unicode.IsControl) || strings.TrimSpace(path) != path || strings.HasSuffix(path, `\`) {
+		return "", fmt.Errorf("systemd cannot express the directory %q: it has a control character, a leading or trailing space or a trailing backslash", path)
+	}
+	return strings.ReplaceAll(path, "%", "%%"), nil
+}
+
+// systemdUnit renders a systemd user unit
+func systemdUnit(spec serviceSpec) (string, error) {
+	dir, err := systemdPath(spec.Dir)
+	if err != nil {
+		return "", err
+	}
+	words := []string{systemdQuote(spec.Exec)}
+	for _, arg := range spec.Args {
+		words = append(words, systemdQuote(arg))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[Unit]\n")
+	fmt.Fprintf(&sb, "Description=aura watch for %s\n\n", dir)
+	sb.WriteString("[Service]\n")
+	fmt.Fprintf(&sb, "WorkingDirectory=%s\n", dir)
+	fmt.Fprintf(&sb, "ExecStart=%s\n", strings.Join(words, " "))
+	sb.WriteString("Restart=on-failure\n")
+	sb.WriteString("RestartSec=5\n\n")
+	sb.WriteString("[Install]\n")
+	sb.WriteString("WantedBy=default.target\n")
+	return sb.String(), nil
+}
+
+// launchdLabel is the reverse-DNS label launchd knows the agent by
+func launchdLabel(spec serviceSpec) string {
+	return "com.agilira." + spec.Name
+}
+
+// launchdPlist renders a launchd agent definition
+func launchdPlist(spec serviceSpec) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	sb.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	fmt.Fprintf(&sb, "  <key>Label</key>\n  <string>%s</string>\n", html.EscapeString(launchdLabel(spec)))
+	sb.WriteString("  <key>ProgramArguments</key>\n  <array>\n")
+	for _, arg := range append([]string{spec.Exec}, spec.Args...) {
+		fmt.Fprintf(&sb, "    <string>%s</string>\n", html.EscapeString(arg))
+	}
+	sb.WriteString("  </array>\n")
+	fmt.Fprintf(&sb, "  <key>WorkingDirectory</key>\n  <string>%s</string>\n", html.EscapeString(spec.Dir))
+	sb.WriteString("  <key>RunAtLoad</key>\n  <true/>\n")
+	sb.WriteString("  <key>KeepAlive</key>\n  <true/>\n")
+	sb.WriteString("</dict>\n</plist>\n")
+	return sb.String()
+}
+
+// windowsCommandLine quotes arguments for a scheduled task action
+func windowsCommandLine(exe string, args []string) string {
+	words := []string{`"` + exe + `"`}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"") {
+			arg = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// servicePath returns where the service definition is written, if the
+// platform uses a file for it
+func servicePath(spec serviceSpec) (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "systemd", "user", spec.Name+".service"), nil
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "LaunchAgents", launchdLabel(spec)+".plist"), nil
+	}
+	return "", nil
+}
+
+// serviceDefinition renders the definition for the running platform
+func serviceDefinition(spec serviceSpec) (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return systemdUnit(spec)
+	case "darwin":
+		return launchdPlist(spec), nil
+	case "windows":
+		// A scheduled task started at logon: a real service would have to
+		// implement the service control protocol
+		return "schtasks /Create /F /SC ONLOGON /TN " + spec.Name + " /TR " + windowsCommandLine(spec.Exec, spec.Args), nil
+	}
+	return "", fmt.Errorf("background services are not supported on %s", runtime.GOOS)
+}
+
+// runServiceTool runs systemctl/launchctl/schtasks, echoing the command
+func runServiceTool(name string, args ...string) error {
+	fmt.Printf("  %s %s\n", name, strings.Join(args, " "))
+	// #nosec G204 - Fixed service manager binaries with generated arguments
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v\n%s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// installService registers and starts the service
+func installService(spec serviceSpec) error {
+	definition, err := serviceDefinition(spec)
+	if err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		if err := runServiceTool("schtasks", "/Create", "/F", "/SC", "ONLOGON", "/TN", spec.Name, "/TR", windowsCommandLine(spec.Exec, spec.Args)); err != nil {
+			return err
+		}
+		return runServiceTool("schtasks", "/Run", "/TN", spec.Name)
+	}
+
+	path, err := servicePath(spec)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(definition), 0600); err != nil {
+		return err
+	}
+	fmt.Printf("  Wrote %s\n", path)
+
+	if runtime.GOOS == "darwin" {
+		return runServiceTool("launchctl", "load", "-w", path)
+	}
+	if err := runServiceTool("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	return runServiceTool("systemctl", "--user", "enable", "--now", spec.Name+".service")
+}
+
+// uninstallService stops and removes the service
+func uninstallService(spec serviceSpec) error {
+	switch runtime.GOOS {
+	case "windows":
+		return runServiceTool("schtasks", "/Delete", "/F", "/TN", spec.Name)
+	case "linux", "darwin":
+	default:
+		return fmt.Errorf("background services are not supported on %s", runtime.GOOS)
+	}
+
+	path, err := servicePath(spec)
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS == "darwin" {
+		_ = runServiceTool("launchctl", "unload", "-w", path)
+	} else {
+		_ = runServiceTool("systemctl", "--user", "disable", "--now", spec.Name+".service")
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	fmt.Printf("  Removed %s\n", path)
+	return nil
+}
+
+// serviceSetup changes to the project directory and builds the service spec
+func serviceSetup(ctx *orpheus.Context) (serviceSpec, error) {
+	workDir := ctx.GetGlobalFlagString("directory")
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return serviceSpec{}, orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	spec, err := newServiceSpec(ctx)
+	if err != nil {
+		return spec, orpheus.ExecutionError("service", err.Error())
+	}
+	return spec, nil
+}
+
+// watchInstallServiceCommand registers `aura watch` as a background service
+func watchInstallServiceCommand(ctx *orpheus.Context) error {
+	spec, err := serviceSetup(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Fail now rather than in a service that restarts forever
+	if err := loadConfig(ctx.GetGlobalFlagString("config")); err != nil {
+		return err
+	}
+
+	if ctx.GetFlagBool("print") {
+		definition, err := serviceDefinition(spec)
+		if err != nil {
+			return orpheus.ValidationError("service", err.Error())
+		}
+		fmt.Print(definition)
+		return nil
+	}
+
+	fmt.Printf("Installing service: %s\n", spec.Name)
+	if err := installService(spec); err != nil {
+		return orpheus.ExecutionError("service", err.Error())
+	}
+	fmt.Printf("%s Service %s installed and started\n", checkMark(), spec.Name)
+	return nil
+}
+
+// watchUninstallServiceCommand removes a service created by install-service
+func watchUninstallServiceCommand(ctx *orpheus.Context) error {
+	spec, err := serviceSetup(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removing service: %s\n", spec.Name)
+	if err := uninstallService(spec); err != nil {
+		return orpheus.ExecutionError("service", err.Error())
+	}
+	fmt.Printf("%s Service %s removed\n", checkMark(), spec.Name)
+	return nil
+}
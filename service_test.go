@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// ===== SERVICE.GO UNIT TESTS =====
+
+func TestServiceName(t *testing.T) {
+	tests := map[string]string{
+		"/home/dev/api":         "aura-watch-api",
+		"/home/dev/My Project!": "aura-watch-My-Project",
+		"/srv/build/web.app_v2": "aura-watch-web.app_v2",
+	}
+	for dir, expected := range tests {
+		if got := serviceName(dir); got != expected {
+			t.Errorf("serviceName(%q) = %q, want %q", dir, got, expected)
+		}
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	spec := serviceSpec{
+		Name: "aura-watch-api",
+		Exec: "/usr/local/bin/aura",
+		Args: []string{"-D", "/home/dev/my api", "watch", "-t", "build,test"},
+		Dir:  "/home/dev/my api",
+	}
+
+	unit, err := systemdUnit(spec)
+	if err != nil {
+		t.Fatalf("systemdUnit() unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"WorkingDirectory=/home/dev/my api\n",
+		`ExecStart=/usr/local/bin/aura -D "/home/dev/my api" watch -t build,test`,
+		"Restart=on-failure",
+		"WantedBy=default.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("systemdUnit() missing %q:\n%s", want, unit)
+		}
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":      "plain",
+		"":           `""`,
+		"a b":        `"a b"`,
+		`50%$HOME"x`: `"50%%$$HOME\"x"`,
+	}
+	for input, expected := range tests {
+		if got := systemdQuote(input); got != expected {
+			t.Errorf("systemdQuote(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestSystemdPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+		wantErr  bool
+	}{
+		{"/home/dev/api", "/home/dev/api", false},
+		{"/home/dev/my api", "/home/dev/my api", false},
+		{`/home/dev/"quoted" $api`, `/home/dev/"quoted" $api`, false},
+		{"/home/dev/100%", "/home/dev/100%%", false},
+		{"/home/dev/a\nb", "", true},
+		{"/home/dev/tab\t", "", true},
+		{"/home/dev/api ", "", true},
+		{`/home/dev/api\`, "", true},
+	}
+	for _, tt := range tests {
+		got, err := systemdPath(tt.path)
+		if (err != nil) != tt.wantErr || got != tt.expected {
+			t.Errorf("systemdPath(%q) = %q, %v; want %q, error %v", tt.path, got, err, tt.expected, tt.wantErr)
+		}
+	}
+
+	if _, err := systemdUnit(serviceSpec{Name: "aura-watch-x", Exec: "/usr/local/bin/aura", Dir: "/home/dev/a\nb"}); err == nil {
+		t.Errorf("systemdUnit() accepted a directory with a line break")
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	spec := serviceSpec{
+		Name: "aura-watch-api",
+		Exec: "/usr/local/bin/aura",
+		Args: []string{"watch", "-t", "a&b"},
+		Dir:  "/Users/dev/api",
+	}
+
+	plist := launchdPlist(spec)
+	for _, want := range []string{
+		"<string>com.agilira.aura-watch-api</string>",
+		"<string>/usr/local/bin/aura</string>\n    <string>watch</string>",
+		"<string>a&amp;b</string>",
+		"<key>KeepAlive</key>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("launchdPlist() missing %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestWindowsCommandLine(t *testing.T) {
+	got := windowsCommandLine(`C:\Program Files\aura.exe`, []string{"-D", `C:\My Projects\api`, "watch"})
+	expected := `"C:\Program Files\aura.exe" -D "C:\My Projects\api" watch`
+	if got != expected {
+		t.Errorf("windowsCommandLine() = %q, want %q", got, expected)
+	}
+}
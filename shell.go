@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// resolveShell returns the shell: setting commands run through: shell,
+// the target's own, else the config's, else the user default; "" leaves
+// the platform's, cmd on Windows and bash elsewhere
+func resolveShell(shell Var) string {
+	if shell != "" {
+		return string(shell)
+	}
+	if cfg.Shell != "" {
+		return string(cfg.Shell)
+	}
+	return userDefaults.Shell
+}
+
+// shellArgs turns a shell: setting into the program and arguments an
+// inline command follows. The setting is a program (bash, pwsh, a path)
+// optionally with arguments (`bash -eo pipefail`); the flag taking the
+// command is added unless the setting ends with it. A setting naming an
+// existing program as a whole is a path with spaces, not arguments
+func shellArgs(shell string) []string {
+	switch {
+	case shell == "" && runtime.GOOS == "windows":
+		return []string{"cmd", "/C"}
+	case shell == "":
+		return []string{"/bin/bash", "-c"}
+	}
+	if _, err := exec.LookPath(shell); err == nil {
+		return []string{shell, shellCommandFlag(shell)}
+	}
+
+	args := strings.Fields(shell)
+	flag := shellCommandFlag(args[0])
+	if !strings.EqualFold(args[len(args)-1], flag) {
+		args = append(args, flag)
+	}
+	return args
+}
+
+// shellCommandFlag returns the flag a shell expects before an inline command
+func shellCommandFlag(shell string) string {
+	switch strings.ToLower(strings.TrimSuffix(filepath.Base(shell), filepath.Ext(shell))) {
+	case "cmd":
+		return "/C"
+	case "pwsh", "powershell":
+		return "-Command"
+	}
+	return "-c"
+}
+
+// validateShells checks that the shells of the config and its targets are
+// installed, so a missing one fails at load rather than on the first
+// command. A shell needed on one platform only takes a platform map, and
+// targets whose platforms: leave them out here are not checked
+func validateShells() error {
+	check := func(shell Var, owner string) error {
+		if shell == "" {
+			return nil
+		}
+		if strings.TrimSpace(string(shell)) == "" {
+			return fmt.Errorf("empty shell for %s", owner)
+		}
+		program := shellArgs(string(shell))[0]
+		if _, err := exec.LookPath(program); err != nil {
+			return fmt.Errorf("shell '%s' for %s not found: %v", program, owner, err)
+		}
+		return nil
+	}
+
+	if err := check(cfg.Shell, "the config"); err != nil {
+		return err
+	}
+	for _, stage := range []struct {
+		name   string
+		target Target
+	}{{"prologue", cfg.Prologue}, {"epilogue", cfg.Epilogue}} {
+		if err := check(stage.target.Shell, stage.name); err != nil {
+			return err
+		}
+	}
+	for _, member := range cfg.memberStages {
+		for _, stage := range []struct {
+			name   string
+			target Target
+		}{{"prologue", member.prologue}, {"epilogue", member.epilogue}} {
+			if err := check(stage.target.Shell, member.namespace+":"+stage.name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range sortedKeys(cfg.Targets) {
+		target := cfg.Targets[name]
+		if skippedOnPlatform(&target) {
+			continue
+		}
+		if err := check(target.Shell, fmt.Sprintf("target '%s'", name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ===== SHELL.GO UNIT TESTS =====
+
+func TestShellArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shells")
+	}
+
+	tests := []struct {
+		shell    string
+		expected []string
+	}{
+		{"", []string{"/bin/bash", "-c"}},
+		{"sh", []string{"sh", "-c"}},
+		{"bash -eo pipefail", []string{"bash", "-eo", "pipefail", "-c"}},
+		{"bash -e -c", []string{"bash", "-e", "-c"}},
+		{"pwsh -NoProfile", []string{"pwsh", "-NoProfile", "-Command"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			if got := shellArgs(tt.shell); !slices.Equal(got, tt.expected) {
+				t.Errorf("shellArgs(%q) = %q, expected %q", tt.shell, got, tt.expected)
+			}
+		})
+	}
+
+	// A program path with spaces is not split into arguments
+	sh := filepath.Join(t.TempDir(), "my tools", "sh")
+	if err := os.MkdirAll(filepath.Dir(sh), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/bin/sh", sh); err != nil {
+		t.Fatal(err)
+	}
+	if got := shellArgs(sh); !slices.Equal(got, []string{sh, "-c"}) {
+		t.Errorf("shellArgs(%q) = %q, expected the path as the program", sh, got)
+	}
+}
+
+func TestResolveShell(t *testing.T) {
+	original, originalDefaults := cfg, userDefaults
+	defer func() { cfg, userDefaults = original, originalDefaults }()
+
+	userDefaults = UserDefaults{Shell: "zsh"}
+	cfg = Config{}
+	if got := resolveShell(""); got != "zsh" {
+		t.Errorf("resolveShell() = %q, expected the user default", got)
+	}
+	cfg.Shell = "sh"
+	if got := resolveShell(""); got != "sh" {
+		t.Errorf("resolveShell() = %q, expected the config's shell over the user default", got)
+	}
+	if got := resolveShell("bash"); got != "bash" {
+		t.Errorf("resolveShell() = %q, expected the target's shell", got)
+	}
+}
+
+func TestValidateShells(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shells")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"none", Config{Targets: map[string]Target{"build": {}}}, ""},
+		{"installed", Config{Shell: "sh", Targets: map[string]Target{"build": {Shell: "sh -e"}}}, ""},
+		{"missing for the config", Config{Shell: "aura-no-such-shell"}, "shell 'aura-no-such-shell' for the config not found"},
+		{"missing for a target", Config{Targets: map[string]Target{"build": {Shell: "aura-no-such-shell -x"}}}, "for target 'build' not found"},
+		{"missing on another platform", Config{Targets: map[string]Target{"other": {Shell: "cmd", Platforms: stringList{"windows"}}}}, ""},
+		{"missing on this platform", Config{Targets: map[string]Target{"build": {Shell: "aura-no-such-shell", Platforms: stringList{runtime.GOOS}}}}, "for target 'build' not found"},
+		{"missing for the prologue", Config{Prologue: Target{Shell: "aura-no-such-shell"}}, "for prologue not found"},
+		{"blank", Config{Shell: " "}, "empty shell"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = tt.config
+			err := validateShells()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("validateShells() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validateShells() error = %v, expected %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTargetShell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shells")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Shell: "sh"}
+
+	// The target's shell runs its commands; other targets keep the config's
+	target := Target{Shell: "bash -o pipefail", Run: []string{"false | true"}}
+	if err := ExecuteAllWithContext("strict", &target, false, false); err == nil {
+		t.Error("ExecuteAllWithContext() error = nil, expected pipefail to fail the pipeline")
+	}
+	target.Shell = ""
+	if err := ExecuteAllWithContext("plain", &target, false, false); err != nil {
+		t.Errorf("ExecuteAllWithContext() error = %v, expected sh to ignore the failing stage", err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// simulatedFailures are the targets --simulate-failure makes fail: their
+// first command is not run but fails, so onerror, continue_on_error,
+// retries and notifications can be tried without breaking real commands
+var simulatedFailures map[string]bool
+
+// errSimulatedFailure is the error a simulated failure reports
+var errSimulatedFailure = errors.New("simulated failure (--simulate-failure)")
+
+// parseSimulatedFailures reads the --simulate-failure value, a comma
+// separated list of target=<name>, checking every target has commands
+func parseSimulatedFailures(spec string) (map[string]bool, error) {
+	failures := map[string]bool{}
+	for _, entry := range strings.Split(spec, ",") {
+		kind, name, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || kind != "target" || name == "" {
+			return nil, fmt.Errorf("invalid --simulate-failure '%s' (use target=<name>)", strings.TrimSpace(entry))
+		}
+		target, exists := cfg.Targets[name]
+		if !exists {
+			return nil, fmt.Errorf("cannot simulate a failure of '%s': no such target", name)
+		}
+		if len(target.Run) == 0 {
+			return nil, fmt.Errorf("cannot simulate a failure of '%s': it has no commands", name)
+		}
+		failures[name] = true
+	}
+	return failures, nil
+}
+
+// simulateFailure stands in for the first command of a target chosen with
+// --simulate-failure and returns the error it fails with
+func simulateFailure(name, command string) error {
+	logWarn("simulating a failure of target '%s' instead of running: %s", name, command)
+	return errSimulatedFailure
+}
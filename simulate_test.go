@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== SIMULATE.GO UNIT TESTS =====
+
+func TestParseSimulatedFailures(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{
+		"test": {Run: []string{"go test ./..."}},
+		"lint": {Run: []string{"golangci-lint run"}},
+		"all":  {Deps: []string{"test"}},
+	}}
+
+	tests := []struct {
+		name     string
+		spec     string
+		expected string
+		wantErr  bool
+	}{
+		{"one target", "target=test", "test", false},
+		{"several targets", "target=test, target=lint", "lint,test", false},
+		{"missing prefix", "test", "", true},
+		{"unknown kind", "command=test", "", true},
+		{"unknown target", "target=nope", "", true},
+		{"no commands", "target=all", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures, err := parseSimulatedFailures(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSimulatedFailures() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.Join(sortedKeys(failures), ","); got != tt.expected {
+				t.Errorf("parseSimulatedFailures() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSimulatedFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original; simulatedFailures = nil }()
+	chdirTemp(t)
+
+	// The first command is not run; continue_on_error still runs the rest
+	target := Target{Run: []string{"touch first", "touch second"}, ContinueOnError: true}
+	cfg = Config{Targets: map[string]Target{"test": target}}
+	simulatedFailures = map[string]bool{"test": true}
+	stderr := captureStderr(t)
+	err := ExecuteAllWithContext("test", &target, false, false)
+	output := stderr()
+	if err != nil {
+		t.Fatalf("ExecuteAllWithContext() error = %v, expected continue_on_error to go on", err)
+	}
+	if !strings.Contains(output, errSimulatedFailure.Error()) {
+		t.Errorf("stderr = %q, expected the simulated failure", output)
+	}
+	if _, err := os.Stat("first"); err == nil {
+		t.Error("the simulated command should not run")
+	}
+	if _, err := os.Stat("second"); err != nil {
+		t.Error("the command after the simulated failure should run")
+	}
+
+	target.ContinueOnError = false
+	target.Onerror = "tests failed"
+	if err := ExecuteAllWithContext("test", &target, false, false); err == nil || !strings.Contains(err.Error(), "tests failed") {
+		t.Errorf("ExecuteAllWithContext() error = %v, expected the onerror message", err)
+	}
+}
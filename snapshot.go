@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// envSnapshot records what a build depends on outside the repository, so
+// two machines can be compared with `aura env diff`
+type envSnapshot struct {
+	TakenAt    time.Time         `json:"taken_at"`
+	Hostname   string            `json:"hostname"`
+	OS         string            `json:"os"`
+	Arch       string            `json:"arch"`
+	CPUs       int               `json:"cpus"`
+	GoRuntime  string            `json:"go_runtime"`
+	ConfigFile string            `json:"config_file"`
+	ConfigHash string            `json:"config_hash,omitempty"`
+	Tools      map[string]string `json:"tools"`
+	Env        map[string]string `json:"env"`
+}
+
+// probedTools are the toolchains whose versions a snapshot records when
+// they are on PATH, with the arguments that print the version
+var probedTools = map[string][]string{
+	"bash":    {"--version"},
+	"cargo":   {"--version"},
+	"clang":   {"--version"},
+	"cmake":   {"--version"},
+	"docker":  {"--version"},
+	"gcc":     {"--version"},
+	"git":     {"--version"},
+	"go":      {"version"},
+	"java":    {"-version"},
+	"make":    {"--version"},
+	"node":    {"--version"},
+	"npm":     {"--version"},
+	"python3": {"--version"},
+	"rustc":   {"--version"},
+}
+
+// secretEnvRegex matches variable names whose values never go into a
+// snapshot
+var secretEnvRegex = regexp.MustCompile(`(?i)(secret|token|passw|pwd|key|credential|auth|private|cookie|session)`)
+
+// redactedValue replaces the value of secret variables
+const redactedValue = "<redacted>"
+
+// redactEnv copies an environment, hiding the values of secret variables
+func redactEnv(env map[string]string) map[string]string {
+	redacted := make(map[string]string, len(env))
+	for k, v := range env {
+		// PWD is the working directory, not a password
+		if k != "PWD" && k != "OLDPWD" && secretEnvRegex.MatchString(k) {
+			v = redactedValue
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+// toolVersion runs a tool's version command and returns the first line it
+// prints; java writes it to stderr
+func toolVersion(name string, args []string) (string, bool) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// #nosec G204 - Only the fixed probedTools list is executed
+	out, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	if err != nil {
+		return "", false
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(line), true
+}
+
+// takeSnapshot captures the current machine. The config is optional: its
+// hash and declared tools are recorded when it exists.
+func takeSnapshot(configFile string) envSnapshot {
+	hostname, _ := os.Hostname()
+	snap := envSnapshot{
+		TakenAt:    time.Now().UTC(),
+		Hostname:   hostname,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		CPUs:       runtime.NumCPU(),
+		GoRuntime:  runtime.Version(),
+		ConfigFile: configFile,
+		Tools:      map[string]string{},
+		Env:        map[string]string{},
+	}
+
+	for _, name := range sortedKeys(probedTools) {
+		if version, ok := toolVersion(name, probedTools[name]); ok {
+			snap.Tools[name] = version
+		}
+	}
+
+	// #nosec G304 - Config file path is provided by the user
+	if data, err := os.ReadFile(configFile); err == nil {
+		sum := sha256.Sum256(data)
+		snap.ConfigHash = hex.EncodeToString(sum[:])
+		if loadConfig(configFile) == nil {
+			for name, tool := range cfg.Tools {
+				snap.Tools[name] = tool.Version + " (declared)"
+			}
+		}
+	}
+
+	for _, kv := range os.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			snap.Env[k] = v
+		}
+	}
+	snap.Env = redactEnv(snap.Env)
+	return snap
+}
+
+// flatten turns a snapshot into sorted "key: value" entries for diffing
+func (s envSnapshot) flatten() map[string]string {
+	entries := map[string]string{
+		"hostname":    s.Hostname,
+		"os":          s.OS,
+		"arch":        s.Arch,
+		"cpus":        fmt.Sprint(s.CPUs),
+		"go_runtime":  s.GoRuntime,
+		"config_file": s.ConfigFile,
+		"config_hash": s.ConfigHash,
+	}
+	for k, v := range s.Tools {
+		entries["tool."+k] = v
+	}
+	for k, v := range s.Env {
+		entries["env."+k] = v
+	}
+	return entries
+}
+
+// diffSnapshots lists what differs between two snapshots: "~" changed,
+// "-" only in a, "+" only in b
+func diffSnapshots(a, b envSnapshot) []string {
+	left, right := a.flatten(), b.flatten()
+	keys := map[string]bool{}
+	for k := range left {
+		keys[k] = true
+	}
+	for k := range right {
+		keys[k] = true
+	}
+
+	var lines []string
+	for _, k := range sortedKeys(keys) {
+		l, inLeft := left[k]
+		r, inRight := right[k]
+		switch {
+		case !inRight:
+			lines = append(lines, fmt.Sprintf("- %s: %s", k, l))
+		case !inLeft:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", k, r))
+		case l != r:
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", k, l, r))
+		}
+	}
+	return lines
+}
+
+// readSnapshot loads a snapshot file
+func readSnapshot(path string) (envSnapshot, error) {
+	var snap envSnapshot
+	// #nosec G304 - Snapshot path is provided by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snap, err
+	}
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return snap, fmt.Errorf("%s is not an aura env snapshot: %v", path, err)
+	}
+	return snap, nil
+}
+
+// envCommand shows the available env subcommands
+func envCommand(ctx *orpheus.Context) error {
+	fmt.Println("Capture and compare build environments")
+	fmt.Println("Use 'aura env <subcommand>':")
+	fmt.Println("  snapshot  - Record tool versions, OS, config hash and (redacted) env vars")
+	fmt.Println("  diff      - Compare two snapshots: aura env diff <a.json> <b.json>")
+	return nil
+}
+
+// envSnapshotCommand writes a snapshot of the current machine
+func envSnapshotCommand(ctx *orpheus.Context) error {
+	workDir := ctx.GetGlobalFlagString("directory")
+	output := ctx.GetFlagString("output")
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+
+	snap := takeSnapshot(ctx.GetGlobalFlagString("config"))
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return orpheus.ExecutionError("env", err.Error())
+	}
+	if err := os.WriteFile(output, append(data, '\n'), 0600); err != nil {
+		return orpheus.ExecutionError("env", err.Error())
+	}
+	fmt.Printf("%s Wrote environment snapshot to %s (%d tools, %d variables)\n", checkMark(), output, len(snap.Tools), len(snap.Env))
+	return nil
+}
+
+// envDiffCommand compares two snapshots
+func envDiffCommand(ctx *orpheus.Context) error {
+	args := positionalArgs(ctx)
+	if len(args) != 2 {
+		return orpheus.ValidationError("env diff", "usage: aura env diff <snapshot-a> <snapshot-b>")
+	}
+
+	var snaps [2]envSnapshot
+	for i, path := range args {
+		snap, err := readSnapshot(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return orpheus.NotFoundError("env diff", fmt.Sprintf("snapshot '%s' not found", path))
+		}
+		if err != nil {
+			return orpheus.ValidationError("env diff", err.Error())
+		}
+		snaps[i] = snap
+	}
+
+	lines := diffSnapshots(snaps[0], snaps[1])
+	if len(lines) == 0 {
+		fmt.Println("No differences")
+		return nil
+	}
+
+	fmt.Printf("--- %s (%s, %s)\n", args[0], snaps[0].Hostname, snaps[0].TakenAt.Format(time.RFC3339))
+	fmt.Printf("+++ %s (%s, %s)\n", args[1], snaps[1].Hostname, snaps[1].TakenAt.Format(time.RFC3339))
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+	fmt.Printf("\n%d differences\n", len(lines))
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// ===== SNAPSHOT.GO UNIT TESTS =====
+
+func TestRedactEnv(t *testing.T) {
+	env := map[string]string{
+		"PATH":                  "/usr/bin",
+		"PWD":                   "/src",
+		"GITHUB_TOKEN":          "ghp_x",
+		"AWS_SECRET_ACCESS_KEY": "abc",
+		"DB_PASSWORD":           "hunter2",
+		"SSH_AUTH_SOCK":         "/tmp/agent",
+		"GOFLAGS":               "-mod=mod",
+	}
+	redacted := redactEnv(env)
+
+	for _, k := range []string{"PATH", "PWD", "GOFLAGS"} {
+		if redacted[k] != env[k] {
+			t.Errorf("%s should be kept, got %q", k, redacted[k])
+		}
+	}
+	for _, k := range []string{"GITHUB_TOKEN", "AWS_SECRET_ACCESS_KEY", "DB_PASSWORD", "SSH_AUTH_SOCK"} {
+		if redacted[k] != redactedValue {
+			t.Errorf("%s should be redacted, got %q", k, redacted[k])
+		}
+	}
+	if env["GITHUB_TOKEN"] != "ghp_x" {
+		t.Error("redactEnv() should not modify its input")
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	a := envSnapshot{OS: "linux", Arch: "amd64", CPUs: 8, ConfigHash: "aaa",
+		Tools: map[string]string{"go": "go1.22", "node": "v20"},
+		Env:   map[string]string{"PATH": "/usr/bin", "CGO_ENABLED": "1"}}
+	b := envSnapshot{OS: "linux", Arch: "arm64", CPUs: 8, ConfigHash: "aaa",
+		Tools: map[string]string{"go": "go1.23"},
+		Env:   map[string]string{"PATH": "/usr/bin", "GOFLAGS": "-race"}}
+
+	expected := []string{
+		"~ arch: amd64 -> arm64",
+		"- env.CGO_ENABLED: 1",
+		"+ env.GOFLAGS: -race",
+		"~ tool.go: go1.22 -> go1.23",
+		"- tool.node: v20",
+	}
+	if got := diffSnapshots(a, b); !slices.Equal(got, expected) {
+		t.Errorf("diffSnapshots() = %q, expected %q", got, expected)
+	}
+	if got := diffSnapshots(a, a); len(got) != 0 {
+		t.Errorf("identical snapshots should not differ, got %q", got)
+	}
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "aura.yaml")
+	if err := os.WriteFile(configFile, []byte("tools:\n  golangci-lint:\n    version: v1.59.0\n    go: github.com/golangci/golangci-lint/cmd/golangci-lint\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AURA_TEST_API_KEY", "secret")
+
+	snap := takeSnapshot(configFile)
+	if len(snap.ConfigHash) != 64 {
+		t.Errorf("config hash = %q", snap.ConfigHash)
+	}
+	if snap.Tools["golangci-lint"] != "v1.59.0 (declared)" {
+		t.Errorf("declared tool = %q", snap.Tools["golangci-lint"])
+	}
+	if snap.Env["AURA_TEST_API_KEY"] != redactedValue {
+		t.Errorf("secret variable recorded as %q", snap.Env["AURA_TEST_API_KEY"])
+	}
+
+	missing := takeSnapshot(filepath.Join(dir, "missing.yaml"))
+	if missing.ConfigHash != "" || missing.OS == "" {
+		t.Errorf("snapshot without config = %+v", missing)
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snap.json")
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSnapshot(path); err == nil || !strings.Contains(err.Error(), "not an aura env snapshot") {
+		t.Errorf("readSnapshot() error = %v", err)
+	}
+}
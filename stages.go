@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Modes of a profile's prologue or epilogue
+const (
+	stageExtend  = "extend"
+	stageReplace = "replace"
+)
+
+// validateProfileStages checks the mode of every profile prologue and
+// epilogue, those of workspace members included
+func validateProfileStages() error {
+	if err := checkProfileStages(cfg.Profiles); err != nil {
+		return err
+	}
+	for _, member := range cfg.memberStages {
+		if err := checkProfileStages(member.profiles); err != nil {
+			return fmt.Errorf("workspace member %s: %v", member.namespace, err)
+		}
+	}
+	return nil
+}
+
+// checkProfileStages checks the mode of the prologue and epilogue of
+// profiles
+func checkProfileStages(profiles map[string]Profile) error {
+	for _, name := range sortedKeys(profiles) {
+		profile := profiles[name]
+		for _, stage := range []struct {
+			key    string
+			change *ProfileStage
+		}{{"prologue", profile.Prologue}, {"epilogue", profile.Epilogue}} {
+			if stage.change == nil {
+				continue
+			}
+			if mode := stage.change.Mode; mode != "" && mode != stageExtend && mode != stageReplace {
+				return fmt.Errorf("profile '%s': %s mode '%s' must be %s or %s", name, stage.key, mode, stageExtend, stageReplace)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeStage applies a profile's change to the base prologue or epilogue.
+// replace drops the base. extend adds the profile's deps after the base
+// ones and runs its commands after the base commands when baseFirst, before
+// them otherwise; profile vars win over base vars
+func mergeStage(base Target, change *ProfileStage, baseFirst bool) Target {
+	if change == nil {
+		return base
+	}
+	if change.Mode == stageReplace {
+		return change.Target
+	}
+
+	merged := base
+	merged.Deps = slices.Concat(base.Deps, change.Deps)
+	if baseFirst {
+		merged.Run = slices.Concat(base.Run, change.Run)
+	} else {
+		merged.Run = slices.Concat(change.Run, base.Run)
+	}
+	if len(change.Vars) > 0 {
+		merged.Vars = maps.Clone(base.Vars)
+		if merged.Vars == nil {
+			merged.Vars = make(map[string]Var, len(change.Vars))
+		}
+		maps.Copy(merged.Vars, change.Vars)
+	}
+	if change.Onerror != "" {
+		merged.Onerror = change.Onerror
+	}
+	merged.ContinueOnError = base.ContinueOnError || change.ContinueOnError
+	return merged
+}
+
+// activePrologue is the prologue with the active profile applied; profile
+// setup runs after the base setup
+func (c *Config) activePrologue() Target {
+	return mergeStage(c.Prologue, c.Profiles[activeProfile].Prologue, true)
+}
+
+// activeEpilogue is the epilogue with the active profile applied; profile
+// teardown runs before the base teardown, so the two nest like the prologue
+func (c *Config) activeEpilogue() Target {
+	return mergeStage(c.Epilogue, c.Profiles[activeProfile].Epilogue, false)
+}
+
+// memberStage is the prologue and epilogue of a workspace member, with the
+// changes the member's own profiles make to them
+type memberStage struct {
+	namespace string
+	dir       string
+	prologue  Target
+	epilogue  Target
+	profiles  map[string]Profile
+}
+
+// active applies the member's active profile to its prologue or epilogue,
+// which runs in the member's directory and sees its vars
+func (m memberStage) active(epilogue bool) Target {
+	var merged Target
+	if profile := m.profiles[activeProfile]; epilogue {
+		merged = mergeStage(m.epilogue, profile.Epilogue, false)
+	} else {
+		merged = mergeStage(m.prologue, profile.Prologue, true)
+	}
+	merged.namespace, merged.dir = m.namespace, m.dir
+	return merged
+}
+
+// stage is a prologue or epilogue as a build runs it, with the name its
+// commands are reported and expanded under
+type stage struct {
+	name   string
+	target Target
+}
+
+// activePrologues lists the prologues of a build in the order they run:
+// the workspace's, then those of its members in the order they were
+// loaded, so members set up inside the workspace setup
+func (c *Config) activePrologues() []stage {
+	stages := []stage{{"prologue", c.activePrologue()}}
+	for _, member := range c.memberStages {
+		if target := member.active(false); len(target.Run) > 0 || len(target.Deps) > 0 {
+			stages = append(stages, stage{member.namespace + ":prologue", target})
+		}
+	}
+	return stages
+}
+
+// activeEpilogues lists the epilogues of a build in the order they run:
+// those of the members in reverse, then the workspace's, so teardown
+// nests like the prologues
+func (c *Config) activeEpilogues() []stage {
+	var stages []stage
+	for _, member := range slices.Backward(c.memberStages) {
+		if target := member.active(true); len(target.Run) > 0 || len(target.Deps) > 0 {
+			stages = append(stages, stage{member.namespace + ":epilogue", target})
+		}
+	}
+	return append(stages, stage{"epilogue", c.activeEpilogue()})
+}
+
+// stageNamed returns the active prologue or epilogue a build runs under
+// name, the workspace's own or a member's such as lib:prologue
+func (c *Config) stageNamed(name string) (Target, bool) {
+	switch name {
+	case "prologue":
+		return c.activePrologue(), true
+	case "epilogue":
+		return c.activeEpilogue(), true
+	}
+	for _, member := range c.memberStages {
+		switch name {
+		case member.namespace + ":prologue":
+			return member.active(false), true
+		case member.namespace + ":epilogue":
+			return member.active(true), true
+		}
+	}
+	return Target{}, false
+}
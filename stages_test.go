@@ -0,0 +1,199 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ===== STAGES.GO UNIT TESTS =====
+
+func TestMergeStage(t *testing.T) {
+	base := Target{
+		Deps: []string{"setup-db"},
+		Run:  []string{"echo base"},
+		Vars: map[string]Var{"LEVEL": "info", "KEEP": "yes"},
+	}
+
+	tests := []struct {
+		name      string
+		change    *ProfileStage
+		baseFirst bool
+		expected  Target
+	}{
+		{"no change", nil, true, base},
+		{
+			name:      "extend prologue",
+			change:    &ProfileStage{Target: Target{Deps: []string{"login"}, Run: []string{"echo profile"}, Vars: map[string]Var{"LEVEL": "debug"}}},
+			baseFirst: true,
+			expected: Target{
+				Deps: []string{"setup-db", "login"},
+				Run:  []string{"echo base", "echo profile"},
+				Vars: map[string]Var{"LEVEL": "debug", "KEEP": "yes"},
+			},
+		},
+		{
+			name:      "extend epilogue",
+			change:    &ProfileStage{Target: Target{Run: []string{"echo profile"}, ContinueOnError: true}, Mode: stageExtend},
+			baseFirst: false,
+			expected: Target{
+				Deps:            []string{"setup-db"},
+				Run:             []string{"echo profile", "echo base"},
+				Vars:            base.Vars,
+				ContinueOnError: true,
+			},
+		},
+		{
+			name:      "replace",
+			change:    &ProfileStage{Target: Target{Run: []string{"echo only"}}, Mode: stageReplace},
+			baseFirst: true,
+			expected:  Target{Run: []string{"echo only"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeStage(base, tt.change, tt.baseFirst)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("mergeStage() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+
+	if base.Vars["LEVEL"] != "info" || len(base.Run) != 1 {
+		t.Errorf("mergeStage() modified the base: %+v", base)
+	}
+}
+
+func TestActiveStages(t *testing.T) {
+	originalProfile := activeProfile
+	defer func() { activeProfile = originalProfile }()
+
+	c := Config{
+		Prologue: Target{Run: []string{"echo setup"}},
+		Epilogue: Target{Run: []string{"echo teardown"}},
+		Profiles: map[string]Profile{
+			"ci": {
+				Prologue: &ProfileStage{Target: Target{Run: []string{"echo ci setup"}}},
+				Epilogue: &ProfileStage{Target: Target{Run: []string{"echo ci teardown"}}},
+			},
+		},
+	}
+
+	activeProfile = ""
+	if run := c.activePrologue().Run; !reflect.DeepEqual(run, []string{"echo setup"}) {
+		t.Errorf("without a profile prologue = %v", run)
+	}
+
+	activeProfile = "ci"
+	if run := c.activePrologue().Run; !reflect.DeepEqual(run, []string{"echo setup", "echo ci setup"}) {
+		t.Errorf("ci prologue = %v", run)
+	}
+	if run := c.activeEpilogue().Run; !reflect.DeepEqual(run, []string{"echo ci teardown", "echo teardown"}) {
+		t.Errorf("ci epilogue = %v", run)
+	}
+}
+
+func TestActiveMemberStages(t *testing.T) {
+	originalProfile := activeProfile
+	defer func() { activeProfile = originalProfile }()
+
+	c := Config{
+		Prologue: Target{Run: []string{"echo setup"}},
+		Epilogue: Target{Run: []string{"echo teardown"}},
+		memberStages: []memberStage{
+			{
+				namespace: "lib",
+				dir:       "lib",
+				prologue:  Target{Run: []string{"echo lib setup"}},
+				epilogue:  Target{Run: []string{"echo lib teardown"}},
+				profiles: map[string]Profile{
+					"ci": {Prologue: &ProfileStage{Target: Target{Run: []string{"echo lib ci setup"}}, Mode: stageReplace}},
+				},
+			},
+			{
+				namespace: "app",
+				dir:       "app",
+				epilogue:  Target{Run: []string{"echo app teardown"}},
+			},
+		},
+	}
+	names := func(stages []stage) []string {
+		var names []string
+		for _, stage := range stages {
+			names = append(names, stage.name)
+		}
+		return names
+	}
+
+	activeProfile = ""
+	if got := names(c.activePrologues()); !reflect.DeepEqual(got, []string{"prologue", "lib:prologue"}) {
+		t.Errorf("prologues = %v, expected the workspace's then lib's", got)
+	}
+	if got := names(c.activeEpilogues()); !reflect.DeepEqual(got, []string{"app:epilogue", "lib:epilogue", "epilogue"}) {
+		t.Errorf("epilogues = %v, expected the members' in reverse then the workspace's", got)
+	}
+	lib, ok := c.stageNamed("lib:prologue")
+	if !ok || lib.dir != "lib" || lib.namespace != "lib" || !reflect.DeepEqual(lib.Run, []string{"echo lib setup"}) {
+		t.Errorf("lib:prologue = %+v, %v", lib, ok)
+	}
+	if _, ok := c.stageNamed("app:prologue"); !ok {
+		t.Error("app:prologue should be named even when empty")
+	}
+	if _, ok := c.stageNamed("lib:build"); ok {
+		t.Error("lib:build is not a stage")
+	}
+
+	// The member's own ci profile replaces its prologue
+	activeProfile = "ci"
+	lib, _ = c.stageNamed("lib:prologue")
+	if !reflect.DeepEqual(lib.Run, []string{"echo lib ci setup"}) || lib.dir != "lib" || lib.namespace != "lib" {
+		t.Errorf("ci lib:prologue = %+v", lib)
+	}
+}
+
+func TestValidateProfileStages(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name   string
+		mode   string
+		errMsg string
+	}{
+		{"default mode", "", ""},
+		{"extend", stageExtend, ""},
+		{"replace", stageReplace, ""},
+		{"unknown", "append", "epilogue mode 'append' must be extend or replace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Profiles: map[string]Profile{"ci": {Epilogue: &ProfileStage{Mode: tt.mode}}}}
+			err := validateProfileStages()
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("validateProfileStages() unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("validateProfileStages() error = %v, expected %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateMemberProfileStages(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{memberStages: []memberStage{{
+		namespace: "lib",
+		profiles:  map[string]Profile{"ci": {Prologue: &ProfileStage{Mode: "append"}}},
+	}}}
+	err := validateProfileStages()
+	if expected := "workspace member lib: profile 'ci': prologue mode 'append' must be extend or replace"; err == nil || err.Error() != expected {
+		t.Errorf("validateProfileStages() error = %v, expected %q", err, expected)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Values of --output-mode, how the lines targets print are shown
+const (
+	outputPrefixed    = "prefixed"    // as printed, each tagged with its target
+	outputGrouped     = "grouped"     // all at once when the target finishes
+	outputInterleaved = "interleaved" // as printed, untagged
+)
+
+// outputMode is the --output-mode of the build
+var outputMode = outputInterleaved
+
+// prefixColors colors the target tags of prefixed output
+var prefixColors bool
+
+// groupedOutput holds what running targets printed in grouped mode;
+// guarded by logMu
+var groupedOutput = map[string]*strings.Builder{}
+
+// resolveOutputMode validates --output-mode; unset, parallel builds tag
+// their lines and sequential ones show them as printed
+func resolveOutputMode(mode string, concurrent bool) (string, error) {
+	switch mode {
+	case "":
+		if concurrent {
+			return outputPrefixed, nil
+		}
+		return outputInterleaved, nil
+	case outputPrefixed, outputGrouped, outputInterleaved:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown output mode '%s': use prefixed, grouped or interleaved", mode)
+}
+
+// targetText is the text output of a target as the output mode shows it:
+// tagged, or held back for its group ("") until the target finishes.
+// Called with logMu held
+func targetText(target, text string) string {
+	switch outputMode {
+	case outputGrouped:
+		if group := groupedOutput[target]; group != nil {
+			group.WriteString(text)
+			return ""
+		}
+	case outputPrefixed:
+		tag := "[" + target + "] "
+		if prefixColors {
+			tag = fmt.Sprintf("\033[%dm[%s]\033[0m ", prefixColor(target), target)
+		}
+		var b strings.Builder
+		for _, line := range strings.SplitAfter(text, "\n") {
+			if line != "" {
+				b.WriteString(tag + line)
+			}
+		}
+		return b.String()
+	}
+	return text
+}
+
+// prefixColor picks a terminal color for a target's tag, the same one on
+// every build
+func prefixColor(target string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(target))
+	return 31 + int(h.Sum32()%6)
+}
+
+// groupOutput holds a target's output in grouped mode and returns the
+// function that prints it in one piece
+func groupOutput(target string) func() {
+	if outputMode != outputGrouped || logFormat != "text" {
+		return func() {}
+	}
+	logMu.Lock()
+	groupedOutput[target] = &strings.Builder{}
+	logMu.Unlock()
+	return func() {
+		logMu.Lock()
+		defer logMu.Unlock()
+		fmt.Fprint(os.Stdout, groupedOutput[target].String())
+		delete(groupedOutput, target)
+	}
+}
+
+// outputStream shows a command's output line by line as it is printed,
+// through the same transcoding, sanitizing and filters as buffered output.
+// The command's stdout and stderr share one stream
+type outputStream struct {
+	mu      sync.Mutex
+	target  string
+	command string
+	opts    *Target
+	partial []byte
+}
+
+// newOutputStream returns the stream a target's command prints through,
+// or nil when its output has to be complete before it can be shown
+func newOutputStream(name, command string, target *Target) *outputStream {
+	for _, f := range target.OutputFilter {
+		if f.PrettyJSON {
+			return nil
+		}
+	}
+	return &outputStream{target: name, command: command, opts: target}
+}
+
+// Write shows the complete lines of p and keeps the last unfinished one
+func (s *outputStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.partial = append(s.partial, p...)
+	if i := bytes.LastIndexByte(s.partial, '\n'); i >= 0 {
+		s.show(string(s.partial[:i+1]))
+		s.partial = append(s.partial[:0], s.partial[i+1:]...)
+	}
+	return len(p), nil
+}
+
+// flush shows what is left once the command has exited
+func (s *outputStream) flush() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.partial) > 0 {
+		s.show(string(s.partial) + "\n")
+		s.partial = nil
+	}
+}
+
+// show prints complete lines of raw output
+func (s *outputStream) show(out string) {
+	out = transcodeOutput(out, targetCodePage(s.opts))
+	if !s.opts.RawOutput {
+		out, _ = sanitizeOutput(out)
+	}
+	out = filterOutput(out, s.opts.OutputFilter)
+	for _, line := range strings.SplitAfter(out, "\n") {
+		if line == "" {
+			continue
+		}
+		logEvent{Level: levelInfo, Event: "output", Target: s.target, Command: s.command, Output: line}.emit(line)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== STREAM.GO UNIT TESTS =====
+
+func TestOutputStream(t *testing.T) {
+	defer func() { outputMode = outputInterleaved }()
+
+	tests := []struct {
+		name     string
+		target   Target
+		mode     string
+		writes   []string
+		expected string
+	}{
+		{"lines", Target{}, outputInterleaved, []string{"one\ntw", "o\n"}, "one\ntwo\n"},
+		{"unfinished line", Target{}, outputInterleaved, []string{"done"}, "done\n"},
+		{"prefixed", Target{}, outputPrefixed, []string{"a\nb\n"}, "[gen] a\n[gen] b\n"},
+		{"sanitized", Target{}, outputInterleaved, []string{"x\x1b[2Ky\n"}, "xy\n"},
+		{"raw", Target{RawOutput: true}, outputInterleaved, []string{"x\x1b[2Ky\n"}, "x\x1b[2Ky\n"},
+		{"filtered", Target{OutputFilter: []OutputFilter{{Exclude: "^debug"}}}, outputInterleaved, []string{"debug: x\nok\n"}, "ok\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputMode = tt.mode
+			stdout := captureStdout(t)
+			stream := newOutputStream("gen", "make", &tt.target)
+			for _, w := range tt.writes {
+				if _, err := stream.Write([]byte(w)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			stream.flush()
+			if got := stdout(); got != tt.expected {
+				t.Errorf("stream showed %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+
+	// Whole-output filters cannot work line by line
+	if stream := newOutputStream("gen", "make", &Target{OutputFilter: []OutputFilter{{PrettyJSON: true}}}); stream != nil {
+		t.Error("newOutputStream() should buffer the output for pretty_json")
+	}
+}
+
+func TestTargetOutputStreams(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{}
+
+	// A failing command's output is shown before its error, each line once
+	target := Target{Run: []string{"echo building; echo warning >&2; exit 1"}}
+	stdout := captureStdout(t)
+	if err := ExecuteAllWithContext("lib", &target, false, false); err == nil {
+		t.Fatal("ExecuteAllWithContext() error = nil, expected the exit status")
+	}
+	output := stdout()
+	if strings.Count(output, "building\n") != 1 || strings.Count(output, "warning\n") != 1 {
+		t.Errorf("stdout = %q, expected both lines once", output)
+	}
+}
+
+func TestResolveOutputMode(t *testing.T) {
+	tests := []struct {
+		mode       string
+		concurrent bool
+		expected   string
+		wantErr    bool
+	}{
+		{"", false, outputInterleaved, false},
+		{"", true, outputPrefixed, false},
+		{"grouped", true, outputGrouped, false},
+		{"interleaved", true, outputInterleaved, false},
+		{"prefixed", false, outputPrefixed, false},
+		{"tagged", true, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := resolveOutputMode(tt.mode, tt.concurrent)
+		if (err != nil) != tt.wantErr || got != tt.expected {
+			t.Errorf("resolveOutputMode(%q, %v) = %q, %v; expected %q", tt.mode, tt.concurrent, got, err, tt.expected)
+		}
+	}
+}
+
+func TestGroupedOutput(t *testing.T) {
+	defer func() { outputMode = outputInterleaved }()
+	outputMode = outputGrouped
+
+	stdout := captureStdout(t)
+	flushLib := groupOutput("lib")
+	flushApp := groupOutput("app")
+	logInfo("output", "lib", "lib 1")
+	logInfo("output", "app", "app 1")
+	logInfo("output", "lib", "lib 2")
+	flushApp()
+	logInfo("output", "", "done")
+	flushLib()
+	if got, expected := stdout(), "app 1\ndone\nlib 1\nlib 2\n"; got != expected {
+		t.Errorf("stdout = %q, expected each target's lines together once it finished", got)
+	}
+}
+
+func TestPrefixColor(t *testing.T) {
+	defer func() { outputMode, prefixColors = outputInterleaved, false }()
+	outputMode, prefixColors = outputPrefixed, true
+
+	color := prefixColor("test")
+	if color < 31 || color > 36 || prefixColor("test") != color {
+		t.Errorf("prefixColor() = %d, expected a stable basic color", color)
+	}
+	if got := targetText("test", "ok\n"); !strings.HasPrefix(got, "\033[") || !strings.HasSuffix(got, "[test]\033[0m ok\n") {
+		t.Errorf("targetText() = %q, expected a colored tag", got)
+	}
+}
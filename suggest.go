@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// depSuggestion holds config entries a target is missing
+type depSuggestion struct {
+	Deps    []string
+	Sources []string
+	Outputs []string
+}
+
+// shellOperators split words even when written without spaces around them,
+// longest first
+var shellOperators = []string{"&>>", "2>>", "&&", "||", ">>", "2>", "&>", ";", "|", "<", ">"}
+
+// redirectOperators are followed by a file the command writes
+var redirectOperators = map[string]bool{">": true, ">>": true, "2>": true, "2>>": true, "&>": true, "&>>": true}
+
+// outputFlags are followed by a file the command writes
+var outputFlags = map[string]bool{"-o": true, "--output": true, "--out": true}
+
+// shellWords splits a command into words and operators the way a POSIX
+// shell would, without expanding anything
+func shellWords(command string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == '\\' && i+1 < len(command):
+			i++
+			word.WriteByte(command[i])
+			inWord = true
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(command[i+1:], c)
+			if end < 0 {
+				end = len(command) - i - 1
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			flush()
+		default:
+			op := ""
+			for _, candidate := range shellOperators {
+				if strings.HasPrefix(command[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			// "2>" only redirects at the start of a word
+			if op == "" || (op[0] == '2' && inWord) {
+				word.WriteByte(c)
+				inWord = true
+				continue
+			}
+			flush()
+			words = append(words, op)
+			i += len(op) - 1
+		}
+	}
+	flush()
+	return words
+}
+
+// projectPath returns word as a clean, slash-separated path relative to the
+// working directory when it names a place inside the project
+func projectPath(word, wd string) (string, bool) {
+	if word == "" || strings.Contains(word, "$") || strings.Contains(word, "://") {
+		return "", false
+	}
+	path := filepath.FromSlash(word)
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(wd, path)
+		if err != nil {
+			return "", false
+		}
+		path = rel
+	}
+	path = filepath.Clean(path)
+	if !filepath.IsLocal(path) || path == "." {
+		return "", false
+	}
+	return filepath.ToSlash(path), true
+}
+
+// commandFiles finds the project files a target's commands read and write,
+// judging by their words: redirections and -o/--output name outputs, other
+// words naming existing files, matching globs or naming files generated
+// by a target are inputs
+func commandFiles(commands []string, generated func(string) bool) (inputs, outputs []string) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, nil
+	}
+	written := map[string]bool{}
+	for _, command := range commands {
+		words := shellWords(command)
+		for i := 0; i < len(words); i++ {
+			word := words[i]
+			if redirectOperators[word] || outputFlags[word] {
+				if i+1 < len(words) {
+					i++
+					if path, ok := projectPath(words[i], wd); ok && !written[path] {
+						written[path] = true
+						outputs = append(outputs, path)
+					}
+				}
+				continue
+			}
+			if strings.HasPrefix(word, "-") {
+				// --output=file writes, --config=file reads
+				flag, value, ok := strings.Cut(word, "=")
+				if !ok {
+					continue
+				}
+				if outputFlags[flag] {
+					if path, ok := projectPath(value, wd); ok && !written[path] {
+						written[path] = true
+						outputs = append(outputs, path)
+					}
+					continue
+				}
+				word = value
+			}
+
+			path, ok := projectPath(word, wd)
+			if !ok || written[path] {
+				continue
+			}
+			if strings.ContainsAny(path, "*?[") {
+				if len(globFiles(path)) > 0 {
+					inputs = append(inputs, path)
+				}
+			} else if info, err := os.Stat(path); (err == nil && info.Mode().IsRegular()) || (err != nil && generated(path)) {
+				inputs = append(inputs, path)
+			}
+		}
+	}
+	slices.Sort(inputs)
+	return slices.Compact(inputs), outputs
+}
+
+// matchesAny reports whether path matches one of the patterns, which are
+// expanded for the target name
+func matchesAny(path string, patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(filepath.Clean(ParseVars(pattern, name)))
+		if pattern == path || matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestDeps compares what a target's commands appear to read and write
+// with what it declares. Inputs produced by another target become deps on
+// that target, other inputs become sources
+func suggestDeps(name string) (depSuggestion, error) {
+	target, ok := cfg.Targets[name]
+	if !ok {
+		return depSuggestion{}, fmt.Errorf("target '%s' not found", name)
+	}
+	ectx := planContext(name, target)
+	var commands []string
+	for _, cmd := range target.Run {
+		commands = append(commands, ectx.Expand(cmd))
+	}
+	producer := func(path string) string {
+		for _, other := range sortedKeys(cfg.Targets) {
+			if other != name && matchesAny(path, cfg.Targets[other].Outputs, other) {
+				return other
+			}
+		}
+		return ""
+	}
+	inputs, outputs := commandFiles(commands, func(path string) bool { return producer(path) != "" })
+
+	// Deps already reachable need not be repeated
+	reachable := map[string]bool{}
+	var walk func(deps []string)
+	walk = func(deps []string) {
+		for _, dep := range deps {
+			if !reachable[dep] {
+				reachable[dep] = true
+				walk(GetTarget(dep).Deps)
+			}
+		}
+	}
+	walk(target.Deps)
+
+	ignore := loadIgnore()
+	var suggestion depSuggestion
+	for _, input := range inputs {
+		if ignore.Ignored(filepath.FromSlash(input), false) || matchesAny(input, target.Sources, name) || matchesAny(input, target.Outputs, name) || slices.Contains(target.Deps, input) {
+			continue
+		}
+		switch dep := producer(input); {
+		case dep == "":
+			suggestion.Sources = append(suggestion.Sources, input)
+		case !reachable[dep] && !slices.Contains(suggestion.Deps, dep):
+			suggestion.Deps = append(suggestion.Deps, dep)
+		}
+	}
+	for _, output := range outputs {
+		if !matchesAny(output, target.Outputs, name) {
+			suggestion.Outputs = append(suggestion.Outputs, output)
+		}
+	}
+	return suggestion, nil
+}
+
+// yamlList renders values as a YAML flow sequence
+func yamlList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// suggestDepsCommand prints deps, sources and outputs entries a target
+// appears to be missing, ready to paste into the config
+func suggestDepsCommand(ctx *orpheus.Context) error {
+	args := positionalArgs(ctx)
+	if len(args) != 1 {
+		return orpheus.ValidationError("suggest-deps", "usage: aura suggest-deps <target>")
+	}
+	if err := planSetup(ctx); err != nil {
+		return err
+	}
+	name := args[0]
+	if _, ok := cfg.Targets[name]; !ok {
+		return orpheus.NotFoundError(name, fmt.Sprintf("target '%s' not found", name))
+	}
+
+	suggestion, err := suggestDeps(name)
+	if err != nil {
+		return orpheus.ExecutionError("suggest-deps", err.Error())
+	}
+	if len(suggestion.Deps)+len(suggestion.Sources)+len(suggestion.Outputs) == 0 {
+		fmt.Printf("Target '%s' declares every file its commands name.\n", name)
+		fmt.Printf("Run 'aura build -t %s --trace-deps' on Linux to check the files they read indirectly.\n", name)
+		return nil
+	}
+
+	target := cfg.Targets[name]
+	fmt.Printf("# Suggested entries for target '%s', merged with what it declares:\n", name)
+	fmt.Printf("  %s:\n", name)
+	for _, entry := range []struct {
+		key     string
+		current []string
+		add     []string
+	}{
+		{"deps", target.Deps, suggestion.Deps},
+		{"sources", target.Sources, suggestion.Sources},
+		{"outputs", target.Outputs, suggestion.Outputs},
+	} {
+		if len(entry.add) > 0 {
+			fmt.Printf("    %s: %s\n", entry.key, yamlList(append(slices.Clone(entry.current), entry.add...)))
+		}
+	}
+	return nil
+}
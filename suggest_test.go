@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ===== SUGGEST.GO UNIT TESTS =====
+
+func TestShellWords(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected []string
+	}{
+		{"cc -o bin/app main.c", []string{"cc", "-o", "bin/app", "main.c"}},
+		{`echo "a b" 'c d' e\ f`, []string{"echo", "a b", "c d", "e f"}},
+		{"gen>out.txt<in.txt", []string{"gen", ">", "out.txt", "<", "in.txt"}},
+		{"make 2>err.log && cat a|wc -l", []string{"make", "2>", "err.log", "&&", "cat", "a", "|", "wc", "-l"}},
+		{"tool x2>y", []string{"tool", "x2", ">", "y"}},
+		{"cmd >> log; next &> all.log", []string{"cmd", ">>", "log", ";", "next", "&>", "all.log"}},
+		{`echo ""`, []string{"echo", ""}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := shellWords(tt.command); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("shellWords(%q) = %q, expected %q", tt.command, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSuggestDeps(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+	writeFiles(t, map[string]string{
+		"src/a.c":     "",
+		"src/b.c":     "",
+		"include/x.h": "",
+		"config.mk":   "",
+		"debug.log":   "",
+		".auraignore": "*.log\n",
+	})
+	cfg = Config{Targets: map[string]Target{
+		"gen": {Outputs: []string{"gen/version.h"}, Run: []string{"echo '#define V 1' > gen/version.h"}},
+		"lib": {Deps: []string{"gen"}, Run: []string{"echo lib"}},
+		"build": {
+			Deps:    []string{"lib"},
+			Sources: []string{"src/*.c"},
+			Outputs: []string{"bin/app"},
+			Run: []string{
+				"cc -Iinclude -o bin/app src/*.c include/x.h gen/version.h --config=config.mk debug.log missing.h",
+				"sha256sum bin/app > $OUT_SUM",
+			},
+			Vars: map[string]Var{"OUT_SUM": "bin/app.sha256"},
+		},
+		"pack": {Run: []string{"tar czf dist.tgz bin/app /etc/hosts ../elsewhere.txt"}},
+	}}
+
+	tests := []struct {
+		target   string
+		expected depSuggestion
+	}{
+		{"build", depSuggestion{Sources: []string{"config.mk", "include/x.h"}, Outputs: []string{"bin/app.sha256"}}},
+		{"pack", depSuggestion{Deps: []string{"build"}}},
+		{"gen", depSuggestion{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := suggestDeps(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("suggestDeps(%q) = %+v, expected %+v", tt.target, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := suggestDeps("missing"); err == nil {
+		t.Error("suggestDeps() should fail for an unknown target")
+	}
+}
+
+func TestYAMLList(t *testing.T) {
+	if got := yamlList([]string{"src/*.c", `a "b"`}); got != `["src/*.c", "a \"b\""]` {
+		t.Errorf("yamlList() = %s", got)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// Target statuses of the build summary
+const (
+	summaryOK      = "ok"
+	summaryFailed  = "failed"
+	summarySkipped = "skipped" // not run: not for this platform, left out by a commit marker, a dependency failed or the build stopped
+)
+
+// summaryRow is one target of the build summary
+type summaryRow struct {
+	Target     string `json:"target"`
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Commands   int    `json:"commands"`
+	CPUMS      int64  `json:"cpu_ms,omitempty"`         // user and system time of its commands
+	PeakRSS    int64  `json:"peak_rss_bytes,omitempty"` // memory of its largest process
+	Attempts   int    `json:"attempts,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Reason     string `json:"reason,omitempty"` // why a target was skipped
+}
+
+// buildSummaryDoc is the --summary=json document
+type buildSummaryDoc struct {
+	Result     string       `json:"result"` // passing or failing
+	DurationMS int64        `json:"duration_ms"`
+	Targets    []summaryRow `json:"targets"`
+}
+
+// resolveSummary validates --summary; unset, builds on a terminal or in
+// plain logs end with the table, while CI (which prints its own summary
+// lines) and --log-format json get none
+func resolveSummary(format string) (string, error) {
+	switch format {
+	case "":
+		if logFormat != "text" || detectCI() != "" {
+			return "none", nil
+		}
+		return "table", nil
+	case "table", "json", "none":
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown summary format '%s': use table, json or none", format)
+}
+
+// summarizeBuild lists every target of the build in dependency order with
+// how it went; targets without a result did not run
+func summarizeBuild(names []string, list []targetResult) []summaryRow {
+	set := querySet{}
+	for _, name := range names {
+		set[name] = true
+	}
+	results := map[string]targetResult{}
+	for _, r := range list {
+		results[r.Name] = r
+	}
+
+	var rows []summaryRow
+	for _, name := range queryOrder(reachable(set, -1, targetEdges)) {
+		row := summaryRow{Target: name, Status: summarySkipped, Commands: len(cfg.Targets[name].Run)}
+		if r, ok := results[name]; !ok {
+			row.Reason = markerSkips[name]
+		} else if r.Skipped != "" {
+			row.Reason = r.Skipped
+		} else {
+			row.Status = summaryOK
+			row.DurationMS = r.Duration.Milliseconds()
+			row.CPUMS, row.PeakRSS = r.Usage.CPU.Milliseconds(), r.Usage.PeakRSS
+			row.Attempts = max(1, r.Attempts)
+			if r.Err != nil {
+				row.Status, row.Error = summaryFailed, r.Err.Error()
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// writeSummaryTable prints the summary as an aligned table with a closing
+// count of targets per status
+func writeSummaryTable(w io.Writer, rows []summaryRow, took time.Duration) {
+	if len(rows) == 0 {
+		return
+	}
+	colors := map[string]string{summaryOK: colorGreen, summaryFailed: colorRed, summarySkipped: colorYellow}
+	counts := map[string]int{}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w)
+	fmt.Fprintln(tw, "TARGET\tSTATUS\tDURATION\tCPU\tMEMORY\tCOMMANDS")
+	for _, row := range rows {
+		counts[row.Status]++
+		duration, cpu := "-", "-"
+		if row.Status != summarySkipped {
+			duration = (time.Duration(row.DurationMS) * time.Millisecond).String()
+		}
+		if row.CPUMS > 0 || row.PeakRSS > 0 {
+			cpu = (time.Duration(row.CPUMS) * time.Millisecond).String()
+		}
+		line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%d", row.Target, paint(os.Stdout, colors[row.Status], row.Status), duration, cpu, formatMemory(row.PeakRSS), row.Commands)
+		if row.Reason != "" {
+			line += "\t(" + row.Reason + ")"
+		}
+		fmt.Fprintln(tw, line)
+	}
+	_ = tw.Flush()
+	fmt.Fprintf(w, "%d targets: %d ok, %d failed, %d skipped in %s\n", len(rows), counts[summaryOK], counts[summaryFailed], counts[summarySkipped], took.Round(time.Millisecond))
+}
+
+// writeSummaryJSON prints the summary as one JSON document
+func writeSummaryJSON(w io.Writer, rows []summaryRow, buildErr error, took time.Duration) error {
+	doc := buildSummaryDoc{Result: "passing", DurationMS: took.Milliseconds(), Targets: rows}
+	if buildErr != nil {
+		doc.Result = "failing"
+	}
+	if doc.Targets == nil {
+		doc.Targets = []summaryRow{}
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
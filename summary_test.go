@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ===== SUMMARY.GO UNIT TESTS =====
+
+func TestResolveSummary(t *testing.T) {
+	originalFormat := logFormat
+	defer func() { logFormat = originalFormat }()
+	t.Setenv("CI", "")
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "")
+
+	tests := []struct {
+		format    string
+		logFormat string
+		expected  string
+		wantErr   bool
+	}{
+		{"", "text", "table", false},
+		{"", "json", "none", false},
+		{"json", "text", "json", false},
+		{"none", "text", "none", false},
+		{"xml", "text", "", true},
+	}
+
+	for _, tt := range tests {
+		logFormat = tt.logFormat
+		got, err := resolveSummary(tt.format)
+		if (err != nil) != tt.wantErr || got != tt.expected {
+			t.Errorf("resolveSummary(%q) with %s logs = %q, %v; expected %q", tt.format, tt.logFormat, got, err, tt.expected)
+		}
+	}
+
+	logFormat = "text"
+	t.Setenv("CI", "true")
+	if got, _ := resolveSummary(""); got != "none" {
+		t.Errorf("resolveSummary() in CI = %q, expected none (CI prints result lines)", got)
+	}
+}
+
+func TestSummarizeBuild(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{
+		"lib":    {Run: []string{"make", "make install"}},
+		"app":    {Deps: []string{"lib"}, Run: []string{"go build"}},
+		"deploy": {Deps: []string{"app"}, Run: []string{"./deploy.sh"}},
+		"docs":   {Run: []string{"mkdocs build"}},
+	}}
+
+	rows := summarizeBuild([]string{"deploy"}, []targetResult{
+		{Name: "lib", Duration: 1500 * time.Millisecond, Usage: resourceUsage{CPU: 1200 * time.Millisecond, PeakRSS: 64 << 20}, Attempts: 2},
+		{Name: "app", Duration: time.Second, Err: errors.New("exit status 1")},
+	})
+	expected := []summaryRow{
+		{Target: "lib", Status: summaryOK, DurationMS: 1500, Commands: 2, CPUMS: 1200, PeakRSS: 64 << 20, Attempts: 2},
+		{Target: "app", Status: summaryFailed, DurationMS: 1000, Commands: 1, Attempts: 1, Error: "exit status 1"},
+		{Target: "deploy", Status: summarySkipped, Commands: 1},
+	}
+	if len(rows) != len(expected) {
+		t.Fatalf("summarizeBuild() = %+v, expected the 3 targets of the build", rows)
+	}
+	for i := range rows {
+		if rows[i] != expected[i] {
+			t.Errorf("row %d = %+v, expected %+v", i, rows[i], expected[i])
+		}
+	}
+}
+
+func TestWriteSummaryTable(t *testing.T) {
+	rows := []summaryRow{
+		{Target: "lib", Status: summaryOK, DurationMS: 1500, Commands: 2, CPUMS: 1200, PeakRSS: 64 << 20, Attempts: 1},
+		{Target: "application", Status: summaryFailed, DurationMS: 20, Commands: 1, Attempts: 1, Error: "exit status 1"},
+		{Target: "deploy", Status: summarySkipped, Commands: 1},
+	}
+
+	var table bytes.Buffer
+	writeSummaryTable(&table, rows, 1520*time.Millisecond)
+	lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "TARGET       STATUS") {
+		t.Fatalf("table = %q, expected a header, 3 rows and the counts", table.String())
+	}
+	if lines[1] != "lib          ok       1.5s      1.2s  64.0MiB  2" {
+		t.Errorf("lib row = %q, expected its CPU time and memory", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "deploy       skipped  -") {
+		t.Errorf("skipped row = %q, expected no duration", lines[3])
+	}
+	if lines[4] != "3 targets: 1 ok, 1 failed, 1 skipped in 1.52s" {
+		t.Errorf("counts = %q", lines[4])
+	}
+
+	var out bytes.Buffer
+	if err := writeSummaryJSON(&out, rows, errors.New("failed"), time.Second); err != nil {
+		t.Fatal(err)
+	}
+	var doc buildSummaryDoc
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("summary is not JSON: %v", err)
+	}
+	if doc.Result != "failing" || doc.DurationMS != 1000 || len(doc.Targets) != 3 || doc.Targets[1].Error != "exit status 1" {
+		t.Errorf("summary = %+v", doc)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// tmpdirVar names the per-target temporary directory builtin
+const tmpdirVar = "TMPDIR_TARGET"
+
+// keepTemp leaves $TMPDIR_TARGET directories in place for inspection
+var keepTemp bool
+
+// tempNameRegex matches the characters not used in temp directory names
+var tempNameRegex = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// lookupTempDir resolves $TMPDIR_TARGET, creating the directory the first
+// time a command of the target refers to it
+func (c *expandContext) lookupTempDir(name string) (string, bool) {
+	if name != tmpdirVar {
+		return "", false
+	}
+	if c.tempDir != "" {
+		return c.tempDir, true
+	}
+	dir, err := os.MkdirTemp("", "aura-"+tempNameRegex.ReplaceAllString(c.target, "_")+"-")
+	if err != nil {
+		logWarn("cannot create $%s for %s: %v", tmpdirVar, c.target, err)
+		return "", false
+	}
+	c.tempDir = dir
+	return dir, true
+}
+
+// removeTempDir deletes the target's temporary directory, if one was
+// created, unless --keep-temp asked to keep it
+func (c *expandContext) removeTempDir() {
+	if c.tempDir == "" {
+		return
+	}
+	if keepTemp {
+		fmt.Printf("Kept $%s of %s: %s\n", tmpdirVar, c.target, c.tempDir)
+		return
+	}
+	if err := os.RemoveAll(c.tempDir); err != nil {
+		logWarn("cannot remove %s: %v", c.tempDir, err)
+	}
+	c.tempDir = ""
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ===== TEMPDIR.GO UNIT TESTS =====
+
+func TestTempDirLifecycle(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{"it:test": {}}}
+
+	ectx := newExpandContext("it:test", nil)
+	dir := ectx.Expand("$TMPDIR_TARGET")
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("$TMPDIR_TARGET = %q is not a directory: %v", dir, err)
+	}
+	if !strings.HasPrefix(filepath.Base(dir), "aura-it_test-") {
+		t.Errorf("temp dir name %q should mention the target", filepath.Base(dir))
+	}
+	if again := ectx.Expand("${TMPDIR_TARGET}"); again != dir {
+		t.Errorf("second reference = %q, expected the same directory %q", again, dir)
+	}
+	if other := newExpandContext("it:test", nil).Expand("$TMPDIR_TARGET"); other == dir {
+		t.Error("each target instance should get its own directory")
+	} else {
+		defer func() { _ = os.RemoveAll(other) }()
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "scratch"), []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ectx.removeTempDir()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("temp dir should be removed, stat error = %v", err)
+	}
+}
+
+func TestTempDirKeep(t *testing.T) {
+	defer func() { keepTemp = false }()
+	keepTemp = true
+
+	ectx := newExpandContext("build", nil)
+	dir := ectx.Expand("$TMPDIR_TARGET")
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	ectx.removeTempDir()
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("--keep-temp should keep %s: %v", dir, err)
+	}
+}
+
+func TestTempDirUnused(t *testing.T) {
+	ectx := newExpandContext("build", nil)
+	ectx.Expand("echo no temp dir here")
+	if ectx.tempDir != "" {
+		t.Errorf("no directory should be created without a reference, got %s", ectx.tempDir)
+	}
+	ectx.removeTempDir()
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+	"gopkg.in/yaml.v3"
+)
+
+// configTestFile is the file of assertions `aura test-config` checks
+// against the build config
+type configTestFile struct {
+	Tests []configTest `yaml:"tests"`
+}
+
+// configTest is one named group of assertions, checked with the config
+// loaded under Profile. Loading is a check of its own: a config that fails
+// validation, e.g. because of a dependency cycle, fails every test
+type configTest struct {
+	Name    string            `yaml:"name"`
+	Profile string            `yaml:"profile"`
+	Expect  []configAssertion `yaml:"expect"`
+}
+
+// configAssertion is one expectation about a target or a var: a command
+// of the target, expanded as `aura plan` shows it, contains (or no command
+// contains) a string; the target depends on another, directly or not; the
+// var has a value
+type configAssertion struct {
+	Target      string  `yaml:"target"`
+	Contains    string  `yaml:"contains"`
+	NotContains string  `yaml:"not_contains"`
+	DependsOn   string  `yaml:"depends_on"`
+	Var         string  `yaml:"var"`
+	Equals      *string `yaml:"equals"`
+}
+
+// check returns why the loaded config does not meet the assertion, or ""
+func (a configAssertion) check() string {
+	if a.Var != "" {
+		value, ok := newExpandContext("", nil).Lookup(a.Var)
+		switch {
+		case !ok:
+			return fmt.Sprintf("var '%s' is not defined", a.Var)
+		case value != *a.Equals:
+			return fmt.Sprintf("var '%s' is %q, expected %q", a.Var, value, *a.Equals)
+		}
+		return ""
+	}
+
+	target, ok := cfg.Targets[a.Target]
+	if !ok {
+		return fmt.Sprintf("target '%s' not found", a.Target)
+	}
+	ectx := planContext(a.Target, target)
+	wrapper := ectx.Expand(string(commandWrapper(&target)))
+	var commands []string
+	for _, cmd := range target.Run {
+		commands = append(commands, wrapCommand(wrapper, ectx.Expand(cmd)))
+	}
+	contains := func(s string) bool {
+		return slices.ContainsFunc(commands, func(cmd string) bool { return strings.Contains(cmd, s) })
+	}
+	listed := func() string {
+		if len(commands) == 0 {
+			return " (it has no commands)"
+		}
+		return ":\n      $ " + strings.Join(commands, "\n      $ ")
+	}
+
+	if a.Contains != "" && !contains(a.Contains) {
+		return fmt.Sprintf("no command of target '%s' contains %q%s", a.Target, a.Contains, listed())
+	}
+	if a.NotContains != "" && contains(a.NotContains) {
+		return fmt.Sprintf("a command of target '%s' contains %q%s", a.Target, a.NotContains, listed())
+	}
+	if a.DependsOn != "" {
+		deps := reachable(querySet{a.Target: true}, -1, targetEdges)
+		if !deps[a.DependsOn] || a.DependsOn == a.Target {
+			return fmt.Sprintf("target '%s' does not depend on '%s'", a.Target, a.DependsOn)
+		}
+	}
+	return ""
+}
+
+// validate rejects assertions that would check nothing
+func (a configAssertion) validate() error {
+	switch {
+	case a.Var != "" && a.Target != "":
+		return fmt.Errorf("an assertion is about a target or a var, not both")
+	case a.Var != "" && a.Equals == nil:
+		return fmt.Errorf("assertion on var '%s' needs equals", a.Var)
+	case a.Target != "" && a.Contains == "" && a.NotContains == "" && a.DependsOn == "":
+		return fmt.Errorf("assertion on target '%s' needs contains, not_contains or depends_on", a.Target)
+	case a.Var == "" && a.Target == "":
+		return fmt.Errorf("an assertion needs a target or a var")
+	}
+	return nil
+}
+
+// loadConfigTests reads and checks a config test file
+func loadConfigTests(path string) ([]configTest, error) {
+	// #nosec G304 - The test file is chosen by the user
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file configTestFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if len(file.Tests) == 0 {
+		return nil, fmt.Errorf("%s has no tests", path)
+	}
+	for i, test := range file.Tests {
+		if test.Name == "" {
+			return nil, fmt.Errorf("test %d in %s has no name", i+1, path)
+		}
+		for _, assertion := range test.Expect {
+			if err := assertion.validate(); err != nil {
+				return nil, fmt.Errorf("test '%s': %v", test.Name, err)
+			}
+		}
+	}
+	return file.Tests, nil
+}
+
+// runConfigTest loads the config under the test's profile and returns the
+// failed assertions
+func runConfigTest(configFile string, test configTest) []string {
+	cfg = Config{}
+	if err := loadConfigProfile(configFile, test.Profile); err != nil {
+		return []string{fmt.Sprintf("config does not load: %v", err)}
+	}
+	var failures []string
+	for _, assertion := range test.Expect {
+		if failure := assertion.check(); failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+// testConfigCommand checks the assertions of a config test file
+func testConfigCommand(ctx *orpheus.Context) error {
+	workDir := ctx.GetGlobalFlagString("directory")
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	path := ctx.GetFlagString("file")
+	tests, err := loadConfigTests(path)
+	if err != nil {
+		return orpheus.ValidationError("test-config", err.Error())
+	}
+
+	configFile := ctx.GetGlobalFlagString("config")
+	failed := 0
+	for _, test := range tests {
+		failures := runConfigTest(configFile, test)
+		if len(failures) == 0 {
+			fmt.Printf("%s %s\n", checkMark(), test.Name)
+			continue
+		}
+		failed++
+		fmt.Printf("%s %s\n", crossMark(), test.Name)
+		for _, failure := range failures {
+			fmt.Printf("    %s\n", failure)
+		}
+	}
+
+	fmt.Printf("\n%d tests, %d failed\n", len(tests), failed)
+	if failed > 0 {
+		return orpheus.ExecutionError("test-config", fmt.Sprintf("%d of %d config tests failed", failed, len(tests)))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// ===== TESTCONFIG.GO UNIT TESTS =====
+
+const configUnderTest = `vars:
+  VERSION: "1.2"
+profiles:
+  release:
+    vars:
+      FLAGS: "-s -w"
+targets:
+  gen:
+    run: ["go generate ./..."]
+  build:
+    deps: [gen]
+    run: ["go build -ldflags='${FLAGS}' -o app-${VERSION}"]
+  release:
+    deps: [build]
+    run: ["tar czf app.tgz app-${VERSION}"]
+`
+
+func TestRunConfigTest(t *testing.T) {
+	original, originalProfile := cfg, activeProfile
+	defer func() { cfg, activeProfile = original, originalProfile }()
+	chdirTemp(t)
+	writeFiles(t, map[string]string{"aura.yaml": configUnderTest})
+	value := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		test     configTest
+		expected []string
+	}{
+		{"passing", configTest{Profile: "release", Expect: []configAssertion{
+			{Target: "build", Contains: "-ldflags='-s -w'"},
+			{Target: "release", DependsOn: "gen"},
+			{Var: "FLAGS", Equals: value("-s -w")},
+		}}, nil},
+		{"not contains", configTest{Profile: "release", Expect: []configAssertion{
+			{Target: "build", NotContains: "-s -w"},
+		}}, []string{"a command of target 'build' contains"}},
+		{"missing command", configTest{Expect: []configAssertion{
+			{Target: "gen", Contains: "stringer"},
+		}}, []string{"no command of target 'gen' contains \"stringer\""}},
+		{"not a dependency", configTest{Expect: []configAssertion{
+			{Target: "gen", DependsOn: "build"},
+			{Target: "gen", DependsOn: "gen"},
+		}}, []string{"does not depend on 'build'", "does not depend on 'gen'"}},
+		{"var values", configTest{Expect: []configAssertion{
+			{Var: "VERSION", Equals: value("2.0")},
+			{Var: "AURA_TEST_UNDEFINED", Equals: value("")},
+		}}, []string{"var 'VERSION' is \"1.2\", expected \"2.0\"", "var 'AURA_TEST_UNDEFINED' is not defined"}},
+		{"unknown target", configTest{Expect: []configAssertion{
+			{Target: "deploy", Contains: "kubectl"},
+		}}, []string{"target 'deploy' not found"}},
+		{"unknown profile", configTest{Profile: "nightly"}, []string{"config does not load"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures := runConfigTest("aura.yaml", tt.test)
+			if len(failures) != len(tt.expected) {
+				t.Fatalf("runConfigTest() = %q, expected %d failures", failures, len(tt.expected))
+			}
+			for i, failure := range failures {
+				if !strings.Contains(failure, tt.expected[i]) {
+					t.Errorf("failure %d = %q, expected it to contain %q", i, failure, tt.expected[i])
+				}
+			}
+		})
+	}
+
+	// A cycle fails loading, and with it the test
+	writeFiles(t, map[string]string{"aura.yaml": "targets:\n  a:\n    deps: [b]\n  b:\n    deps: [a]\n"})
+	if failures := runConfigTest("aura.yaml", configTest{}); len(failures) != 1 || !strings.Contains(failures[0], "dependency cycle") {
+		t.Errorf("runConfigTest() with a cycle = %q", failures)
+	}
+}
+
+func TestLoadConfigTests(t *testing.T) {
+	chdirTemp(t)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", "tests:\n  - name: ok\n    expect:\n      - target: build\n        contains: go\n      - var: V\n        equals: \"\"\n", ""},
+		{"no tests", "tests: []\n", "has no tests"},
+		{"no name", "tests:\n  - expect: []\n", "has no name"},
+		{"no check", "tests:\n  - name: t\n    expect:\n      - target: build\n", "needs contains, not_contains or depends_on"},
+		{"var without value", "tests:\n  - name: t\n    expect:\n      - var: V\n", "needs equals"},
+		{"no subject", "tests:\n  - name: t\n    expect:\n      - contains: go\n", "needs a target or a var"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeFiles(t, map[string]string{"aura.test.yaml": tt.content})
+			_, err := loadConfigTests("aura.test.yaml")
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("loadConfigTests() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("loadConfigTests() error = %v, expected %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/agilira/orpheus/pkg/orpheus"
+)
+
+// toolchainDir is where tools are installed, shared by all projects so a
+// given tool version is only downloaded once per machine
+func toolchainDir() string {
+	if base, ok := userCacheBase(); ok {
+		return filepath.Join(base, "tools")
+	}
+	return ".aura_tools"
+}
+
+// toolDir is the install directory of one tool version
+func toolDir(name string, tool Tool) string {
+	return filepath.Join(toolchainDir(), name, tool.Version)
+}
+
+// toolDirs returns the install directories of every declared tool
+func toolDirs() []string {
+	var dirs []string
+	for _, name := range sortedKeys(cfg.Tools) {
+		dirs = append(dirs, toolDir(name, cfg.Tools[name]))
+	}
+	return dirs
+}
+
+// toolBinary is the file name a tool is installed under
+func toolBinary(name string, tool Tool) string {
+	binary := name
+	if tool.Go != "" {
+		// go install names the binary after the last path element,
+		// skipping a major version suffix such as /v2
+		elems := strings.Split(tool.Go, "/")
+		binary = elems[len(elems)-1]
+		if len(elems) > 1 && isMajorVersion(binary) {
+			binary = elems[len(elems)-2]
+		}
+	}
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	return binary
+}
+
+func isMajorVersion(elem string) bool {
+	digits, ok := strings.CutPrefix(elem, "v")
+	return ok && digits != "" && strings.Trim(digits, "0123456789") == ""
+}
+
+// toolInstalled reports whether a tool's binary is already in the toolchain dir
+func toolInstalled(name string, tool Tool) bool {
+	info, err := os.Stat(filepath.Join(toolDir(name, tool), toolBinary(name, tool)))
+	return err == nil && !info.IsDir()
+}
+
+// validateTools checks that every tool has a version and exactly one source
+func validateTools() error {
+	for _, name := range sortedKeys(cfg.Tools) {
+		tool := cfg.Tools[name]
+		switch {
+		case tool.Version == "":
+			return fmt.Errorf("tool %s: missing version", name)
+		case tool.Go == "" && tool.URL == "":
+			return fmt.Errorf("tool %s: needs either go or url", name)
+		case tool.Go != "" && tool.URL != "":
+			return fmt.Errorf("tool %s: go and url are mutually exclusive", name)
+		}
+	}
+	return nil
+}
+
+// toolURL fills ${version}, ${os} and ${arch} placeholders in a download URL
+func toolURL(tool Tool) string {
+	return strings.NewReplacer(
+		"${version}", tool.Version,
+		"${os}", runtime.GOOS,
+		"${arch}", runtime.GOARCH,
+	).Replace(tool.URL)
+}
+
+// installTool installs one tool into its toolchain directory
+func installTool(name string, tool Tool) error {
+	dir := toolDir(name, tool)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	if tool.Go != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		// #nosec G204 - Package and version come from the project config
+		cmd := exec.Command("go", "install", tool.Go+"@"+tool.Version)
+		cmd.Env = append(os.Environ(), "GOBIN="+abs)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("go install %s@%s: %v\n%s", tool.Go, tool.Version, err, out)
+		}
+		return nil
+	}
+	return downloadTool(toolURL(tool), filepath.Join(dir, toolBinary(name, tool)))
+}
+
+var toolClient = &http.Client{Timeout: 5 * time.Minute}
+
+// downloadTool fetches a binary to dest, writing through a temp file so an
+// interrupted download never looks installed
+func downloadTool(url, dest string) error {
+	resp, err := toolClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: %s", url, resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("download %s: %v", url, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// #nosec G302 - Downloaded tools must be executable
+	if err := os.Chmod(tmp.Name(), 0750); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// syncTools installs every declared tool that is missing, or all of them
+// with force, and returns how many were installed
+func syncTools(force, verbose bool) (int, error) {
+	var errs []error
+	installed := 0
+	for _, name := range sortedKeys(cfg.Tools) {
+		tool := cfg.Tools[name]
+		if !force && toolInstalled(name, tool) {
+			if verbose {
+				fmt.Printf("  %s %s (cached)\n", name, tool.Version)
+			}
+			continue
+		}
+
+		fmt.Printf("  Installing %s %s\n", name, tool.Version)
+		if err := installTool(name, tool); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+			continue
+		}
+		installed++
+	}
+	return installed, errors.Join(errs...)
+}
+
+// toolsSetup changes to the working directory and loads the configuration
+func toolsSetup(ctx *orpheus.Context) error {
+	workDir := ctx.GetGlobalFlagString("directory")
+	configFile := ctx.GetGlobalFlagString("config")
+
+	// Change to working directory
+	if workDir != "." {
+		if err := os.Chdir(workDir); err != nil {
+			return orpheus.ValidationError("directory", fmt.Sprintf("cannot change to directory '%s': %v", workDir, err))
+		}
+	}
+	return loadConfig(configFile)
+}
+
+// toolsCommand shows usage for the tools subcommands
+func toolsCommand(ctx *orpheus.Context) error {
+	fmt.Println("Project tool management")
+	fmt.Println("Use 'aura tools <subcommand>':")
+	fmt.Println("  sync  - Install missing tools into the toolchain cache")
+	fmt.Println("  list  - Show declared tools and whether they are installed")
+	return nil
+}
+
+// toolsSyncCommand installs the tools declared in the config
+func toolsSyncCommand(ctx *orpheus.Context) error {
+	if err := toolsSetup(ctx); err != nil {
+		return err
+	}
+	if len(cfg.Tools) == 0 {
+		fmt.Println("No tools declared")
+		return nil
+	}
+
+	fmt.Printf("Syncing tools into %s\n", toolchainDir())
+	installed, err := syncTools(ctx.GetFlagBool("force"), ctx.GetGlobalFlagBool("verbose"))
+	if err != nil {
+		return orpheus.ExecutionError("tools sync", err.Error())
+	}
+	fmt.Printf("%s Tools ready (%d installed, %d cached)\n", checkMark(), installed, len(cfg.Tools)-installed)
+	return nil
+}
+
+// toolsListCommand shows the declared tools and their install state
+func toolsListCommand(ctx *orpheus.Context) error {
+	if err := toolsSetup(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("Declared tools:")
+	fmt.Println("---------------")
+	if len(cfg.Tools) == 0 {
+		fmt.Println("No tools declared")
+		return nil
+	}
+
+	for _, name := range sortedKeys(cfg.Tools) {
+		tool := cfg.Tools[name]
+		source := tool.URL
+		if tool.Go != "" {
+			source = "go install " + tool.Go
+		}
+		state := crossMark() + " missing"
+		if toolInstalled(name, tool) {
+			state = checkMark() + " installed"
+		}
+		fmt.Printf("  %s %s  %s  (%s)\n", name, tool.Version, state, source)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== TOOLS.GO UNIT TESTS =====
+
+func TestToolBinary(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     Tool
+		expected string
+	}{
+		{"protoc", Tool{URL: "https://example.com/protoc"}, "protoc"},
+		{"lint", Tool{Go: "github.com/golangci/golangci-lint/cmd/golangci-lint"}, "golangci-lint"},
+		{"mockgen", Tool{Go: "go.uber.org/mock/mockgen/v2"}, "mockgen"},
+		{"tool", Tool{Go: "example.com/v2tool"}, "v2tool"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := tt.expected
+			if runtime.GOOS == "windows" {
+				expected += ".exe"
+			}
+			if got := toolBinary(tt.name, tt.tool); got != expected {
+				t.Errorf("toolBinary() = %q, want %q", got, expected)
+			}
+		})
+	}
+}
+
+func TestValidateTools(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name    string
+		tool    Tool
+		wantErr bool
+	}{
+		{"go tool", Tool{Version: "v1.0.0", Go: "example.com/cmd/tool"}, false},
+		{"url tool", Tool{Version: "1.0", URL: "https://example.com/tool"}, false},
+		{"missing version", Tool{Go: "example.com/cmd/tool"}, true},
+		{"missing source", Tool{Version: "1.0"}, true},
+		{"both sources", Tool{Version: "1.0", Go: "example.com/tool", URL: "https://example.com/tool"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Tools: map[string]Tool{"tool": tt.tool}}
+			if err := validateTools(); (err != nil) != tt.wantErr {
+				t.Errorf("validateTools() error = %v, wantErr %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestToolURL(t *testing.T) {
+	got := toolURL(Tool{Version: "25.1", URL: "https://example.com/${version}/tool-${os}-${arch}"})
+	expected := "https://example.com/25.1/tool-" + runtime.GOOS + "-" + runtime.GOARCH
+	if got != expected {
+		t.Errorf("toolURL() = %q, want %q", got, expected)
+	}
+}
+
+func TestSyncToolsDownload(t *testing.T) {
+	original, originalDefaults := cfg, userDefaults
+	defer func() { cfg, userDefaults = original, originalDefaults }()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !strings.HasSuffix(r.URL.Path, "/1.2/mytool") {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("#!/bin/sh\necho mytool\n"))
+	}))
+	defer server.Close()
+
+	userDefaults = UserDefaults{CacheDir: t.TempDir()}
+	cfg = Config{Tools: map[string]Tool{"mytool": {Version: "1.2", URL: server.URL + "/${version}/mytool"}}}
+
+	installed, err := syncTools(false, false)
+	if err != nil || installed != 1 {
+		t.Fatalf("syncTools() = %d, %v; want 1 installed", installed, err)
+	}
+	if !toolInstalled("mytool", cfg.Tools["mytool"]) {
+		t.Fatalf("tool not installed after sync")
+	}
+	data, err := os.ReadFile(filepath.Join(toolDir("mytool", cfg.Tools["mytool"]), toolBinary("mytool", cfg.Tools["mytool"])))
+	if err != nil || !strings.Contains(string(data), "echo mytool") {
+		t.Errorf("installed binary content = %q, %v", data, err)
+	}
+
+	// A second sync is served from the cache
+	if installed, err := syncTools(false, false); err != nil || installed != 0 || requests != 1 {
+		t.Errorf("cached syncTools() = %d, %v with %d requests; want 0 installed, 1 request", installed, err, requests)
+	}
+	if installed, err := syncTools(true, false); err != nil || installed != 1 || requests != 2 {
+		t.Errorf("forced syncTools() = %d, %v with %d requests; want 1 installed, 2 requests", installed, err, requests)
+	}
+}
+
+func TestSyncToolsDownloadFailure(t *testing.T) {
+	original, originalDefaults := cfg, userDefaults
+	defer func() { cfg, userDefaults = original, originalDefaults }()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	userDefaults = UserDefaults{CacheDir: t.TempDir()}
+	cfg = Config{Tools: map[string]Tool{"broken": {Version: "1.0", URL: server.URL + "/broken"}}}
+
+	if _, err := syncTools(false, false); err == nil {
+		t.Errorf("syncTools() expected error for 404 download")
+	}
+	if toolInstalled("broken", cfg.Tools["broken"]) {
+		t.Errorf("failed download left an installed binary behind")
+	}
+}
+
+func TestToolPathEnvIncludesTools(t *testing.T) {
+	original, originalDefaults := cfg, userDefaults
+	defer func() { cfg, userDefaults = original, originalDefaults }()
+
+	userDefaults = UserDefaults{CacheDir: t.TempDir()}
+	cfg = Config{Tools: map[string]Tool{"mytool": {Version: "1.2", URL: "https://example.com/mytool"}}}
+
+	dir := toolDir("mytool", cfg.Tools["mytool"])
+	for _, kv := range toolPathEnv() {
+		if k, v, _ := strings.Cut(kv, "="); strings.EqualFold(k, "PATH") {
+			if !strings.HasPrefix(v, dir) {
+				t.Errorf("PATH = %q, want it to start with %q", v, dir)
+			}
+			return
+		}
+	}
+	t.Errorf("toolPathEnv() has no PATH entry")
+}
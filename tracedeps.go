@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// traceDeps runs target commands under a system call tracer and reports
+// the project files they read without declaring them (build --trace-deps)
+var traceDeps bool
+
+// tracedSyscalls are the calls needed to tell which files a process tree
+// opened, and from which directory
+const tracedSyscalls = "open,openat,openat2,creat,execve,chdir,clone,clone3,fork,vfork"
+
+var (
+	traceLineRegex       = regexp.MustCompile(`^(?:(\d+)\s+)?(\w+)\((.*)\)\s+=\s+(-?\d+)`)
+	traceUnfinishedRegex = regexp.MustCompile(`^(\d+)\s+(.*) <unfinished \.\.\.>$`)
+	traceResumedRegex    = regexp.MustCompile(`^(\d+)\s+<\.\.\. \w+ resumed>(.*)$`)
+	traceQuotedRegex     = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+	traceWriteRegex      = regexp.MustCompile(`O_WRONLY|O_RDWR|O_CREAT|O_TRUNC`)
+)
+
+// tracerPath returns the tracer --trace-deps uses on this platform. Only
+// strace on Linux is supported; fs_usage and ETW need administrator rights
+// and trace the whole system
+func tracerPath() (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", fmt.Errorf("--trace-deps needs strace, which is only available on Linux")
+	}
+	path, err := exec.LookPath("strace")
+	if err != nil {
+		return "", fmt.Errorf("--trace-deps needs strace: %v", err)
+	}
+	return path, nil
+}
+
+// depTrace collects the files a target's commands read and wrote
+type depTrace struct {
+	tracer  string
+	dir     string // absolute project directory, or the sandbox
+	workDir string // directory below dir the commands start in
+	log     string
+	read    map[string]bool
+	written map[string]bool
+}
+
+// newDepTrace prepares tracing for commands started in workDir below dir,
+// or below the current directory when dir is empty; nil when tracing is
+// off or not possible
+func newDepTrace(dir, workDir string) *depTrace {
+	if !traceDeps {
+		return nil
+	}
+	tracer, err := tracerPath()
+	if err != nil {
+		return nil
+	}
+	if dir == "" {
+		if dir, err = os.Getwd(); err != nil {
+			return nil
+		}
+	}
+	log, err := os.CreateTemp("", "aura-trace-*.log")
+	if err != nil {
+		logWarn("cannot trace dependencies: %v", err)
+		return nil
+	}
+	_ = log.Close()
+	return &depTrace{tracer: tracer, dir: dir, workDir: workDir, log: log.Name(), read: map[string]bool{}, written: map[string]bool{}}
+}
+
+// wrap makes cmd run under the tracer
+func (d *depTrace) wrap(cmd *exec.Cmd) {
+	if d == nil || cmd.Err != nil {
+		return
+	}
+	args := []string{d.tracer, "-f", "-qq", "-e", "trace=" + tracedSyscalls, "-o", d.log, "--", cmd.Path}
+	cmd.Args = append(args, cmd.Args[1:]...)
+	cmd.Path = d.tracer
+}
+
+// collect adds the accesses logged by the last command
+func (d *depTrace) collect() {
+	if d == nil {
+		return
+	}
+	file, err := os.Open(d.log)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	parseTrace(file, filepath.Join(d.dir, d.workDir), d.read, d.written)
+}
+
+// close removes the trace log
+func (d *depTrace) close() {
+	if d != nil {
+		_ = os.Remove(d.log)
+	}
+}
+
+// parseTrace reads strace -f output, lines prefixed by the pid, adding the absolute paths of files
+// opened successfully to read or written. Relative paths are resolved
+// against each process's directory, which children inherit from the parent
+func parseTrace(r io.Reader, dir string, read, written map[string]bool) {
+	cwd := map[string]string{}
+	pending := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := traceUnfinishedRegex.FindStringSubmatch(line); m != nil {
+			pending[m[1]] = m[2]
+			continue
+		}
+		if m := traceResumedRegex.FindStringSubmatch(line); m != nil {
+			line = m[1] + " " + pending[m[1]] + m[2]
+			delete(pending, m[1])
+		}
+		m := traceLineRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		pid, call, args := m[1], m[2], m[3]
+		result, _ := strconv.Atoi(m[4])
+		if result < 0 {
+			continue
+		}
+		base, ok := cwd[pid]
+		if !ok {
+			base = dir
+		}
+
+		switch call {
+		case "clone", "clone3", "fork", "vfork":
+			// The child may already have logged calls, chdir included
+			if _, seen := cwd[m[4]]; !seen {
+				cwd[m[4]] = base
+			}
+			continue
+		}
+		q := traceQuotedRegex.FindStringSubmatch(args)
+		if q == nil {
+			continue
+		}
+		path := q[1]
+		if unquoted, err := strconv.Unquote(`"` + path + `"`); err == nil {
+			path = unquoted
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+		}
+		path = filepath.Clean(path)
+
+		switch call {
+		case "chdir":
+			cwd[pid] = path
+		case "execve":
+			read[path] = true
+		case "creat":
+			written[path] = true
+		case "open", "openat", "openat2":
+			if traceWriteRegex.MatchString(args) {
+				written[path] = true
+			} else if !written[path] {
+				read[path] = true
+			}
+		}
+	}
+}
+
+// undeclaredInputs lists the project files the target read that are not in
+// its sources, its outputs or the outputs and file deps of its dependencies
+func (d *depTrace) undeclaredInputs(name string, target *Target) []string {
+	if d == nil {
+		return nil
+	}
+	declared := map[string]bool{}
+	for _, file := range expandSources(target.Sources, name) {
+		declared[filepath.Clean(filepath.FromSlash(file))] = true
+	}
+	for _, file := range expandPatterns(target.Outputs, name) {
+		declared[filepath.Clean(filepath.FromSlash(file))] = true
+	}
+	seen := map[string]bool{}
+	var walk func(deps []string)
+	walk = func(deps []string) {
+		for _, dep := range deps {
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			if isFileDep(dep) {
+				declared[filepath.Clean(filepath.FromSlash(dep))] = true
+				continue
+			}
+			depTarget := GetTarget(dep)
+			for _, file := range expandPatterns(depTarget.Outputs, dep) {
+				declared[filepath.Clean(filepath.FromSlash(file))] = true
+			}
+			walk(depTarget.Deps)
+		}
+	}
+	walk(target.Deps)
+
+	ignore := loadIgnore()
+	var undeclared []string
+	for _, path := range sortedKeys(d.read) {
+		rel, err := filepath.Rel(d.dir, path)
+		if err != nil || !filepath.IsLocal(rel) || d.written[path] || declared[rel] {
+			continue
+		}
+		if rel == ".git" || strings.HasPrefix(filepath.ToSlash(rel), ".git/") || ignore.Ignored(rel, false) {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		undeclared = append(undeclared, filepath.ToSlash(rel))
+	}
+	return undeclared
+}
+
+// reportUndeclared warns about inputs a target read without declaring them
+func reportUndeclared(w io.Writer, name string, files []string) {
+	if len(files) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "[!] Warning: target '%s' read %d undeclared inputs; add them to sources or to a dependency's outputs:\n", name, len(files))
+	for _, file := range files {
+		fmt.Fprintf(w, "  %s\n", file)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== TRACEDEPS.GO UNIT TESTS =====
+
+func TestParseTrace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("strace logs hold POSIX paths")
+	}
+	root := "/work"
+	log := strings.Join([]string{
+		`100 execve("/bin/bash", ["bash", "-c", "make"], 0x7ffd /* 20 vars */) = 0`,
+		`100 openat(AT_FDCWD, "/etc/ld.so.cache", O_RDONLY|O_CLOEXEC) = 3`,
+		`100 openat(AT_FDCWD, "config.mk", O_RDONLY) = 3`,
+		`100 openat(AT_FDCWD, "missing.h", O_RDONLY) = -1 ENOENT (No such file or directory)`,
+		`100 clone(child_stack=NULL, flags=CLONE_CHILD_CLEARTID|SIGCHLD <unfinished ...>`,
+		`101 chdir("src") = 0`,
+		`100 <... clone resumed>, child_tidptr=0x7f) = 101`,
+		`101 openat(AT_FDCWD, "main.c", O_RDONLY) = 4`,
+		`101 openat(AT_FDCWD, "../out/main.o", O_WRONLY|O_CREAT|O_TRUNC, 0666) = 5`,
+		`101 openat(AT_FDCWD, "../out/main.o", O_RDONLY) = 5`,
+		`101 openat(AT_FDCWD, "we\"ird.h", O_RDONLY) = 6`,
+		`101 +++ exited with 0 +++`,
+		`100 --- SIGCHLD {si_signo=SIGCHLD} ---`,
+	}, "\n")
+
+	read, written := map[string]bool{}, map[string]bool{}
+	parseTrace(strings.NewReader(log), root, read, written)
+
+	expectRead := []string{"/bin/bash", "/etc/ld.so.cache", "/work/config.mk", "/work/src/main.c", `/work/src/we"ird.h`}
+	if got := sortedKeys(read); !reflect.DeepEqual(got, expectRead) {
+		t.Errorf("read = %v, expected %v", got, expectRead)
+	}
+	if !written["/work/out/main.o"] || len(written) != 1 {
+		t.Errorf("written = %v, expected only /work/out/main.o", written)
+	}
+}
+
+func TestUndeclaredInputs(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	chdirTemp(t)
+	writeFiles(t, map[string]string{
+		"src/main.c":  "int main() {}",
+		"config.mk":   "CC=cc",
+		"gen/api.h":   "// generated",
+		"build.sh":    "#!/bin/sh",
+		"notes.log":   "ignored",
+		".auraignore": "*.log\n",
+		"out/app":     "binary",
+		".git/HEAD":   "ref: refs/heads/main",
+	})
+	cfg = Config{Targets: map[string]Target{
+		"gen": {Outputs: []string{"gen/*.h"}, Run: []string{"echo gen"}},
+		"app": {Deps: []string{"gen", "build.sh"}, Sources: []string{"src/*.c"}, Outputs: []string{"out/app"}, Run: []string{"echo app"}},
+	}}
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trace := &depTrace{dir: dir, read: map[string]bool{}, written: map[string]bool{}}
+	for _, file := range []string{"src/main.c", "config.mk", "gen/api.h", "build.sh", "notes.log", "out/app", ".git/HEAD", "src", "gone.h", "tmp.txt"} {
+		trace.read[filepath.Join(dir, filepath.FromSlash(file))] = true
+	}
+	trace.read[filepath.Join(filepath.Dir(dir), "outside.h")] = true
+	trace.written[filepath.Join(dir, "tmp.txt")] = true
+	writeFiles(t, map[string]string{"tmp.txt": "scratch"})
+
+	target := GetTarget("app")
+	got := trace.undeclaredInputs("app", &target)
+	if expected := []string{"config.mk"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("undeclaredInputs() = %v, expected %v", got, expected)
+	}
+
+	var nilTrace *depTrace
+	if got := nilTrace.undeclaredInputs("app", &target); got != nil {
+		t.Errorf("nil trace should report nothing, got %v", got)
+	}
+}
+
+func TestReportUndeclared(t *testing.T) {
+	var buf bytes.Buffer
+	reportUndeclared(&buf, "app", nil)
+	if buf.Len() != 0 {
+		t.Errorf("no undeclared inputs should print nothing, got %q", buf.String())
+	}
+
+	reportUndeclared(&buf, "app", []string{"config.mk", "build.sh"})
+	out := buf.String()
+	for _, want := range []string{"target 'app' read 2 undeclared inputs", "  config.mk\n", "  build.sh\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDepTraceWrap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("strace is Linux only")
+	}
+	cmd := exec.Command("/bin/sh", "-c", "make")
+	trace := &depTrace{tracer: "/usr/bin/strace", log: "/tmp/trace.log"}
+	trace.wrap(cmd)
+
+	if cmd.Path != "/usr/bin/strace" {
+		t.Errorf("Path = %q, expected the tracer", cmd.Path)
+	}
+	expected := []string{"/usr/bin/strace", "-f", "-qq", "-e", "trace=" + tracedSyscalls, "-o", "/tmp/trace.log", "--", "/bin/sh", "-c", "make"}
+	if !reflect.DeepEqual(cmd.Args, expected) {
+		t.Errorf("Args = %v, expected %v", cmd.Args, expected)
+	}
+
+	var nilTrace *depTrace
+	plain := exec.Command("/bin/sh", "-c", "make")
+	nilTrace.wrap(plain)
+	if len(plain.Args) != 3 {
+		t.Errorf("nil trace should leave the command alone, got %v", plain.Args)
+	}
+}
@@ -1,19 +1,237 @@
 package main
 
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
 type Var string
 
 type Target struct {
-	Run             []string `yaml:"run"`
-	Deps            []string `yaml:"deps"`
-	Onerror         string   `yaml:"onerror"`
-	ContinueOnError bool     `yaml:"continue_on_error"`
+	Desc            string         `yaml:"desc"`
+	Run             []string       `yaml:"run"`
+	Deps            []string       `yaml:"deps"`
+	Sources         []string       `yaml:"sources"`
+	Outputs         []string       `yaml:"outputs"`
+	Watch           []string       `yaml:"watch"`
+	Probes          []string       `yaml:"probes"`
+	Vars            map[string]Var `yaml:"vars"`
+	Onerror         string         `yaml:"onerror"`
+	ContinueOnError bool           `yaml:"continue_on_error"`
+	Dangerous       bool           `yaml:"dangerous"`
+	MinInterval     string         `yaml:"min_interval"`
+	WarnAfter       string         `yaml:"warn_after"`
+	Retries         int            `yaml:"retries"`
+	Timeout         string         `yaml:"timeout"`
+	KillGrace       string         `yaml:"kill_grace"`
+	OutputFilter    []OutputFilter `yaml:"output_filter"`
+	RawOutput       bool           `yaml:"raw_output"`
+	ProblemMatchers []string       `yaml:"problem_matchers"`
+	OutputMode      OutputMode     `yaml:"output_mode"`
+	ReproArchive    *ReproArchive  `yaml:"repro_archive"`
+	Mutex           string         `yaml:"mutex"`
+	Sandbox         bool           `yaml:"sandbox"`
+	Shell           Var            `yaml:"shell"`
+	Params          []string       `yaml:"params"`
+	OutputEncoding  string         `yaml:"output_encoding"`
+	Platforms       stringList     `yaml:"platforms"`
+	CommandWrapper  Var            `yaml:"command_wrapper"`
+	Network         string         `yaml:"network"`
+
+	namespace string // prefix of the include or member that defined the target
+	dir       string // directory of the member that defined the target
+}
+
+// Profile is a named set of overrides selected with --profile
+type Profile struct {
+	Vars     map[string]Var `yaml:"vars"`
+	Prologue *ProfileStage  `yaml:"prologue"`
+	Epilogue *ProfileStage  `yaml:"epilogue"`
+}
+
+// ProfileStage changes the prologue or epilogue while its profile is active
+type ProfileStage struct {
+	Target `yaml:",inline"`
+	Mode   string `yaml:"mode"` // extend (default) or replace
+}
+
+// Tool is a binary the project needs, installed by `aura tools sync` from
+// either a go install package or a download URL
+type Tool struct {
+	Version string `yaml:"version"`
+	Go      string `yaml:"go"`
+	URL     string `yaml:"url"`
+}
+
+// Hooks are commands run at points of the build lifecycle
+type Hooks struct {
+	PostBuild   []string `yaml:"post_build"`
+	OnInterrupt []string `yaml:"on_interrupt"`
+}
+
+// WatchRule is one `aura watch` pipeline: a change to a file matching
+// Patterns rebuilds Targets once no further change arrived for Debounce
+type WatchRule struct {
+	Name     string   `yaml:"name"`
+	Patterns []string `yaml:"patterns"`
+	Targets  []string `yaml:"targets"`
+	Debounce string   `yaml:"debounce"`
+}
+
+// Notifier delivers build events (see notify.go) to a command, webhook or
+// mailbox; with no events listed it receives all of them
+type Notifier struct {
+	Events  []string       `yaml:"events"`
+	Command string         `yaml:"command"`
+	Webhook string         `yaml:"webhook"`
+	Email   *EmailNotifier `yaml:"email"`
+}
+
+// EmailNotifier mails build events through an SMTP server (see email.go).
+// Subject and body are text/template over the event; settings expand
+// variables, so the password can come from the environment
+type EmailNotifier struct {
+	SMTP     string   `yaml:"smtp"` // host:port
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Subject  string   `yaml:"subject"`
+	Body     string   `yaml:"body"`
+}
+
+// CacheConfig controls where the build cache is stored
+type CacheConfig struct {
+	Path string `yaml:"path"`
+	Hash string `yaml:"hash"` // sha256 (default), sha512 or xxhash
+
+	// Probes are commands printing tool versions, recorded with each build
+	Probes map[string]string `yaml:"probes"`
 }
 
 type Config struct {
-	ContinueOnError bool              `yaml:"continue_on_error"`
-	Includes        []string          `yaml:"include"`
-	Prologue        Target            `yaml:"prologue"`
-	Vars            map[string]Var    `yaml:"vars"`
-	Targets         map[string]Target `yaml:"targets"`
-	Epilogue        Target            `yaml:"epilogue"`
+	ContinueOnError bool               `yaml:"continue_on_error"`
+	EnvCacheTTL     string             `yaml:"env_cache_ttl"`
+	Parallel        int                `yaml:"parallel"`
+	KillGrace       string             `yaml:"kill_grace"`
+	Shell           Var                `yaml:"shell"`
+	OutputEncoding  string             `yaml:"output_encoding"`
+	CommandWrapper  Var                `yaml:"command_wrapper"`
+	Cache           CacheConfig        `yaml:"cache"`
+	ToolPaths       []string           `yaml:"toolpaths"`
+	Tools           map[string]Tool    `yaml:"tools"`
+	Hooks           Hooks              `yaml:"hooks"`
+	Watch           []WatchRule        `yaml:"watch"`
+	Notify          []Notifier         `yaml:"notify"`
+	Includes        []Include          `yaml:"include"`
+	Members         []string           `yaml:"members"`
+	Prologue        Target             `yaml:"prologue"`
+	Vars            map[string]Var     `yaml:"vars"`
+	Profiles        map[string]Profile `yaml:"profiles"`
+	Targets         map[string]Target  `yaml:"targets"`
+	Epilogue        Target             `yaml:"epilogue"`
+
+	memberStages []memberStage // prologues and epilogues of workspace members
+}
+
+// Include is an entry of include:, a path or a {path, prefix, when} mapping
+type Include struct {
+	Path   string      `yaml:"path"`
+	Prefix string      `yaml:"prefix"`
+	When   IncludeWhen `yaml:"when"`
+}
+
+// IncludeWhen limits an include to some platforms, profiles or
+// environments. Each condition lists alternatives; all conditions given
+// must hold
+type IncludeWhen struct {
+	OS      stringList `yaml:"os"`      // GOOS, GOARCH or os/arch
+	Profile stringList `yaml:"profile"` // active --profile
+	Env     stringList `yaml:"env"`     // NAME (set, not empty) or NAME=value
+}
+
+// stringList accepts a single string as a one-element list
+type stringList []string
+
+// UnmarshalYAML accepts `os: windows` as well as `os: [windows, darwin]`
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = stringList{node.Value}
+		return nil
+	}
+	var values []string
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+	*l = values
+	return nil
+}
+
+// UnmarshalYAML accepts a plain path as well as the mapping form
+func (i *Include) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&i.Path)
+	}
+	type plain Include
+	return node.Decode((*plain)(i))
+}
+
+// Known GOOS/GOARCH values accepted as platform keys
+var (
+	knownOS   = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js", "linux", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows"}
+	knownArch = []string{"386", "amd64", "arm", "arm64", "loong64", "mips", "mips64", "mips64le", "mipsle", "ppc64", "ppc64le", "riscv64", "s390x", "wasm"}
+)
+
+// UnmarshalYAML accepts either a plain value or a per-platform map such as
+// `CC: {windows: cl, linux: gcc, darwin: clang, default: cc}`, resolved
+// against the running OS/arch at load time.
+func (v *Var) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		var s string
+		if err := node.Decode(&s); err != nil {
+			return err
+		}
+		*v = Var(s)
+		return nil
+	case yaml.MappingNode:
+		var values map[string]string
+		if err := node.Decode(&values); err != nil {
+			return fmt.Errorf("line %d: platform variable values must be strings", node.Line)
+		}
+		for key := range values {
+			if !isPlatformKey(key) {
+				return fmt.Errorf("line %d: unknown platform %q (use an OS, an arch, os/arch or default)", node.Line, key)
+			}
+		}
+		*v = Var(selectPlatformValue(values, runtime.GOOS, runtime.GOARCH))
+		return nil
+	default:
+		return fmt.Errorf("line %d: variable must be a string or a platform map", node.Line)
+	}
+}
+
+// selectPlatformValue picks the most specific entry: os/arch, then os,
+// then arch, then default. Returns "" when nothing matches.
+func selectPlatformValue(values map[string]string, goos, goarch string) string {
+	for _, key := range []string{goos + "/" + goarch, goos, goarch, "default"} {
+		if val, ok := values[key]; ok {
+			return val
+		}
+	}
+	return ""
+}
+
+func isPlatformKey(key string) bool {
+	if key == "default" {
+		return true
+	}
+	if osName, arch, found := strings.Cut(key, "/"); found {
+		return slices.Contains(knownOS, osName) && slices.Contains(knownArch, arch)
+	}
+	return slices.Contains(knownOS, key) || slices.Contains(knownArch, key)
 }
@@ -3,7 +3,11 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 // ===== TYPES.GO UNIT TESTS =====
@@ -404,6 +408,65 @@ func TestTargetDependencyResolution(t *testing.T) {
 	}
 }
 
+func TestSelectPlatformValue(t *testing.T) {
+	values := map[string]string{
+		"linux/arm64": "aarch64-linux-gnu-gcc",
+		"linux":       "gcc",
+		"windows":     "cl",
+		"arm64":       "clang-arm",
+		"default":     "cc",
+	}
+
+	tests := []struct {
+		goos, goarch string
+		expected     string
+	}{
+		{"linux", "arm64", "aarch64-linux-gnu-gcc"},
+		{"linux", "amd64", "gcc"},
+		{"windows", "amd64", "cl"},
+		{"darwin", "arm64", "clang-arm"},
+		{"freebsd", "amd64", "cc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+tt.goarch, func(t *testing.T) {
+			if got := selectPlatformValue(values, tt.goos, tt.goarch); got != tt.expected {
+				t.Errorf("selectPlatformValue(%s/%s) = %q, want %q", tt.goos, tt.goarch, got, tt.expected)
+			}
+		})
+	}
+
+	if got := selectPlatformValue(map[string]string{"plan9": "x"}, "linux", "amd64"); got != "" {
+		t.Errorf("selectPlatformValue() without match = %q, want empty", got)
+	}
+}
+
+func TestVarUnmarshalPlatformMap(t *testing.T) {
+	content := "CC:\n  " + runtime.GOOS + ": native\n  default: other\nOUT: app\n"
+
+	var vars map[string]Var
+	if err := yaml.Unmarshal([]byte(content), &vars); err != nil {
+		t.Fatalf("yaml.Unmarshal() unexpected error: %v", err)
+	}
+	if vars["CC"] != "native" {
+		t.Errorf("CC = %q, want %q", vars["CC"], "native")
+	}
+	if vars["OUT"] != "app" {
+		t.Errorf("OUT = %q, want %q", vars["OUT"], "app")
+	}
+
+	errorCases := map[string]string{
+		"unknown platform": "CC:\n  windoze: cl\n",
+		"must be a string": "CC:\n  - gcc\n",
+	}
+	for errPart, bad := range errorCases {
+		err := yaml.Unmarshal([]byte(bad), &vars)
+		if err == nil || !strings.Contains(err.Error(), errPart) {
+			t.Errorf("yaml.Unmarshal(%q) error = %v, want it to contain %q", bad, err, errPart)
+		}
+	}
+}
+
 // ===== BENCHMARK TESTS =====
 
 func BenchmarkGetTargetSimple(b *testing.B) {
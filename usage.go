@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// resourceUsage is what the processes of a command or target used: CPU
+// time (user and system) and the peak memory of the largest process,
+// zero where the platform does not report it
+type resourceUsage struct {
+	CPU     time.Duration
+	PeakRSS int64 // bytes
+}
+
+// add combines the usage of two commands run one after the other: CPU
+// time adds up, memory peaks do not
+func (u resourceUsage) add(other resourceUsage) resourceUsage {
+	return resourceUsage{CPU: u.CPU + other.CPU, PeakRSS: max(u.PeakRSS, other.PeakRSS)}
+}
+
+// targetUsage sums the usage of the commands of each running target
+var targetUsage struct {
+	sync.Mutex
+	byTarget map[string]resourceUsage
+}
+
+// recordUsage adds a command's usage to its target's
+func recordUsage(target string, usage resourceUsage) {
+	targetUsage.Lock()
+	defer targetUsage.Unlock()
+	if targetUsage.byTarget == nil {
+		targetUsage.byTarget = map[string]resourceUsage{}
+	}
+	targetUsage.byTarget[target] = targetUsage.byTarget[target].add(usage)
+}
+
+// takeUsage returns what a target's commands used since the last call
+func takeUsage(target string) resourceUsage {
+	targetUsage.Lock()
+	defer targetUsage.Unlock()
+	usage := targetUsage.byTarget[target]
+	delete(targetUsage.byTarget, target)
+	return usage
+}
+
+// formatMemory shows a byte count in binary units, - when unknown
+func formatMemory(n int64) string {
+	const unit = 1024
+	if n <= 0 {
+		return "-"
+	}
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value, suffix := float64(n)/unit, "KiB"
+	for _, next := range []string{"MiB", "GiB", "TiB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f%s", value, suffix)
+}
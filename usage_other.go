@@ -0,0 +1,27 @@
+//go:build !unix && !windows
+
+package main
+
+import (
+	"os/exec"
+)
+
+// usageTracker reads the CPU time of a command from its exit status; the
+// platform does not report memory
+type usageTracker struct{}
+
+// trackUsage starts measuring a started command
+func trackUsage(cmd *exec.Cmd) *usageTracker {
+	return &usageTracker{}
+}
+
+// finish returns what the command used once it exited
+func (t *usageTracker) finish(cmd *exec.Cmd) resourceUsage {
+	if cmd.ProcessState == nil {
+		return resourceUsage{}
+	}
+	return resourceUsage{CPU: cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()}
+}
+
+// close releases what tracking held
+func (t *usageTracker) close() {}
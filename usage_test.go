@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// ===== USAGE.GO UNIT TESTS =====
+
+func TestResourceUsageAdd(t *testing.T) {
+	got := resourceUsage{CPU: time.Second, PeakRSS: 100}.add(resourceUsage{CPU: 2 * time.Second, PeakRSS: 50})
+	if expected := (resourceUsage{CPU: 3 * time.Second, PeakRSS: 100}); got != expected {
+		t.Errorf("add() = %+v, expected %+v", got, expected)
+	}
+}
+
+func TestTargetUsage(t *testing.T) {
+	recordUsage("usage-lib", resourceUsage{CPU: time.Second, PeakRSS: 10})
+	recordUsage("usage-lib", resourceUsage{CPU: time.Second, PeakRSS: 30})
+	recordUsage("usage-app", resourceUsage{CPU: time.Second})
+
+	if got := takeUsage("usage-lib"); got != (resourceUsage{CPU: 2 * time.Second, PeakRSS: 30}) {
+		t.Errorf("takeUsage(usage-lib) = %+v, expected both commands", got)
+	}
+	if got := takeUsage("usage-lib"); got != (resourceUsage{}) {
+		t.Errorf("takeUsage(usage-lib) again = %+v, expected nothing", got)
+	}
+	takeUsage("usage-app")
+}
+
+func TestFormatMemory(t *testing.T) {
+	tests := []struct {
+		bytes    int64
+		expected string
+	}{
+		{0, "-"},
+		{512, "512B"},
+		{1536, "1.5KiB"},
+		{64 << 20, "64.0MiB"},
+		{3 << 30, "3.0GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatMemory(tt.bytes); got != tt.expected {
+			t.Errorf("formatMemory(%d) = %q, expected %q", tt.bytes, got, tt.expected)
+		}
+	}
+}
+
+func TestRunMeasured(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+
+	// The shell's child is measured too
+	cmd := exec.Command("/bin/sh", "-c", "i=0; while [ $i -lt 20000 ]; do i=$((i+1)); done; true")
+	_, usage, err := runMeasured(context.Background(), cmd, commandLimits{grace: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.CPU <= 0 {
+		t.Errorf("runMeasured() usage = %+v, expected CPU time", usage)
+	}
+}
@@ -0,0 +1,59 @@
+//go:build unix
+
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"syscall"
+)
+
+// usageTracker reads what a command used from its wait status, which
+// covers the processes it started and waited for too
+type usageTracker struct{}
+
+// trackUsage starts measuring a started command
+func trackUsage(cmd *exec.Cmd) *usageTracker {
+	return &usageTracker{}
+}
+
+// finish returns what the command used once it exited
+func (t *usageTracker) finish(cmd *exec.Cmd) resourceUsage {
+	state := cmd.ProcessState
+	if state == nil {
+		return resourceUsage{}
+	}
+	usage := resourceUsage{CPU: state.UserTime() + state.SystemTime()}
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		usage.PeakRSS = maxRSSBytes(int64(rusage.Maxrss))
+		// Linux starts a child's ru_maxrss at the peak of the process it was
+		// forked from, aura here. A figure no higher than aura's own peak is
+		// aura's, so the command's is unknown: it only used less than that
+		if runtime.GOOS == "linux" && usage.PeakRSS <= ownPeakRSS() {
+			usage.PeakRSS = 0
+		}
+	}
+	return usage
+}
+
+// ownPeakRSS returns the most memory aura itself used so far in bytes, zero
+// when unknown
+func ownPeakRSS() int64 {
+	var self syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &self); err != nil {
+		return 0
+	}
+	return maxRSSBytes(int64(self.Maxrss))
+}
+
+// maxRSSBytes converts ru_maxrss, in bytes on Apple systems and kilobytes
+// elsewhere, to bytes
+func maxRSSBytes(maxrss int64) int64 {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "ios" {
+		return maxrss
+	}
+	return maxrss * 1024
+}
+
+// close releases what tracking held
+func (t *usageTracker) close() {}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// ===== USAGE_UNIX.GO UNIT TESTS =====
+
+func TestRunMeasuredMemory(t *testing.T) {
+	own := ownPeakRSS()
+	if own <= 0 {
+		t.Skip("cannot read aura's own memory")
+	}
+
+	_, usage, err := runMeasured(context.Background(), exec.Command("true"), commandLimits{grace: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.PeakRSS >= own {
+		t.Errorf("runMeasured(true) PeakRSS = %d, expected less than aura's own %d", usage.PeakRSS, own)
+	}
+
+	// A shell holding a string larger than aura reports its own peak
+	big := own/1024/1024 + 32
+	cmd := exec.Command("/bin/sh", "-c", fmt.Sprintf("x=$(head -c %d /dev/zero | tr '\\0' a); true", big<<20))
+	if _, usage, err = runMeasured(context.Background(), cmd, commandLimits{grace: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	if usage.PeakRSS < big<<20 {
+		t.Errorf("runMeasured() PeakRSS = %d, expected at least %d", usage.PeakRSS, big<<20)
+	}
+}
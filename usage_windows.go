@@ -0,0 +1,114 @@
+//go:build windows
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	kernel32DLL               = syscall.NewLazyDLL("kernel32.dll")
+	createJobObject           = kernel32DLL.NewProc("CreateJobObjectW")
+	assignProcessToJobObject  = kernel32DLL.NewProc("AssignProcessToJobObject")
+	queryInformationJobObject = kernel32DLL.NewProc("QueryInformationJobObject")
+)
+
+// Job object information classes and the process rights assigning needs
+const (
+	jobObjectBasicAccountingInformation = 1
+	jobObjectExtendedLimitInformation   = 9
+	processSetQuota                     = 0x0100
+	processTerminate                    = 0x0001
+)
+
+// jobAccounting is JOBOBJECT_BASIC_ACCOUNTING_INFORMATION; times are in
+// 100ns units
+type jobAccounting struct {
+	TotalUserTime             int64
+	TotalKernelTime           int64
+	ThisPeriodTotalUserTime   int64
+	ThisPeriodTotalKernelTime int64
+	TotalPageFaultCount       uint32
+	TotalProcesses            uint32
+	ActiveProcesses           uint32
+	TotalTerminatedProcesses  uint32
+}
+
+// jobExtendedLimits is JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+type jobExtendedLimits struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+	IoCounters              [6]uint64
+	ProcessMemoryLimit      uintptr
+	JobMemoryLimit          uintptr
+	PeakProcessMemoryUsed   uintptr
+	PeakJobMemoryUsed       uintptr
+}
+
+// usageTracker puts a command in a job object, which accounts for the
+// processes it starts as well. Children started before the command joined
+// the job are missed; without a job only the command's own CPU time is
+// known
+type usageTracker struct {
+	job syscall.Handle
+}
+
+// trackUsage starts measuring a started command
+func trackUsage(cmd *exec.Cmd) *usageTracker {
+	t := &usageTracker{}
+	job, _, _ := createJobObject.Call(0, 0)
+	if job == 0 {
+		return t
+	}
+	process, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(cmd.Process.Pid))
+	if err != nil {
+		_ = syscall.CloseHandle(syscall.Handle(job))
+		return t
+	}
+	defer func() { _ = syscall.CloseHandle(process) }()
+	if ok, _, _ := assignProcessToJobObject.Call(job, uintptr(process)); ok == 0 {
+		_ = syscall.CloseHandle(syscall.Handle(job))
+		return t
+	}
+	t.job = syscall.Handle(job)
+	return t
+}
+
+// finish returns what the command used once it exited: the job's CPU
+// time and the peak memory committed by one of its processes
+func (t *usageTracker) finish(cmd *exec.Cmd) resourceUsage {
+	var usage resourceUsage
+	if cmd.ProcessState != nil {
+		usage.CPU = cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()
+	}
+	if t.job == 0 {
+		return usage
+	}
+	var accounting jobAccounting
+	if ok, _, _ := queryInformationJobObject.Call(uintptr(t.job), jobObjectBasicAccountingInformation, uintptr(unsafe.Pointer(&accounting)), unsafe.Sizeof(accounting), 0); ok != 0 {
+		usage.CPU = time.Duration(accounting.TotalUserTime+accounting.TotalKernelTime) * 100
+	}
+	var limits jobExtendedLimits
+	if ok, _, _ := queryInformationJobObject.Call(uintptr(t.job), jobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&limits)), unsafe.Sizeof(limits), 0); ok != 0 {
+		usage.PeakRSS = int64(limits.PeakProcessMemoryUsed)
+	}
+	return usage
+}
+
+// close releases the job object
+func (t *usageTracker) close() {
+	if t.job != 0 {
+		_ = syscall.CloseHandle(t.job)
+		t.job = 0
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UserDefaults holds per-user settings from ~/.config/aura/config.yaml.
+// Project configs and CLI flags take precedence over every value here.
+type UserDefaults struct {
+	Parallel int    `yaml:"parallel"`
+	CacheDir string `yaml:"cache_dir"`
+	Shell    string `yaml:"shell"`
+}
+
+var userDefaults UserDefaults
+
+// userConfigPath returns the location of the user defaults file, honouring
+// AURA_USER_CONFIG and XDG_CONFIG_HOME
+func userConfigPath() string {
+	if path := os.Getenv("AURA_USER_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "aura", "config.yaml")
+}
+
+// loadUserDefaults reads the user defaults file; a missing file is not an error
+func loadUserDefaults(path string) (UserDefaults, error) {
+	var defaults UserDefaults
+	if path == "" {
+		return defaults, nil
+	}
+
+	// #nosec G304 - Path comes from the user's own config directory
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return defaults, nil
+	}
+	if err != nil {
+		return defaults, err
+	}
+
+	if err := yaml.Unmarshal(data, &defaults); err != nil {
+		return defaults, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if defaults.Parallel < 0 {
+		return defaults, fmt.Errorf("%s: parallel must not be negative", path)
+	}
+	if strings.HasPrefix(defaults.CacheDir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			defaults.CacheDir = filepath.Join(home, defaults.CacheDir[2:])
+		}
+	}
+	return defaults, nil
+}
+
+// effectiveParallel resolves the job count: CLI flag > project config > user defaults > 1
+func effectiveParallel(flagValue int, flagChanged bool) int {
+	switch {
+	case flagChanged:
+		return flagValue
+	case cfg.Parallel > 0:
+		return cfg.Parallel
+	case userDefaults.Parallel > 0:
+		return userDefaults.Parallel
+	}
+	return 1
+}
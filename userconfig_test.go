@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ===== USERCONFIG.GO UNIT TESTS =====
+
+func TestLoadUserDefaults(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("no home directory: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected UserDefaults
+		wantErr  bool
+	}{
+		{
+			name:     "all keys",
+			content:  "parallel: 4\ncache_dir: /tmp/aura\nshell: zsh\n",
+			expected: UserDefaults{Parallel: 4, CacheDir: "/tmp/aura", Shell: "zsh"},
+		},
+		{
+			name:     "home expansion",
+			content:  "cache_dir: ~/.cache/aura\n",
+			expected: UserDefaults{CacheDir: filepath.Join(home, ".cache/aura")},
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			expected: UserDefaults{},
+		},
+		{
+			name:    "negative parallel",
+			content: "parallel: -2\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			content: "parallel: [\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("Failed to write user config: %v", err)
+			}
+
+			got, err := loadUserDefaults(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("loadUserDefaults() expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadUserDefaults() unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("loadUserDefaults() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadUserDefaultsMissingFile(t *testing.T) {
+	got, err := loadUserDefaults(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Errorf("loadUserDefaults() unexpected error for missing file: %v", err)
+	}
+	if got != (UserDefaults{}) {
+		t.Errorf("loadUserDefaults() = %+v, want zero value", got)
+	}
+}
+
+func TestEffectiveParallel(t *testing.T) {
+	originalCfg, originalDefaults := cfg, userDefaults
+	defer func() { cfg, userDefaults = originalCfg, originalDefaults }()
+
+	tests := []struct {
+		name        string
+		flagValue   int
+		flagChanged bool
+		project     int
+		user        int
+		expected    int
+	}{
+		{"nothing set", 1, false, 0, 0, 1},
+		{"user default", 1, false, 0, 8, 8},
+		{"project beats user", 1, false, 2, 8, 2},
+		{"flag beats everything", 3, true, 2, 8, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Parallel: tt.project}
+			userDefaults = UserDefaults{Parallel: tt.user}
+			if got := effectiveParallel(tt.flagValue, tt.flagChanged); got != tt.expected {
+				t.Errorf("effectiveParallel() = %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestShellCommandFlag(t *testing.T) {
+	tests := map[string]string{
+		"bash":           "-c",
+		"/usr/bin/zsh":   "-c",
+		"cmd":            "/C",
+		"cmd.exe":        "/C",
+		"pwsh":           "-Command",
+		"powershell.exe": "-Command",
+	}
+	for shell, expected := range tests {
+		if got := shellCommandFlag(shell); got != expected {
+			t.Errorf("shellCommandFlag(%q) = %q, want %q", shell, got, expected)
+		}
+	}
+}
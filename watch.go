@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultWatchPatterns are watched for -t targets that declare neither
+// watch nor sources patterns
+var defaultWatchPatterns = []string{"*.go", "*.yaml", "*.yml", "*.toml", "*.json", "*.md", "*.txt"}
+
+// minInterval returns a target's min_interval; loadConfig has validated it
+func minInterval(name string) time.Duration {
+	d, _ := time.ParseDuration(GetTarget(name).MinInterval)
+	return d
+}
+
+// validateWatch checks min_interval values and the watch: rules
+func validateWatch() error {
+	for _, name := range sortedKeys(cfg.Targets) {
+		if value := cfg.Targets[name].MinInterval; value != "" {
+			if d, err := time.ParseDuration(value); err != nil || d < 0 {
+				return fmt.Errorf("invalid min_interval '%s' for target '%s'", value, name)
+			}
+		}
+		for _, pattern := range cfg.Targets[name].Watch {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid watch pattern '%s' for target '%s'", pattern, name)
+			}
+		}
+	}
+	for i, rule := range cfg.Watch {
+		if len(rule.Patterns) == 0 || len(rule.Targets) == 0 {
+			return fmt.Errorf("watch rule %d needs patterns and targets", i+1)
+		}
+		if rule.Debounce != "" {
+			if d, err := time.ParseDuration(rule.Debounce); err != nil || d < 0 {
+				return fmt.Errorf("invalid debounce '%s' in watch rule %d", rule.Debounce, i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// watchThrottle decides when watch-triggered targets run. Targets without
+// min_interval run on every change; the others run at most once per
+// interval, and a change during the cooldown runs them once it is over.
+type watchThrottle struct {
+	lastRun map[string]time.Time
+	pending []string
+}
+
+func newWatchThrottle() *watchThrottle {
+	return &watchThrottle{lastRun: map[string]time.Time{}}
+}
+
+// trigger queues targets after a file change
+func (w *watchThrottle) trigger(names []string) {
+	for _, name := range names {
+		if !slices.Contains(w.pending, name) {
+			w.pending = append(w.pending, name)
+		}
+	}
+}
+
+// remaining is how long a target still has to cool down at now
+func (w *watchThrottle) remaining(name string, now time.Time) time.Duration {
+	last, ok := w.lastRun[name]
+	if !ok {
+		return 0
+	}
+	return max(0, minInterval(name)-now.Sub(last))
+}
+
+// due takes the queued targets that may run at now, in trigger order
+func (w *watchThrottle) due(now time.Time) []string {
+	var ready, waiting []string
+	for _, name := range w.pending {
+		if w.remaining(name, now) > 0 {
+			waiting = append(waiting, name)
+			continue
+		}
+		ready = append(ready, name)
+		w.lastRun[name] = now
+	}
+	w.pending = waiting
+	return ready
+}
+
+// watchPipeline is one set of watched patterns and the targets they rebuild
+type watchPipeline struct {
+	name       string
+	patterns   []string
+	targets    []string
+	watches    map[string][]string // patterns of each target; targets without any rebuild on every change
+	debounce   time.Duration
+	singleFile bool
+	throttle   *watchThrottle
+	seen       map[string]time.Time // modification times from the last scan
+	changed    []string             // files changed while debouncing
+	changedAt  time.Time            // last change still waiting for its debounce
+	queued     map[string][]string  // single-file mode: files each target still has to run for
+}
+
+// watchJob is one target run; file is set in single-file mode
+type watchJob struct {
+	target string
+	file   string
+}
+
+// watchPatterns returns the patterns that rebuild a target: its watch
+// patterns, else its sources; nil when it declares neither
+func watchPatterns(name string) []string {
+	target := cfg.Targets[name]
+	patterns := target.Watch
+	if len(patterns) == 0 {
+		patterns = target.Sources
+	}
+	var expanded []string
+	for _, pattern := range patterns {
+		expanded = append(expanded, ParseVars(pattern, name))
+	}
+	return expanded
+}
+
+// watchedTargets returns the targets that declare watch patterns
+func watchedTargets() []string {
+	var names []string
+	for _, name := range sortedKeys(cfg.Targets) {
+		if len(cfg.Targets[name].Watch) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// newWatchPipelines returns a single pipeline for the given targets, each
+// rebuilt by its own patterns. Without targets it returns one pipeline per
+// watch: rule, plus one for the targets that declare watch patterns
+func newWatchPipelines(targets []string, singleFile bool) []*watchPipeline {
+	newPipeline := func(name string, patterns, targets []string, debounce time.Duration) *watchPipeline {
+		return &watchPipeline{
+			name:       name,
+			patterns:   patterns,
+			targets:    targets,
+			debounce:   debounce,
+			singleFile: singleFile,
+			throttle:   newWatchThrottle(),
+			seen:       map[string]time.Time{},
+			queued:     map[string][]string{},
+		}
+	}
+
+	// Each target only rebuilds on changes to its own patterns
+	newTargetsPipeline := func(name string, targets []string) *watchPipeline {
+		var patterns []string
+		watches := map[string][]string{}
+		for _, target := range targets {
+			own := watchPatterns(target)
+			if own == nil {
+				own = defaultWatchPatterns
+			} else {
+				watches[target] = own
+			}
+			for _, pattern := range own {
+				if !slices.Contains(patterns, pattern) {
+					patterns = append(patterns, pattern)
+				}
+			}
+		}
+		p := newPipeline(name, patterns, targets, 0)
+		p.watches = watches
+		return p
+	}
+	if len(targets) > 0 {
+		return []*watchPipeline{newTargetsPipeline("", targets)}
+	}
+
+	var pipelines []*watchPipeline
+	for _, rule := range cfg.Watch {
+		name := rule.Name
+		if name == "" {
+			name = strings.Join(rule.Targets, ",")
+		}
+		debounce, _ := time.ParseDuration(rule.Debounce)
+		pipelines = append(pipelines, newPipeline(name, rule.Patterns, rule.Targets, debounce))
+	}
+	if watched := watchedTargets(); len(watched) > 0 {
+		pipelines = append(pipelines, newTargetsPipeline("targets", watched))
+	}
+	return pipelines
+}
+
+// matching returns the changed files that rebuild target
+func (p *watchPipeline) matching(target string, files []string) []string {
+	patterns, ok := p.watches[target]
+	if !ok {
+		return files
+	}
+	var matched []string
+	for _, file := range files {
+		for _, pattern := range patterns {
+			segments := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+			if matchSegments(segments, strings.Split(file, "/")) {
+				matched = append(matched, file)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// poll compares a scan of the watched files with the previous one and
+// returns what to run now; changed reports whether this poll saw a change
+func (p *watchPipeline) poll(now time.Time, files map[string]time.Time) (jobs []watchJob, changed bool) {
+	for _, file := range sortedKeys(files) {
+		if prev, ok := p.seen[file]; !ok || files[file].After(prev) {
+			changed = true
+			if !slices.Contains(p.changed, file) {
+				p.changed = append(p.changed, file)
+			}
+		}
+	}
+	p.seen = files
+	if changed {
+		p.changedAt = now
+	}
+
+	if !p.changedAt.IsZero() && now.Sub(p.changedAt) >= p.debounce {
+		p.changedAt = time.Time{}
+		for _, target := range p.targets {
+			files := p.matching(target, p.changed)
+			if len(files) == 0 {
+				continue
+			}
+			p.throttle.trigger([]string{target})
+			if !p.singleFile {
+				continue
+			}
+			for _, file := range files {
+				if !slices.Contains(p.queued[target], file) {
+					p.queued[target] = append(p.queued[target], file)
+				}
+			}
+		}
+		p.changed = nil
+	}
+
+	for _, target := range p.throttle.due(now) {
+		if !p.singleFile {
+			jobs = append(jobs, watchJob{target: target})
+			continue
+		}
+		for _, file := range p.queued[target] {
+			jobs = append(jobs, watchJob{target: target, file: file})
+		}
+		delete(p.queued, target)
+	}
+	return jobs, changed
+}
+
+// watch polls the pipeline's files forever, rebuilding on changes and
+// printing a status line per rebuild
+func (p *watchPipeline) watch(interval time.Duration, verbose bool) {
+	label := ""
+	if p.name != "" {
+		label = p.name + ": "
+	}
+
+	// Initial scan
+	p.seen = scanModTimes(p.patterns)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var now time.Time
+		select {
+		case <-runCtx.Done():
+			return
+		case now = <-ticker.C:
+		}
+		jobs, changed := p.poll(now, scanModTimes(p.patterns))
+		if changed {
+			logInfo("watch_change", "", "[%s] %sFile changes detected, rebuilding...", now.Format("15:04:05"), label)
+		}
+
+		// Rebuild targets, holding back those still in their min_interval
+		failed := false
+		for _, job := range jobs {
+			var params map[string]string
+			if job.file != "" {
+				params = map[string]string{"CHANGED_FILE": job.file}
+			}
+			if err := runTargetWithParams(job.target, params, verbose, false); err != nil {
+				logEvent{Level: levelError, Event: "watch_rebuild", Target: job.target, Error: err.Error()}.emit(fmt.Sprintf("%s '%s': %v\n", paint(os.Stderr, colorRed, "Error rebuilding target"), job.target, err))
+				failed = true
+			}
+		}
+		if changed {
+			for _, target := range p.throttle.pending {
+				logInfo("watch_throttle", target, "  %s%s: min_interval not reached, runs in %s", label, target, p.throttle.remaining(target, now).Round(time.Second))
+			}
+		}
+
+		switch {
+		case len(jobs) > 0 && failed:
+			logEvent{Level: levelError, Event: "watch_done", Message: "rebuild failed"}.emit(fmt.Sprintf("[%s] %s%s after %s\n", time.Now().Format("15:04:05"), label, paint(os.Stderr, colorRed, "✗ Rebuild failed"), time.Since(now).Round(time.Millisecond)))
+		case len(jobs) > 0:
+			logInfo("watch_done", "", "[%s] %sRebuild completed in %s", time.Now().Format("15:04:05"), label, time.Since(now).Round(time.Millisecond))
+		case !changed && verbose:
+			logDebug("watch_idle", "", "[%s] %sNo changes detected", now.Format("15:04:05"), label)
+		}
+	}
+}
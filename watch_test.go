@@ -0,0 +1,222 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// ===== WATCH.GO UNIT TESTS =====
+
+func TestValidateMinIntervals(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name        string
+		value       string
+		expectError bool
+	}{
+		{"unset", "", false},
+		{"valid", "30s", false},
+		{"not a duration", "often", true},
+		{"negative", "-1m", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Targets: map[string]Target{"test": {MinInterval: tt.value}}}
+			err := validateWatch()
+			if tt.expectError && err == nil {
+				t.Error("validateWatch() expected error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("validateWatch() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestWatchThrottle(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{Targets: map[string]Target{
+		"lint": {Run: []string{"golint"}},
+		"test": {Run: []string{"go test ./..."}, MinInterval: "1m"},
+	}}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := newWatchThrottle()
+
+	steps := []struct {
+		offset   time.Duration
+		changed  bool
+		expected []string
+	}{
+		{0, true, []string{"lint", "test"}},         // first run is never held back
+		{10 * time.Second, true, []string{"lint"}},  // test is cooling down
+		{20 * time.Second, true, []string{"lint"}},  // still one pending test run
+		{50 * time.Second, false, nil},              // nothing new, test still waiting
+		{60 * time.Second, false, []string{"test"}}, // cooldown over: the held change runs
+		{90 * time.Second, false, nil},              // nothing pending
+		{130 * time.Second, true, []string{"lint", "test"}},
+	}
+
+	for _, step := range steps {
+		if step.changed {
+			throttle.trigger([]string{"lint", "test"})
+		}
+		if got := throttle.due(start.Add(step.offset)); !slices.Equal(got, step.expected) {
+			t.Errorf("due() at +%s = %v, expected %v", step.offset, got, step.expected)
+		}
+	}
+}
+
+func TestValidateWatchRules(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name        string
+		rule        WatchRule
+		expectError bool
+	}{
+		{"valid", WatchRule{Patterns: []string{"**/*.go"}, Targets: []string{"server"}, Debounce: "200ms"}, false},
+		{"no patterns", WatchRule{Targets: []string{"server"}}, true},
+		{"no targets", WatchRule{Patterns: []string{"*.go"}}, true},
+		{"bad debounce", WatchRule{Patterns: []string{"*.go"}, Targets: []string{"server"}, Debounce: "soon"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg = Config{Watch: []WatchRule{tt.rule}}
+			err := validateWatch()
+			if tt.expectError && err == nil {
+				t.Error("validateWatch() expected error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("validateWatch() unexpected error: %v", err)
+			}
+		})
+	}
+
+	cfg = Config{Targets: map[string]Target{"api": {Watch: []string{"api/[*.go"}}}}
+	if err := validateWatch(); err == nil {
+		t.Error("validateWatch() expected error for a malformed target watch pattern")
+	}
+}
+
+func TestNewWatchPipelines(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	cfg = Config{Watch: []WatchRule{
+		{Name: "server", Patterns: []string{"**/*.go"}, Targets: []string{"server"}, Debounce: "300ms"},
+		{Patterns: []string{"**/*.scss"}, Targets: []string{"css", "assets"}},
+	}}
+
+	pipelines := newWatchPipelines(nil, false)
+	if len(pipelines) != 2 {
+		t.Fatalf("newWatchPipelines() returned %d pipelines, expected 2", len(pipelines))
+	}
+	if pipelines[0].name != "server" || pipelines[0].debounce != 300*time.Millisecond {
+		t.Errorf("first pipeline = %+v", pipelines[0])
+	}
+	if pipelines[1].name != "css,assets" {
+		t.Errorf("unnamed rule should be named after its targets, got %q", pipelines[1].name)
+	}
+
+	// -t targets replace the rules
+	pipelines = newWatchPipelines([]string{"build"}, false)
+	if len(pipelines) != 1 || !slices.Equal(pipelines[0].patterns, defaultWatchPatterns) || pipelines[0].name != "" {
+		t.Errorf("newWatchPipelines(build) = %+v", pipelines)
+	}
+}
+
+func TestWatchPipelineTargetPatterns(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{
+		"api":  {Watch: []string{"api/**/*.go"}, Sources: []string{"**/*"}},
+		"css":  {Sources: []string{"assets/*.scss"}},
+		"docs": {Run: []string{"mkdocs build"}},
+		"lint": {Watch: []string{"*.go"}},
+	}}
+
+	pipelines := newWatchPipelines(nil, false)
+	if len(pipelines) != 1 || pipelines[0].name != "targets" || !slices.Equal(pipelines[0].targets, []string{"api", "lint"}) {
+		t.Fatalf("newWatchPipelines() = %+v, expected the targets with watch patterns", pipelines)
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := newWatchPipelines([]string{"api", "css", "docs"}, true)[0]
+	if expected := []string{"api/**/*.go", "assets/*.scss", "*.go", "*.yaml", "*.yml", "*.toml", "*.json", "*.md", "*.txt"}; !slices.Equal(p.patterns, expected) {
+		t.Errorf("patterns = %v, expected %v", p.patterns, expected)
+	}
+	p.seen = map[string]time.Time{}
+
+	// docs declares no patterns and rebuilds on every change
+	jobs, _ := p.poll(start, map[string]time.Time{"api/v1/user.go": start, "assets/site.scss": start})
+	expected := []watchJob{
+		{target: "api", file: "api/v1/user.go"},
+		{target: "css", file: "assets/site.scss"},
+		{target: "docs", file: "api/v1/user.go"},
+		{target: "docs", file: "assets/site.scss"},
+	}
+	if !slices.Equal(jobs, expected) {
+		t.Errorf("poll() = %v, expected %v", jobs, expected)
+	}
+}
+
+func TestWatchPipelineDebounce(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{"server": {Run: []string{"go build"}}}}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := newWatchPipelines([]string{"server"}, false)[0]
+	p.debounce = 2 * time.Second
+	p.seen = map[string]time.Time{"main.go": start}
+
+	steps := []struct {
+		offset   time.Duration
+		modified time.Duration // modification of main.go, relative to start
+		changed  bool
+		expected []watchJob
+	}{
+		{1 * time.Second, 1 * time.Second, true, nil},                             // change seen, debouncing
+		{2 * time.Second, 2 * time.Second, true, nil},                             // another save restarts the debounce
+		{3 * time.Second, 2 * time.Second, false, nil},                            // quiet for 1s
+		{4 * time.Second, 2 * time.Second, false, []watchJob{{target: "server"}}}, // quiet for 2s: rebuild
+		{5 * time.Second, 2 * time.Second, false, nil},
+	}
+
+	for _, step := range steps {
+		jobs, changed := p.poll(start.Add(step.offset), map[string]time.Time{"main.go": start.Add(step.modified)})
+		if changed != step.changed || !slices.Equal(jobs, step.expected) {
+			t.Errorf("poll() at +%s = %v, %t, expected %v, %t", step.offset, jobs, changed, step.expected, step.changed)
+		}
+	}
+}
+
+func TestWatchPipelineSingleFile(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{Targets: map[string]Target{"test": {Run: []string{"go test ./$(dirname $CHANGED_FILE)"}}}}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := newWatchPipelines([]string{"test"}, true)[0]
+	p.seen = map[string]time.Time{"a/a.go": start, "b/b.go": start}
+
+	// Nothing changed
+	if jobs, changed := p.poll(start.Add(time.Second), map[string]time.Time{"a/a.go": start, "b/b.go": start}); changed || len(jobs) > 0 {
+		t.Errorf("poll() without changes = %v, %t", jobs, changed)
+	}
+
+	// A modified and a new file each get their own run; deleted files do not
+	jobs, changed := p.poll(start.Add(2*time.Second), map[string]time.Time{"a/a.go": start.Add(time.Second), "c/c.go": start})
+	expected := []watchJob{{target: "test", file: "a/a.go"}, {target: "test", file: "c/c.go"}}
+	if !changed || !slices.Equal(jobs, expected) {
+		t.Errorf("poll() = %v, %t, expected %v", jobs, changed, expected)
+	}
+}
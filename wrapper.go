@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+)
+
+// noWrapper is the command_wrapper of a target that opts out of the
+// config's
+const noWrapper = "none"
+
+// commandWrapper returns the command_wrapper a target's commands run
+// under: its own, else the config's, "" for none
+func commandWrapper(target *Target) Var {
+	wrapper := target.CommandWrapper
+	if wrapper == "" {
+		wrapper = cfg.CommandWrapper
+	}
+	if strings.TrimSpace(string(wrapper)) == noWrapper {
+		return ""
+	}
+	return wrapper
+}
+
+// wrapCommand puts an expanded command behind the wrapper (ccache, nice -n19,
+// /usr/bin/time -v). The wrapper prefixes the command line as written, so
+// it applies to its first program; cd commands, which aura carries out
+// itself, are left alone
+func wrapCommand(wrapper, command string) string {
+	wrapper = strings.TrimSpace(wrapper)
+	if wrapper == "" || strings.HasPrefix(command, "cd ") || strings.TrimSpace(command) == "" {
+		return command
+	}
+	return wrapper + " " + command
+}
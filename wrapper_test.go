@@ -0,0 +1,75 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ===== WRAPPER.GO UNIT TESTS =====
+
+func TestCommandWrapper(t *testing.T) {
+	original := cfg
+	defer func() { cfg = original }()
+
+	tests := []struct {
+		name     string
+		config   Var
+		target   Var
+		expected Var
+	}{
+		{"unset", "", "", ""},
+		{"config", "ccache", "", "ccache"},
+		{"target", "", "nice -n19", "nice -n19"},
+		{"target overrides config", "ccache", "sccache", "sccache"},
+		{"target opts out", "ccache", "none", ""},
+	}
+
+	for _, tt := range tests {
+		cfg = Config{CommandWrapper: tt.config}
+		if got := commandWrapper(&Target{CommandWrapper: tt.target}); got != tt.expected {
+			t.Errorf("%s: commandWrapper() = %q, expected %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestWrapCommand(t *testing.T) {
+	tests := []struct {
+		wrapper  string
+		command  string
+		expected string
+	}{
+		{"", "gcc -c main.c", "gcc -c main.c"},
+		{"ccache", "gcc -c main.c", "ccache gcc -c main.c"},
+		{" /usr/bin/time -v ", "make", "/usr/bin/time -v make"},
+		{"nice -n19", "cd build", "cd build"},
+		{"nice -n19", "  ", "  "},
+	}
+
+	for _, tt := range tests {
+		if got := wrapCommand(tt.wrapper, tt.command); got != tt.expected {
+			t.Errorf("wrapCommand(%q, %q) = %q, expected %q", tt.wrapper, tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestTargetRunsWrapped(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX shell commands")
+	}
+	original := cfg
+	defer func() { cfg = original }()
+	cfg = Config{CommandWrapper: "env WRAPPED=$MODE", Vars: map[string]Var{"MODE": "yes"}}
+
+	// The wrapper is expanded like the commands it runs
+	target := Target{Run: []string{"echo hi"}}
+	stdout := captureStdout(t)
+	err := ExecuteAllWithContext("lib", &target, false, false)
+	output := stdout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "env WRAPPED=yes echo hi\n") {
+		t.Errorf("stdout = %q, expected the wrapped command", output)
+	}
+}
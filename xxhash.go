@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// XXH64 (https://xxhash.com) with seed 0, the hash cache.hash selects as
+// xxhash. It is written out here rather than imported so the module keeps
+// its short dependency list
+
+var (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+// xxhash64 is a streaming XXH64: input is consumed in 32-byte stripes over
+// four lanes, and a partial stripe waits in buf until Sum64
+type xxhash64 struct {
+	v     [4]uint64
+	total uint64
+	buf   [32]byte
+	n     int
+}
+
+// newXXHash64 returns an XXH64 hash.Hash64; Sum appends it big-endian
+func newXXHash64() hash.Hash64 {
+	d := &xxhash64{}
+	d.Reset()
+	return d
+}
+
+func (d *xxhash64) Reset() {
+	d.v = [4]uint64{xxPrime1 + xxPrime2, xxPrime2, 0, -xxPrime1}
+	d.total, d.n = 0, 0
+}
+
+func (d *xxhash64) Size() int      { return 8 }
+func (d *xxhash64) BlockSize() int { return 32 }
+
+func (d *xxhash64) Write(p []byte) (int, error) {
+	n := len(p)
+	d.total += uint64(n)
+	if d.n+len(p) < len(d.buf) {
+		d.n += copy(d.buf[d.n:], p)
+		return n, nil
+	}
+	if d.n > 0 {
+		p = p[copy(d.buf[d.n:], p):]
+		d.stripe(d.buf[:])
+	}
+	for ; len(p) >= len(d.buf); p = p[len(d.buf):] {
+		d.stripe(p)
+	}
+	d.n = copy(d.buf[:], p)
+	return n, nil
+}
+
+// stripe mixes 32 bytes into the four lanes
+func (d *xxhash64) stripe(p []byte) {
+	for i := range d.v {
+		d.v[i] = xxRound(d.v[i], binary.LittleEndian.Uint64(p[8*i:]))
+	}
+}
+
+func (d *xxhash64) Sum(b []byte) []byte {
+	return binary.BigEndian.AppendUint64(b, d.Sum64())
+}
+
+func (d *xxhash64) Sum64() uint64 {
+	var h uint64
+	if d.total >= uint64(len(d.buf)) {
+		h = bits.RotateLeft64(d.v[0], 1) + bits.RotateLeft64(d.v[1], 7) + bits.RotateLeft64(d.v[2], 12) + bits.RotateLeft64(d.v[3], 18)
+		for _, v := range d.v {
+			h = (h^xxRound(0, v))*xxPrime1 + xxPrime4
+		}
+	} else {
+		h = d.v[2] + xxPrime5
+	}
+	h += d.total
+
+	p := d.buf[:d.n]
+	for ; len(p) >= 8; p = p[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(p))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(p) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(p)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		p = p[4:]
+	}
+	for _, b := range p {
+		h ^= uint64(b) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+// xxRound mixes one 8-byte word into a lane
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	return bits.RotateLeft64(acc, 31) * xxPrime1
+}
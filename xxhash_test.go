@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ===== XXHASH.GO UNIT TESTS =====
+
+func TestXXHash64(t *testing.T) {
+	tests := map[string]uint64{
+		"":    0xef46db3751d8e999,
+		"a":   0xd24ec4f1a98c6e5b,
+		"abc": 0x44bc2cf5ad770999,
+		"Nobody inspects the spammish repetition": 0xfbcea83c8a378bf1,
+	}
+	for input, expected := range tests {
+		d := newXXHash64()
+		_, _ = d.Write([]byte(input))
+		if got := d.Sum64(); got != expected {
+			t.Errorf("XXH64(%q) = %016x, want %016x", input, got, expected)
+		}
+		if got := fmt.Sprintf("%x", d.Sum(nil)); got != fmt.Sprintf("%016x", expected) {
+			t.Errorf("Sum(%q) = %s, want %016x", input, got, expected)
+		}
+	}
+}
+
+func TestXXHash64Chunks(t *testing.T) {
+	// The sum must not depend on how the input is split across writes,
+	// across stripes or inside one
+	data := []byte(strings.Repeat("0123456789abcdefghijklmnopqrstuvwxyz", 10))
+	whole := newXXHash64()
+	_, _ = whole.Write(data)
+
+	for _, size := range []int{1, 3, 8, 31, 32, 33, 100} {
+		d := newXXHash64()
+		for p := data; len(p) > 0; {
+			n := min(size, len(p))
+			_, _ = d.Write(p[:n])
+			p = p[n:]
+		}
+		if d.Sum64() != whole.Sum64() {
+			t.Errorf("writes of %d bytes: %016x, want %016x", size, d.Sum64(), whole.Sum64())
+		}
+	}
+
+	whole.Reset()
+	if got := whole.Sum64(); got != 0xef46db3751d8e999 {
+		t.Errorf("Sum64() after Reset() = %016x, want the empty input's", got)
+	}
+}